@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -24,6 +26,7 @@ type Config struct {
 	MaxFileSize     int64         // 最大下载文件大小（字节）
 	SpeedThreshold  float64       // 速度阈值（KB/s），用于判断是否成功
 	Concurrency     int           // 并发探测数量
+	HashMode        string        // 内容校验方式：off / prefix / full
 }
 
 // LinkItem 链接项
@@ -35,6 +38,12 @@ type LinkItem struct {
 	Type        string `json:"type"`
 	Status      string `json:"status"`
 	CreatedAt   string `json:"created_at"`
+
+	// 下载包和 R2 文件链接由服务端附带上传文件的大小和哈希
+	ExpectedSize         int64  `json:"expected_size,omitempty"`
+	ExpectedSHA256       string `json:"expected_sha256,omitempty"`
+	ExpectedPrefixSHA256 string `json:"expected_prefix_sha256,omitempty"`
+	PrefixSize           int64  `json:"prefix_size,omitempty"`
 }
 
 // AllLinksResponse 所有链接的响应
@@ -52,6 +61,7 @@ type ProbeResult struct {
 	Status         string  `json:"status"`
 	ErrorMessage   string  `json:"error_message,omitempty"`
 	UserAgent      string  `json:"user_agent"`
+	ContentSHA256  string  `json:"content_sha256,omitempty"`
 }
 
 // BatchReportRequest 批量上报请求
@@ -93,6 +103,7 @@ func main() {
 	maxSize := flag.Int64("max-size", 1*1024, "最大下载文件大小（字节）")
 	speedThreshold := flag.Float64("speed-threshold", 2.0, "速度阈值（KB/s）")
 	concurrency := flag.Int("concurrency", 1, "并发探测数量")
+	hashMode := flag.String("hash-mode", "prefix", "内容校验方式：off 不校验，prefix 下载前 1MB 校验，full 下载完整文件校验")
 	flag.Parse()
 
 	config := Config{
@@ -102,6 +113,7 @@ func main() {
 		MaxFileSize:     *maxSize,
 		SpeedThreshold:  *speedThreshold,
 		Concurrency:     *concurrency,
+		HashMode:        *hashMode,
 	}
 
 	log.Printf("🚀 Agent 启动")
@@ -111,6 +123,7 @@ func main() {
 	log.Printf("   最大文件大小: %d KB", config.MaxFileSize/(1024))
 	log.Printf("   速度阈值: %.2f KB/s", config.SpeedThreshold)
 	log.Printf("   并发数量: %d", config.Concurrency)
+	log.Printf("   内容校验: %s", config.HashMode)
 
 	// 立即执行一次
 	log.Println("⏰ 开始首次探测...")
@@ -139,18 +152,21 @@ func runProbe(config *Config) {
 
 	log.Printf("📋 获取到 %d 个链接", links.Total)
 
-	// 2. 提取所有需要探测的URL（去重）
-	urlSet := make(map[string]bool)
+	// 2. 提取所有需要探测的URL（去重，同一URL优先保留带期望哈希的链接）
+	linkSet := make(map[string]LinkItem)
 	for _, link := range links.Links {
-		if link.URL != "" {
-			urlSet[link.URL] = true
+		if link.URL == "" {
+			continue
+		}
+		if existing, ok := linkSet[link.URL]; !ok || existing.ExpectedSHA256 == "" {
+			linkSet[link.URL] = link
 		}
 	}
 
 	// 转换为数组
-	urls := make([]string, 0, len(urlSet))
-	for url := range urlSet {
-		urls = append(urls, url)
+	urls := make([]LinkItem, 0, len(linkSet))
+	for _, link := range linkSet {
+		urls = append(urls, link)
 	}
 
 	log.Printf("🔍 需要探测 %d 个URL", len(urls))
@@ -165,15 +181,17 @@ func runProbe(config *Config) {
 
 	successCount := 0
 	failedCount := 0
+	mismatchCount := 0
 	var statsMutex sync.Mutex
 
 	completed := 0
 	var completedMutex sync.Mutex
 
-	for _, url := range urls {
+	for _, link := range urls {
 		wg.Add(1)
-		go func(targetURL string) {
+		go func(target LinkItem) {
 			defer wg.Done()
+			targetURL := target.URL
 
 			// 获取信号量
 			semaphore <- struct{}{}
@@ -187,7 +205,7 @@ func runProbe(config *Config) {
 
 			log.Printf("   [%d/%d] 探测: %s", currentIndex, len(urls), targetURL)
 
-			result := probeURL(targetURL, config)
+			result := probeURL(target, config)
 
 			// 保存结果
 			resultsMutex.Lock()
@@ -201,13 +219,17 @@ func runProbe(config *Config) {
 				log.Printf("   [%d/%d] 探测: %s ✓ 成功 | 速度: %.2f KB/s | 耗时: %d ms",
 					currentIndex, len(urls), targetURL,
 					result.SpeedKbps, *result.DownloadTimeMs)
+			} else if result.Status == "content_mismatch" {
+				mismatchCount++
+				log.Printf("   [%d/%d] 探测: %s ⚠️ 内容不一致 | %s",
+					currentIndex, len(urls), targetURL, result.ErrorMessage)
 			} else {
 				failedCount++
 				log.Printf("   [%d/%d] 探测: %s ✗ 失败 | 原因: %s",
 					currentIndex, len(urls), targetURL, result.ErrorMessage)
 			}
 			statsMutex.Unlock()
-		}(url)
+		}(link)
 	}
 
 	// 等待所有探测完成
@@ -228,6 +250,9 @@ func runProbe(config *Config) {
 	log.Printf("   探测总数: %d", len(urls))
 	log.Printf("   成功: %d (%.1f%%)", successCount, float64(successCount)*100/float64(len(urls)))
 	log.Printf("   失败: %d (%.1f%%)", failedCount, float64(failedCount)*100/float64(len(urls)))
+	if mismatchCount > 0 {
+		log.Printf("   内容不一致: %d", mismatchCount)
+	}
 	log.Println()
 }
 
@@ -255,14 +280,15 @@ func fetchAllLinks(serverURL string) (*AllLinksResponse, error) {
 }
 
 // probeURL 探测单个URL的下载速度（支持重试）
-func probeURL(url string, config *Config) ProbeResult {
+func probeURL(link LinkItem, config *Config) ProbeResult {
 	const maxAttempts = 3 // 总共尝试3次（首次 + 重试1次）
+	url := link.URL
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		result := probeURLOnce(url, config)
+		result := probeURLOnce(link, config)
 
-		// 如果成功，直接返回
-		if result.Status == "success" {
+		// 如果成功或内容不一致，直接返回（内容不一致不是网络问题，重试没有意义）
+		if result.Status == "success" || result.Status == "content_mismatch" {
 			return result
 		}
 
@@ -373,8 +399,59 @@ func probeRedirectTarget(url string, config *Config) ProbeResult {
 	return result
 }
 
+// contentCheck 内容校验参数
+type contentCheck struct {
+	limit          int64  // 只下载并校验前 limit 字节，0 表示完整文件
+	expectedSHA256 string // 完整文件或前缀的 SHA-256
+	expectedSize   int64  // 完整文件大小
+}
+
+// contentCheckFor 根据校验方式和服务端提供的期望值决定如何校验，返回 nil 表示不校验
+func contentCheckFor(link LinkItem, config *Config) *contentCheck {
+	switch config.HashMode {
+	case "full":
+		if link.ExpectedSHA256 != "" {
+			return &contentCheck{expectedSHA256: link.ExpectedSHA256, expectedSize: link.ExpectedSize}
+		}
+	case "prefix":
+		if link.ExpectedPrefixSHA256 != "" && link.PrefixSize > 0 {
+			return &contentCheck{limit: link.PrefixSize, expectedSHA256: link.ExpectedPrefixSHA256, expectedSize: link.ExpectedSize}
+		}
+	}
+	return nil
+}
+
+// verify 比对下载内容，返回不一致的原因；一致时返回空字符串
+// 前缀校验时从 Content-Range（206）或 Content-Length（200）得到完整文件大小
+func (check *contentCheck) verify(resp *http.Response, downloaded int64, sum string) string {
+	fileSize := downloaded
+	if check.limit > 0 {
+		fileSize = -1
+		if resp.StatusCode == http.StatusPartialContent {
+			if i := strings.LastIndex(resp.Header.Get("Content-Range"), "/"); i >= 0 {
+				fmt.Sscanf(resp.Header.Get("Content-Range")[i+1:], "%d", &fileSize)
+			}
+		} else {
+			fileSize = resp.ContentLength
+		}
+	}
+
+	if check.expectedSize > 0 && fileSize >= 0 && fileSize != check.expectedSize {
+		return fmt.Sprintf("文件大小不一致: 期望 %d 字节，实际 %d 字节", check.expectedSize, fileSize)
+	}
+	if sum != check.expectedSHA256 {
+		if check.limit > 0 {
+			return fmt.Sprintf("文件前 %d 字节 SHA-256 不一致: 期望 %s，实际 %s", check.limit, check.expectedSHA256, sum)
+		}
+		return fmt.Sprintf("文件 SHA-256 不一致: 期望 %s，实际 %s", check.expectedSHA256, sum)
+	}
+	return ""
+}
+
 // probeURLOnce 执行单次URL探测
-func probeURLOnce(url string, config *Config) ProbeResult {
+func probeURLOnce(link LinkItem, config *Config) ProbeResult {
+	url := link.URL
+	check := contentCheckFor(link, config)
 	result := ProbeResult{
 		URL:       url,
 		UserAgent: "SpeedProbeAgent/1.0",
@@ -395,7 +472,7 @@ func probeURLOnce(url string, config *Config) ProbeResult {
 	// 记录开始时间
 	startTime := time.Now()
 
-	// 发起请求（使用 Range 头只请求前1KB）
+	// 发起请求（使用 Range 头只请求前1KB；内容校验时请求前缀或完整文件）
 	maxDownloadSize := config.MaxFileSize - 1
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -403,7 +480,12 @@ func probeURLOnce(url string, config *Config) ProbeResult {
 		return result
 	}
 	req.Header.Set("User-Agent", result.UserAgent)
-	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxDownloadSize))
+	switch {
+	case check == nil:
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", maxDownloadSize))
+	case check.limit > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", check.limit-1))
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -448,14 +530,23 @@ func probeURLOnce(url string, config *Config) ProbeResult {
 		return result
 	}
 
-	// 下载内容并计算速度
+	// 下载内容并计算速度（内容校验时同时计算 SHA-256）
 	totalSize := int64(0)
 	buffer := make([]byte, 32*1024) // 32KB buffer
+	hasher := sha256.New()
+	var body io.Reader = resp.Body
+	if check != nil && check.limit > 0 {
+		// 服务端忽略 Range 返回完整文件时，只读取前缀
+		body = io.LimitReader(resp.Body, check.limit)
+	}
 
 	for {
-		n, err := resp.Body.Read(buffer)
+		n, err := body.Read(buffer)
 		if n > 0 {
 			totalSize += int64(n)
+			if check != nil {
+				hasher.Write(buffer[:n])
+			}
 		}
 
 		if err == io.EOF {
@@ -479,6 +570,16 @@ func probeURLOnce(url string, config *Config) ProbeResult {
 	result.DownloadTimeMs = &downloadTimeMs
 	result.SpeedKbps = speedKbps
 
+	// 内容校验：与上传文件不一致时上报 content_mismatch，服务端立即发送篡改告警
+	if check != nil {
+		result.ContentSHA256 = hex.EncodeToString(hasher.Sum(nil))
+		if reason := check.verify(resp, totalSize, result.ContentSHA256); reason != "" {
+			result.Status = "content_mismatch"
+			result.ErrorMessage = reason
+			return result
+		}
+	}
+
 	// 判断是否成功（基于速度阈值或有效性检查）
 	if isValid || speedKbps >= config.SpeedThreshold {
 		result.Status = "success"
@@ -117,7 +117,8 @@ func main() {
 		fmt.Printf("密钥: %s\n", twoFactorSecret)
 		fmt.Printf("二维码 URL: %s\n", key.URL())
 		fmt.Println("\n请使用 Google Authenticator 或其他 TOTP 应用扫描二维码或手动输入密钥")
-		fmt.Println("================================\n")
+		fmt.Println("================================")
+		fmt.Println()
 	}
 
 	// 生成密码哈希
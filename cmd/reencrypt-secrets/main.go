@@ -0,0 +1,72 @@
+package main
+
+import (
+	"aws_cdn/internal/config"
+	"aws_cdn/internal/database"
+	"aws_cdn/internal/secrets"
+	"aws_cdn/internal/services"
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+)
+
+// 使用当前主密钥重新加密数据库中的凭证
+// 启用凭证加密后运行一次以加密历史明文数据；轮换主密钥（新增版本）后运行以迁移旧密文，
+// 完成且没有失败记录后即可从配置中移除旧版本主密钥。
+func main() {
+	dryRun := flag.Bool("dry-run", false, "只统计需要重新加密的记录，不写入数据库")
+	flag.Parse()
+
+	// 加载环境变量
+	if err := godotenv.Load(); err != nil {
+		log.Println("未找到 .env 文件，使用环境变量")
+	}
+
+	// 初始化配置
+	cfg := config.Load()
+
+	// 初始化主密钥
+	if err := secrets.Configure(cfg.Secrets.MasterKey, cfg.Secrets.MasterKeys, cfg.Secrets.MasterKeyFile, cfg.Secrets.ActiveKeyVersion); err != nil {
+		log.Fatalf("凭证加密主密钥无效: %v", err)
+	}
+	if !secrets.Configured() {
+		log.Fatal(secrets.ErrMasterKeyNotSet)
+	}
+	log.Printf("当前主密钥版本: %d，已加载版本: %v", secrets.CurrentKeyring().ActiveVersion(), secrets.CurrentKeyring().Versions())
+
+	// 初始化数据库
+	db, err := database.Initialize(database.DatabaseConfig{
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		User:     cfg.Database.User,
+		Password: cfg.Database.Password,
+		DBName:   cfg.Database.DBName,
+		SSLMode:  cfg.Database.SSLMode,
+	})
+	if err != nil {
+		log.Fatalf("数据库初始化失败: %v", err)
+	}
+
+	results, err := services.ReencryptSecrets(db, *dryRun)
+	if err != nil {
+		log.Fatalf("重新加密凭证失败: %v", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if *dryRun {
+			log.Printf("%s: 共 %d 条，需要重新加密 %d 条，无法解密 %d 条", result.Table, result.Total, result.Reencrypted, len(result.Failed))
+		} else {
+			log.Printf("%s: 共 %d 条，重新加密 %d 条，失败 %d 条", result.Table, result.Total, result.Reencrypted, len(result.Failed))
+		}
+		for _, reason := range result.Failed {
+			log.Printf("  %s", reason)
+		}
+		failed += len(result.Failed)
+	}
+	if failed > 0 {
+		log.Fatalf("%d 条记录处理失败，请确认已配置对应版本的主密钥后重新运行", failed)
+	}
+	log.Println("凭证重新加密完成")
+}
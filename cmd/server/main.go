@@ -7,6 +7,7 @@ import (
 	"aws_cdn/internal/models"
 	"aws_cdn/internal/redis"
 	"aws_cdn/internal/router"
+	"aws_cdn/internal/secrets"
 	"aws_cdn/internal/services"
 	"context"
 	"os"
@@ -28,6 +29,15 @@ func main() {
 	// 初始化配置
 	cfg := config.Load()
 
+	// 初始化凭证加密主密钥（Cloudflare、AWS 账号凭证加密存储）
+	if err := secrets.Configure(cfg.Secrets.MasterKey, cfg.Secrets.MasterKeys, cfg.Secrets.MasterKeyFile, cfg.Secrets.ActiveKeyVersion); err != nil {
+		log.WithError(err).Fatal("凭证加密主密钥无效")
+	}
+	// 账号凭证只以密文保存，未配置主密钥时无法保存账号，直接退出而不是运行到保存时才失败
+	if !secrets.Configured() {
+		log.WithError(secrets.ErrMasterKeyNotSet).Fatal("凭证加密主密钥为必填配置，请生成密钥（openssl rand -base64 32）并设置 SECRETS_MASTER_KEY 后重启，升级步骤见 README「凭证加密与主密钥轮换」")
+	}
+
 	// 初始化数据库
 	db, err := database.Initialize(database.DatabaseConfig{
 		Host:     cfg.Database.Host,
@@ -139,15 +149,16 @@ func main() {
 		log.Info("定时任务已禁用：兜底规则检查")
 	}
 
+	// 初始化路由（传入 Telegram 服务、Redis、兜底规则服务、定时任务服务）
+	// 依赖路由内服务的定时任务在 SetupRouter 中注册，因此需在启动定时任务前初始化路由
+	r := router.SetupRouter(db, db2, db3, cfg, telegramService, redisClient, fallbackRuleService, schedulerService)
+
 	// 启动所有定时任务
 	go schedulerService.Start()
 
 	log.Info("定时任务服务已启动")
 	log.Info("  - 注意：链接探测由独立的 agent 进程执行")
 
-	// 初始化路由（传入 Telegram 服务、Redis、兜底规则服务）
-	r := router.SetupRouter(db, db2, db3, cfg, telegramService, redisClient, fallbackRuleService)
-
 	// 启动服务器
 	port := os.Getenv("SERVER_PORT")
 	if port == "" {
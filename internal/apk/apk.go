@@ -0,0 +1,114 @@
+package apk
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Metadata 从 APK 解析出的元数据
+// 只提取信息，不校验签名是否有效；签名方案为空表示未签名。
+type Metadata struct {
+	PackageName       string   `json:"package_name"`
+	VersionCode       int64    `json:"version_code"`
+	VersionName       string   `json:"version_name"`
+	MinSDK            int      `json:"min_sdk"`
+	SigningCertSHA256 string   `json:"signing_cert_sha256"` // 第一个签名者证书 DER 的 SHA-256（十六进制小写）
+	SignatureSchemes  []string `json:"signature_schemes"`   // 检测到的签名方案，如 v1、v2、v3
+}
+
+// ErrNotAPK 文件不是 zip 或不包含 AndroidManifest.xml
+var ErrNotAPK = errors.New("不是有效的 APK 文件")
+
+const maxManifestSize = 4 << 20
+
+// Inspect 解析 APK 的 AndroidManifest.xml 和签名信息
+// 签名证书优先取 v3/v2 签名块，没有时取 v1（META-INF 下的 PKCS#7 签名文件）
+func Inspect(r io.ReaderAt, size int64) (*Metadata, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrNotAPK
+	}
+
+	var manifest *zip.File
+	var v1Signatures []*zip.File
+	for _, file := range archive.File {
+		switch {
+		case file.Name == "AndroidManifest.xml":
+			manifest = file
+		case strings.HasPrefix(file.Name, "META-INF/") && isV1SignatureFile(file.Name):
+			v1Signatures = append(v1Signatures, file)
+		}
+	}
+	if manifest == nil {
+		return nil, ErrNotAPK
+	}
+
+	data, err := readZipFile(manifest, maxManifestSize)
+	if err != nil {
+		return nil, fmt.Errorf("读取 AndroidManifest.xml 失败: %w", err)
+	}
+	meta, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析 AndroidManifest.xml 失败: %w", err)
+	}
+
+	meta.SignatureSchemes = []string{}
+	if len(v1Signatures) > 0 {
+		meta.SignatureSchemes = append(meta.SignatureSchemes, "v1")
+	}
+	blockCert, schemes, err := readSigningBlock(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("解析 APK 签名块失败: %w", err)
+	}
+	meta.SignatureSchemes = append(meta.SignatureSchemes, schemes...)
+
+	cert := blockCert
+	if cert == nil && len(v1Signatures) > 0 {
+		signature, err := readZipFile(v1Signatures[0], maxManifestSize)
+		if err != nil {
+			return nil, fmt.Errorf("读取 v1 签名文件失败: %w", err)
+		}
+		if cert, err = firstPKCS7Certificate(signature); err != nil {
+			return nil, fmt.Errorf("解析 v1 签名文件失败: %w", err)
+		}
+	}
+	if cert != nil {
+		sum := sha256.Sum256(cert)
+		meta.SigningCertSHA256 = hex.EncodeToString(sum[:])
+	}
+	return meta, nil
+}
+
+// Signed 是否检测到任一签名方案
+func (m *Metadata) Signed() bool {
+	return len(m.SignatureSchemes) > 0
+}
+
+func isV1SignatureFile(name string) bool {
+	if path.Dir(name) != "META-INF" {
+		return false
+	}
+	switch strings.ToUpper(path.Ext(name)) {
+	case ".RSA", ".DSA", ".EC":
+		return true
+	}
+	return false
+}
+
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%s 过大（%d 字节）", file.Name, file.UncompressedSize64)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, limit))
+}
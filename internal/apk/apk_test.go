@@ -0,0 +1,212 @@
+package apk
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"unicode/utf16"
+)
+
+type testAttr struct {
+	name     uint32
+	raw      uint32
+	dataType byte
+	data     uint32
+}
+
+// buildManifest 生成最小的二进制 AndroidManifest.xml：manifest（package、versionCode、versionName）和 uses-sdk（minSdkVersion）
+func buildManifest() []byte {
+	pool := []string{"versionCode", "versionName", "minSdkVersion", "package", "manifest", "uses-sdk", "com.example.app", "1.2.3"}
+
+	var strs bytes.Buffer
+	offsets := make([]uint32, len(pool))
+	for i, s := range pool {
+		offsets[i] = uint32(strs.Len())
+		units := utf16.Encode([]rune(s))
+		binary.Write(&strs, binary.LittleEndian, uint16(len(units)))
+		binary.Write(&strs, binary.LittleEndian, units)
+		binary.Write(&strs, binary.LittleEndian, uint16(0))
+	}
+	for strs.Len()%4 != 0 {
+		strs.WriteByte(0)
+	}
+	var stringPool bytes.Buffer
+	header := 28 + 4*len(pool)
+	binary.Write(&stringPool, binary.LittleEndian, []uint16{chunkStringPool, 28})
+	binary.Write(&stringPool, binary.LittleEndian, []uint32{uint32(header + strs.Len()), uint32(len(pool)), 0, 0, uint32(header), 0})
+	binary.Write(&stringPool, binary.LittleEndian, offsets)
+	stringPool.Write(strs.Bytes())
+
+	var resourceMap bytes.Buffer
+	binary.Write(&resourceMap, binary.LittleEndian, []uint16{chunkResourceMap, 8})
+	binary.Write(&resourceMap, binary.LittleEndian, []uint32{8 + 12, attrVersionCode, attrVersionName, attrMinSdkVersion})
+
+	element := func(name uint32, attrs []testAttr) []byte {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, []uint16{chunkStartElement, 16})
+		binary.Write(&buf, binary.LittleEndian, []uint32{uint32(36 + 20*len(attrs)), 1, noIndex, noIndex, name})
+		binary.Write(&buf, binary.LittleEndian, []uint16{20, 20, uint16(len(attrs)), 0, 0, 0})
+		for _, attr := range attrs {
+			binary.Write(&buf, binary.LittleEndian, []uint32{noIndex, attr.name, attr.raw})
+			binary.Write(&buf, binary.LittleEndian, []uint16{8})
+			buf.Write([]byte{0, attr.dataType})
+			binary.Write(&buf, binary.LittleEndian, attr.data)
+		}
+		return buf.Bytes()
+	}
+
+	var body bytes.Buffer
+	body.Write(stringPool.Bytes())
+	body.Write(resourceMap.Bytes())
+	body.Write(element(4, []testAttr{
+		{name: 3, raw: 6, dataType: typeString, data: 6},
+		{name: 0, raw: noIndex, dataType: typeIntDec, data: 42},
+		{name: 1, raw: 7, dataType: typeString, data: 7},
+	}))
+	body.Write(element(5, []testAttr{{name: 2, raw: noIndex, dataType: typeIntDec, data: 21}}))
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, []uint16{chunkXML, 8})
+	binary.Write(&out, binary.LittleEndian, uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func lp(data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+	return append(out, data...)
+}
+
+// insertSigningBlock 在中央目录之前插入只含 v2 签名的签名块，并修正 EOCD 中的中央目录偏移
+func insertSigningBlock(apk, cert []byte) []byte {
+	signedData := append(append(lp(lp([]byte("digest"))), lp(lp(cert))...), lp(nil)...)
+	signer := append(append(lp(signedData), lp(nil)...), lp([]byte("pubkey"))...)
+	value := lp(lp(signer))
+
+	var pairs bytes.Buffer
+	binary.Write(&pairs, binary.LittleEndian, uint64(4+len(value)))
+	binary.Write(&pairs, binary.LittleEndian, uint32(blockIDv2))
+	pairs.Write(value)
+	return insertRawSigningBlock(apk, pairs.Bytes())
+}
+
+// insertRawSigningBlock 插入由任意 ID-值对组成的签名块
+func insertRawSigningBlock(apk, pairs []byte) []byte {
+	size := uint64(len(pairs) + 24)
+	var block bytes.Buffer
+	binary.Write(&block, binary.LittleEndian, size)
+	block.Write(pairs)
+	binary.Write(&block, binary.LittleEndian, size)
+	block.WriteString(signingBlockMagic)
+
+	eocd := len(apk) - eocdMinSize
+	cd := binary.LittleEndian.Uint32(apk[eocd+16:])
+	out := append(append(append([]byte{}, apk[:cd]...), block.Bytes()...), apk[cd:]...)
+	binary.LittleEndian.PutUint32(out[eocd+block.Len()+16:], cd+uint32(block.Len()))
+	return out
+}
+
+func certHash(cert []byte) string {
+	sum := sha256.Sum256(cert)
+	return hex.EncodeToString(sum[:])
+}
+
+// 解析 manifest 中的包名、版本和最低 SDK；签名块中的 v2 证书
+func TestInspectV2(t *testing.T) {
+	cert := []byte{0x30, 0x03, 0x02, 0x01, 0x07}
+	data := insertSigningBlock(buildZip(t, map[string][]byte{"AndroidManifest.xml": buildManifest()}), cert)
+
+	meta, err := Inspect(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.PackageName != "com.example.app" || meta.VersionCode != 42 || meta.VersionName != "1.2.3" || meta.MinSDK != 21 {
+		t.Fatalf("manifest = %+v", meta)
+	}
+	if !meta.Signed() || meta.SignatureSchemes[0] != "v2" || meta.SigningCertSHA256 != certHash(cert) {
+		t.Fatalf("签名 = %v %s", meta.SignatureSchemes, meta.SigningCertSHA256)
+	}
+}
+
+// 没有签名块时从 v1 PKCS#7 签名文件取证书；没有任何签名时签名方案为空
+func TestInspectV1AndUnsigned(t *testing.T) {
+	cert := []byte{0x30, 0x03, 0x02, 0x01, 0x09}
+	must := func(b []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	signedData := must(asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      asn1.RawValue{FullBytes: must(asn1.Marshal(struct{ OID asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}}))},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert},
+		SignerInfos:      asn1.RawValue{Tag: asn1.TagSet, IsCompound: true},
+	}))
+	pkcs7 := must(asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData}}))
+
+	data := buildZip(t, map[string][]byte{"AndroidManifest.xml": buildManifest(), "META-INF/CERT.RSA": pkcs7})
+	meta, err := Inspect(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.SignatureSchemes) != 1 || meta.SignatureSchemes[0] != "v1" || meta.SigningCertSHA256 != certHash(cert) {
+		t.Fatalf("v1 签名 = %v %s", meta.SignatureSchemes, meta.SigningCertSHA256)
+	}
+
+	data = buildZip(t, map[string][]byte{"AndroidManifest.xml": buildManifest()})
+	if meta, err = Inspect(bytes.NewReader(data), int64(len(data))); err != nil || meta.Signed() || meta.SigningCertSHA256 != "" {
+		t.Fatalf("未签名 APK = %+v, %v", meta, err)
+	}
+
+	data = buildZip(t, map[string][]byte{"readme.txt": []byte("hi")})
+	if _, err := Inspect(bytes.NewReader(data), int64(len(data))); err != ErrNotAPK {
+		t.Fatalf("非 APK 应返回 ErrNotAPK: %v", err)
+	}
+}
+
+// 签名块中 ID-值对的长度被截断或超大（接近 MaxInt64）时返回错误，不能 panic
+func TestInspectCorruptSigningBlock(t *testing.T) {
+	apk := buildZip(t, map[string][]byte{"AndroidManifest.xml": buildManifest()})
+	for _, length := range []uint64{0, 3, 64, 1<<63 - 1, 1<<63 + 8, 1<<64 - 1} {
+		var pairs bytes.Buffer
+		binary.Write(&pairs, binary.LittleEndian, length)
+		binary.Write(&pairs, binary.LittleEndian, uint32(blockIDv2))
+		pairs.Write(make([]byte, 16))
+
+		data := insertRawSigningBlock(apk, pairs.Bytes())
+		if _, err := Inspect(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Fatalf("长度 %d 应返回错误", length)
+		}
+	}
+}
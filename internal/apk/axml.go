@@ -0,0 +1,252 @@
+package apk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// 二进制 XML（AXML）格式：文件头之后依次是字符串池、资源 ID 表和各个 XML 节点块。
+// 属性名可能被混淆，android 命名空间的属性优先按资源 ID 识别。
+const (
+	chunkStringPool   = 0x0001
+	chunkXML          = 0x0003
+	chunkResourceMap  = 0x0180
+	chunkStartElement = 0x0102
+
+	stringPoolUTF8 = 1 << 8
+
+	typeReference = 0x01
+	typeString    = 0x03
+	typeIntDec    = 0x10
+	typeIntHex    = 0x11
+
+	attrVersionCode   = 0x0101021b
+	attrVersionName   = 0x0101021c
+	attrMinSdkVersion = 0x0101020c
+
+	noIndex = 0xffffffff
+)
+
+var errTruncated = errors.New("数据不完整")
+
+type axmlAttribute struct {
+	name     string
+	resource uint32
+	raw      string // 原始字符串值（rawValue），没有时为空
+	dataType byte
+	data     uint32
+}
+
+// parseManifest 从 AndroidManifest.xml（AXML）中读取包名、版本和最低 SDK
+func parseManifest(data []byte) (*Metadata, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data) != chunkXML {
+		return nil, fmt.Errorf("不是二进制 XML")
+	}
+
+	var pool []string
+	var resources []uint32
+	meta := &Metadata{}
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+8 <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		headerSize := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if size < 8 || offset+size > len(data) {
+			return nil, errTruncated
+		}
+		chunk := data[offset : offset+size]
+
+		switch chunkType {
+		case chunkStringPool:
+			var err error
+			if pool, err = parseStringPool(chunk); err != nil {
+				return nil, err
+			}
+		case chunkResourceMap:
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resources = append(resources, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case chunkStartElement:
+			name, attrs, err := parseStartElement(chunk, headerSize, pool, resources)
+			if err != nil {
+				return nil, err
+			}
+			applyManifestAttributes(meta, name, attrs, pool)
+		}
+		offset += size
+	}
+
+	if meta.PackageName == "" {
+		return nil, fmt.Errorf("未找到 manifest 包名")
+	}
+	return meta, nil
+}
+
+func applyManifestAttributes(meta *Metadata, element string, attrs []axmlAttribute, pool []string) {
+	for _, attr := range attrs {
+		switch {
+		case element == "manifest" && attr.name == "package":
+			meta.PackageName = attr.stringValue(pool)
+		case element == "manifest" && attr.is(attrVersionCode, "versionCode"):
+			meta.VersionCode = int64(attr.intValue())
+		case element == "manifest" && attr.is(attrVersionName, "versionName"):
+			meta.VersionName = attr.stringValue(pool)
+		case element == "uses-sdk" && attr.is(attrMinSdkVersion, "minSdkVersion"):
+			meta.MinSDK = int(attr.intValue())
+		}
+	}
+}
+
+func (a axmlAttribute) is(resource uint32, name string) bool {
+	if a.resource != 0 {
+		return a.resource == resource
+	}
+	return a.name == name
+}
+
+func (a axmlAttribute) stringValue(pool []string) string {
+	switch {
+	case a.raw != "":
+		return a.raw
+	case a.dataType == typeString && int(a.data) < len(pool):
+		return pool[a.data]
+	case a.dataType == typeReference:
+		return fmt.Sprintf("@0x%08x", a.data) // 引用资源（如 @string/version），不解析 resources.arsc
+	case a.dataType == typeIntDec:
+		return fmt.Sprintf("%d", int32(a.data))
+	}
+	return ""
+}
+
+func (a axmlAttribute) intValue() int32 {
+	switch a.dataType {
+	case typeIntDec, typeIntHex:
+		return int32(a.data)
+	case typeString:
+		var value int32
+		fmt.Sscanf(a.raw, "%d", &value)
+		return value
+	}
+	return 0
+}
+
+func parseStartElement(chunk []byte, headerSize int, pool []string, resources []uint32) (string, []axmlAttribute, error) {
+	if headerSize+20 > len(chunk) {
+		return "", nil, errTruncated
+	}
+	body := chunk[headerSize:]
+	name := lookupString(pool, binary.LittleEndian.Uint32(body[4:]))
+	attrStart := int(binary.LittleEndian.Uint16(body[8:]))
+	attrSize := int(binary.LittleEndian.Uint16(body[10:]))
+	attrCount := int(binary.LittleEndian.Uint16(body[12:]))
+	if attrSize < 20 || attrStart+attrSize*attrCount > len(body) {
+		return "", nil, errTruncated
+	}
+
+	attrs := make([]axmlAttribute, 0, attrCount)
+	for i := 0; i < attrCount; i++ {
+		raw := body[attrStart+i*attrSize:]
+		nameIndex := binary.LittleEndian.Uint32(raw[4:])
+		attr := axmlAttribute{
+			name:     lookupString(pool, nameIndex),
+			raw:      lookupString(pool, binary.LittleEndian.Uint32(raw[8:])),
+			dataType: raw[15],
+			data:     binary.LittleEndian.Uint32(raw[16:]),
+		}
+		if int(nameIndex) < len(resources) {
+			attr.resource = resources[nameIndex]
+		}
+		attrs = append(attrs, attr)
+	}
+	return name, attrs, nil
+}
+
+func lookupString(pool []string, index uint32) string {
+	if index == noIndex || int(index) >= len(pool) {
+		return ""
+	}
+	return pool[index]
+}
+
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errTruncated
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	utf8 := binary.LittleEndian.Uint32(chunk[16:])&stringPoolUTF8 != 0
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, errTruncated
+	}
+
+	pool := make([]string, count)
+	for i := range pool {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if offset >= len(chunk) {
+			return nil, errTruncated
+		}
+		var err error
+		if utf8 {
+			pool[i], err = decodeUTF8String(chunk[offset:])
+		} else {
+			pool[i], err = decodeUTF16String(chunk[offset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pool, nil
+}
+
+// decodeUTF8String UTF-8 字符串：UTF-16 长度、UTF-8 字节长度（各 1~2 字节），然后是内容
+func decodeUTF8String(data []byte) (string, error) {
+	_, n := utf8Length(data)
+	if n == 0 {
+		return "", errTruncated
+	}
+	length, m := utf8Length(data[n:])
+	if m == 0 || n+m+length > len(data) {
+		return "", errTruncated
+	}
+	return string(data[n+m : n+m+length]), nil
+}
+
+func utf8Length(data []byte) (int, int) {
+	if len(data) < 1 {
+		return 0, 0
+	}
+	if data[0]&0x80 == 0 {
+		return int(data[0]), 1
+	}
+	if len(data) < 2 {
+		return 0, 0
+	}
+	return int(data[0]&0x7f)<<8 | int(data[1]), 2
+}
+
+// decodeUTF16String UTF-16 字符串：字符数（1~2 个 uint16），然后是内容
+func decodeUTF16String(data []byte) (string, error) {
+	if len(data) < 2 {
+		return "", errTruncated
+	}
+	length := int(binary.LittleEndian.Uint16(data))
+	offset := 2
+	if length&0x8000 != 0 {
+		if len(data) < 4 {
+			return "", errTruncated
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(data[2:]))
+		offset = 4
+	}
+	if offset+length*2 > len(data) {
+		return "", errTruncated
+	}
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,211 @@
+package apk
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// APK 签名块位于 zip 中央目录之前：
+//
+//	uint64 块大小 | ID-值对（uint64 长度, uint32 ID, 值）... | uint64 块大小 | "APK Sig Block 42"
+//
+// v2/v3 的值是带 uint32 长度前缀的签名者序列，签名者的 signed data 中依次为摘要序列、证书序列（DER）等。
+const (
+	signingBlockMagic = "APK Sig Block 42"
+
+	blockIDv2  = 0x7109871a
+	blockIDv3  = 0xf05368c0
+	blockIDv31 = 0x1b93ad61
+
+	eocdSignature        = 0x06054b50
+	zip64LocatorSig      = 0x07064b50
+	zip64EOCDSignature   = 0x06064b50
+	eocdMinSize          = 22
+	maxEOCDCommentLength = 0xffff
+	maxSigningBlockSize  = 64 << 20
+)
+
+// readSigningBlock 返回签名块中第一个签名者的证书（优先 v3）和检测到的签名方案；没有签名块时返回空
+func readSigningBlock(r io.ReaderAt, size int64) ([]byte, []string, error) {
+	cdOffset, err := centralDirectoryOffset(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cdOffset < 32 {
+		return nil, nil, nil
+	}
+
+	footer := make([]byte, 24)
+	if _, err := r.ReadAt(footer, cdOffset-24); err != nil {
+		return nil, nil, err
+	}
+	if string(footer[8:]) != signingBlockMagic {
+		return nil, nil, nil
+	}
+	blockSize := int64(binary.LittleEndian.Uint64(footer))
+	if blockSize < 24 || blockSize > maxSigningBlockSize || blockSize+8 > cdOffset {
+		return nil, nil, fmt.Errorf("签名块大小异常: %d", blockSize)
+	}
+	block := make([]byte, blockSize-24)
+	if _, err := r.ReadAt(block, cdOffset-blockSize); err != nil {
+		return nil, nil, err
+	}
+
+	values := map[uint32][]byte{}
+	for offset := 0; offset+12 <= len(block); {
+		// 先按 uint64 比较，避免恶意构造的超大长度在转换为 int 后溢出
+		pairLength := binary.LittleEndian.Uint64(block[offset:])
+		if pairLength < 4 || pairLength > uint64(len(block)-offset-8) {
+			return nil, nil, errTruncated
+		}
+		length := int(pairLength)
+		id := binary.LittleEndian.Uint32(block[offset+8:])
+		values[id] = block[offset+12 : offset+8+length]
+		offset += 8 + length
+	}
+
+	var cert []byte
+	var schemes []string
+	for _, scheme := range []struct {
+		id   uint32
+		name string
+	}{{blockIDv2, "v2"}, {blockIDv3, "v3"}, {blockIDv31, "v3.1"}} {
+		value, ok := values[scheme.id]
+		if !ok {
+			continue
+		}
+		schemes = append(schemes, scheme.name)
+		if first, err := firstSignerCertificate(value); err != nil {
+			return nil, nil, fmt.Errorf("%s 签名: %w", scheme.name, err)
+		} else if scheme.id != blockIDv31 || cert == nil {
+			cert = first // v3 覆盖 v2；v3.1 只在前面都没有证书时使用
+		}
+	}
+	return cert, schemes, nil
+}
+
+// firstSignerCertificate 签名者序列 -> 第一个签名者 -> signed data -> 跳过摘要序列 -> 证书序列 -> 第一个证书
+func firstSignerCertificate(value []byte) ([]byte, error) {
+	signers, err := lengthPrefixed(value)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := lengthPrefixed(signers)
+	if err != nil {
+		return nil, err
+	}
+	signedData, err := lengthPrefixed(signer)
+	if err != nil {
+		return nil, err
+	}
+	digests, err := lengthPrefixed(signedData)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := lengthPrefixed(signedData[4+len(digests):])
+	if err != nil {
+		return nil, err
+	}
+	return lengthPrefixed(certs)
+}
+
+func lengthPrefixed(data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, errTruncated
+	}
+	length := int(binary.LittleEndian.Uint32(data))
+	if 4+length > len(data) {
+		return nil, errTruncated
+	}
+	return data[4 : 4+length], nil
+}
+
+// centralDirectoryOffset 从 EOCD（必要时 zip64 EOCD）读取中央目录偏移
+func centralDirectoryOffset(r io.ReaderAt, size int64) (int64, error) {
+	tailSize := int64(eocdMinSize + maxEOCDCommentLength)
+	if tailSize > size {
+		tailSize = size
+	}
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil {
+		return 0, err
+	}
+
+	sig := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sig, eocdSignature)
+	eocd := bytes.LastIndex(tail, sig)
+	if eocd < 0 || eocd+eocdMinSize > len(tail) {
+		return 0, fmt.Errorf("未找到 zip 中央目录结尾")
+	}
+	offset := int64(binary.LittleEndian.Uint32(tail[eocd+16:]))
+	if offset != 0xffffffff {
+		return offset, nil
+	}
+
+	// zip64：EOCD 之前是 zip64 定位记录，指向 zip64 EOCD
+	eocdPos := size - tailSize + int64(eocd)
+	locator := make([]byte, 20)
+	if eocdPos < 20 {
+		return 0, errTruncated
+	}
+	if _, err := r.ReadAt(locator, eocdPos-20); err != nil {
+		return 0, err
+	}
+	if binary.LittleEndian.Uint32(locator) != zip64LocatorSig {
+		return 0, fmt.Errorf("未找到 zip64 定位记录")
+	}
+	record := make([]byte, 56)
+	if _, err := r.ReadAt(record, int64(binary.LittleEndian.Uint64(locator[8:]))); err != nil {
+		return 0, err
+	}
+	if binary.LittleEndian.Uint32(record) != zip64EOCDSignature {
+		return 0, fmt.Errorf("zip64 中央目录结尾无效")
+	}
+	return int64(binary.LittleEndian.Uint64(record[48:])), nil
+}
+
+// PKCS#7 SignedData（v1 签名文件 META-INF/*.RSA 等），只取证书
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     pkcs7RawCertificates `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue        `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+type pkcs7RawCertificates struct {
+	Raw asn1.RawContent
+}
+
+// firstPKCS7Certificate 返回 PKCS#7 SignedData 中第一个证书的 DER
+func firstPKCS7Certificate(data []byte) ([]byte, error) {
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	var signed pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+		return nil, err
+	}
+	if len(signed.Certificates.Raw) == 0 {
+		return nil, fmt.Errorf("签名文件中没有证书")
+	}
+	var certs asn1.RawValue
+	if _, err := asn1.Unmarshal(signed.Certificates.Raw, &certs); err != nil {
+		return nil, err
+	}
+	var first asn1.RawValue
+	if _, err := asn1.Unmarshal(certs.Bytes, &first); err != nil {
+		return nil, err
+	}
+	return first.FullBytes, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -14,6 +15,7 @@ type Config struct {
 	JWT           JWTConfig
 	AWS           AWSConfig
 	Cloudflare    CloudflareConfig
+	Secrets       SecretsConfig
 	ScheduledTask ScheduledTaskConfig
 }
 
@@ -38,6 +40,8 @@ type ServerConfig struct {
 	Sitename         string
 	BlockChinaIP     bool   // 是否禁止中国 IP 访问
 	GeoIPCountryDB   string // GeoLite2-Country.mmdb 或 GeoIP2-Country.mmdb 文件路径
+	PublicBaseURL    string // 服务对外访问地址（如 https://api.example.com），轮播 HTML 通过它查询访客国家、上报点击
+	TrustedProxies   []string // 可信反向代理（IP 或 CIDR），只有来自这些地址的请求才采用 X-Forwarded-For 中的客户端 IP；未配置时保持 Gin 默认行为
 }
 
 type JWTConfig struct {
@@ -59,11 +63,30 @@ type CloudflareConfig struct {
 	APIToken string // 如果使用Token认证，优先使用Token
 }
 
+// SecretsConfig 凭证加密配置
+type SecretsConfig struct {
+	MasterKey        string // AES-256 主密钥（32 字节的 base64 或 hex），作为版本 1 的主密钥
+	MasterKeys       string // 多版本主密钥，格式 "版本:密钥,版本:密钥"，用于密钥轮换
+	MasterKeyFile    string // 主密钥文件，每行一个 "版本:密钥"（只有一行时可以省略版本）
+	ActiveKeyVersion int    // 加密新凭证使用的主密钥版本，为 0 时使用最大版本
+}
+
 type ScheduledTaskConfig struct {
-	EnableSpeedProbeAlert           bool // 是否启用速度探测告警检查任务
-	EnableCleanOldResults           bool // 是否启用清理旧探测结果任务
-	EnableUpdateCustomDownloadLinks bool // 是否启用更新自定义下载链接实际URL任务
-	EnableFallbackRuleCheck         bool // 是否启用兜底规则检查任务
+	EnableSpeedProbeAlert           bool     // 是否启用速度探测告警检查任务
+	EnableCleanOldResults           bool     // 是否启用清理旧探测结果任务
+	EnableUpdateCustomDownloadLinks bool     // 是否启用更新自定义下载链接实际URL任务
+	EnableFallbackRuleCheck         bool     // 是否启用兜底规则检查任务
+	EnableRedirectSchedule          bool     // 是否启用重定向定时流量切换任务
+	EnableRedirectHealthCheck       bool     // 是否启用轮播目标健康检查任务
+	EnableCertificateMonitor        bool     // 是否启用证书过期/续期监控任务
+	CertificateExpiryAlertDays      int      // 证书剩余有效期少于多少天时告警
+	EnableNSDelegationCheck         bool     // 是否启用 NS 委派检查任务
+	NSResolvers                     []string // NS 委派检查使用的上游 DNS 服务器（host:port）
+	EnableDomainReputationCheck     bool     // 是否启用域名拦截/信誉检测任务
+	ReputationResolvers             []string // 信誉检测对比解析结果使用的 DNS 服务器（host:port）
+	EnableAutoDomainRotation        bool     // 是否自动轮换疑似被拦截的重定向源域名
+	EnableZoneBaselineCheck         bool     // 是否启用 Cloudflare Zone 基线漂移检测任务
+	EnableUploadSessionCleanup      bool     // 是否启用过期分片上传清理任务
 }
 
 func Load() *Config {
@@ -103,6 +126,8 @@ func Load() *Config {
 			Sitename:         getEnv("SITENAME", ""),
 			BlockChinaIP:     getBoolEnv("BLOCK_CHINA_IP", false),
 			GeoIPCountryDB:   getEnv("GEOIP_COUNTRY_DB", ""),
+			PublicBaseURL:    getEnv("PUBLIC_BASE_URL", ""),
+			TrustedProxies:   getListEnv("TRUSTED_PROXIES", ""),
 		},
 		JWT: JWTConfig{
 			Secret:      getEnv("JWT_SECRET", "your-secret-key"),
@@ -120,11 +145,28 @@ func Load() *Config {
 			APIKey:   getEnv("CLOUDFLARE_API_KEY", ""),
 			APIToken: getEnv("CLOUDFLARE_API_TOKEN", ""),
 		},
+		Secrets: SecretsConfig{
+			MasterKey:        getEnv("SECRETS_MASTER_KEY", ""),
+			MasterKeys:       getEnv("SECRETS_MASTER_KEYS", ""),
+			MasterKeyFile:    getEnv("SECRETS_MASTER_KEY_FILE", ""),
+			ActiveKeyVersion: getIntEnv("SECRETS_ACTIVE_KEY_VERSION", 0),
+		},
 		ScheduledTask: ScheduledTaskConfig{
 			EnableSpeedProbeAlert:           getBoolEnv("ENABLE_SPEED_PROBE_ALERT", true),
 			EnableCleanOldResults:           getBoolEnv("ENABLE_CLEAN_OLD_RESULTS", true),
 			EnableUpdateCustomDownloadLinks: getBoolEnv("ENABLE_UPDATE_CUSTOM_DOWNLOAD_LINKS", true),
 			EnableFallbackRuleCheck:         getBoolEnv("ENABLE_FALLBACK_RULE_CHECK", true),
+			EnableRedirectSchedule:          getBoolEnv("ENABLE_REDIRECT_SCHEDULE", true),
+			EnableRedirectHealthCheck:       getBoolEnv("ENABLE_REDIRECT_HEALTH_CHECK", true),
+			EnableCertificateMonitor:        getBoolEnv("ENABLE_CERTIFICATE_MONITOR", true),
+			CertificateExpiryAlertDays:      getIntEnv("CERTIFICATE_EXPIRY_ALERT_DAYS", 30),
+			EnableNSDelegationCheck:         getBoolEnv("ENABLE_NS_DELEGATION_CHECK", true),
+			NSResolvers:                     getListEnv("NS_RESOLVERS", "8.8.8.8:53,1.1.1.1:53"),
+			EnableDomainReputationCheck:     getBoolEnv("ENABLE_DOMAIN_REPUTATION_CHECK", true),
+			ReputationResolvers:             getListEnv("REPUTATION_RESOLVERS", "8.8.8.8:53,1.1.1.1:53,223.5.5.5:53,119.29.29.29:53"),
+			EnableAutoDomainRotation:        getBoolEnv("ENABLE_AUTO_DOMAIN_ROTATION", false),
+			EnableZoneBaselineCheck:         getBoolEnv("ENABLE_ZONE_BASELINE_CHECK", true),
+			EnableUploadSessionCleanup:      getBoolEnv("ENABLE_UPLOAD_SESSION_CLEANUP", true),
 		},
 	}
 }
@@ -157,3 +199,14 @@ func getIntEnv(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getListEnv 读取逗号分隔的列表
+func getListEnv(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -46,6 +46,17 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.Domain{},
 		&models.RedirectRule{},
 		&models.RedirectTarget{},
+		&models.RedirectSchedule{},
+		&models.RedirectTargetHealthEvent{},
+		&models.RedirectRoute{},
+		&models.RedirectRuleVersion{},
+		&models.RedirectClickStat{},
+		&models.DomainReputationCheck{},
+		&models.AWSAccount{},
+		&models.CFZoneBaseline{},
+		&models.CFZoneBaselineAttachment{},
+		&models.UploadSession{},
+		&models.DownloadPackageVersion{},
 		&models.User{},
 		&models.DownloadPackage{},
 		&models.AuditLog{},
@@ -0,0 +1,58 @@
+// Package geoip 读取 MaxMind DB（GeoLite2-Country.mmdb / GeoIP2-Country.mmdb）查询 IP 所属国家。
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Reader 国家库读取器（并发只读安全）
+type Reader struct {
+	db *geoip2.Reader
+}
+
+// Open 打开国家库文件
+func Open(path string) (*Reader, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 GeoIP 数据库失败: %w", err)
+	}
+	return &Reader{db: db}, nil
+}
+
+// newReader 从内存中的数据库内容创建读取器
+func newReader(buf []byte) (*Reader, error) {
+	db, err := geoip2.FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("读取 GeoIP 数据库失败: %w", err)
+	}
+	return &Reader{db: db}, nil
+}
+
+// Country 查询 IP 所属国家的 ISO 代码（大写，如 MM）；未收录时返回空字符串
+func (r *Reader) Country(ipStr string) (string, error) {
+	ip := net.ParseIP(strings.TrimSpace(ipStr))
+	if ip == nil {
+		return "", fmt.Errorf("无效的 IP 地址: %s", ipStr)
+	}
+
+	record, err := r.db.Country(ip)
+	if err != nil {
+		return "", err
+	}
+
+	// 优先使用 country，缺失时使用 registered_country
+	code := record.Country.IsoCode
+	if code == "" {
+		code = record.RegisteredCountry.IsoCode
+	}
+	return strings.ToUpper(code), nil
+}
+
+// Close 释放数据库文件
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
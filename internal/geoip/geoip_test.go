@@ -0,0 +1,64 @@
+package geoip
+
+import (
+	"testing"
+)
+
+// encodeString 按 MaxMind DB 格式编码短字符串（长度 < 29）
+func encodeString(s string) []byte {
+	return append([]byte{0x40 | byte(len(s))}, s...)
+}
+
+// 构造只有一个节点的 IPv4 库：0.0.0.0/1 指向 {"country": {"iso_code": "MM"}}，128.0.0.0/1 未收录；IPv4 库不能查询 IPv6 地址。
+func TestReaderCountry(t *testing.T) {
+	var buf []byte
+	// 搜索树：1 个节点，record_size=24；左记录 = node_count + 16 + 数据偏移 0，右记录 = node_count（未收录）
+	buf = append(buf, 0x00, 0x00, 0x11, 0x00, 0x00, 0x01)
+	buf = append(buf, make([]byte, 16)...)
+	// 数据段
+	buf = append(buf, 0xE1)
+	buf = append(buf, encodeString("country")...)
+	buf = append(buf, 0xE1)
+	buf = append(buf, encodeString("iso_code")...)
+	buf = append(buf, encodeString("mm")...)
+	// 元数据
+	buf = append(buf, "\xAB\xCD\xEFMaxMind.com"...)
+	buf = append(buf, 0xE5)
+	buf = append(buf, encodeString("binary_format_major_version")...)
+	buf = append(buf, 0xA1, 0x02)
+	buf = append(buf, encodeString("database_type")...)
+	buf = append(buf, encodeString("GeoLite2-Country")...)
+	buf = append(buf, encodeString("node_count")...)
+	buf = append(buf, 0xC1, 0x01)
+	buf = append(buf, encodeString("record_size")...)
+	buf = append(buf, 0xA1, 0x18)
+	buf = append(buf, encodeString("ip_version")...)
+	buf = append(buf, 0xA1, 0x04)
+
+	reader, err := newReader(buf)
+	if err != nil {
+		t.Fatalf("newReader: %v", err)
+	}
+
+	cases := map[string]string{
+		"1.2.3.4":   "MM",
+		"127.0.0.1": "MM",
+		"200.1.1.1": "",
+	}
+	for ip, want := range cases {
+		got, err := reader.Country(ip)
+		if err != nil {
+			t.Fatalf("%s: %v", ip, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q want %q", ip, got, want)
+		}
+	}
+
+	if _, err := reader.Country("not-an-ip"); err == nil {
+		t.Error("expected error for invalid ip")
+	}
+	if _, err := reader.Country("::1"); err == nil {
+		t.Error("expected error for ipv6 lookup in ipv4 database")
+	}
+}
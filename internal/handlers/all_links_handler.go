@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
 	"aws_cdn/internal/services"
 	"net/http"
 	"net/url"
@@ -19,6 +20,7 @@ type AllLinksHandler struct {
 	focusProbeLinkService     *services.FocusProbeLinkService
 	speedProbeService         *services.SpeedProbeService
 	domainRedirectService     *services.DomainRedirectService
+	redirectHealthService     *services.RedirectHealthService
 }
 
 func NewAllLinksHandler(
@@ -29,6 +31,7 @@ func NewAllLinksHandler(
 	focusProbeLinkService *services.FocusProbeLinkService,
 	speedProbeService *services.SpeedProbeService,
 	domainRedirectService *services.DomainRedirectService,
+	redirectHealthService *services.RedirectHealthService,
 ) *AllLinksHandler {
 	return &AllLinksHandler{
 		downloadPackageService:    downloadPackageService,
@@ -38,6 +41,7 @@ func NewAllLinksHandler(
 		focusProbeLinkService:     focusProbeLinkService,
 		speedProbeService:         speedProbeService,
 		domainRedirectService:     domainRedirectService,
+		redirectHealthService:     redirectHealthService,
 	}
 }
 
@@ -47,11 +51,30 @@ type LinkItem struct {
 	URL         string `json:"url"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Type        string `json:"type"` // download_package, custom_download_link, r2_apk_file, redirect_rule
+	Type        string `json:"type"` // download_package, custom_download_link, r2_apk_file, redirect_rule, redirect_target
 	Status      string `json:"status,omitempty"`
 	FilePath    string `json:"file_path,omitempty"` // R2文件路径
 	Domain      string `json:"domain,omitempty"`    // R2域名
 	CreatedAt   string `json:"created_at"`
+
+	// 下载包和 R2 文件链接附带上传文件的大小和哈希，探测端下载后比对，不一致时上报 content_mismatch
+	ExpectedSize         int64  `json:"expected_size,omitempty"`
+	ExpectedSHA256       string `json:"expected_sha256,omitempty"`
+	ExpectedPrefixSHA256 string `json:"expected_prefix_sha256,omitempty"` // 文件前 PrefixSize 字节的 SHA-256
+	PrefixSize           int64  `json:"prefix_size,omitempty"`
+}
+
+// setExpectedContent 填充链接的期望内容；尚未完成文件检查（没有哈希）时不填充，探测端跳过比对
+func (l *LinkItem) setExpectedContent(inspection models.FileInspection, size int64) {
+	if inspection.FileSHA256 == "" {
+		return
+	}
+	l.ExpectedSize = size
+	l.ExpectedSHA256 = inspection.FileSHA256
+	if inspection.FilePrefixSHA256 != "" {
+		l.ExpectedPrefixSHA256 = inspection.FilePrefixSHA256
+		l.PrefixSize = models.FilePrefixHashSize
+	}
 }
 
 // AllLinksResponse 所有链接的响应结构
@@ -213,11 +236,63 @@ func (h *AllLinksHandler) GetAllLinks(c *gin.Context) {
 					Domain:      domain.Domain,
 					CreatedAt:   file.CreatedAt.Format("2006-01-02 15:04:05"),
 				}
+				var fileSize int64
+				if file.FileSize != nil {
+					fileSize = *file.FileSize
+				}
+				item.setExpectedContent(file.FileInspection, fileSize)
 				response.Links = append(response.Links, item)
 			}
 		}
 	}
 
+	// 5. 开启健康检查的轮播规则目标（探测结果用于自动摘除/恢复目标）
+	healthRules, err := h.redirectHealthService.ListProbeTargets()
+	if err != nil {
+		log.WithError(err).Error("获取轮播健康检查目标失败")
+	} else {
+		for _, rule := range healthRules {
+			for _, target := range rule.Targets {
+				status := "active"
+				if target.AutoDisabled {
+					status = "auto_disabled"
+				}
+				item := LinkItem{
+					ID:          target.ID,
+					URL:         target.TargetURL,
+					Name:        rule.SourceDomain,
+					Description: "轮播目标",
+					Type:        "redirect_target",
+					Status:      status,
+					Domain:      rule.SourceDomain,
+					CreatedAt:   target.CreatedAt.Format("2006-01-02 15:04:05"),
+				}
+				response.Links = append(response.Links, item)
+			}
+		}
+	}
+
+	// 6. 指向下载包地址的链接（如自定义下载链接）按下载包文件比对内容
+	downloadPackages, err := h.downloadPackageService.ListAllDownloadPackages()
+	if err != nil {
+		log.WithError(err).Error("获取下载包列表失败")
+	} else {
+		packagesByURL := make(map[string]models.DownloadPackage, len(downloadPackages))
+		for _, pkg := range downloadPackages {
+			if pkg.Status == models.DownloadPackageStatusCompleted && pkg.DownloadURL != "" {
+				packagesByURL[pkg.DownloadURL] = pkg
+			}
+		}
+		for i := range response.Links {
+			if response.Links[i].ExpectedSHA256 != "" {
+				continue
+			}
+			if pkg, ok := packagesByURL[response.Links[i].URL]; ok {
+				response.Links[i].setExpectedContent(pkg.FileInspection, pkg.FileSize)
+			}
+		}
+	}
+
 	if c.Query("debug") == "true" {
 		c.JSON(http.StatusOK, response)
 		return
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AWSAccountHandler struct {
+	service *services.AWSAccountService
+}
+
+func NewAWSAccountHandler(service *services.AWSAccountService) *AWSAccountHandler {
+	return &AWSAccountHandler{service: service}
+}
+
+// ListAWSAccounts 列出所有 AWS 账号
+func (h *AWSAccountHandler) ListAWSAccounts(c *gin.Context) {
+	log := logger.GetLogger()
+	accounts, err := h.service.ListAWSAccounts()
+	if err != nil {
+		log.WithError(err).Error("列出AWS账号失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// GetAWSAccount 获取 AWS 账号信息
+func (h *AWSAccountHandler) GetAWSAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	account, err := h.service.GetAWSAccount(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
+// CreateAWSAccount 创建 AWS 账号
+func (h *AWSAccountHandler) CreateAWSAccount(c *gin.Context) {
+	log := logger.GetLogger()
+	var req struct {
+		Name            string `json:"name" binding:"required"`
+		AccessKeyID     string `json:"access_key_id" binding:"required"`
+		SecretAccessKey string `json:"secret_access_key" binding:"required"`
+		Region          string `json:"region"`
+		S3BucketName    string `json:"s3_bucket_name"`
+		Note            string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.service.CreateAWSAccount(strings.TrimSpace(req.Name), req.AccessKeyID, req.SecretAccessKey, req.Region, req.S3BucketName, req.Note)
+	if err != nil {
+		log.WithError(err).WithField("name", req.Name).Error("创建AWS账号操作失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"account_id": account.ID,
+		"name":       account.Name,
+	}).Info("AWS账号创建成功")
+	c.JSON(http.StatusOK, account)
+}
+
+// UpdateAWSAccount 更新 AWS 账号
+func (h *AWSAccountHandler) UpdateAWSAccount(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	var req struct {
+		Name            *string `json:"name"`
+		AccessKeyID     *string `json:"access_key_id"`
+		SecretAccessKey *string `json:"secret_access_key"`
+		Region          *string `json:"region"`
+		S3BucketName    *string `json:"s3_bucket_name"`
+		Note            *string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "账号名称不能为空"})
+		return
+	}
+
+	account, err := h.service.UpdateAWSAccount(uint(id), req.Name, req.AccessKeyID, req.SecretAccessKey, req.Region, req.S3BucketName, req.Note)
+	if err != nil {
+		log.WithError(err).WithField("account_id", id).Error("更新AWS账号操作失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"account_id": account.ID,
+		"name":       account.Name,
+	}).Info("AWS账号更新成功")
+	c.JSON(http.StatusOK, account)
+}
+
+// DeleteAWSAccount 删除 AWS 账号
+func (h *AWSAccountHandler) DeleteAWSAccount(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	if err := h.service.DeleteAWSAccount(uint(id)); err != nil {
+		log.WithError(err).WithField("account_id", id).Error("删除AWS账号操作失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.WithField("account_id", id).Info("AWS账号删除成功")
+	c.JSON(http.StatusOK, gin.H{"message": "AWS账号删除成功"})
+}
+
+// VerifyAWSAccount 通过 STS 校验账号凭证，返回凭证所属的 AWS 账号
+func (h *AWSAccountHandler) VerifyAWSAccount(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	identity, err := h.service.VerifyAWSAccount(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, identity)
+}
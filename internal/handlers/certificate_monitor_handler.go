@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CertificateMonitorHandler struct {
+	service *services.CertificateMonitorService
+}
+
+func NewCertificateMonitorHandler(service *services.CertificateMonitorService) *CertificateMonitorHandler {
+	return &CertificateMonitorHandler{service: service}
+}
+
+// ListExpiringCertificates 证书到期报表：days 天内过期或监控发现问题的证书（days 默认取告警天数）
+func (h *CertificateMonitorHandler) ListExpiringCertificates(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 days 参数"})
+			return
+		}
+		days = parsed
+	}
+
+	items, err := h.service.ListExpiringCertificates(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
@@ -165,6 +165,24 @@ func (h *CFAccountHandler) DeleteCFAccount(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Cloudflare账号删除成功"})
 }
 
+// CheckCFAccountCapabilities 重新检测账号 API Token 的有效性和各功能权限
+func (h *CFAccountHandler) CheckCFAccountCapabilities(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	account, err := h.service.RecheckCapabilities(uint(id))
+	if err != nil {
+		log.WithError(err).WithField("account_id", id).Error("检测Cloudflare账号权限失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
+
 // AddZones 批量添加域名到指定 CF 账号
 func (h *CFAccountHandler) AddZones(c *gin.Context) {
 	log := logger.GetLogger()
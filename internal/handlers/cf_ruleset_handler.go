@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"aws_cdn/internal/services/cloudflare"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CFRulesetHandler struct {
+	service *services.CFRulesetService
+}
+
+func NewCFRulesetHandler(service *services.CFRulesetService) *CFRulesetHandler {
+	return &CFRulesetHandler{service: service}
+}
+
+// ListManagedRules 列出 Zone 上由本系统创建的 Ruleset 规则（orphaned 表示已无引用）
+func (h *CFRulesetHandler) ListManagedRules(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	rules, err := h.service.ListManagedRules(uint(id), c.Param("zone"))
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"account_id": id,
+			"zone_id":    c.Param("zone"),
+		}).Error("获取 Zone 规则失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// DeleteManagedRule 删除 Zone 上由本系统创建的规则
+func (h *CFRulesetHandler) DeleteManagedRule(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	phase := cloudflare.RulesetPhase(c.Param("phase"))
+	if err := h.service.DeleteManagedRule(uint(id), c.Param("zone"), phase, c.Param("rule_id")); err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"account_id": id,
+			"zone_id":    c.Param("zone"),
+			"phase":      phase,
+			"rule_id":    c.Param("rule_id"),
+		}).Error("删除 Zone 规则失败")
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrManagedRuleNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrRuleNotManaged):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CFZoneBaselineHandler struct {
+	service *services.CFZoneBaselineService
+}
+
+func NewCFZoneBaselineHandler(service *services.CFZoneBaselineService) *CFZoneBaselineHandler {
+	return &CFZoneBaselineHandler{service: service}
+}
+
+// ListSupportedSettings 列出基线支持的 Zone 设置及期望值
+func (h *CFZoneBaselineHandler) ListSupportedSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.service.SupportedSettings()})
+}
+
+// ListBaselines 列出所有基线
+func (h *CFZoneBaselineHandler) ListBaselines(c *gin.Context) {
+	baselines, err := h.service.ListBaselines()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": baselines})
+}
+
+// CreateBaseline 创建基线
+func (h *CFZoneBaselineHandler) CreateBaseline(c *gin.Context) {
+	var req struct {
+		Name     string   `json:"name" binding:"required"`
+		Settings []string `json:"settings"` // 为空时包含全部支持的设置
+		Note     string   `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseline, err := h.service.CreateBaseline(req.Name, req.Settings, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, baseline)
+}
+
+// UpdateBaseline 更新基线
+func (h *CFZoneBaselineHandler) UpdateBaseline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的基线 ID"})
+		return
+	}
+
+	var req struct {
+		Name     *string  `json:"name"`
+		Settings []string `json:"settings"`
+		Note     *string  `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseline, err := h.service.UpdateBaseline(uint(id), req.Name, req.Settings, req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, baseline)
+}
+
+// DeleteBaseline 删除基线
+func (h *CFZoneBaselineHandler) DeleteBaseline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的基线 ID"})
+		return
+	}
+
+	if err := h.service.DeleteBaseline(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// ListAttachments 列出 Zone 与基线的关联及漂移状态（可按 cf_account_id、drift_status 筛选）
+func (h *CFZoneBaselineHandler) ListAttachments(c *gin.Context) {
+	var cfAccountID uint
+	if idStr := c.Query("cf_account_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+			return
+		}
+		cfAccountID = uint(id)
+	}
+
+	attachments, err := h.service.ListAttachments(cfAccountID, c.Query("drift_status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": attachments})
+}
+
+// AttachBaseline 为 Zone 关联基线并立即检测一次
+func (h *CFZoneBaselineHandler) AttachBaseline(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	var req struct {
+		BaselineID uint `json:"baseline_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.AttachBaseline(uint(id), c.Param("zone"), req.BaselineID)
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"account_id":  id,
+			"zone_id":     c.Param("zone"),
+			"baseline_id": req.BaselineID,
+		}).Error("关联 Zone 基线失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// DetachBaseline 取消 Zone 的基线关联
+func (h *CFZoneBaselineHandler) DetachBaseline(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	if err := h.service.DetachBaseline(uint(id), c.Param("zone")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已取消关联"})
+}
+
+// CheckBaseline 立即检测 Zone 与基线的差异
+func (h *CFZoneBaselineHandler) CheckBaseline(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	report, err := h.service.CheckZone(uint(id), c.Param("zone"))
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"account_id": id,
+			"zone_id":    c.Param("zone"),
+		}).Error("检测 Zone 基线失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ApplyBaseline 重新应用 Zone 关联的基线
+func (h *CFZoneBaselineHandler) ApplyBaseline(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的账号 ID"})
+		return
+	}
+
+	report, err := h.service.ApplyBaseline(uint(id), c.Param("zone"))
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"account_id": id,
+			"zone_id":    c.Param("zone"),
+		}).Error("应用 Zone 基线失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dnsRecordRequest 创建/更新 DNS 记录的请求体
+// name 可以是完整域名或相对名称（"@" 表示根域名）；同名同类型的多个值放在 values 中
+type dnsRecordRequest struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type" binding:"required"`
+	Values  []string `json:"values" binding:"required"`
+	TTL     int64    `json:"ttl"`     // 秒，默认 300；Cloudflare 可用 1 表示自动
+	Proxied bool     `json:"proxied"` // 仅 Cloudflare 的 A/AAAA/CNAME 记录
+}
+
+func (r dnsRecordRequest) toRecord() services.DNSRecord {
+	return services.DNSRecord{
+		Name:    r.Name,
+		Type:    r.Type,
+		Values:  r.Values,
+		TTL:     r.TTL,
+		Proxied: r.Proxied,
+	}
+}
+
+// ListDNSRecords 列出域名的 DNS 记录
+func (h *DomainHandler) ListDNSRecords(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	records, err := h.service.ListDNSRecords(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": records})
+}
+
+// CreateDNSRecord 创建 DNS 记录
+func (h *DomainHandler) CreateDNSRecord(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	var req dnsRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.service.CreateDNSRecord(uint(id), req.toRecord())
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"domain_id": id,
+			"name":      req.Name,
+			"type":      req.Type,
+		}).Error("创建DNS记录失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// UpdateDNSRecord 覆盖更新 DNS 记录（按名称和类型定位）
+func (h *DomainHandler) UpdateDNSRecord(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	var req dnsRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.service.UpdateDNSRecord(uint(id), req.toRecord())
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"domain_id": id,
+			"name":      req.Name,
+			"type":      req.Type,
+		}).Error("更新DNS记录失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteDNSRecord 删除 DNS 记录，通过查询参数 name 和 type 指定
+func (h *DomainHandler) DeleteDNSRecord(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	name := c.Query("name")
+	recordType := c.Query("type")
+	if recordType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 type 参数"})
+		return
+	}
+
+	if err := h.service.DeleteDNSRecord(uint(id), name, recordType); err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"domain_id": id,
+			"name":      name,
+			"type":      recordType,
+		}).Error("删除DNS记录失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// maxZoneFileSize 区域文件大小上限
+const maxZoneFileSize = 2 << 20
+
+// ExportZoneFile 导出域名的 BIND 格式区域文件
+func (h *DomainHandler) ExportZoneFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	domain, err := h.service.GetDomain(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "域名不存在"})
+		return
+	}
+	content, err := h.service.ExportZoneFile(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", domain.DomainName+".zone"))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// ImportZoneFile 导入 BIND 格式区域文件（multipart 字段 file；dry_run=true 时只返回与现有记录的差异）
+func (h *DomainHandler) ImportZoneFile(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传区域文件"})
+		return
+	}
+	if file.Size > maxZoneFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "区域文件不能超过 2MB"})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取区域文件失败"})
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取区域文件失败"})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.service.ImportZoneFile(uint(id), string(data), dryRun)
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"domain_id": id,
+			"filename":  file.Filename,
+		}).Error("导入区域文件失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -22,11 +22,12 @@ func NewDomainHandler(service *services.DomainService) *DomainHandler {
 func (h *DomainHandler) TransferDomain(c *gin.Context) {
 	log := logger.GetLogger()
 	var req struct {
-		DomainName  string `json:"domain_name" binding:"required"`
-		Registrar   string `json:"registrar"`     // 原注册商（可选，已废弃）
-		DNSProvider string `json:"dns_provider"`  // aws 或 cloudflare，默认为 aws
-		CFAccountID *uint  `json:"cf_account_id"` // CF 账号 ID（可选）
-		GroupID     *uint  `json:"group_id"`      // 分组ID，可选
+		DomainName   string `json:"domain_name" binding:"required"`
+		Registrar    string `json:"registrar"`      // 原注册商（可选，已废弃）
+		DNSProvider  string `json:"dns_provider"`   // aws 或 cloudflare，默认为 aws
+		CFAccountID  *uint  `json:"cf_account_id"`  // CF 账号 ID（可选）
+		AWSAccountID *uint  `json:"aws_account_id"` // 证书和托管区域所在的 AWS 账号 ID（可选，默认账号）
+		GroupID      *uint  `json:"group_id"`       // 分组ID，可选
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -53,7 +54,7 @@ func (h *DomainHandler) TransferDomain(c *gin.Context) {
 		return
 	}
 
-	domain, err := h.service.TransferDomain(req.DomainName, req.Registrar, dnsProvider, req.CFAccountID, req.GroupID)
+	domain, err := h.service.TransferDomain(req.DomainName, req.Registrar, dnsProvider, req.CFAccountID, req.AWSAccountID, req.GroupID)
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_name":  req.DomainName,
@@ -127,7 +128,18 @@ func (h *DomainHandler) ListDomains(c *gin.Context) {
 		}
 	}
 
-	domains, total, err := h.service.ListDomains(page, pageSize, groupID, search, cfAccountID, usedStatus)
+	var reputation *string
+	if reputationStr := c.Query("reputation"); reputationStr != "" {
+		switch models.DomainReputation(reputationStr) {
+		case models.DomainReputationOK, models.DomainReputationWarning, models.DomainReputationSuspectedBlocked, models.DomainReputationUnknown, "unchecked":
+			reputation = &reputationStr
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的信誉状态，可选值: ok、warning、suspected_blocked、unknown、unchecked"})
+			return
+		}
+	}
+
+	domains, total, err := h.service.ListDomains(page, pageSize, groupID, search, cfAccountID, usedStatus, reputation)
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"page":          page,
@@ -136,6 +148,7 @@ func (h *DomainHandler) ListDomains(c *gin.Context) {
 			"search":        search,
 			"cf_account_id": cfAccountID,
 			"used_status":   usedStatus,
+			"reputation":    reputation,
 		}).Error("列出域名失败")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -228,6 +241,23 @@ func (h *DomainHandler) GetDomainStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": status})
 }
 
+// CheckNSDelegation 立即检查域名的 NS 委派
+func (h *DomainHandler) CheckNSDelegation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	result, err := h.service.CheckNSDelegationByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // DeleteDomain 删除域名
 func (h *DomainHandler) DeleteDomain(c *gin.Context) {
 	log := logger.GetLogger()
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainReputationHandler struct {
+	service *services.DomainReputationService
+}
+
+func NewDomainReputationHandler(service *services.DomainReputationService) *DomainReputationHandler {
+	return &DomainReputationHandler{service: service}
+}
+
+// CheckDomain 立即检测域名是否被拦截
+func (h *DomainReputationHandler) CheckDomain(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	report, err := h.service.CheckDomainByID(uint(id))
+	if err != nil {
+		log.WithError(err).WithField("domain_id", id).Error("域名信誉检测失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListChecks 查询域名的信誉检测记录（可按 detector 筛选）
+func (h *DomainReputationHandler) ListChecks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的域名 ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	checks, total, err := h.service.ListChecks(uint(id), c.Query("detector"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      checks,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainRotationHandler struct {
+	service *services.DomainRotationService
+}
+
+func NewDomainRotationHandler(service *services.DomainRotationService) *DomainRotationHandler {
+	return &DomainRotationHandler{service: service}
+}
+
+// ListSpareDomains 查询备用域名池（可按 group_id 筛选）
+func (h *DomainRotationHandler) ListSpareDomains(c *gin.Context) {
+	var groupID *uint
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" && groupIDStr != "0" {
+		id, err := strconv.ParseUint(groupIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分组 ID"})
+			return
+		}
+		gid := uint(id)
+		groupID = &gid
+	}
+
+	domains, err := h.service.ListSpareDomains(groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": domains})
+}
+
+// RotateSourceDomain 将规则的源域名换成备用域名
+func (h *DomainRotationHandler) RotateSourceDomain(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req struct {
+		DomainName string `json:"domain_name"` // 指定备用域名，为空时使用分组的下一个备用域名
+		Reason     string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.RotateSourceDomain(uint(id), req.DomainName, req.Reason, c.GetString("username"))
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"rule_id":     id,
+			"domain_name": req.DomainName,
+		}).Error("轮换源域名失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -46,6 +46,18 @@ func (h *DownloadPackageHandler) CreateDownloadPackage(c *gin.Context) {
 		return
 	}
 
+	// 可选：下载包所在的 AWS 账号，默认与域名一致
+	var awsAccountID *uint
+	if value := c.PostForm("aws_account_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 aws_account_id"})
+			return
+		}
+		accountID := uint(id)
+		awsAccountID = &accountID
+	}
+
 	// 获取上传的文件
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -107,7 +119,7 @@ func (h *DownloadPackageHandler) CreateDownloadPackage(c *gin.Context) {
 	fileDataReader := bytes.NewReader(fileData)
 
 	// 创建下载包（使用domainID，服务层会从域名获取domain_name）
-	pkg, err := h.service.CreateDownloadPackage(uint(domainID), fileName, fileDataReader, fileSize)
+	pkg, err := h.service.CreateDownloadPackage(uint(domainID), awsAccountID, fileName, fileDataReader, fileSize)
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_id": domainID,
@@ -160,14 +172,14 @@ func (h *DownloadPackageHandler) GetDownloadPackage(c *gin.Context) {
 
 	// 查询 CloudFront 状态和启用状态
 	if pkg.CloudFrontID != "" {
-		status, err := h.service.GetCloudFrontStatus(pkg.CloudFrontID)
+		status, err := h.service.GetCloudFrontStatus(pkg)
 		if err != nil {
 			response.CloudFrontStatus = "unknown"
 		} else {
 			response.CloudFrontStatus = status
 		}
 
-		enabled, err := h.service.GetCloudFrontEnabled(pkg.CloudFrontID)
+		enabled, err := h.service.GetCloudFrontEnabled(pkg)
 		if err != nil {
 			response.CloudFrontEnabled = false
 		} else {
@@ -189,7 +201,7 @@ func (h *DownloadPackageHandler) GetDownloadPackage(c *gin.Context) {
 	}
 
 	// 检查 S3 Bucket Policy 状态
-	s3PolicyConfigured, err := h.service.CheckS3BucketPolicyForDownloads()
+	s3PolicyConfigured, err := h.service.CheckS3BucketPolicyForDownloads(pkg)
 	if err == nil {
 		response.S3BucketPolicyConfigured = s3PolicyConfigured
 	}
@@ -263,14 +275,14 @@ func (h *DownloadPackageHandler) ListDownloadPackagesByDomain(c *gin.Context) {
 
 		// 获取CloudFront状态和启用状态
 		if pkg.CloudFrontID != "" {
-			status, err := h.service.GetCloudFrontStatus(pkg.CloudFrontID)
+			status, err := h.service.GetCloudFrontStatus(&packages[i])
 			if err != nil {
 				responses[i].CloudFrontStatus = "unknown"
 			} else {
 				responses[i].CloudFrontStatus = status
 			}
 
-			enabled, err := h.service.GetCloudFrontEnabled(pkg.CloudFrontID)
+			enabled, err := h.service.GetCloudFrontEnabled(&packages[i])
 			if err != nil {
 				responses[i].CloudFrontEnabled = false
 			} else {
@@ -404,6 +416,18 @@ func (h *DownloadPackageHandler) FixDownloadPackage(c *gin.Context) {
 		return
 	}
 
+	// dry_run=true 时只返回修复计划，不做任何变更
+	if c.Query("dry_run") == "true" {
+		plan, err := h.service.PlanFixDownloadPackage(uint(id))
+		if err != nil {
+			log.WithError(err).WithField("package_id", id).Error("生成下载包修复计划失败")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
 	log.WithField("package_id", id).Info("开始修复下载包")
 	if err := h.service.FixDownloadPackage(uint(id)); err != nil {
 		log.WithError(err).WithField("package_id", id).Error("修复下载包操作失败")
@@ -444,3 +468,19 @@ func (h *DownloadPackageHandler) UpdateDownloadPackageNote(c *gin.Context) {
 	log.WithField("package_id", id).Info("下载包备注更新成功")
 	c.JSON(http.StatusOK, gin.H{"message": "备注更新成功"})
 }
+
+// InspectDownloadPackage 重新检查下载包文件（SHA-256 与 APK 元数据）
+func (h *DownloadPackageHandler) InspectDownloadPackage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+
+	pkg, err := h.service.InspectDownloadPackage(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, pkg)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DownloadPackageVersionHandler struct {
+	service *services.DownloadPackageVersionService
+}
+
+func NewDownloadPackageVersionHandler(service *services.DownloadPackageVersionService) *DownloadPackageVersionHandler {
+	return &DownloadPackageVersionHandler{service: service}
+}
+
+// ListVersions 列出下载包的全部版本
+func (h *DownloadPackageVersionHandler) ListVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+
+	versions, err := h.service.ListVersions(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// UploadVersion 上传新版本（表单上传，不会自动发布；大文件请使用 package_version 上传会话）
+func (h *DownloadPackageVersionHandler) UploadVersion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件上传失败: " + err.Error()})
+		return
+	}
+	fileReader, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "打开文件失败: " + err.Error()})
+		return
+	}
+	defer fileReader.Close()
+
+	version, err := h.service.UploadVersion(uint(id), file.Filename, fileReader, file.Size, c.PostForm("note"), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// PromoteVersion 发布指定版本，force=true 时跳过 APK 发布前检查
+func (h *DownloadPackageVersionHandler) PromoteVersion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本 ID"})
+		return
+	}
+
+	result, err := h.service.PromoteVersion(uint(id), uint(versionID), c.Query("force") == "true", c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RollbackVersion 回滚到上一次发布的版本
+func (h *DownloadPackageVersionHandler) RollbackVersion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+
+	result, err := h.service.RollbackVersion(uint(id), c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// InspectVersion 重新检查版本文件（SHA-256 与 APK 元数据）
+func (h *DownloadPackageVersionHandler) InspectVersion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本 ID"})
+		return
+	}
+
+	version, err := h.service.InspectVersion(uint(id), uint(versionID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// DeleteVersion 删除非当前版本
+func (h *DownloadPackageVersionHandler) DeleteVersion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的下载包 ID"})
+		return
+	}
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本 ID"})
+		return
+	}
+
+	if err := h.service.DeleteVersion(uint(id), uint(versionID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "版本已删除"})
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"aws_cdn/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GeoHandler struct {
+	redirectService *services.RedirectService
+}
+
+func NewGeoHandler(redirectService *services.RedirectService) *GeoHandler {
+	return &GeoHandler{redirectService: redirectService}
+}
+
+// GetCountry 查询访客所属国家（公共接口，供轮播 HTML 按国家路由使用）
+func (h *GeoHandler) GetCountry(c *gin.Context) {
+	ip := c.ClientIP()
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"ip":      ip,
+		"country": h.redirectService.LookupCountry(ip),
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
 	"aws_cdn/internal/services"
 	"net/http"
 	"strconv"
@@ -481,11 +482,19 @@ func (h *R2Handler) UploadFile(c *gin.Context) {
 		// 不影响上传成功的响应
 	}
 
+	// 计算 SHA-256 并解析 APK 元数据（表单文件支持随机读取，无需重新下载）
+	response := gin.H{"message": "文件上传成功", "key": key}
+	if record, err := h.fileService.InspectFile(uint(r2BucketID), key, src); err != nil {
+		log.WithError(err).Warn("检查上传文件失败")
+	} else {
+		response["inspection"] = record.FileInspection
+	}
+
 	log.WithFields(map[string]interface{}{
 		"bucket_id": r2BucketID,
 		"key":       key,
 	}).Info("文件上传成功")
-	c.JSON(http.StatusOK, gin.H{"message": "文件上传成功", "key": key})
+	c.JSON(http.StatusOK, response)
 }
 
 // CreateDirectory 创建目录
@@ -602,6 +611,16 @@ func (h *R2Handler) ListApkFiles(c *gin.Context) {
 		return
 	}
 
+	// 文件记录中的 SHA-256 与 APK 元数据
+	records := make(map[string]models.R2File)
+	if list, err := h.fileService.ListR2FileRecords(uint(r2BucketID)); err != nil {
+		log.WithError(err).Warn("获取文件记录失败")
+	} else {
+		for _, record := range list {
+			records[record.FilePath] = record
+		}
+	}
+
 	// 过滤出APK文件
 	apkFiles := make([]map[string]interface{}, 0)
 	for _, file := range files {
@@ -622,10 +641,18 @@ func (h *R2Handler) ListApkFiles(c *gin.Context) {
 				}
 			}
 
-			apkFiles = append(apkFiles, map[string]interface{}{
+			item := map[string]interface{}{
 				"file_name": fileName,
 				"file_path": file,
-			})
+			}
+			if record, ok := records[file]; ok {
+				item["file_size"] = record.FileSize
+				item["file_sha256"] = record.FileSHA256
+				item["apk"] = record.APK
+				item["inspected_at"] = record.InspectedAt
+				item["inspect_error"] = record.InspectError
+			}
+			apkFiles = append(apkFiles, item)
 		}
 	}
 
@@ -676,3 +703,49 @@ func (h *R2Handler) GetApkFileUrls(c *gin.Context) {
 
 	c.JSON(http.StatusOK, urls)
 }
+
+// GetFileRecord 获取文件记录（包含 SHA-256 与 APK 元数据）
+func (h *R2Handler) GetFileRecord(c *gin.Context) {
+	r2BucketID, err := strconv.ParseUint(c.Param("r2_bucket_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的存储桶 ID"})
+		return
+	}
+
+	filePath := c.Query("file_path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 file_path 参数"})
+		return
+	}
+
+	record, err := h.fileService.GetR2FileRecord(uint(r2BucketID), filePath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文件记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// InspectFile 重新检查文件（从 R2 下载并计算 SHA-256、解析 APK 元数据）
+func (h *R2Handler) InspectFile(c *gin.Context) {
+	log := logger.GetLogger()
+	r2BucketID, err := strconv.ParseUint(c.Param("r2_bucket_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的存储桶 ID"})
+		return
+	}
+
+	filePath := c.Query("file_path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 file_path 参数"})
+		return
+	}
+
+	record, err := h.fileService.InspectFile(uint(r2BucketID), filePath, nil)
+	if err != nil {
+		log.WithError(err).Error("检查文件失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
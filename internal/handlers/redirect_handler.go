@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
 	"aws_cdn/internal/services"
 	"net/http"
 	"strconv"
@@ -27,6 +28,7 @@ func (h *RedirectHandler) CreateRedirectRule(c *gin.Context) {
 		CertificateARN string   `json:"certificate_arn"` // 可选，用于创建CloudFront分发
 		DNSProvider    string   `json:"dns_provider"`    // aws 或 cloudflare，默认为 aws
 		GroupID        *uint    `json:"group_id"`        // 分组ID，可选
+		SelectionMode  string   `json:"selection_mode"`  // 目标选择算法，可选：round_robin、smooth_weighted、weighted_random
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -41,7 +43,7 @@ func (h *RedirectHandler) CreateRedirectRule(c *gin.Context) {
 		return
 	}
 
-	result, err := h.service.CreateRedirectRule(req.SourceDomain, req.TargetURLs, req.CertificateARN, req.DNSProvider, req.GroupID)
+	result, err := h.service.CreateRedirectRule(req.SourceDomain, req.TargetURLs, req.CertificateARN, req.DNSProvider, req.GroupID, models.RedirectSelectionMode(req.SelectionMode), c.GetString("username"))
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"source_domain":   req.SourceDomain,
@@ -83,12 +85,16 @@ func (h *RedirectHandler) GetRedirectRule(c *gin.Context) {
 
 	// 构建响应并查询状态
 	ruleMap := gin.H{
-		"id":            rule.ID,
-		"source_domain": rule.SourceDomain,
-		"cloudfront_id": rule.CloudFrontID,
-		"targets":       rule.Targets,
-		"created_at":    rule.CreatedAt,
-		"updated_at":    rule.UpdatedAt,
+		"id":             rule.ID,
+		"source_domain":  rule.SourceDomain,
+		"cloudfront_id":  rule.CloudFrontID,
+		"selection_mode": rule.EffectiveSelectionMode(),
+		// 健康检查配置
+		"health_check_enabled": rule.HealthCheckEnabled,
+		"min_active_targets":   rule.MinActiveTargets,
+		"targets":              rule.Targets,
+		"created_at":           rule.CreatedAt,
+		"updated_at":           rule.UpdatedAt,
 	}
 
 	// 查询 CloudFront 状态和启用状态
@@ -146,6 +152,10 @@ func (h *RedirectHandler) GetRedirectRule(c *gin.Context) {
 			"target_url": target.TargetURL,
 			"weight":     target.Weight,
 			"is_active":  target.IsActive,
+			// 健康检查状态
+			"auto_disabled":        target.AutoDisabled,
+			"consecutive_failures": target.ConsecutiveFailures,
+			"health_checked_at":    target.HealthCheckedAt,
 		}
 		// 检查URL状态
 		urlStatus := h.service.CheckURLStatus(target.TargetURL)
@@ -192,17 +202,22 @@ func (h *RedirectHandler) ListRedirectRules(c *gin.Context) {
 				"target_url": target.TargetURL,
 				"weight":     target.Weight,
 				"is_active":  target.IsActive,
+				// 健康检查状态
+				"auto_disabled": target.AutoDisabled,
 			}
 		}
 
 		rulesWithStatus[i] = gin.H{
-			"id":            rule.ID,
-			"source_domain": rule.SourceDomain,
-			"cloudfront_id": rule.CloudFrontID,
-			"status":        rule.Status,
-			"targets":       targets,
-			"created_at":    rule.CreatedAt,
-			"updated_at":    rule.UpdatedAt,
+			"id":             rule.ID,
+			"source_domain":  rule.SourceDomain,
+			"cloudfront_id":  rule.CloudFrontID,
+			"status":         rule.Status,
+			"selection_mode": rule.EffectiveSelectionMode(),
+			// 健康检查配置
+			"health_check_enabled": rule.HealthCheckEnabled,
+			"targets":              targets,
+			"created_at":           rule.CreatedAt,
+			"updated_at":           rule.UpdatedAt,
 		}
 	}
 
@@ -224,6 +239,7 @@ func (h *RedirectHandler) AddTarget(c *gin.Context) {
 
 	var req struct {
 		TargetURL string `json:"target_url" binding:"required"`
+		Weight    *int   `json:"weight"` // 权重，可选，默认为 1
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -231,7 +247,12 @@ func (h *RedirectHandler) AddTarget(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddTarget(uint(id), req.TargetURL); err != nil {
+	weight := 1
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+
+	if err := h.service.AddTarget(uint(id), req.TargetURL, weight, c.GetString("username")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -239,6 +260,63 @@ func (h *RedirectHandler) AddTarget(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "目标添加成功"})
 }
 
+// UpdateTarget 更新重定向目标的权重和启用状态
+func (h *RedirectHandler) UpdateTarget(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标 ID"})
+		return
+	}
+
+	var req struct {
+		Weight   *int  `json:"weight"`
+		IsActive *bool `json:"is_active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateTarget(uint(id), req.Weight, req.IsActive, c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "目标更新成功"})
+}
+
+// UpdateSelectionMode 更新重定向规则的目标选择算法
+func (h *RedirectHandler) UpdateSelectionMode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req struct {
+		SelectionMode string `json:"selection_mode" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := models.RedirectSelectionMode(req.SelectionMode)
+	if !mode.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的选择算法"})
+		return
+	}
+
+	if err := h.service.UpdateSelectionMode(uint(id), mode, c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "选择算法更新成功"})
+}
+
 // RemoveTarget 删除重定向目标
 func (h *RedirectHandler) RemoveTarget(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -247,7 +325,7 @@ func (h *RedirectHandler) RemoveTarget(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RemoveTarget(uint(id)); err != nil {
+	if err := h.service.RemoveTarget(uint(id), c.GetString("username")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -329,8 +407,20 @@ func (h *RedirectHandler) FixRedirectRule(c *gin.Context) {
 		return
 	}
 
+	// dry_run=true 时只返回修复计划，不做任何变更
+	if c.Query("dry_run") == "true" {
+		plan, err := h.service.PlanFixRedirectRule(uint(id))
+		if err != nil {
+			log.WithError(err).WithField("rule_id", id).Error("生成重定向规则修复计划失败")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
 	log.WithField("rule_id", id).Info("开始修复重定向规则")
-	result, err := h.service.FixRedirectRule(uint(id))
+	result, err := h.service.FixRedirectRule(uint(id), c.GetString("username"))
 	if err != nil {
 		log.WithError(err).WithField("rule_id", id).Error("修复重定向规则操作失败")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -376,3 +466,64 @@ func (h *RedirectHandler) UpdateRedirectRuleNote(c *gin.Context) {
 	log.WithField("rule_id", id).Info("重定向规则备注更新成功")
 	c.JSON(http.StatusOK, gin.H{"message": "备注更新成功"})
 }
+
+// ListRuleVersions 查询重定向规则的部署版本
+func (h *RedirectHandler) ListRuleVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	versions, total, err := h.service.ListVersions(uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      versions,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RollbackRule 回滚重定向规则到指定版本
+func (h *RedirectHandler) RollbackRule(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本号"})
+		return
+	}
+
+	if err := h.service.RollbackToVersion(uint(id), version, c.GetString("username")); err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"rule_id": id,
+			"version": version,
+		}).Error("回滚重定向规则失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"rule_id": id,
+		"version": version,
+	}).Info("重定向规则回滚成功")
+	c.JSON(http.StatusOK, gin.H{"message": "回滚成功"})
+}
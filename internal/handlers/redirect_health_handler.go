@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RedirectHealthHandler struct {
+	service *services.RedirectHealthService
+}
+
+func NewRedirectHealthHandler(service *services.RedirectHealthService) *RedirectHealthHandler {
+	return &RedirectHealthHandler{service: service}
+}
+
+// UpdateHealthCheckConfig 更新轮播规则的健康检查配置
+func (h *RedirectHealthHandler) UpdateHealthCheckConfig(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req struct {
+		Enabled          bool `json:"enabled"`
+		MinActiveTargets int  `json:"min_active_targets"` // 最少保留的活跃目标数，默认 1
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MinActiveTargets == 0 {
+		req.MinActiveTargets = 1
+	}
+
+	if err := h.service.UpdateHealthCheckConfig(uint(id), req.Enabled, req.MinActiveTargets); err != nil {
+		log.WithError(err).WithField("rule_id", id).Error("更新健康检查配置失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "健康检查配置更新成功"})
+}
+
+// ListHealthEvents 查询轮播规则的目标摘除/恢复记录
+func (h *RedirectHealthHandler) ListHealthEvents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	events, total, err := h.service.ListHealthEvents(uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RedirectRouteHandler struct {
+	service *services.RedirectRouteService
+}
+
+func NewRedirectRouteHandler(service *services.RedirectRouteService) *RedirectRouteHandler {
+	return &RedirectRouteHandler{service: service}
+}
+
+// redirectRouteRequest 路由条件请求体
+type redirectRouteRequest struct {
+	Name      string   `json:"name"`
+	Priority  int      `json:"priority"`                      // 数值大的先匹配
+	Countries []string `json:"countries"`                     // 国家 ISO 代码，为空表示不限
+	Platforms []string `json:"platforms"`                     // android、ios、desktop，为空表示不限
+	InApp     string   `json:"in_app"`                        // 应用内 WebView：空（不限）、only、exclude
+	TargetIDs []uint   `json:"target_ids" binding:"required"` // 命中时可选的目标ID
+	Enabled   *bool    `json:"enabled"`                       // 默认启用
+}
+
+// toModel 转换为模型
+func (r *redirectRouteRequest) toModel() *models.RedirectRoute {
+	ids := make([]string, 0, len(r.TargetIDs))
+	for _, id := range r.TargetIDs {
+		ids = append(ids, strconv.FormatUint(uint64(id), 10))
+	}
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &models.RedirectRoute{
+		Name:      r.Name,
+		Priority:  r.Priority,
+		Countries: strings.Join(r.Countries, ","),
+		Platforms: strings.Join(r.Platforms, ","),
+		InApp:     models.RedirectInAppMode(r.InApp),
+		TargetIDs: strings.Join(ids, ","),
+		Enabled:   enabled,
+	}
+}
+
+// ListRoutes 列出重定向规则的路由条件
+func (h *RedirectRouteHandler) ListRoutes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	routes, err := h.service.ListRoutes(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": routes})
+}
+
+// CreateRoute 创建路由条件
+func (h *RedirectRouteHandler) CreateRoute(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req redirectRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	route := req.toModel()
+	route.RuleID = uint(id)
+
+	if err := h.service.CreateRoute(route, c.GetString("username")); err != nil {
+		log.WithError(err).WithField("rule_id", id).Error("创建路由条件失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
+
+// UpdateRoute 更新路由条件
+func (h *RedirectRouteHandler) UpdateRoute(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的路由 ID"})
+		return
+	}
+
+	var req redirectRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateRoute(uint(id), req.toModel(), c.GetString("username")); err != nil {
+		log.WithError(err).WithField("route_id", id).Error("更新路由条件失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "路由条件更新成功"})
+}
+
+// DeleteRoute 删除路由条件
+func (h *RedirectRouteHandler) DeleteRoute(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的路由 ID"})
+		return
+	}
+
+	if err := h.service.DeleteRoute(uint(id), c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "路由条件删除成功"})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RedirectScheduleHandler struct {
+	service *services.RedirectScheduleService
+}
+
+func NewRedirectScheduleHandler(service *services.RedirectScheduleService) *RedirectScheduleHandler {
+	return &RedirectScheduleHandler{service: service}
+}
+
+// redirectScheduleRequest 定时切换窗口请求体
+type redirectScheduleRequest struct {
+	Name      string       `json:"name"`
+	Timezone  string       `json:"timezone"`                      // 时区，默认 Asia/Yangon
+	StartTime string       `json:"start_time" binding:"required"` // HH:MM
+	EndTime   string       `json:"end_time" binding:"required"`   // HH:MM
+	Weekdays  string       `json:"weekdays"`                      // 逗号分隔 0-6，为空表示每天
+	Weights   map[uint]int `json:"weights" binding:"required"`    // 窗口内目标权重：{"<target_id>": weight}
+	Priority  int          `json:"priority"`
+	Enabled   *bool        `json:"enabled"` // 默认启用
+}
+
+// toModel 转换为模型
+func (r *redirectScheduleRequest) toModel() (*models.RedirectSchedule, error) {
+	weightsJSON, err := services.EncodeScheduleWeights(r.Weights)
+	if err != nil {
+		return nil, err
+	}
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	return &models.RedirectSchedule{
+		Name:        r.Name,
+		Timezone:    r.Timezone,
+		StartTime:   r.StartTime,
+		EndTime:     r.EndTime,
+		Weekdays:    r.Weekdays,
+		WeightsJSON: weightsJSON,
+		Priority:    r.Priority,
+		Enabled:     enabled,
+	}, nil
+}
+
+// ListSchedules 列出重定向规则的定时切换窗口
+func (h *RedirectScheduleHandler) ListSchedules(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// CreateSchedule 创建定时切换窗口
+func (h *RedirectScheduleHandler) CreateSchedule(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req redirectScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schedule.RuleID = uint(id)
+
+	if err := h.service.CreateSchedule(schedule, c.GetString("username")); err != nil {
+		log.WithError(err).WithField("rule_id", id).Error("创建定时切换窗口失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule 更新定时切换窗口
+func (h *RedirectScheduleHandler) UpdateSchedule(c *gin.Context) {
+	log := logger.GetLogger()
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的窗口 ID"})
+		return
+	}
+
+	var req redirectScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateSchedule(uint(id), schedule, c.GetString("username")); err != nil {
+		log.WithError(err).WithField("schedule_id", id).Error("更新定时切换窗口失败")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "窗口更新成功"})
+}
+
+// DeleteSchedule 删除定时切换窗口
+func (h *RedirectScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的窗口 ID"})
+		return
+	}
+
+	if err := h.service.DeleteSchedule(uint(id), c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "窗口删除成功"})
+}
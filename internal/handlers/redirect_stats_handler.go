@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 公共点击上报的频率限制：同一客户端 IP 对同一规则每分钟最多上报的次数
+const (
+	redirectBeaconLimit  = 30
+	redirectBeaconWindow = time.Minute
+)
+
+type RedirectStatsHandler struct {
+	service     *services.RedirectService
+	beaconLimit *services.ClickRateLimiter
+}
+
+func NewRedirectStatsHandler(service *services.RedirectService) *RedirectStatsHandler {
+	return &RedirectStatsHandler{
+		service:     service,
+		beaconLimit: services.NewClickRateLimiter(redirectBeaconLimit, redirectBeaconWindow),
+	}
+}
+
+// IngestBeacon 接收轮播 HTML 的点击上报（公共接口）
+// sendBeacon 以 text/plain 发送 JSON，避免跨域预检；国家优先使用服务端按 IP 查询的结果
+func (h *RedirectStatsHandler) IngestBeacon(c *gin.Context) {
+	var req struct {
+		RuleID  uint   `json:"rule_id" binding:"required"`
+		Target  string `json:"target" binding:"required"`
+		Country string `json:"country"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 先确认规则存在且开启了点击统计，不存在的规则 ID 不占用限流记录
+	rule, err := h.service.GetRedirectRule(req.RuleID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "重定向规则不存在"})
+		return
+	}
+	if !rule.ClickTracking {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	clientIP := c.ClientIP()
+	if !h.beaconLimit.Allow(fmt.Sprintf("%s|%d", clientIP, rule.ID), time.Now()) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "上报过于频繁，请稍后再试"})
+		return
+	}
+
+	country := h.service.LookupCountry(clientIP)
+	if country == "" {
+		country = req.Country
+	}
+
+	if err := h.service.RecordRuleClick(rule, services.RedirectClick{
+		RuleID:    req.RuleID,
+		TargetURL: req.Target,
+		Country:   country,
+		UserAgent: c.Request.UserAgent(),
+		Source:    models.RedirectClickSourceBeacon,
+	}); err != nil {
+		logger.GetLogger().WithError(err).WithField("rule_id", req.RuleID).Debug("记录重定向点击失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateClickTracking 开启/关闭点击统计
+func (h *RedirectStatsHandler) UpdateClickTracking(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateClickTracking(uint(id), *req.Enabled, c.GetString("username")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "点击统计设置已更新"})
+}
+
+// GetStats 查询规则的点击统计
+// 参数：granularity（hour、day，默认 day）、start_date、end_date（2006-01-02，默认最近 7 天）
+func (h *RedirectStatsHandler) GetStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的规则 ID"})
+		return
+	}
+
+	today := time.Now().In(time.Local)
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	start := end.AddDate(0, 0, -6)
+	if s := c.Query("start_date"); s != "" {
+		if start, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始日期，格式为 2006-01-02"})
+			return
+		}
+	}
+	if s := c.Query("end_date"); s != "" {
+		if end, err = time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束日期，格式为 2006-01-02"})
+			return
+		}
+	}
+
+	stats, err := h.service.GetClickStats(uint(id), c.Query("granularity"), start, end)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
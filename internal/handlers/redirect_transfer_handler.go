@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/services"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RedirectTransferHandler struct {
+	service *services.RedirectTransferService
+}
+
+func NewRedirectTransferHandler(service *services.RedirectTransferService) *RedirectTransferHandler {
+	return &RedirectTransferHandler{service: service}
+}
+
+// maxRedirectImportSize 导入文件大小上限
+const maxRedirectImportSize = 10 << 20
+
+// ExportRules 导出重定向规则（format=yaml|csv，可按 group_id 过滤）
+func (h *RedirectTransferHandler) ExportRules(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", services.RedirectTransferFormatYAML))
+
+	var groupID *uint
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		id, err := strconv.ParseUint(groupIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分组 ID"})
+			return
+		}
+		gid := uint(id)
+		groupID = &gid
+	}
+
+	data, err := h.service.Export(format, groupID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "application/x-yaml"
+	if format == services.RedirectTransferFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := fmt.Sprintf("redirects-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// ImportRules 导入重定向规则（multipart 字段 file；dry_run=true 时只返回预计变更）
+func (h *RedirectTransferHandler) ImportRules(c *gin.Context) {
+	log := logger.GetLogger()
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传导入文件"})
+		return
+	}
+	if file.Size > maxRedirectImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导入文件不能超过 10MB"})
+		return
+	}
+
+	// 未指定格式时按扩展名判断
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file.Filename)) {
+		case ".csv":
+			format = services.RedirectTransferFormatCSV
+		default:
+			format = services.RedirectTransferFormatYAML
+		}
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取导入文件失败"})
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取导入文件失败"})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := h.service.Import(format, data, dryRun, c.GetString("username"))
+	if err != nil {
+		log.WithError(err).WithField("filename", file.Filename).Error("导入重定向规则失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
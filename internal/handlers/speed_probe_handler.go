@@ -32,6 +32,7 @@ func (h *SpeedProbeHandler) ReportProbeResult(c *gin.Context) {
 		Status         string  `json:"status"`
 		ErrorMessage   string  `json:"error_message"`
 		UserAgent      string  `json:"user_agent"`
+		ContentSHA256  string  `json:"content_sha256"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -65,6 +66,7 @@ func (h *SpeedProbeHandler) ReportProbeResult(c *gin.Context) {
 		Status:         status,
 		ErrorMessage:   req.ErrorMessage,
 		UserAgent:      userAgent,
+		ContentSHA256:  req.ContentSHA256,
 	}
 
 	if err := h.service.ReportProbeResult(result); err != nil {
@@ -99,6 +101,7 @@ func (h *SpeedProbeHandler) BatchReportProbeResults(c *gin.Context) {
 			Status         string  `json:"status"`
 			ErrorMessage   string  `json:"error_message"`
 			UserAgent      string  `json:"user_agent"`
+			ContentSHA256  string  `json:"content_sha256"`
 		} `json:"results" binding:"required"`
 	}
 
@@ -133,6 +136,7 @@ func (h *SpeedProbeHandler) BatchReportProbeResults(c *gin.Context) {
 			Status:         status,
 			ErrorMessage:   r.ErrorMessage,
 			UserAgent:      userAgent,
+			ContentSHA256:  r.ContentSHA256,
 		}
 	}
 
@@ -259,7 +263,7 @@ func (h *SpeedProbeHandler) DeleteProbeResult(c *gin.Context) {
 }
 
 // ListAlertLogs 分页查询告警记录，支持丰富筛选
-// Query: page, page_size, url, time_window_from, time_window_to, created_start, created_end, alert_sent, failed_rate_min, failed_rate_max
+// Query: page, page_size, url, time_window_from, time_window_to, created_start, created_end, alert_sent, failed_rate_min, failed_rate_max, alert_type
 func (h *SpeedProbeHandler) ListAlertLogs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
@@ -305,6 +309,8 @@ func (h *SpeedProbeHandler) ListAlertLogs(c *gin.Context) {
 		}
 	}
 
+	filters.AlertType = c.Query("alert_type")
+
 	logs, total, err := h.service.ListAlertLogs(page, pageSize, &filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
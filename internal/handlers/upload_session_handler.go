@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"aws_cdn/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadSessionHandler struct {
+	service *services.UploadSessionService
+}
+
+func NewUploadSessionHandler(service *services.UploadSessionService) *UploadSessionHandler {
+	return &UploadSessionHandler{service: service}
+}
+
+// CreateSession 发起上传（分片上传或预签名直传）
+func (h *UploadSessionHandler) CreateSession(c *gin.Context) {
+	var req services.CreateUploadSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.service.CreateSession(req, c.GetString("username"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// ListSessions 列出上传会话，可按 status 过滤
+func (h *UploadSessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// GetSession 获取上传会话及已上传、缺失的分片
+func (h *UploadSessionHandler) GetSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+
+	detail, err := h.service.GetSession(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// PresignPut 重新获取直传的预签名 PUT 地址
+func (h *UploadSessionHandler) PresignPut(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+
+	session, err := h.service.PresignPut(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// PresignParts 获取分片预签名上传地址
+func (h *UploadSessionHandler) PresignParts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+
+	var req struct {
+		PartNumbers []int64 `json:"part_numbers" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	urls, err := h.service.PresignParts(uint(id), req.PartNumbers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": urls})
+}
+
+// UploadPart 经服务端代理上传单个分片，请求体为分片原始数据
+func (h *UploadSessionHandler) UploadPart(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+	partNumber, err := strconv.ParseInt(c.Param("part_number"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分片序号"})
+		return
+	}
+
+	if err := h.service.UploadPart(uint(id), partNumber, c.Request.Body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "分片上传成功", "part_number": partNumber})
+}
+
+// CompleteSession 校验上传结果并创建下载包或 R2 文件记录
+func (h *UploadSessionHandler) CompleteSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+
+	// 请求体可省略；直传时可携带 PUT 响应中的 ETag
+	var req struct {
+		ETag string `json:"etag"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	session, err := h.service.CompleteSession(uint(id), req.ETag)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// AbortSession 取消上传
+func (h *UploadSessionHandler) AbortSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的上传会话 ID"})
+		return
+	}
+
+	if err := h.service.AbortSession(uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "上传已取消"})
+}
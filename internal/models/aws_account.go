@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AWSAccount AWS 账号模型
+// 域名和下载包可以分布在多个 AWS 账号下，以分摊 CloudFront 分发配额并隔离封禁影响；
+// 未关联账号的域名和下载包使用环境变量中配置的默认账号。
+type AWSAccount struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Name            string         `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"` // 账号名称
+	AccessKeyID     string         `json:"-" gorm:"type:text"`                                 // Access Key ID（加密存储）
+	SecretAccessKey string         `json:"-" gorm:"type:text"`                                 // Secret Access Key（加密存储）
+	AccessKeyHint   string         `json:"access_key_hint" gorm:"type:varchar(50)"`            // 脱敏后的 Access Key ID，用于界面展示
+	Region          string         `json:"region" gorm:"type:varchar(50)"`                     // 默认区域
+	S3BucketName    string         `json:"s3_bucket_name" gorm:"type:varchar(255)"`            // 该账号下存放下载包和重定向 HTML 的存储桶
+	Note            string         `json:"note" gorm:"type:text"`                              // 备注
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// SecretsError 凭证解密失败的原因（未配置或缺少对应版本的主密钥），为空表示正常
+	SecretsError string `json:"secrets_error,omitempty" gorm:"-"`
+
+	secrets encryptedFields
+}
+
+// TableName 指定表名
+func (AWSAccount) TableName() string {
+	return "aws_accounts"
+}
+
+// secretFields 需要加密存储的字段
+func (a *AWSAccount) secretFields() map[string]*string {
+	return map[string]*string{
+		"access_key_id":     &a.AccessKeyID,
+		"secret_access_key": &a.SecretAccessKey,
+	}
+}
+
+// AfterFind 查询后解密凭证
+func (a *AWSAccount) AfterFind(_ *gorm.DB) error {
+	a.SecretsError = a.secrets.decrypt(a.secretFields())
+	return nil
+}
+
+// BeforeSave 保存前加密凭证（未配置主密钥时拒绝保存）
+func (a *AWSAccount) BeforeSave(_ *gorm.DB) error {
+	return a.secrets.encrypt(a.secretFields())
+}
+
+// AfterSave 保存后恢复明文，调用方可以继续使用该对象
+func (a *AWSAccount) AfterSave(_ *gorm.DB) error {
+	a.SecretsError = a.secrets.decrypt(a.secretFields())
+	return nil
+}
+
+// NeedsReencrypt 凭证是否为明文或不是当前版本主密钥加密的
+func (a *AWSAccount) NeedsReencrypt() bool {
+	return a.secrets.needsReencrypt
+}
@@ -14,15 +14,84 @@ type CFAccount struct {
 	APIToken          string         `json:"-" gorm:"type:text"`                                  // Cloudflare API Token（用于管理 R2 存储桶、自定义域名等，加密存储）
 	R2APIToken        string         `json:"-" gorm:"type:text"`                                  // R2 API Token（用于 R2 API 操作，加密存储）
 	AccountID         string         `json:"account_id" gorm:"type:varchar(100)"`                 // Cloudflare Account ID
-	R2AccessKeyID     string         `json:"-" gorm:"type:varchar(255)"`                          // R2 Access Key ID（账号维度，加密存储）
+	R2AccessKeyID     string         `json:"-" gorm:"type:text"`                                  // R2 Access Key ID（账号维度，加密存储）
 	R2SecretAccessKey string         `json:"-" gorm:"type:text"`                                  // R2 Secret Access Key（账号维度，加密存储）
 	Note              string         `json:"note" gorm:"type:text"`                               // 备注
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// API Token 校验结果，创建/更新 Token 时检测，也可以手动重新检测
+	TokenStatus           string                             `json:"token_status" gorm:"type:varchar(20)"`          // active、invalid 等，为空表示未检测
+	Capabilities          map[CFCapability]CFCapabilityCheck `json:"capabilities" gorm:"type:json;serializer:json"` // 各功能所需权限的检测结果
+	CapabilitiesCheckedAt *time.Time                         `json:"capabilities_checked_at"`                       // 最近一次检测时间
+
+	// SecretsError 凭证解密失败的原因（未配置或缺少对应版本的主密钥），为空表示正常
+	SecretsError string `json:"secrets_error,omitempty" gorm:"-"`
+
+	secrets encryptedFields
+}
+
+// CFCapability API Token 支撑的功能，每项对应 Cloudflare 的一组权限
+type CFCapability string
+
+const (
+	CFCapabilityDNS      CFCapability = "dns"      // Zone - DNS - Edit
+	CFCapabilityRulesets CFCapability = "rulesets" // Zone - 规则集（Transform/Redirect/Cache/WAF Rules）- Edit
+	CFCapabilityWorkers  CFCapability = "workers"  // Account - Workers Scripts - Edit
+	CFCapabilityR2       CFCapability = "r2"       // Account - Workers R2 Storage - Edit（使用 R2 API Token）
+	CFCapabilityPages    CFCapability = "pages"    // Account - Cloudflare Pages - Edit
+	CFCapabilityKV       CFCapability = "kv"       // Account - Workers KV Storage - Edit
+)
+
+// CFCapabilityStatus 权限检测结果
+type CFCapabilityStatus string
+
+const (
+	CFCapabilityGranted CFCapabilityStatus = "granted"
+	CFCapabilityMissing CFCapabilityStatus = "missing"
+	CFCapabilityUnknown CFCapabilityStatus = "unknown" // 无法检测（如未配置 Account ID、没有可用于探测的 Zone、网络错误）
+)
+
+// CFCapabilityCheck 单项功能的检测结果
+type CFCapabilityCheck struct {
+	Status  CFCapabilityStatus `json:"status"`
+	Message string             `json:"message,omitempty"`
 }
 
 // TableName 指定表名
 func (CFAccount) TableName() string {
 	return "cf_accounts"
 }
+
+// secretFields 需要加密存储的字段
+func (a *CFAccount) secretFields() map[string]*string {
+	return map[string]*string{
+		"api_token":            &a.APIToken,
+		"r2_api_token":         &a.R2APIToken,
+		"r2_access_key_id":     &a.R2AccessKeyID,
+		"r2_secret_access_key": &a.R2SecretAccessKey,
+	}
+}
+
+// AfterFind 查询后解密凭证
+func (a *CFAccount) AfterFind(_ *gorm.DB) error {
+	a.SecretsError = a.secrets.decrypt(a.secretFields())
+	return nil
+}
+
+// BeforeSave 保存前加密凭证（未配置主密钥时拒绝保存）
+func (a *CFAccount) BeforeSave(_ *gorm.DB) error {
+	return a.secrets.encrypt(a.secretFields())
+}
+
+// AfterSave 保存后恢复明文，调用方可以继续使用该对象
+func (a *CFAccount) AfterSave(_ *gorm.DB) error {
+	a.SecretsError = a.secrets.decrypt(a.secretFields())
+	return nil
+}
+
+// NeedsReencrypt 凭证是否为明文或不是当前版本主密钥加密的
+func (a *CFAccount) NeedsReencrypt() bool {
+	return a.secrets.needsReencrypt
+}
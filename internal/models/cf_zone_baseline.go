@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CFZoneBaseline Cloudflare Zone 设置基线
+// 声明一组 Zone 应保持的设置（如 HTTP/3、TLS 1.3、关闭 Rocket Loader），关联到 Zone 后定时检测漂移并可一键恢复。
+type CFZoneBaseline struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"` // 基线名称
+	Settings  []string       `json:"settings" gorm:"type:json;serializer:json"`          // 需要保持的设置项，见 GET /zone-baselines/settings
+	Note      string         `json:"note" gorm:"type:text"`                              // 备注
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (CFZoneBaseline) TableName() string {
+	return "cf_zone_baselines"
+}
+
+// ZoneDriftStatus Zone 与基线的一致性
+type ZoneDriftStatus string
+
+const (
+	ZoneDriftStatusUnchecked ZoneDriftStatus = "unchecked" // 关联后尚未检测
+	ZoneDriftStatusInSync    ZoneDriftStatus = "in_sync"   // 与基线一致
+	ZoneDriftStatusDrifted   ZoneDriftStatus = "drifted"   // 存在与基线不一致的设置
+	ZoneDriftStatusError     ZoneDriftStatus = "error"     // 读取 Zone 设置失败
+)
+
+// ZoneSettingDrift 一项与基线不一致的设置
+type ZoneSettingDrift struct {
+	Setting  string `json:"setting"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// CFZoneBaselineAttachment Zone 关联的基线及最近一次漂移检测结果（每个 Zone 最多关联一个基线）
+type CFZoneBaselineAttachment struct {
+	ID          uint               `json:"id" gorm:"primaryKey"`
+	CFAccountID uint               `json:"cf_account_id" gorm:"not null;index"`
+	ZoneID      string             `json:"zone_id" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ZoneName    string             `json:"zone_name" gorm:"type:varchar(255)"`
+	BaselineID  uint               `json:"baseline_id" gorm:"not null;index"`
+	Baseline    *CFZoneBaseline    `json:"baseline,omitempty" gorm:"foreignKey:BaselineID"`
+	DriftStatus ZoneDriftStatus    `json:"drift_status" gorm:"type:varchar(20);not null;default:'unchecked';index"`
+	Drift       []ZoneSettingDrift `json:"drift" gorm:"type:json;serializer:json"` // 不一致的设置
+	LastError   string             `json:"last_error" gorm:"type:text"`
+	CheckedAt   *time.Time         `json:"checked_at"` // 最近一次检测时间
+	AppliedAt   *time.Time         `json:"applied_at"` // 最近一次应用基线时间
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CFZoneBaselineAttachment) TableName() string {
+	return "cf_zone_baseline_attachments"
+}
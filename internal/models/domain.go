@@ -26,23 +26,39 @@ const (
 
 // Domain 域名模型
 type Domain struct {
-	ID                uint           `json:"id" gorm:"primaryKey"`
-	DomainName        string         `json:"domain_name" gorm:"type:varchar(255);not null"`
-	Registrar         string         `json:"registrar"`                                          // 原注册商
-	GroupID           *uint          `json:"group_id" gorm:"index"`                              // 所属分组ID
-	Group             *Group         `json:"group,omitempty" gorm:"foreignKey:GroupID"`          // 分组关联
-	CFAccountID       *uint          `json:"cf_account_id" gorm:"index"`                         // 关联的 Cloudflare 账号 ID（可选）
-	CFAccount         *CFAccount     `json:"cf_account,omitempty" gorm:"foreignKey:CFAccountID"` // CF 账号关联
-	DNSProvider       DNSProvider    `json:"dns_provider" gorm:"type:varchar(20);default:'aws'"` // DNS提供商: aws, cloudflare
-	Status            DomainStatus   `json:"status" gorm:"default:'pending'"`
-	NServers          string         `json:"n_servers" gorm:"type:text"`                  // NS 服务器配置，JSON 格式
-	CertificateStatus string         `json:"certificate_status" gorm:"default:'pending'"` // 证书状态: pending, issued, failed
-	CertificateARN    string         `json:"certificate_arn"`                             // ACM 证书 ARN
-	HostedZoneID      string         `json:"hosted_zone_id"`                              // Route53 Hosted Zone ID 或 Cloudflare Zone ID
-	Note              string         `json:"note" gorm:"type:text"`                       // 备注
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                            uint             `json:"id" gorm:"primaryKey"`
+	DomainName                    string           `json:"domain_name" gorm:"type:varchar(255);not null"`
+	Registrar                     string           `json:"registrar"`                                          // 原注册商
+	GroupID                       *uint            `json:"group_id" gorm:"index"`                              // 所属分组ID
+	Group                         *Group           `json:"group,omitempty" gorm:"foreignKey:GroupID"`          // 分组关联
+	CFAccountID                   *uint            `json:"cf_account_id" gorm:"index"`                         // 关联的 Cloudflare 账号 ID（可选）
+	CFAccount                     *CFAccount       `json:"cf_account,omitempty" gorm:"foreignKey:CFAccountID"` // CF 账号关联
+	AWSAccountID                  *uint            `json:"aws_account_id" gorm:"index"`                        // 证书、托管区域所在的 AWS 账号 ID，为空表示使用默认账号（环境变量配置）
+	DNSProvider                   DNSProvider      `json:"dns_provider" gorm:"type:varchar(20);default:'aws'"` // DNS提供商: aws, cloudflare
+	Status                        DomainStatus     `json:"status" gorm:"default:'pending'"`
+	NServers                      string           `json:"n_servers" gorm:"type:text"`                              // NS 服务器配置，JSON 格式
+	NSDelegationStatus            string           `json:"ns_delegation_status" gorm:"type:varchar(20)"`            // NS 委派检查结果: correct, partial, wrong, not_delegated，为空表示未检查
+	NSDelegationDetail            string           `json:"ns_delegation_detail" gorm:"type:text"`                   // 公网解析到的 NS 及与预期的差异
+	NSCheckedAt                   *time.Time       `json:"ns_checked_at"`                                           // 最近一次 NS 委派检查时间
+	CertificateStatus             string           `json:"certificate_status" gorm:"default:'pending'"`             // 证书状态: pending, issued, failed
+	CertificateARN                string           `json:"certificate_arn"`                                         // ACM 证书 ARN
+	CertificateNotAfter           *time.Time       `json:"certificate_not_after"`                                   // 证书过期时间（由证书监控任务更新）
+	CertificateRenewalEligibility string           `json:"certificate_renewal_eligibility" gorm:"type:varchar(20)"` // ACM 续期资格: ELIGIBLE, INELIGIBLE
+	CertificateRenewalStatus      string           `json:"certificate_renewal_status" gorm:"type:varchar(30)"`      // ACM 托管续期状态，未开始续期时为空
+	CertificateInUseBy            string           `json:"certificate_in_use_by" gorm:"type:text"`                  // 使用证书的资源 ARN，JSON 数组
+	CertificateCheckedAt          *time.Time       `json:"certificate_checked_at"`                                  // 最近一次证书监控时间
+	CertificateAlert              string           `json:"certificate_alert" gorm:"type:text"`                      // 证书监控发现的问题，为空表示正常
+	CertificateAlertedAt          *time.Time       `json:"-"`                                                       // 最近一次发送告警的时间
+	CertificateAlertKey           string           `json:"-" gorm:"type:varchar(100)"`                              // 最近一次告警的问题类型，类型变化时立即重新告警
+	Reputation                    DomainReputation `json:"reputation" gorm:"type:varchar(20);index"`                // 域名信誉: ok, warning, suspected_blocked, unknown，为空表示未检测
+	ReputationDetail              string           `json:"reputation_detail" gorm:"type:text"`                      // 最近一次信誉检测的异常说明
+	ReputationCheckedAt           *time.Time       `json:"reputation_checked_at"`                                   // 最近一次信誉检测时间
+	RetiredAt                     *time.Time       `json:"retired_at"`                                              // 从重定向规则轮换下线的时间，下线后不再进入备用域名池
+	HostedZoneID                  string           `json:"hosted_zone_id"`                                          // Route53 Hosted Zone ID 或 Cloudflare Zone ID
+	Note                          string           `json:"note" gorm:"type:text"`                                   // 备注
+	CreatedAt                     time.Time        `json:"created_at"`
+	UpdatedAt                     time.Time        `json:"updated_at"`
+	DeletedAt                     gorm.DeletedAt   `json:"-" gorm:"index"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// DomainReputation 域名信誉（综合各检测器的最近一次结果）
+type DomainReputation string
+
+const (
+	DomainReputationOK               DomainReputation = "ok"                // 所有检测正常
+	DomainReputationWarning          DomainReputation = "warning"           // 部分检测异常（如个别解析器超时、HTTP 不可达）
+	DomainReputationSuspectedBlocked DomainReputation = "suspected_blocked" // 疑似被运营商拦截或被浏览器标记
+	DomainReputationUnknown          DomainReputation = "unknown"           // 检测全部失败，无法判断
+)
+
+// ReputationResult 单个检测器的结果
+type ReputationResult string
+
+const (
+	ReputationResultOK      ReputationResult = "ok"
+	ReputationResultWarning ReputationResult = "warning"
+	ReputationResultBlocked ReputationResult = "blocked"
+	ReputationResultError   ReputationResult = "error" // 检测器自身出错（如 API 不可用），不计入判断
+)
+
+// DomainReputationCheck 域名信誉检测记录（时间序列，每次检测每个检测器一行）
+type DomainReputationCheck struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	DomainID  uint             `json:"domain_id" gorm:"not null;index:idx_domain_reputation_checks_domain,priority:1"`
+	Detector  string           `json:"detector" gorm:"type:varchar(32);not null"` // dns、http、safe_browsing
+	Result    ReputationResult `json:"result" gorm:"type:varchar(16);not null"`
+	Detail    string           `json:"detail" gorm:"type:text"`
+	CheckedAt time.Time        `json:"checked_at" gorm:"not null;index:idx_domain_reputation_checks_domain,priority:2;index"`
+}
+
+// TableName 指定表名
+func (DomainReputationCheck) TableName() string {
+	return "domain_reputation_checks"
+}
@@ -17,6 +17,14 @@ const (
 	DownloadPackageStatusFailed     DownloadPackageStatus = "failed"     // 失败
 )
 
+// 发布版本后 CloudFront 缓存失效的状态；完成前 CDN 仍可能返回旧文件，探测到的内容不一致不告警
+const (
+	InvalidationStatusPending    = "pending"    // 文件已切换，正在创建缓存失效
+	InvalidationStatusInProgress = "InProgress" // CloudFront 处理中
+	InvalidationStatusCompleted  = "Completed"  // 已完成
+	InvalidationStatusFailed     = "failed"     // 创建缓存失效失败，缓存过期前可能一直返回旧文件
+)
+
 // DownloadPackage 下载包模型
 type DownloadPackage struct {
 	ID               uint                  `json:"id" gorm:"primaryKey"`
@@ -24,6 +32,7 @@ type DownloadPackage struct {
 	Domain           Domain                `json:"domain" gorm:"foreignKey:DomainID"`
 	GroupID          *uint                 `json:"group_id" gorm:"index"`                                               // 所属分组ID
 	Group            *Group                `json:"group,omitempty" gorm:"foreignKey:GroupID"`                           // 分组关联
+	AWSAccountID     *uint                 `json:"aws_account_id" gorm:"index"`                                         // S3 文件和 CloudFront 分发所在的 AWS 账号 ID，为空表示使用默认账号
 	DomainName       string                `json:"domain_name" gorm:"type:varchar(255);not null"`                       // 下载域名
 	FileName         string                `json:"file_name" gorm:"type:varchar(255);not null"`                         // 文件名
 	FileSize         int64                 `json:"file_size" gorm:"not null"`                                           // 文件大小（字节）
@@ -32,12 +41,19 @@ type DownloadPackage struct {
 	CloudFrontID     string                `json:"cloudfront_id" gorm:"column:cloudfront_id;type:varchar(255)"`         // CloudFront分发ID
 	CloudFrontDomain string                `json:"cloudfront_domain" gorm:"column:cloudfront_domain;type:varchar(255)"` // CloudFront域名
 	DownloadURL      string                `json:"download_url" gorm:"type:varchar(500)"`                               // 下载URL（通过域名访问）
+	CurrentVersionID *uint                 `json:"current_version_id"`                                                  // 当前发布的版本ID，为空表示未启用版本管理
 	Status           DownloadPackageStatus `json:"status" gorm:"default:'pending'"`
 	ErrorMessage     string                `json:"error_message" gorm:"type:text"` // 错误信息
 	Note             string                `json:"note" gorm:"type:text"`            // 备注
 	CreatedAt        time.Time             `json:"created_at"`
 	UpdatedAt        time.Time             `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt        `json:"-" gorm:"index"`
+
+	FileInspection `gorm:"embedded"` // 文件 SHA-256 与 APK 元数据
+
+	InvalidationID     string     `json:"invalidation_id" gorm:"type:varchar(50)"`     // 最近一次发布版本时的 CloudFront 缓存失效 ID
+	InvalidationStatus string     `json:"invalidation_status" gorm:"type:varchar(20)"` // 最近一次缓存失效的状态，为空表示没有发布过版本
+	InvalidationSince  *time.Time `json:"invalidation_since,omitempty"`                // 最近一次发布版本切换文件的时间，超过分发的 MaxTTL 后旧文件的缓存必然过期
 }
 
 // TableName 指定表名
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// DownloadPackageVersion 下载包版本
+// 每个版本保存在独立的 S3 键（versions/ 前缀，不在 CloudFront 源路径下），发布时复制到下载包的 S3Key，旧版本保留用于回滚。
+type DownloadPackageVersion struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	PackageID  uint       `json:"package_id" gorm:"not null;uniqueIndex:idx_package_version"`
+	Version    int        `json:"version" gorm:"not null;uniqueIndex:idx_package_version"` // 版本号，从 1 开始递增
+	S3Key      string     `json:"s3_key" gorm:"type:varchar(500);not null"`
+	FileName   string     `json:"file_name" gorm:"type:varchar(255)"` // 上传时的文件名（下载地址始终使用下载包的文件名）
+	FileSize   int64      `json:"file_size" gorm:"not null"`
+	Note       string     `json:"note" gorm:"type:text"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(100)"`
+	PromotedAt *time.Time `json:"promoted_at"` // 最近一次发布时间
+	PromotedBy string     `json:"promoted_by" gorm:"type:varchar(100)"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	FileInspection `gorm:"embedded"` // 文件 SHA-256 与 APK 元数据，发布前用于比对包名和签名
+}
+
+// TableName 指定表名
+func (DownloadPackageVersion) TableName() string {
+	return "download_package_versions"
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"aws_cdn/internal/secrets"
+	"fmt"
+	"sort"
+)
+
+// encryptedFields 记录模型中加密字段的解密状态
+// 模型在 AfterFind/AfterSave 中解密、BeforeSave 中加密，调用方读写的始终是明文。
+// 解密失败（缺少主密钥或密钥不匹配）的字段置空并保留原始密文，保存时原样写回，避免覆盖掉无法解密的凭证。
+// 数据库写入失败时 AfterSave 不会执行，字段停留在密文；pending 记录 BeforeSave 写入的值，再次保存前先恢复明文，避免重复加密。
+type encryptedFields struct {
+	raw            map[string]string
+	pending        map[string]pendingSecret
+	needsReencrypt bool
+	decryptErr     error
+}
+
+// pendingSecret BeforeSave 写入字段的值及其对应的明文
+type pendingSecret struct {
+	stored    string
+	plaintext string
+}
+
+// decrypt 原地解密字段，返回解密失败的原因（全部成功时为空）
+func (e *encryptedFields) decrypt(fields map[string]*string) string {
+	e.raw = nil
+	e.pending = nil
+	e.needsReencrypt = false
+	e.decryptErr = nil
+	for _, name := range sortedFieldNames(fields) {
+		field := fields[name]
+		if *field == "" {
+			continue
+		}
+		if secrets.NeedsReencrypt(*field) {
+			e.needsReencrypt = true
+		}
+		plaintext, err := secrets.Decrypt(*field)
+		if err != nil {
+			if e.raw == nil {
+				e.raw = make(map[string]string)
+			}
+			e.raw[name] = *field
+			*field = ""
+			if e.decryptErr == nil {
+				e.decryptErr = fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		*field = plaintext
+	}
+	if e.decryptErr != nil {
+		return e.decryptErr.Error()
+	}
+	return ""
+}
+
+// encrypt 原地加密字段，空字段写回解密失败时保留的原始密文
+func (e *encryptedFields) encrypt(fields map[string]*string) error {
+	e.restore(fields)
+	pending := make(map[string]pendingSecret, len(fields))
+	for _, name := range sortedFieldNames(fields) {
+		field := fields[name]
+		plaintext := *field
+		if plaintext == "" {
+			*field = e.raw[name]
+		} else {
+			encrypted, err := secrets.Encrypt(plaintext)
+			if err != nil {
+				e.pending = pending
+				e.restore(fields)
+				return fmt.Errorf("加密 %s 失败: %w", name, err)
+			}
+			*field = encrypted
+		}
+		pending[name] = pendingSecret{stored: *field, plaintext: plaintext}
+	}
+	e.pending = pending
+	return nil
+}
+
+// restore 把上一次 BeforeSave 写入、尚未保存成功的字段恢复为明文（调用方已修改的字段保持不变）
+func (e *encryptedFields) restore(fields map[string]*string) {
+	for name, p := range e.pending {
+		if field, ok := fields[name]; ok && *field == p.stored {
+			*field = p.plaintext
+		}
+	}
+	e.pending = nil
+}
+
+func sortedFieldNames(fields map[string]*string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
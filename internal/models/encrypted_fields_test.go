@@ -0,0 +1,91 @@
+package models
+
+import (
+	"aws_cdn/internal/secrets"
+	"strings"
+	"testing"
+)
+
+const testMasterKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+// 保存时加密、查询后解密；无法解密的凭证保存时原样写回
+func TestCFAccountSecretHooks(t *testing.T) {
+	if err := secrets.SetMasterKey(testMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	defer secrets.SetKeyring(nil)
+
+	account := &CFAccount{APIToken: "cf-token", R2SecretAccessKey: "r2-secret"}
+	if err := account.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !secrets.IsEncrypted(account.APIToken) || !secrets.IsEncrypted(account.R2SecretAccessKey) || account.R2APIToken != "" {
+		t.Fatalf("保存前应加密非空凭证: %+v", account)
+	}
+	stored := account.APIToken
+
+	loaded := &CFAccount{APIToken: stored}
+	loaded.AfterFind(nil)
+	if loaded.APIToken != "cf-token" || loaded.SecretsError != "" || loaded.NeedsReencrypt() {
+		t.Fatalf("查询后应解密: %+v", loaded)
+	}
+
+	legacy := &CFAccount{APIToken: "plain-token"}
+	legacy.AfterFind(nil)
+	if legacy.APIToken != "plain-token" || !legacy.NeedsReencrypt() {
+		t.Fatalf("历史明文应原样可用并标记需要重新加密: %+v", legacy)
+	}
+
+	if err := secrets.SetMasterKey(strings.Repeat("ab", 32)); err != nil {
+		t.Fatal(err)
+	}
+	broken := &CFAccount{APIToken: stored}
+	broken.AfterFind(nil)
+	if broken.APIToken != "" || broken.SecretsError == "" {
+		t.Fatalf("主密钥不匹配时应置空并记录原因: %+v", broken)
+	}
+	broken.Note = "updated"
+	if err := broken.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if broken.APIToken != stored {
+		t.Fatal("无法解密的凭证应原样写回")
+	}
+}
+
+// 数据库写入失败时 AfterSave 不执行，再次保存不能对密文重复加密；无法解密的凭证重试时仍原样写回
+func TestAWSAccountSecretRetrySave(t *testing.T) {
+	if err := secrets.SetMasterKey(testMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	defer secrets.SetKeyring(nil)
+
+	account := &AWSAccount{AccessKeyID: "AKIA", SecretAccessKey: "secret"}
+	if err := account.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := account.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	loaded := &AWSAccount{AccessKeyID: account.AccessKeyID, SecretAccessKey: account.SecretAccessKey}
+	loaded.AfterFind(nil)
+	if loaded.AccessKeyID != "AKIA" || loaded.SecretAccessKey != "secret" {
+		t.Fatalf("重试保存后应只加密一次: %+v", loaded)
+	}
+
+	stored := account.SecretAccessKey
+	if err := secrets.SetMasterKey(strings.Repeat("ab", 32)); err != nil {
+		t.Fatal(err)
+	}
+	broken := &AWSAccount{SecretAccessKey: stored}
+	broken.AfterFind(nil)
+	if err := broken.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := broken.BeforeSave(nil); err != nil {
+		t.Fatal(err)
+	}
+	if broken.SecretAccessKey != stored {
+		t.Fatal("无法解密的凭证重试保存时应原样写回")
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// FilePrefixHashSize 前缀哈希覆盖的字节数（1MB），探测端只下载文件开头时与之比对
+const FilePrefixHashSize = 1 << 20
+
+// APKInfo 从 APK 的 AndroidManifest.xml 和签名块解析出的元数据，非 APK 文件为空
+type APKInfo struct {
+	PackageName       string `json:"package_name" gorm:"type:varchar(255)"`
+	VersionCode       int64  `json:"version_code"`
+	VersionName       string `json:"version_name" gorm:"type:varchar(100)"`
+	MinSDK            int    `json:"min_sdk"`
+	SigningCertSHA256 string `json:"signing_cert_sha256" gorm:"type:varchar(64)"` // 签名证书 SHA-256（十六进制小写）
+	SignatureSchemes  string `json:"signature_schemes" gorm:"type:varchar(50)"`   // 签名方案（逗号分隔，如 v1,v2,v3），为空表示未签名
+}
+
+// FileInspection 上传文件的完整性记录
+// 上传后异步计算，InspectedAt 为空表示尚未完成；文件 SHA-256 用于与探测下载的内容比对。
+type FileInspection struct {
+	FileSHA256   string     `json:"file_sha256" gorm:"column:file_sha256;type:varchar(64)"`
+	APK          APKInfo    `json:"apk" gorm:"embedded;embeddedPrefix:apk_"`
+	InspectedAt  *time.Time `json:"inspected_at"`
+	InspectError string     `json:"inspect_error" gorm:"type:text"` // 读取或解析失败的原因
+
+	FilePrefixSHA256 string `json:"file_prefix_sha256" gorm:"column:file_prefix_sha256;type:varchar(64)"` // 文件前 FilePrefixHashSize 字节的 SHA-256
+}
@@ -15,6 +15,8 @@ type R2File struct {
 	Status      string    `gorm:"type:varchar(20);default:active" json:"status"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	FileInspection `gorm:"embedded"` // 文件 SHA-256 与 APK 元数据
 }
 
 // TableName 指定表名
@@ -16,31 +16,67 @@ const (
 	RedirectRuleStatusFailed     RedirectRuleStatus = "failed"     // 失败
 )
 
+// RedirectSelectionMode 目标选择算法
+type RedirectSelectionMode string
+
+const (
+	RedirectSelectionRoundRobin     RedirectSelectionMode = "round_robin"     // 简单轮询（忽略权重）
+	RedirectSelectionSmoothWeighted RedirectSelectionMode = "smooth_weighted" // 平滑加权轮询（首次访问按权重随机）
+	RedirectSelectionWeightedRandom RedirectSelectionMode = "weighted_random" // 每次按权重随机
+)
+
+// IsValid 判断选择算法是否合法
+func (m RedirectSelectionMode) IsValid() bool {
+	switch m {
+	case RedirectSelectionRoundRobin, RedirectSelectionSmoothWeighted, RedirectSelectionWeightedRandom:
+		return true
+	}
+	return false
+}
+
 // RedirectRule 重定向规则
 type RedirectRule struct {
-	ID           uint               `json:"id" gorm:"primaryKey"`
-	SourceDomain string             `json:"source_domain" gorm:"type:varchar(255);uniqueIndex;not null"`
-	GroupID      *uint              `json:"group_id" gorm:"index"`                     // 所属分组ID
-	Group        *Group             `json:"group,omitempty" gorm:"foreignKey:GroupID"` // 分组关联
-	Targets      []RedirectTarget   `json:"targets" gorm:"foreignKey:RuleID;constraint:OnDelete:CASCADE"`
-	CloudFrontID string             `json:"cloudfront_id"`                                    // CloudFront Distribution ID
-	Status       RedirectRuleStatus `json:"status" gorm:"type:varchar(32);default:'pending'"` // 状态
-	Note         string             `json:"note" gorm:"type:text"`                            // 备注
-	CreatedAt    time.Time          `json:"created_at"`
-	UpdatedAt    time.Time          `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt     `json:"-" gorm:"index"`
+	ID                 uint                  `json:"id" gorm:"primaryKey"`
+	SourceDomain       string                `json:"source_domain" gorm:"type:varchar(255);uniqueIndex;not null"`
+	GroupID            *uint                 `json:"group_id" gorm:"index"`                     // 所属分组ID
+	Group              *Group                `json:"group,omitempty" gorm:"foreignKey:GroupID"` // 分组关联
+	Targets            []RedirectTarget      `json:"targets" gorm:"foreignKey:RuleID;constraint:OnDelete:CASCADE"`
+	CloudFrontID       string                `json:"cloudfront_id"`                                                    // CloudFront Distribution ID
+	Status             RedirectRuleStatus    `json:"status" gorm:"type:varchar(32);default:'pending'"`                 // 状态
+	SelectionMode      RedirectSelectionMode `json:"selection_mode" gorm:"type:varchar(32);default:'smooth_weighted'"` // 目标选择算法
+	ActiveScheduleID   *uint                 `json:"active_schedule_id" gorm:"index"`                                  // 当前生效的定时切换窗口ID（由定时任务维护）
+	HealthCheckEnabled bool                  `json:"health_check_enabled" gorm:"default:false"`                        // 是否根据探测结果自动摘除/恢复目标
+	MinActiveTargets   int                   `json:"min_active_targets" gorm:"default:1"`                              // 自动摘除时至少保留的活跃目标数
+	ClickTracking      bool                  `json:"click_tracking" gorm:"default:false"`                              // 是否上报点击统计（HTML 跳转前发送 beacon）
+	Note               string                `json:"note" gorm:"type:text"`                                            // 备注
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt        `json:"-" gorm:"index"`
+}
+
+// EffectiveSelectionMode 返回实际使用的选择算法（旧数据为空时使用平滑加权轮询）
+func (r *RedirectRule) EffectiveSelectionMode() RedirectSelectionMode {
+	if r.SelectionMode.IsValid() {
+		return r.SelectionMode
+	}
+	return RedirectSelectionSmoothWeighted
 }
 
 // RedirectTarget 重定向目标
 type RedirectTarget struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	RuleID    uint           `json:"rule_id" gorm:"not null"`
-	TargetURL string         `json:"target_url" gorm:"not null"`
-	Weight    int            `json:"weight" gorm:"default:1"` // 权重，用于轮询
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                   uint           `json:"id" gorm:"primaryKey"`
+	RuleID               uint           `json:"rule_id" gorm:"not null"`
+	TargetURL            string         `json:"target_url" gorm:"not null"`
+	Weight               int            `json:"weight" gorm:"default:1"` // 权重，用于加权选择；0 表示暂停分配流量
+	IsActive             bool           `json:"is_active" gorm:"default:true"`
+	AutoDisabled         bool           `json:"auto_disabled" gorm:"default:false"`     // 是否由健康检查自动摘除（仅自动摘除的目标会被自动恢复）
+	ConsecutiveFailures  int            `json:"consecutive_failures" gorm:"default:0"`  // 连续失败探测次数
+	ConsecutiveSuccesses int            `json:"consecutive_successes" gorm:"default:0"` // 连续成功探测次数
+	HealthCheckedAt      *time.Time     `json:"health_checked_at"`                      // 最近一次统计到的探测结果时间
+	HealthCheckedProbeID uint           `json:"-" gorm:"default:0"`                     // 最近一次统计到的探测结果 ID，与 HealthCheckedAt 组成游标
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // TableName 指定表名
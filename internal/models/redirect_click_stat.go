@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// RedirectClickSource 点击记录来源
+type RedirectClickSource string
+
+const (
+	RedirectClickSourceBeacon RedirectClickSource = "beacon" // 轮播 HTML 跳转前上报
+	RedirectClickSourceServer RedirectClickSource = "server" // 服务端 HandleRedirect 302 跳转
+)
+
+// RedirectClickStat 重定向点击按小时聚合的统计
+// 同一规则、小时、目标、国家、设备类型、来源只保留一行，每次点击累加 Clicks
+type RedirectClickStat struct {
+	ID        uint                `json:"id" gorm:"primaryKey"`
+	RuleID    uint                `json:"rule_id" gorm:"not null;uniqueIndex:idx_redirect_click_bucket,priority:1"`
+	Bucket    time.Time           `json:"bucket" gorm:"not null;uniqueIndex:idx_redirect_click_bucket,priority:2;index"` // 所在小时的起始时间
+	TargetID  uint                `json:"target_id" gorm:"not null;uniqueIndex:idx_redirect_click_bucket,priority:3"`
+	Country   string              `json:"country" gorm:"type:varchar(2);not null;default:'';uniqueIndex:idx_redirect_click_bucket,priority:4"` // 国家 ISO 代码，未知为空
+	Platform  RedirectPlatform    `json:"platform" gorm:"type:varchar(16);not null;uniqueIndex:idx_redirect_click_bucket,priority:5"`          // android、ios、desktop
+	InApp     bool                `json:"in_app" gorm:"not null;uniqueIndex:idx_redirect_click_bucket,priority:6"`                             // 是否应用内 WebView
+	Source    RedirectClickSource `json:"source" gorm:"type:varchar(16);not null;uniqueIndex:idx_redirect_click_bucket,priority:7"`
+	Clicks    int64               `json:"clicks" gorm:"not null;default:0"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (RedirectClickStat) TableName() string {
+	return "redirect_click_stats"
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RedirectPlatform 访客设备平台（由 User-Agent 判断）
+type RedirectPlatform string
+
+const (
+	RedirectPlatformAndroid RedirectPlatform = "android" // Android
+	RedirectPlatformIOS     RedirectPlatform = "ios"     // iPhone / iPad / iPod
+	RedirectPlatformDesktop RedirectPlatform = "desktop" // 其他（桌面浏览器）
+)
+
+// IsValid 是否为支持的平台
+func (p RedirectPlatform) IsValid() bool {
+	switch p {
+	case RedirectPlatformAndroid, RedirectPlatformIOS, RedirectPlatformDesktop:
+		return true
+	}
+	return false
+}
+
+// RedirectInAppMode 应用内 WebView 匹配方式
+type RedirectInAppMode string
+
+const (
+	RedirectInAppAny     RedirectInAppMode = ""        // 不限
+	RedirectInAppOnly    RedirectInAppMode = "only"    // 仅匹配 Telegram / Viber / 微信等应用内 WebView
+	RedirectInAppExclude RedirectInAppMode = "exclude" // 仅匹配普通浏览器
+)
+
+// IsValid 是否为支持的匹配方式
+func (m RedirectInAppMode) IsValid() bool {
+	switch m {
+	case RedirectInAppAny, RedirectInAppOnly, RedirectInAppExclude:
+		return true
+	}
+	return false
+}
+
+// RedirectRoute 重定向规则的路由条件
+// 访客同时满足国家、平台、WebView 条件时，只在 TargetIDs 指定的目标中选择；
+// 被任一路由引用的目标不再进入默认目标池，避免 iOS 访客落到 APK 链接。
+type RedirectRoute struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	RuleID    uint              `json:"rule_id" gorm:"not null;index"`        // 所属重定向规则ID
+	Name      string            `json:"name" gorm:"type:varchar(255)"`        // 路由名称
+	Priority  int               `json:"priority" gorm:"default:0"`            // 优先级，数值大的先匹配
+	Countries string            `json:"countries" gorm:"type:varchar(512)"`   // 国家 ISO 代码，逗号分隔，为空表示不限
+	Platforms string            `json:"platforms" gorm:"type:varchar(64)"`    // 平台，逗号分隔：android、ios、desktop，为空表示不限
+	InApp     RedirectInAppMode `json:"in_app" gorm:"type:varchar(16)"`       // 应用内 WebView：空（不限）、only、exclude
+	TargetIDs string            `json:"target_ids" gorm:"type:varchar(1024)"` // 目标ID，逗号分隔
+	Enabled   bool              `json:"enabled" gorm:"index"`                 // 是否启用
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (RedirectRoute) TableName() string {
+	return "redirect_routes"
+}
+
+// GetCountries 解析国家列表（统一为大写）
+func (r *RedirectRoute) GetCountries() []string {
+	var countries []string
+	for _, part := range strings.Split(r.Countries, ",") {
+		if code := strings.ToUpper(strings.TrimSpace(part)); code != "" {
+			countries = append(countries, code)
+		}
+	}
+	return countries
+}
+
+// GetPlatforms 解析平台列表
+func (r *RedirectRoute) GetPlatforms() []RedirectPlatform {
+	var platforms []RedirectPlatform
+	for _, part := range strings.Split(r.Platforms, ",") {
+		if platform := strings.ToLower(strings.TrimSpace(part)); platform != "" {
+			platforms = append(platforms, RedirectPlatform(platform))
+		}
+	}
+	return platforms
+}
+
+// GetTargetIDs 解析目标ID列表
+func (r *RedirectRoute) GetTargetIDs() ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(r.TargetIDs, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
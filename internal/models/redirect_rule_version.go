@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RedirectTargetSnapshot 版本快照中的目标
+type RedirectTargetSnapshot struct {
+	ID           uint   `json:"id"`
+	TargetURL    string `json:"target_url"`
+	Weight       int    `json:"weight"`
+	IsActive     bool   `json:"is_active"`
+	AutoDisabled bool   `json:"auto_disabled"`
+}
+
+// RedirectRuleVersion 重定向规则的部署版本
+// 每次上传新的 index.html 时记录一次（内容与上一版本相同时不记录），用于查看历史和一键回滚。
+type RedirectRuleVersion struct {
+	ID            uint                  `json:"id" gorm:"primaryKey"`
+	RuleID        uint                  `json:"rule_id" gorm:"not null;uniqueIndex:idx_rule_version"` // 所属重定向规则ID
+	Version       int                   `json:"version" gorm:"not null;uniqueIndex:idx_rule_version"` // 版本号，从 1 开始递增
+	TargetsJSON   string                `json:"targets_json" gorm:"type:text"`                        // 目标快照 JSON（目标、权重、启用状态）
+	SelectionMode RedirectSelectionMode `json:"selection_mode" gorm:"type:varchar(32)"`               // 目标选择算法
+	HTMLHash      string                `json:"html_hash" gorm:"type:varchar(64);index"`              // index.html 的 SHA-256
+	HTMLContent   string                `json:"-" gorm:"type:mediumtext"`                             // 渲染后的 index.html，回滚时原样上传
+	Operator      string                `json:"operator" gorm:"type:varchar(255)"`                    // 操作人，定时任务为 system
+	Note          string                `json:"note" gorm:"type:text"`                                // 变更说明
+	RollbackFrom  *int                  `json:"rollback_from,omitempty" gorm:"default:null"`          // 回滚产生的版本：回滚到的源版本号
+	CreatedAt     time.Time             `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RedirectRuleVersion) TableName() string {
+	return "redirect_rule_versions"
+}
+
+// GetTargets 解析目标快照
+func (v *RedirectRuleVersion) GetTargets() ([]RedirectTargetSnapshot, error) {
+	var targets []RedirectTargetSnapshot
+	if v.TargetsJSON == "" {
+		return targets, nil
+	}
+	if err := json.Unmarshal([]byte(v.TargetsJSON), &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
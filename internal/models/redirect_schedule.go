@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RedirectSchedule 重定向规则的定时流量切换窗口
+// 窗口生效期间，规则目标的权重被 WeightsJSON 覆盖（未列出的目标权重为 0）；
+// 没有窗口生效时使用目标自身的 Weight。
+type RedirectSchedule struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	RuleID      uint           `json:"rule_id" gorm:"not null;index"`                          // 所属重定向规则ID
+	Name        string         `json:"name" gorm:"type:varchar(255)"`                          // 窗口名称
+	Timezone    string         `json:"timezone" gorm:"type:varchar(64);default:'Asia/Yangon'"` // 时区，如 Asia/Yangon
+	StartTime   string         `json:"start_time" gorm:"type:varchar(5);not null"`             // 开始时间 HH:MM
+	EndTime     string         `json:"end_time" gorm:"type:varchar(5);not null"`               // 结束时间 HH:MM（小于开始时间表示跨天）
+	Weekdays    string         `json:"weekdays" gorm:"type:varchar(32)"`                       // 生效星期，逗号分隔 0-6（0=周日），为空表示每天
+	WeightsJSON string         `json:"weights_json" gorm:"type:text"`                          // 窗口内权重 JSON：{"<target_id>": weight}
+	Priority    int            `json:"priority" gorm:"default:0"`                              // 优先级，多个窗口同时生效时取最大值
+	Enabled     bool           `json:"enabled" gorm:"index"`                                   // 是否启用（由调用方显式设置，避免 GORM 零值被默认值覆盖）
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (RedirectSchedule) TableName() string {
+	return "redirect_schedules"
+}
+
+// GetWeights 解析窗口内的目标权重（key 为目标ID）
+func (s *RedirectSchedule) GetWeights() (map[uint]int, error) {
+	weights := map[uint]int{}
+	if s.WeightsJSON == "" {
+		return weights, nil
+	}
+	if err := json.Unmarshal([]byte(s.WeightsJSON), &weights); err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
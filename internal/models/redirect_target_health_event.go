@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// RedirectTargetHealthAction 目标健康事件类型
+type RedirectTargetHealthAction string
+
+const (
+	RedirectTargetHealthEvicted  RedirectTargetHealthAction = "evicted"  // 连续探测失败，自动摘除
+	RedirectTargetHealthRestored RedirectTargetHealthAction = "restored" // 连续探测成功，自动恢复
+	RedirectTargetHealthSkipped  RedirectTargetHealthAction = "skipped"  // 应摘除但受最少活跃目标数限制，保留
+)
+
+// RedirectTargetHealthEvent 重定向目标自动摘除/恢复记录
+type RedirectTargetHealthEvent struct {
+	ID                   uint                       `json:"id" gorm:"primaryKey"`
+	RuleID               uint                       `json:"rule_id" gorm:"not null;index"`
+	TargetID             uint                       `json:"target_id" gorm:"not null;index"`
+	TargetURL            string                     `json:"target_url" gorm:"type:text"`
+	Action               RedirectTargetHealthAction `json:"action" gorm:"type:varchar(32);not null;index"`
+	ConsecutiveFailures  int                        `json:"consecutive_failures"`
+	ConsecutiveSuccesses int                        `json:"consecutive_successes"`
+	Reason               string                     `json:"reason" gorm:"type:text"`
+	CreatedAt            time.Time                  `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (RedirectTargetHealthEvent) TableName() string {
+	return "redirect_target_health_events"
+}
@@ -11,6 +11,17 @@ const (
 	SpeedProbeStatusSuccess SpeedProbeStatus = "success" // 成功
 	SpeedProbeStatusFailed  SpeedProbeStatus = "failed"  // 失败
 	SpeedProbeStatusTimeout SpeedProbeStatus = "timeout" // 超时
+
+	// SpeedProbeStatusContentMismatch 下载内容的大小或 SHA-256 与上传文件不一致（CDN 缓存异常、域名劫持或运营商篡改）
+	SpeedProbeStatusContentMismatch SpeedProbeStatus = "content_mismatch"
+)
+
+// SpeedAlertType 告警类型
+type SpeedAlertType string
+
+const (
+	SpeedAlertTypeSpeed           SpeedAlertType = "speed"            // 下载速度未达标
+	SpeedAlertTypeContentMismatch SpeedAlertType = "content_mismatch" // 下载内容被篡改（高优先级，立即发送）
 )
 
 const ThresholdSpeedKbps = 0.1
@@ -27,6 +38,8 @@ type SpeedProbeResult struct {
 	ErrorMessage   string           `json:"error_message,omitempty" gorm:"type:text"`                                   // 错误信息
 	UserAgent      string           `json:"user_agent,omitempty" gorm:"type:varchar(500)"`                              // 客户端User-Agent
 	CreatedAt      time.Time        `json:"created_at" gorm:"index:idx_created_at;index:idx_url_ip_created"`            // 创建时间
+
+	ContentSHA256 string `json:"content_sha256,omitempty" gorm:"type:varchar(64)"` // 探测端下载内容的 SHA-256（完整文件或前缀）
 }
 
 // TableName 指定表名
@@ -49,6 +62,8 @@ type SpeedAlertLog struct {
 	AlertMessage    string    `json:"alert_message,omitempty" gorm:"type:text"`                        // 告警消息
 	IPDetails       string    `json:"ip_details,omitempty" gorm:"type:text"`                           // IP探测详情（JSON格式）
 	CreatedAt       time.Time `json:"created_at" gorm:"index:idx_created_at"`                          // 创建时间
+
+	AlertType SpeedAlertType `json:"alert_type" gorm:"type:varchar(30);not null;default:'speed';index:idx_alert_type"` // 告警类型
 }
 
 // TableName 指定表名
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// UploadTarget 分片上传完成后创建的记录类型
+type UploadTarget string
+
+const (
+	UploadTargetDownloadPackage UploadTarget = "download_package" // 上传到 S3，完成后创建下载包并配置 CloudFront
+	UploadTargetR2File          UploadTarget = "r2_file"          // 上传到 R2 存储桶，完成后同步文件记录
+	UploadTargetPackageVersion  UploadTarget = "package_version"  // 上传下载包新版本，完成后登记版本（不自动发布）
+)
+
+// UploadMode 上传方式
+type UploadMode string
+
+const (
+	UploadModeMultipart UploadMode = "multipart" // 分片上传，支持断点续传
+	UploadModePut       UploadMode = "put"       // 预签名 PUT 单次直传存储桶（单个文件不超过 5GB）
+)
+
+// UploadSessionStatus 上传会话状态
+type UploadSessionStatus string
+
+const (
+	UploadSessionStatusUploading  UploadSessionStatus = "uploading"  // 上传中
+	UploadSessionStatusCompleting UploadSessionStatus = "completing" // 正在合并文件并创建记录，其他完成请求会被拒绝
+	UploadSessionStatusCompleted  UploadSessionStatus = "completed"  // 已完成并创建记录
+	UploadSessionStatusAborted    UploadSessionStatus = "aborted"    // 已取消或过期清理
+	UploadSessionStatusFailed     UploadSessionStatus = "failed"     // 校验、合并或创建记录失败
+)
+
+// UploadSession 上传会话
+// 分片上传：大文件按 PartSize 切片，客户端通过预签名地址直传或经服务端代理逐片上传，断线后按已上传分片续传。
+// 直传：客户端通过预签名 PUT 地址一次上传到存储桶，完成时通过 HEAD 校验大小和 ETag。
+type UploadSession struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	Target       UploadTarget        `json:"target" gorm:"type:varchar(32);not null;index"`
+	Mode         UploadMode          `json:"mode" gorm:"type:varchar(20);not null;default:'multipart'"`
+	DomainID     *uint               `json:"domain_id,omitempty"`      // 下载包：域名 ID
+	AWSAccountID *uint               `json:"aws_account_id,omitempty"` // 下载包：AWS 账号 ID，为空表示默认账号
+	R2BucketID   *uint               `json:"r2_bucket_id,omitempty"`   // R2 文件：存储桶 ID
+	PackageID    *uint               `json:"package_id,omitempty"`     // 下载包版本：下载包 ID
+	Note         string              `json:"note" gorm:"type:text"`    // 下载包版本：版本备注
+	Bucket       string              `json:"bucket" gorm:"type:varchar(255)"`
+	ObjectKey    string              `json:"object_key" gorm:"type:varchar(1024);not null"`
+	UploadID     string              `json:"upload_id" gorm:"type:varchar(512)"` // S3 UploadId，直传时为空
+	FileName     string              `json:"file_name" gorm:"type:varchar(255);not null"`
+	FileSize     int64               `json:"file_size" gorm:"not null"`
+	ContentType  string              `json:"content_type" gorm:"type:varchar(128)"`
+	PartSize     int64               `json:"part_size" gorm:"not null"`
+	PartCount    int64               `json:"part_count" gorm:"not null"`
+	Status       UploadSessionStatus `json:"status" gorm:"type:varchar(20);not null;default:'uploading';index"`
+	ResultID     *uint               `json:"result_id,omitempty"` // 完成后创建的下载包、R2 文件或下载包版本 ID
+	ErrorMessage string              `json:"error_message" gorm:"type:text"`
+	CreatedBy    string              `json:"created_by" gorm:"type:varchar(100)"`
+	ExpiresAt    time.Time           `json:"expires_at" gorm:"index"` // 超过该时间仍未完成的会话会被取消
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	UploadURL string `json:"upload_url,omitempty" gorm:"-"` // 直传：预签名 PUT 地址，仅在创建或刷新时返回
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"aws_cdn/internal/config"
+	"aws_cdn/internal/geoip"
 	"aws_cdn/internal/handlers"
 	"aws_cdn/internal/logger"
 	"aws_cdn/internal/middleware"
@@ -10,6 +11,8 @@ import (
 	"aws_cdn/internal/services"
 	"aws_cdn/internal/services/aws"
 	"aws_cdn/internal/services/cloudflare"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,7 +21,8 @@ import (
 )
 
 // SetupRouter 初始化路由。redisClient 与 fallbackRuleService 由 main 传入以便 main 可创建 FallbackRuleEngine 并注册定时任务。
-func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *services.TelegramService, redisClient *redisv9.Client, fallbackRuleService *services.FallbackRuleService) *gin.Engine {
+// schedulerService 由 main 传入，依赖路由内服务的定时任务在此注册，main 在 SetupRouter 之后启动定时任务。
+func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *services.TelegramService, redisClient *redisv9.Client, fallbackRuleService *services.FallbackRuleService, schedulerService *services.SchedulerService) *gin.Engine {
 	// 设置 Gin 模式
 	gin.SetMode(cfg.Server.Mode)
 
@@ -27,6 +31,15 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 	// 增加请求体大小限制（支持大文件上传，例如 10GB）
 	r.MaxMultipartMemory = 10 << 30 // 10GB
 
+	// 配置 TRUSTED_PROXIES 后只信任来自这些代理的 X-Forwarded-For，避免客户端伪造 IP 绕过按国家路由、封禁和测速统计；
+	// 未配置时保持 Gin 默认行为（信任所有代理），不影响现有部署
+	if len(cfg.Server.TrustedProxies) > 0 {
+		if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			logger.GetLogger().WithError(err).Warn("TRUSTED_PROXIES 配置无效，不信任任何代理")
+			_ = r.SetTrustedProxies(nil)
+		}
+	}
+
 	// CORS 配置
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -63,15 +76,45 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 		s3Origin = s3Svc.GetBucketDomain(cfg.AWS.S3BucketName)
 	}
 
+	// 初始化 GeoIP 国家库（未配置或加载失败时按国家匹配的路由不生效）
+	var geoReader *geoip.Reader
+	if cfg.Server.GeoIPCountryDB != "" {
+		if geoReader, err = geoip.Open(cfg.Server.GeoIPCountryDB); err != nil {
+			log.WithError(err).WithField("path", cfg.Server.GeoIPCountryDB).Warn("加载 GeoIP 国家库失败，按国家路由将不可用")
+			geoReader = nil
+		} else {
+			log.WithField("path", cfg.Server.GeoIPCountryDB).Info("GeoIP 国家库已加载")
+		}
+	}
+	geoEndpoint, beaconEndpoint := "", ""
+	if cfg.Server.PublicBaseURL != "" {
+		geoEndpoint = strings.TrimRight(cfg.Server.PublicBaseURL, "/") + "/api/v1/geo/country"
+		beaconEndpoint = strings.TrimRight(cfg.Server.PublicBaseURL, "/") + "/api/v1/redirect-beacon"
+	}
+
 	// 初始化服务
 	auditService := services.NewAuditService(db)
 	groupService := services.NewGroupService(db)
 	cfAccountService := services.NewCFAccountService(db)
+	awsAccountService := services.NewAWSAccountService(db)
+	// 多 AWS 账号：未关联账号的域名和下载包使用环境变量配置的默认账号
+	awsClientFactory := services.NewAWSClientFactory(awsAccountService, &services.AWSClients{
+		Config:     &cfg.AWS,
+		Route53:    route53Svc,
+		ACM:        acmSvc,
+		CloudFront: cloudFrontSvc,
+		S3:         s3Svc,
+	})
 	domainService := services.NewDomainService(db, route53Svc, acmSvc, cloudFrontSvc, s3Svc, cloudflareSvc, cfAccountService)
-	redirectService := services.NewRedirectService(db, cloudFrontSvc, s3Svc, domainService, &cfg.AWS)
+	domainService.SetNSResolver(services.NewUpstreamNSResolver(cfg.ScheduledTask.NSResolvers))
+	domainService.SetAWSClientFactory(awsClientFactory)
+	redirectService := services.NewRedirectService(db, cloudFrontSvc, s3Svc, domainService, &cfg.AWS, geoReader, geoEndpoint, beaconEndpoint)
+	redirectRouteService := services.NewRedirectRouteService(db, redirectService)
+	redirectScheduleService := services.NewRedirectScheduleService(db, redirectService, auditService)
+	redirectTransferService := services.NewRedirectTransferService(db, redirectService, groupService)
 	authService := services.NewAuthService(db, &cfg.JWT)
 	cloudFrontService := services.NewCloudFrontService(cloudFrontSvc, s3Origin)
-	downloadPackageService := services.NewDownloadPackageService(db, db3, domainService, cloudFrontSvc, s3Svc, route53Svc, &cfg.AWS)
+	downloadPackageService := services.NewDownloadPackageService(db, db3, domainService, cloudFrontSvc, s3Svc, &cfg.AWS)
 
 	// 初始化 R2 服务
 	r2BucketService := services.NewR2BucketService(db, cfAccountService)
@@ -79,11 +122,40 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 	r2CustomDomainService := services.NewR2CustomDomainService(db, cfAccountService, r2CacheRuleService)
 	r2FileService := services.NewR2FileService(db, cfAccountService)
 
+	// 初始化下载包版本服务
+	downloadPackageVersionService := services.NewDownloadPackageVersionService(db, downloadPackageService)
+
+	// 初始化分片上传会话服务（下载包与 R2 文件共用）
+	uploadSessionService := services.NewUploadSessionService(db, downloadPackageService, downloadPackageVersionService, r2FileService)
+
 	// 初始化自定义下载链接服务
 	customDownloadLinkService := services.NewCustomDownloadLinkService(db)
 
 	// 初始化速度探测服务（速度阈值100KB/s，失败率阈值50%）
 	speedProbeService := services.NewSpeedProbeServiceWithTwoDBs(db, db2, telegramService, models.ThresholdSpeedKbps, 0.5)
+	speedProbeService.SetDownloadPackageService(downloadPackageService)
+
+	// 初始化轮播目标健康检查服务（连续失败3次摘除，连续成功5次恢复）
+	redirectHealthService := services.NewRedirectHealthService(db, redirectService, telegramService, models.ThresholdSpeedKbps, 3, 5)
+
+	// 初始化证书到期/续期监控服务
+	certificateMonitorService := services.NewCertificateMonitorService(db, domainService, telegramService, cfg.ScheduledTask.CertificateExpiryAlertDays)
+
+	// 初始化域名拦截/信誉检测服务（恶意网址库暂用本地实现）
+	domainReputationService := services.NewDomainReputationService(db, telegramService,
+		services.NewDNSResolutionDetector(cfg.ScheduledTask.ReputationResolvers),
+		services.NewHTTPReachabilityDetector(),
+		services.NewSafeBrowsingDetector(services.NewLocalSafeBrowsingStub()),
+	)
+
+	// 初始化备用域名池与源域名轮换服务
+	domainRotationService := services.NewDomainRotationService(db, redirectService, domainService, telegramService)
+
+	// 初始化 Cloudflare Zone 基线服务
+	cfZoneBaselineService := services.NewCFZoneBaselineService(db, cfAccountService, telegramService)
+
+	// 初始化 Zone Ruleset 规则服务
+	cfRulesetService := services.NewCFRulesetService(db, cfAccountService)
 
 	// 初始化 Worker 服务
 	cfWorkerService := services.NewCFWorkerService(db)
@@ -105,14 +177,28 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 	groupHandler := handlers.NewGroupHandler(groupService)
 	domainHandler := handlers.NewDomainHandler(domainService)
 	redirectHandler := handlers.NewRedirectHandler(redirectService)
+	redirectScheduleHandler := handlers.NewRedirectScheduleHandler(redirectScheduleService)
+	redirectRouteHandler := handlers.NewRedirectRouteHandler(redirectRouteService)
+	redirectTransferHandler := handlers.NewRedirectTransferHandler(redirectTransferService)
+	redirectStatsHandler := handlers.NewRedirectStatsHandler(redirectService)
+	geoHandler := handlers.NewGeoHandler(redirectService)
+	redirectHealthHandler := handlers.NewRedirectHealthHandler(redirectHealthService)
+	certificateMonitorHandler := handlers.NewCertificateMonitorHandler(certificateMonitorService)
+	domainReputationHandler := handlers.NewDomainReputationHandler(domainReputationService)
+	domainRotationHandler := handlers.NewDomainRotationHandler(domainRotationService)
 	authHandler := handlers.NewAuthHandler(authService)
 	cloudFrontHandler := handlers.NewCloudFrontHandler(cloudFrontService)
 	downloadPackageHandler := handlers.NewDownloadPackageHandler(downloadPackageService)
+	downloadPackageVersionHandler := handlers.NewDownloadPackageVersionHandler(downloadPackageVersionService)
+	uploadSessionHandler := handlers.NewUploadSessionHandler(uploadSessionService)
 	auditHandler := handlers.NewAuditHandler(auditService)
 	cfAccountHandler := handlers.NewCFAccountHandler(cfAccountService)
+	awsAccountHandler := handlers.NewAWSAccountHandler(awsAccountService)
+	cfZoneBaselineHandler := handlers.NewCFZoneBaselineHandler(cfZoneBaselineService)
+	cfRulesetHandler := handlers.NewCFRulesetHandler(cfRulesetService)
 	r2Handler := handlers.NewR2Handler(r2BucketService, r2CustomDomainService, r2CacheRuleService, r2FileService)
 	customDownloadLinkHandler := handlers.NewCustomDownloadLinkHandler(customDownloadLinkService)
-	allLinksHandler := handlers.NewAllLinksHandler(downloadPackageService, customDownloadLinkService, r2CustomDomainService, r2FileService, focusProbeLinkService, speedProbeService, domainRedirectService)
+	allLinksHandler := handlers.NewAllLinksHandler(downloadPackageService, customDownloadLinkService, r2CustomDomainService, r2FileService, focusProbeLinkService, speedProbeService, domainRedirectService, redirectHealthService)
 	speedProbeHandler := handlers.NewSpeedProbeHandler(speedProbeService)
 	cfWorkerHandler := handlers.NewCFWorkerHandler(cfWorkerService)
 	ec2InstanceHandler := handlers.NewEc2InstanceHandler(ec2InstanceService)
@@ -135,12 +221,84 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 		fallbackRuleHandler = handlers.NewFallbackRuleHandler(fallbackRuleService)
 	}
 
+	// 注册依赖路由内服务的定时任务
+	if schedulerService != nil {
+		// 重定向规则定时流量切换（每分钟检查一次窗口是否切换）
+		if cfg.ScheduledTask.EnableRedirectSchedule {
+			schedulerService.AddTask("重定向定时流量切换", redirectScheduleService.ApplyAllSchedules, time.Minute)
+			log.Info("定时任务已启用：重定向定时流量切换（每1分钟执行一次）")
+		} else {
+			log.Info("定时任务已禁用：重定向定时流量切换")
+		}
+
+		// 轮播目标健康检查（根据探测结果自动摘除/恢复目标）
+		if cfg.ScheduledTask.EnableRedirectHealthCheck {
+			schedulerService.AddTask("轮播目标健康检查", redirectHealthService.CheckAllRules, 5*time.Minute)
+			log.Info("定时任务已启用：轮播目标健康检查（每5分钟执行一次）")
+		} else {
+			log.Info("定时任务已禁用：轮播目标健康检查")
+		}
+
+		// 证书到期/续期监控（即将过期、续期失败或验证记录缺失时告警）
+		if cfg.ScheduledTask.EnableCertificateMonitor {
+			schedulerService.AddTask("证书到期监控", certificateMonitorService.CheckAllCertificates, 6*time.Hour)
+			log.Info("定时任务已启用：证书到期监控（每6小时执行一次）")
+		} else {
+			log.Info("定时任务已禁用：证书到期监控")
+		}
+
+		// 待转入域名的 NS 委派检查（委派生效后标记完成并自动申请证书）
+		if cfg.ScheduledTask.EnableNSDelegationCheck {
+			schedulerService.AddTask("NS委派检查", domainService.CheckPendingDelegations, 10*time.Minute)
+			log.Info("定时任务已启用：NS委派检查（每10分钟执行一次）")
+		} else {
+			log.Info("定时任务已禁用：NS委派检查")
+		}
+
+		// 域名拦截/信誉检测（疑似被拦截时告警）
+		if cfg.ScheduledTask.EnableDomainReputationCheck {
+			schedulerService.AddTask("域名信誉检测", domainReputationService.CheckAllDomains, 30*time.Minute)
+			log.Info("定时任务已启用：域名信誉检测（每30分钟执行一次）")
+		} else {
+			log.Info("定时任务已禁用：域名信誉检测")
+		}
+
+		// 自动轮换疑似被拦截的重定向源域名（默认关闭）
+		if cfg.ScheduledTask.EnableAutoDomainRotation {
+			schedulerService.AddTask("重定向源域名自动轮换", domainRotationService.RotateBlockedSources, 30*time.Minute)
+			log.Info("定时任务已启用：重定向源域名自动轮换（每30分钟执行一次）")
+		} else {
+			log.Info("定时任务已禁用：重定向源域名自动轮换")
+		}
+
+		// Cloudflare Zone 设置基线漂移检测
+		if cfg.ScheduledTask.EnableZoneBaselineCheck {
+			schedulerService.AddTask("Zone基线漂移检测", cfZoneBaselineService.CheckAllZones, 6*time.Hour)
+			log.Info("定时任务已启用：Zone基线漂移检测（每6小时执行一次）")
+		} else {
+			log.Info("定时任务已禁用：Zone基线漂移检测")
+		}
+
+		// 过期分片上传清理
+		if cfg.ScheduledTask.EnableUploadSessionCleanup {
+			schedulerService.AddTask("过期分片上传清理", uploadSessionService.CleanupExpiredSessions, time.Hour)
+			log.Info("定时任务已启用：过期分片上传清理（每1小时执行一次）")
+		} else {
+			log.Info("定时任务已禁用：过期分片上传清理")
+		}
+	}
+
 	// API 路由
 	api := r.Group("/api/v1")
 
 	// 公共路由（无需登录）
 	api.POST("/auth/login", authHandler.Login)
 
+	// 访客国家查询（轮播 HTML 按国家路由使用）
+	api.GET("/geo/country", geoHandler.GetCountry)
+	// 轮播 HTML 点击上报
+	api.POST("/redirect-beacon", redirectStatsHandler.IngestBeacon)
+
 	// 所有链接管理（统一查询接口）
 	api.GET("/all-links", allLinksHandler.GetAllLinks)
 	// 速度探测上报接口（公共接口，无需认证）
@@ -158,16 +316,27 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 			domains.POST("", domainHandler.TransferDomain)
 			domains.GET("", domainHandler.ListDomains)
 			domains.GET("/for-select", domainHandler.ListDomainsForSelect) // 轻量级接口，用于下拉选择框
+			domains.GET("/certificates/expiring", certificateMonitorHandler.ListExpiringCertificates)
+			domains.GET("/spare-pool", domainRotationHandler.ListSpareDomains)
 			domains.GET("/:id", domainHandler.GetDomain)
 			domains.DELETE("/:id", domainHandler.DeleteDomain)
 			domains.GET("/:id/ns-servers", domainHandler.GetNServers)
 			domains.GET("/:id/status", domainHandler.GetDomainStatus)
+			domains.POST("/:id/ns-delegation/check", domainHandler.CheckNSDelegation)
+			domains.POST("/:id/reputation/check", domainReputationHandler.CheckDomain)
+			domains.GET("/:id/reputation/checks", domainReputationHandler.ListChecks)
 			domains.POST("/:id/certificate", domainHandler.GenerateCertificate)
 			domains.GET("/:id/certificate/status", domainHandler.GetCertificateStatus)
 			domains.GET("/:id/certificate/check", domainHandler.CheckCertificate)
 			domains.POST("/:id/certificate/fix", domainHandler.FixCertificate)
 			domains.PUT("/:id/note", domainHandler.UpdateDomainNote)
 			domains.PUT("/:id/group", domainHandler.MoveDomainToGroup)
+			domains.GET("/:id/dns-records", domainHandler.ListDNSRecords)
+			domains.POST("/:id/dns-records", domainHandler.CreateDNSRecord)
+			domains.PUT("/:id/dns-records", domainHandler.UpdateDNSRecord)
+			domains.DELETE("/:id/dns-records", domainHandler.DeleteDNSRecord)
+			domains.GET("/:id/zone-file", domainHandler.ExportZoneFile)
+			domains.POST("/:id/zone-file", domainHandler.ImportZoneFile)
 		}
 
 		// 轮播管理
@@ -175,14 +344,33 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 		{
 			redirects.POST("", redirectHandler.CreateRedirectRule)
 			redirects.GET("", redirectHandler.ListRedirectRules)
+			redirects.GET("/export", redirectTransferHandler.ExportRules)
+			redirects.POST("/import", redirectTransferHandler.ImportRules)
 			redirects.GET("/:id", redirectHandler.GetRedirectRule)
 			redirects.DELETE("/:id", redirectHandler.DeleteRule)
 			redirects.POST("/:id/targets", redirectHandler.AddTarget)
+			redirects.PUT("/targets/:id", redirectHandler.UpdateTarget)
 			redirects.DELETE("/targets/:id", redirectHandler.RemoveTarget)
 			redirects.POST("/:id/bind-cloudfront", redirectHandler.BindDomainToCloudFront)
+			redirects.POST("/:id/rotate-source", domainRotationHandler.RotateSourceDomain)
 			redirects.GET("/:id/check", redirectHandler.CheckRedirectRule)
 			redirects.POST("/:id/fix", redirectHandler.FixRedirectRule)
 			redirects.PUT("/:id/note", redirectHandler.UpdateRedirectRuleNote)
+			redirects.PUT("/:id/selection-mode", redirectHandler.UpdateSelectionMode)
+			redirects.GET("/:id/versions", redirectHandler.ListRuleVersions)
+			redirects.POST("/:id/rollback/:version", redirectHandler.RollbackRule)
+			redirects.GET("/:id/schedules", redirectScheduleHandler.ListSchedules)
+			redirects.POST("/:id/schedules", redirectScheduleHandler.CreateSchedule)
+			redirects.PUT("/schedules/:id", redirectScheduleHandler.UpdateSchedule)
+			redirects.DELETE("/schedules/:id", redirectScheduleHandler.DeleteSchedule)
+			redirects.GET("/:id/routes", redirectRouteHandler.ListRoutes)
+			redirects.POST("/:id/routes", redirectRouteHandler.CreateRoute)
+			redirects.PUT("/routes/:id", redirectRouteHandler.UpdateRoute)
+			redirects.DELETE("/routes/:id", redirectRouteHandler.DeleteRoute)
+			redirects.PUT("/:id/health-check", redirectHealthHandler.UpdateHealthCheckConfig)
+			redirects.GET("/:id/health-events", redirectHealthHandler.ListHealthEvents)
+			redirects.PUT("/:id/click-tracking", redirectStatsHandler.UpdateClickTracking)
+			redirects.GET("/:id/stats", redirectStatsHandler.GetStats)
 		}
 
 		// CloudFront 管理
@@ -206,6 +394,26 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 			downloadPackages.GET("/:id/check", downloadPackageHandler.CheckDownloadPackage)
 			downloadPackages.POST("/:id/fix", downloadPackageHandler.FixDownloadPackage)
 			downloadPackages.PUT("/:id/note", downloadPackageHandler.UpdateDownloadPackageNote)
+			downloadPackages.POST("/:id/inspect", downloadPackageHandler.InspectDownloadPackage)
+			downloadPackages.GET("/:id/versions", downloadPackageVersionHandler.ListVersions)
+			downloadPackages.POST("/:id/versions", downloadPackageVersionHandler.UploadVersion)
+			downloadPackages.POST("/:id/versions/:version_id/promote", downloadPackageVersionHandler.PromoteVersion)
+			downloadPackages.POST("/:id/versions/:version_id/inspect", downloadPackageVersionHandler.InspectVersion)
+			downloadPackages.DELETE("/:id/versions/:version_id", downloadPackageVersionHandler.DeleteVersion)
+			downloadPackages.POST("/:id/rollback", downloadPackageVersionHandler.RollbackVersion)
+		}
+
+		// 上传会话（下载包与 R2 文件，分片断点续传或预签名直传）
+		uploadSessions := protected.Group("/upload-sessions")
+		{
+			uploadSessions.GET("", uploadSessionHandler.ListSessions)
+			uploadSessions.POST("", uploadSessionHandler.CreateSession)
+			uploadSessions.GET("/:id", uploadSessionHandler.GetSession)
+			uploadSessions.POST("/:id/presign", uploadSessionHandler.PresignPut)
+			uploadSessions.POST("/:id/parts/presign", uploadSessionHandler.PresignParts)
+			uploadSessions.PUT("/:id/parts/:part_number", uploadSessionHandler.UploadPart)
+			uploadSessions.POST("/:id/complete", uploadSessionHandler.CompleteSession)
+			uploadSessions.DELETE("/:id", uploadSessionHandler.AbortSession)
 		}
 
 		// 分组管理
@@ -233,12 +441,41 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 			cfAccounts.GET("/:id", cfAccountHandler.GetCFAccount)
 			cfAccounts.PUT("/:id", cfAccountHandler.UpdateCFAccount)
 			cfAccounts.DELETE("/:id", cfAccountHandler.DeleteCFAccount)
+			cfAccounts.POST("/:id/capabilities/check", cfAccountHandler.CheckCFAccountCapabilities)
 			cfAccounts.GET("/:id/zones", cfAccountHandler.GetCFAccountZones)
 			cfAccounts.POST("/:id/zones", cfAccountHandler.AddZones)
 			cfAccounts.POST("/:id/zones/apk-security", cfAccountHandler.SetZoneAPKSecurityRule)
+			cfAccounts.PUT("/:id/zones/:zone/baseline", cfZoneBaselineHandler.AttachBaseline)
+			cfAccounts.DELETE("/:id/zones/:zone/baseline", cfZoneBaselineHandler.DetachBaseline)
+			cfAccounts.POST("/:id/zones/:zone/check-baseline", cfZoneBaselineHandler.CheckBaseline)
+			cfAccounts.POST("/:id/zones/:zone/apply-baseline", cfZoneBaselineHandler.ApplyBaseline)
+			cfAccounts.GET("/:id/zones/:zone/managed-rules", cfRulesetHandler.ListManagedRules)
+			cfAccounts.DELETE("/:id/zones/:zone/managed-rules/:phase/:rule_id", cfRulesetHandler.DeleteManagedRule)
 			cfAccounts.POST("/:id/enable-r2", r2Handler.EnableR2)
 		}
 
+		// Cloudflare Zone 设置基线
+		zoneBaselines := protected.Group("/zone-baselines")
+		{
+			zoneBaselines.GET("", cfZoneBaselineHandler.ListBaselines)
+			zoneBaselines.POST("", cfZoneBaselineHandler.CreateBaseline)
+			zoneBaselines.GET("/settings", cfZoneBaselineHandler.ListSupportedSettings)
+			zoneBaselines.GET("/attachments", cfZoneBaselineHandler.ListAttachments)
+			zoneBaselines.PUT("/:id", cfZoneBaselineHandler.UpdateBaseline)
+			zoneBaselines.DELETE("/:id", cfZoneBaselineHandler.DeleteBaseline)
+		}
+
+		// AWS 账号管理
+		awsAccounts := protected.Group("/aws-accounts")
+		{
+			awsAccounts.GET("", awsAccountHandler.ListAWSAccounts)
+			awsAccounts.POST("", awsAccountHandler.CreateAWSAccount)
+			awsAccounts.GET("/:id", awsAccountHandler.GetAWSAccount)
+			awsAccounts.PUT("/:id", awsAccountHandler.UpdateAWSAccount)
+			awsAccounts.DELETE("/:id", awsAccountHandler.DeleteAWSAccount)
+			awsAccounts.POST("/:id/verify", awsAccountHandler.VerifyAWSAccount)
+		}
+
 		// R2 存储桶管理
 		r2Buckets := protected.Group("/r2-buckets")
 		{
@@ -279,6 +516,8 @@ func SetupRouter(db, db2, db3 *gorm.DB, cfg *config.Config, telegramService *ser
 			// APK 文件管理
 			r2Files.GET("/buckets/:r2_bucket_id/apk-files", r2Handler.ListApkFiles)
 			r2Files.GET("/buckets/:r2_bucket_id/apk-file-urls", r2Handler.GetApkFileUrls)
+			r2Files.GET("/buckets/:r2_bucket_id/record", r2Handler.GetFileRecord)
+			r2Files.POST("/buckets/:r2_bucket_id/inspect", r2Handler.InspectFile)
 		}
 
 		// 自定义下载链接管理
@@ -0,0 +1,359 @@
+package secrets
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 密文格式（没有 enc: 前缀的值视为历史明文）：
+//
+//	enc:v2:<主密钥版本>:<base64(nonce|包装后的数据密钥)>:<base64(nonce|密文)>
+//
+// v2 为信封加密：每个值使用随机生成的数据密钥（DEK）加密，DEK 再由指定版本的主密钥加密后与密文一起存储。
+// 轮换主密钥时新增版本并设为当前版本，旧版本密钥保留用于解密，再通过重新加密命令把所有值迁移到当前版本。
+const (
+	encryptedPrefix = "enc:"
+	envelopePrefix  = "enc:v2:"
+)
+
+// ErrMasterKeyNotSet 未配置主密钥
+var ErrMasterKeyNotSet = errors.New("未配置凭证加密主密钥（SECRETS_MASTER_KEY / SECRETS_MASTER_KEYS / SECRETS_MASTER_KEY_FILE）")
+
+// Keyring 按版本保存的主密钥
+type Keyring struct {
+	keys   map[int][]byte
+	active int
+}
+
+var (
+	mu      sync.RWMutex
+	keyring *Keyring
+)
+
+// NewKeyring 创建主密钥环，keys 为 版本 -> base64 或 hex 编码的 32 字节密钥；active 为 0 时使用最大版本
+func NewKeyring(keys map[int]string, active int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrMasterKeyNotSet
+	}
+	k := &Keyring{keys: make(map[int][]byte, len(keys))}
+	for version, encoded := range keys {
+		if version <= 0 {
+			return nil, fmt.Errorf("主密钥版本必须为正整数: %d", version)
+		}
+		decoded, err := decodeKey(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("主密钥版本 %d: %w", version, err)
+		}
+		k.keys[version] = decoded
+		if version > k.active && active == 0 {
+			k.active = version
+		}
+	}
+	if active != 0 {
+		if _, ok := k.keys[active]; !ok {
+			return nil, fmt.Errorf("当前主密钥版本 %d 不存在", active)
+		}
+		k.active = active
+	}
+	return k, nil
+}
+
+// ActiveVersion 当前用于加密的主密钥版本
+func (k *Keyring) ActiveVersion() int {
+	return k.active
+}
+
+// Versions 所有主密钥版本（升序）
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.keys))
+	for version := range k.keys {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Configure 按配置加载主密钥并设置为全局密钥环
+// masterKey 为单个密钥（视为版本 1，兼容早期配置）；masterKeys 为逗号分隔的 "版本:密钥"；
+// masterKeyFile 为密钥文件，每行一个 "版本:密钥"（# 开头为注释，只有一行且不带版本时视为版本 1）；
+// 三者都为空时清除密钥环。
+func Configure(masterKey, masterKeys, masterKeyFile string, activeVersion int) error {
+	keys := make(map[int]string)
+	if masterKey = strings.TrimSpace(masterKey); masterKey != "" {
+		keys[1] = masterKey
+	}
+	if err := parseVersionedKeys(strings.Split(masterKeys, ","), keys); err != nil {
+		return err
+	}
+	if masterKeyFile != "" {
+		lines, err := readKeyFile(masterKeyFile)
+		if err != nil {
+			return err
+		}
+		if err := parseVersionedKeys(lines, keys); err != nil {
+			return fmt.Errorf("主密钥文件 %s: %w", masterKeyFile, err)
+		}
+	}
+
+	if len(keys) == 0 {
+		SetKeyring(nil)
+		return nil
+	}
+	k, err := NewKeyring(keys, activeVersion)
+	if err != nil {
+		return err
+	}
+	SetKeyring(k)
+	return nil
+}
+
+// SetMasterKey 使用单个主密钥（版本 1）；为空时清除密钥环
+func SetMasterKey(key string) error {
+	return Configure(key, "", "", 0)
+}
+
+// SetKeyring 设置全局密钥环，nil 表示未配置
+func SetKeyring(k *Keyring) {
+	mu.Lock()
+	keyring = k
+	mu.Unlock()
+}
+
+// CurrentKeyring 当前的全局密钥环，未配置时为 nil
+func CurrentKeyring() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keyring
+}
+
+// Configured 是否已配置主密钥
+func Configured() bool {
+	return CurrentKeyring() != nil
+}
+
+// IsEncrypted 判断值是否为 Encrypt 的输出
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// KeyVersion 返回密文使用的主密钥版本，明文返回 0
+func KeyVersion(value string) int {
+	if !strings.HasPrefix(value, envelopePrefix) {
+		return 0
+	}
+	version, _, _, err := splitEnvelope(value)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+// NeedsReencrypt 值是否需要重新加密：历史明文或不是当前主密钥版本加密的值
+func NeedsReencrypt(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !strings.HasPrefix(value, envelopePrefix) {
+		return true
+	}
+	k := CurrentKeyring()
+	return k != nil && KeyVersion(value) != k.active
+}
+
+// Encrypt 使用当前版本主密钥做信封加密，空字符串原样返回
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	k := CurrentKeyring()
+	if k == nil {
+		return "", ErrMasterKeyNotSet
+	}
+
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", fmt.Errorf("生成数据密钥失败: %w", err)
+	}
+	sealed, err := seal(dek, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.keys[k.active], dek, wrapAAD(k.active))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%d:%s:%s", envelopePrefix, k.active,
+		base64.StdEncoding.EncodeToString(wrapped),
+		base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt 解密 Encrypt 的输出；没有加密前缀的值视为历史明文原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	k := CurrentKeyring()
+	if k == nil {
+		return "", ErrMasterKeyNotSet
+	}
+
+	if !strings.HasPrefix(value, envelopePrefix) {
+		return "", fmt.Errorf("不支持的密文格式")
+	}
+	version, wrapped, sealed, err := splitEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	key, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("缺少版本 %d 主密钥", version)
+	}
+	dek, err := open(key, wrapped, wrapAAD(version))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dek, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Reencrypt 用当前版本主密钥重新加密，不需要重新加密的值原样返回
+func Reencrypt(value string) (string, error) {
+	if !NeedsReencrypt(value) {
+		return value, nil
+	}
+	plaintext, err := Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(plaintext)
+}
+
+// splitEnvelope 解析 v2 密文
+func splitEnvelope(value string) (version int, wrapped, sealed []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return 0, nil, nil, fmt.Errorf("密文格式错误")
+	}
+	if version, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, nil, nil, fmt.Errorf("密文主密钥版本错误: %w", err)
+	}
+	if wrapped, err = base64.StdEncoding.DecodeString(parts[1]); err != nil {
+		return 0, nil, nil, fmt.Errorf("密文格式错误: %w", err)
+	}
+	if sealed, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return 0, nil, nil, fmt.Errorf("密文格式错误: %w", err)
+	}
+	return version, wrapped, sealed, nil
+}
+
+// wrapAAD 包装数据密钥时绑定主密钥版本，防止密文中的版本号被篡改
+func wrapAAD(version int) []byte {
+	return []byte("aws_cdn/secrets/v2/" + strconv.Itoa(version))
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, sealed, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("密文长度错误")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("解密失败（主密钥不匹配或密文被篡改）: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// parseVersionedKeys 解析 "版本:密钥" 列表，跳过空行和注释；单独一个不带版本的密钥视为版本 1
+func parseVersionedKeys(items []string, keys map[int]string) error {
+	var entries []string
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" && !strings.HasPrefix(item, "#") {
+			entries = append(entries, item)
+		}
+	}
+	for _, entry := range entries {
+		versionStr, key, found := strings.Cut(entry, ":")
+		if !found {
+			if len(entries) > 1 {
+				return fmt.Errorf("多个主密钥时每个都需要指定版本（版本:密钥）")
+			}
+			keys[1] = entry
+			continue
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(versionStr))
+		if err != nil || version <= 0 {
+			return fmt.Errorf("无效的主密钥版本: %q", versionStr)
+		}
+		keys[version] = strings.TrimSpace(key)
+	}
+	return nil
+}
+
+func readKeyFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取主密钥文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取主密钥文件失败: %w", err)
+	}
+	return lines, nil
+}
+
+// decodeKey 解析 base64 或 hex 编码的 32 字节密钥
+func decodeKey(key string) ([]byte, error) {
+	if decoded, err := hex.DecodeString(key); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(key); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("主密钥必须是 32 字节密钥的 base64 或 hex 编码（可用 openssl rand -base64 32 生成）")
+}
@@ -0,0 +1,133 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testKey1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // base64("0123456789abcdef0123456789abcdef")
+	testKey2 = "abababababababababababababababababababababababababababababababab"
+)
+
+// 加密后能解密回原文，每次加密的密文不同；历史明文原样返回
+func TestEncryptDecrypt(t *testing.T) {
+	if err := SetMasterKey(testKey1); err != nil {
+		t.Fatal(err)
+	}
+	defer SetKeyring(nil)
+
+	first, err := Encrypt("AKIAEXAMPLE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := Encrypt("AKIAEXAMPLE")
+	if !IsEncrypted(first) || first == second || KeyVersion(first) != 1 {
+		t.Fatalf("密文格式错误或重复: %q %q", first, second)
+	}
+	if plain, err := Decrypt(first); err != nil || plain != "AKIAEXAMPLE" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	if plain, err := Decrypt("legacy-plaintext"); err != nil || plain != "legacy-plaintext" {
+		t.Fatalf("明文应原样返回: %q, %v", plain, err)
+	}
+	if value, _ := Encrypt(""); value != "" {
+		t.Fatalf("空字符串应原样返回: %q", value)
+	}
+	if NeedsReencrypt(first) || !NeedsReencrypt("legacy-plaintext") || NeedsReencrypt("") {
+		t.Fatal("NeedsReencrypt 判断错误")
+	}
+}
+
+// 密文被篡改、主密钥不同或缺少对应版本时解密失败，未配置主密钥时无法加密
+func TestDecryptFailures(t *testing.T) {
+	if err := SetMasterKey(testKey1); err != nil {
+		t.Fatal(err)
+	}
+	defer SetKeyring(nil)
+
+	value, _ := Encrypt("secret")
+	tampered := value[:len(value)-4] + "AAA="
+	if _, err := Decrypt(tampered); err == nil {
+		t.Fatal("篡改的密文应解密失败")
+	}
+	if _, err := Decrypt("enc:v1:" + strings.TrimPrefix(value, "enc:v2:1:")); err == nil {
+		t.Fatal("不支持的密文格式应解密失败")
+	}
+	// 篡改密文中的主密钥版本号
+	if err := Configure("", "1:"+testKey1+",2:"+testKey1, "", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(strings.Replace(value, "enc:v2:1:", "enc:v2:2:", 1)); err == nil {
+		t.Fatal("版本号被篡改的密文应解密失败")
+	}
+
+	if err := SetMasterKey(testKey2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(value); err == nil {
+		t.Fatal("主密钥不同时应解密失败")
+	}
+	if err := Configure("", "2:"+testKey2, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(value); err == nil || !strings.Contains(err.Error(), "版本 1") {
+		t.Fatalf("缺少版本 1 主密钥时应报错，实际 %v", err)
+	}
+
+	SetKeyring(nil)
+	if _, err := Encrypt("secret"); err != ErrMasterKeyNotSet {
+		t.Fatalf("未配置主密钥时应返回 ErrMasterKeyNotSet，实际 %v", err)
+	}
+	if err := SetMasterKey("too-short"); err == nil {
+		t.Fatal("长度不足的主密钥应报错")
+	}
+}
+
+// 新增主密钥版本后旧密文仍可解密，Reencrypt 迁移到当前版本
+func TestKeyRotation(t *testing.T) {
+	if err := SetMasterKey(testKey1); err != nil {
+		t.Fatal(err)
+	}
+	defer SetKeyring(nil)
+	old, _ := Encrypt("cf-api-token")
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "master.keys")
+	content := "# 主密钥\n1:" + testKey1 + "\n2:" + testKey2 + "\n"
+	if err := os.WriteFile(keyFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Configure("", "", keyFile, 0); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentKeyring().ActiveVersion() != 2 {
+		t.Fatalf("当前版本应为最大版本 2，实际 %d", CurrentKeyring().ActiveVersion())
+	}
+	if !NeedsReencrypt(old) {
+		t.Fatal("旧版本密文应需要重新加密")
+	}
+
+	rotated, err := Reencrypt(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyVersion(rotated) != 2 || NeedsReencrypt(rotated) {
+		t.Fatalf("重新加密后应为版本 2: %q", rotated)
+	}
+	if plain, err := Decrypt(rotated); err != nil || plain != "cf-api-token" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	if plain, err := Decrypt(old); err != nil || plain != "cf-api-token" {
+		t.Fatalf("旧密文应仍可解密: %q, %v", plain, err)
+	}
+
+	if err := Configure("", "1:"+testKey1+",2:"+testKey2, "", 3); err == nil {
+		t.Fatal("不存在的当前版本应报错")
+	}
+	if err := Configure("", testKey1+","+testKey2, "", 0); err == nil {
+		t.Fatal("多个不带版本的主密钥应报错")
+	}
+}
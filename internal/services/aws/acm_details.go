@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+)
+
+// CertificateDetails 证书的有效期、续期和使用情况
+type CertificateDetails struct {
+	Status             string                        // 小写，如 issued、pending_validation、expired
+	NotBefore          *time.Time                    // 生效时间（未签发时为空）
+	NotAfter           *time.Time                    // 过期时间（未签发时为空）
+	RenewalEligibility string                        // ELIGIBLE 或 INELIGIBLE
+	RenewalStatus      string                        // 托管续期状态：PENDING_AUTO_RENEWAL、PENDING_VALIDATION、SUCCESS、FAILED，未开始续期时为空
+	RenewalReason      string                        // 续期失败原因
+	InUseBy            []string                      // 使用该证书的资源 ARN（CloudFront 分发等）
+	ValidationRecords  []CertificateValidationRecord // DNS 验证记录（签发和续期使用相同的 CNAME）
+}
+
+// DescribeCertificateDetails 获取证书的有效期、续期状态和使用情况
+func (s *ACMService) DescribeCertificateDetails(certificateARN string) (*CertificateDetails, error) {
+	result, err := s.client.DescribeCertificate(&acm.DescribeCertificateInput{
+		CertificateArn: aws.String(certificateARN),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取证书详情失败: %w", err)
+	}
+	cert := result.Certificate
+	if cert == nil {
+		return nil, fmt.Errorf("证书详情为空: %s", certificateARN)
+	}
+
+	details := &CertificateDetails{
+		Status:             strings.ToLower(aws.StringValue(cert.Status)),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		RenewalEligibility: aws.StringValue(cert.RenewalEligibility),
+		InUseBy:            aws.StringValueSlice(cert.InUseBy),
+	}
+	if cert.RenewalSummary != nil {
+		details.RenewalStatus = aws.StringValue(cert.RenewalSummary.RenewalStatus)
+		details.RenewalReason = aws.StringValue(cert.RenewalSummary.RenewalStatusReason)
+	}
+	for _, option := range cert.DomainValidationOptions {
+		if option.ResourceRecord != nil {
+			details.ValidationRecords = append(details.ValidationRecords, CertificateValidationRecord{
+				Name:  aws.StringValue(option.ResourceRecord.Name),
+				Type:  aws.StringValue(option.ResourceRecord.Type),
+				Value: aws.StringValue(option.ResourceRecord.Value),
+			})
+		}
+	}
+	return details, nil
+}
@@ -907,3 +907,44 @@ func (s *CloudFrontService) CreateInvalidation(distributionID string, paths []st
 
 	return "", fmt.Errorf("创建缓存失效成功但未返回 ID")
 }
+
+// GetDistributionMaxTTL 获取分发默认缓存行为的最长缓存时间（使用缓存策略时读取策略的 MaxTTL）
+func (s *CloudFrontService) GetDistributionMaxTTL(distributionID string) (time.Duration, error) {
+	dist, err := s.GetDistribution(distributionID)
+	if err != nil {
+		return 0, err
+	}
+	if dist.DistributionConfig == nil || dist.DistributionConfig.DefaultCacheBehavior == nil {
+		return 0, fmt.Errorf("分发配置为空")
+	}
+	behavior := dist.DistributionConfig.DefaultCacheBehavior
+	if behavior.MaxTTL != nil {
+		return time.Duration(*behavior.MaxTTL) * time.Second, nil
+	}
+	if behavior.CachePolicyId == nil {
+		return 0, fmt.Errorf("分发未配置 MaxTTL 或缓存策略")
+	}
+	policy, err := s.client.GetCachePolicy(&cloudfront.GetCachePolicyInput{Id: behavior.CachePolicyId})
+	if err != nil {
+		return 0, fmt.Errorf("获取缓存策略失败: %w", err)
+	}
+	if policy.CachePolicy == nil || policy.CachePolicy.CachePolicyConfig == nil || policy.CachePolicy.CachePolicyConfig.MaxTTL == nil {
+		return 0, fmt.Errorf("缓存策略未配置 MaxTTL")
+	}
+	return time.Duration(*policy.CachePolicy.CachePolicyConfig.MaxTTL) * time.Second, nil
+}
+
+// GetInvalidationStatus 查询缓存失效的状态（InProgress / Completed）
+func (s *CloudFrontService) GetInvalidationStatus(distributionID, invalidationID string) (string, error) {
+	result, err := s.client.GetInvalidation(&cloudfront.GetInvalidationInput{
+		DistributionId: aws.String(distributionID),
+		Id:             aws.String(invalidationID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("查询 CloudFront 缓存失效失败: %w", err)
+	}
+	if result.Invalidation == nil || result.Invalidation.Status == nil {
+		return "", fmt.Errorf("缓存失效状态为空")
+	}
+	return *result.Invalidation.Status, nil
+}
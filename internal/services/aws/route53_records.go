@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// CloudFrontHostedZoneID CloudFront 分发的 Alias 目标托管区域（所有分发相同）
+const CloudFrontHostedZoneID = "Z2FDTNDATAQYW2"
+
+// ResourceRecord Route 53 记录集（简化结构）
+type ResourceRecord struct {
+	Name          string   // 记录名称（带末尾的点）
+	Type          string   // A、AAAA、CNAME、TXT、MX、CAA、NS、SOA 等
+	TTL           int64    // Alias 记录为 0
+	Values        []string // 记录值，TXT 值包含引号
+	AliasDNSName  string   // Alias 目标，非空时 Values 为空
+	AliasZoneID   string   // Alias 目标所在的托管区域
+	SetIdentifier string   // 加权、地理等路由策略记录的标识
+}
+
+// ListResourceRecords 列出托管区域的所有记录集（自动翻页）
+func (s *Route53Service) ListResourceRecords(hostedZoneID string) ([]ResourceRecord, error) {
+	var records []ResourceRecord
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+	}
+	for {
+		result, err := s.client.ListResourceRecordSets(input)
+		if err != nil {
+			return nil, fmt.Errorf("列出 Route 53 记录失败: %w", err)
+		}
+		for _, set := range result.ResourceRecordSets {
+			record := ResourceRecord{
+				Name:          aws.StringValue(set.Name),
+				Type:          aws.StringValue(set.Type),
+				TTL:           aws.Int64Value(set.TTL),
+				SetIdentifier: aws.StringValue(set.SetIdentifier),
+			}
+			for _, value := range set.ResourceRecords {
+				record.Values = append(record.Values, aws.StringValue(value.Value))
+			}
+			if set.AliasTarget != nil {
+				record.AliasDNSName = aws.StringValue(set.AliasTarget.DNSName)
+				record.AliasZoneID = aws.StringValue(set.AliasTarget.HostedZoneId)
+			}
+			records = append(records, record)
+		}
+		if !aws.BoolValue(result.IsTruncated) {
+			return records, nil
+		}
+		input.StartRecordName = result.NextRecordName
+		input.StartRecordType = result.NextRecordType
+		input.StartRecordIdentifier = result.NextRecordIdentifier
+	}
+}
+
+// ChangeResourceRecord 提交单个记录集变更
+// action: CREATE（已存在时失败）、UPSERT（创建或覆盖）、DELETE（必须与现有记录集完全一致）
+func (s *Route53Service) ChangeResourceRecord(hostedZoneID, action string, record ResourceRecord) error {
+	name := record.Name
+	if name != "" && name[len(name)-1] != '.' {
+		name = name + "."
+	}
+
+	set := &route53.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: aws.String(record.Type),
+	}
+	if record.SetIdentifier != "" {
+		set.SetIdentifier = aws.String(record.SetIdentifier)
+	}
+	if record.AliasDNSName != "" {
+		set.AliasTarget = &route53.AliasTarget{
+			DNSName:              aws.String(record.AliasDNSName),
+			HostedZoneId:         aws.String(record.AliasZoneID),
+			EvaluateTargetHealth: aws.Bool(false),
+		}
+	} else {
+		set.TTL = aws.Int64(record.TTL)
+		for _, value := range record.Values {
+			set.ResourceRecords = append(set.ResourceRecords, &route53.ResourceRecord{Value: aws.String(value)})
+		}
+	}
+
+	_, err := s.client.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{{
+				Action:            aws.String(action),
+				ResourceRecordSet: set,
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%s %s 记录 %s 失败: %w", action, record.Type, name, err)
+	}
+	return nil
+}
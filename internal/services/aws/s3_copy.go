@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	maxSingleCopySize = 5 << 30   // CopyObject 单次最大 5GB
+	copyPartSize      = 512 << 20 // 超过上限时按 512MB 分片复制
+)
+
+// CopyObject 在同一存储桶内复制对象（服务端复制，不经过本机），保留 Content-Type 等元数据
+// 目标对象的替换是原子的：读取方要么拿到旧对象，要么拿到完整的新对象
+func (s *S3Service) CopyObject(bucketName, srcKey, dstKey string, size int64) error {
+	source := bucketName + "/" + url.PathEscape(srcKey)
+	if size <= maxSingleCopySize {
+		_, err := s.client.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(bucketName),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(source),
+		})
+		if err != nil {
+			return fmt.Errorf("复制对象 %s 到 %s 失败: %w", srcKey, dstKey, err)
+		}
+		return nil
+	}
+
+	head, err := s.HeadObject(bucketName, srcKey)
+	if err != nil {
+		return err
+	}
+	uploadID, err := s.createMultipartCopy(bucketName, dstKey, aws.StringValue(head.ContentType))
+	if err != nil {
+		return err
+	}
+
+	var parts []*s3.CompletedPart
+	for partNumber, offset := int64(1), int64(0); offset < size; partNumber, offset = partNumber+1, offset+copyPartSize {
+		end := offset + copyPartSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		output, err := s.client.UploadPartCopy(&s3.UploadPartCopyInput{
+			Bucket:          aws.String(bucketName),
+			Key:             aws.String(dstKey),
+			UploadId:        aws.String(uploadID),
+			PartNumber:      aws.Int64(partNumber),
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+		})
+		if err != nil {
+			_ = s.AbortMultipartUpload(bucketName, dstKey, uploadID)
+			return fmt.Errorf("分片复制对象 %s 失败: %w", srcKey, err)
+		}
+		parts = append(parts, &s3.CompletedPart{PartNumber: aws.Int64(partNumber), ETag: output.CopyPartResult.ETag})
+	}
+
+	if _, err := s.CompleteMultipartUpload(bucketName, dstKey, uploadID, parts); err != nil {
+		_ = s.AbortMultipartUpload(bucketName, dstKey, uploadID)
+		return err
+	}
+	return nil
+}
+
+// createMultipartCopy 为分片复制发起分片上传（不设置 ACL，公开访问由存储桶策略控制）
+func (s *S3Service) createMultipartCopy(bucketName, key, contentType string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	output, err := s.client.CreateMultipartUpload(input)
+	if err != nil {
+		return "", fmt.Errorf("发起分片复制失败: %w", err)
+	}
+	return aws.StringValue(output.UploadId), nil
+}
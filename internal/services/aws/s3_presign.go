@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PresignPutObject 生成直传对象的预签名 PUT 地址
+// Content-Type 参与签名，客户端上传时必须携带相同的 Content-Type 头
+// 签名中不带 ACL（否则客户端必须携带 x-amz-acl 头），上传完成后由服务端通过 PutObjectPublicRead 设置
+func (s *S3Service) PresignPutObject(bucketName, key, contentType string, expires time.Duration) (string, error) {
+	if err := s.EnsureBucketExists(bucketName); err != nil {
+		return "", fmt.Errorf("确保存储桶存在失败: %w", err)
+	}
+
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("生成预签名上传地址失败: %w", err)
+	}
+	return url, nil
+}
+
+// PutObjectPublicRead 为对象设置 public-read ACL，与 UploadFileWithACL 一致，存储桶不支持 ACL 时跳过（依赖存储桶策略）
+func (s *S3Service) PutObjectPublicRead(bucketName, key string) error {
+	_, err := s.client.PutObjectAcl(&s3.PutObjectAclInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		ACL:    aws.String("public-read"),
+	})
+	if err != nil && (strings.Contains(err.Error(), "AccessControlListNotSupported") || strings.Contains(err.Error(), "does not allow ACLs")) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("设置对象 %s 公开读失败: %w", key, err)
+	}
+	return nil
+}
+
+// HeadObject 获取对象元数据（大小、ETag、Content-Type）
+func (s *S3Service) HeadObject(bucketName, key string) (*s3.HeadObjectOutput, error) {
+	output, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取对象 %s 信息失败: %w", key, err)
+	}
+	return output, nil
+}
+
+// GetObject 读取对象内容，调用方负责关闭
+func (s *S3Service) GetObject(bucketName, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取对象 %s 失败: %w", key, err)
+	}
+	return output.Body, nil
+}
@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"aws_cdn/internal/config"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// CallerIdentity 凭证对应的 AWS 账号和身份
+type CallerIdentity struct {
+	Account string `json:"account"`
+	ARN     string `json:"arn"`
+	UserID  string `json:"user_id"`
+}
+
+// GetCallerIdentity 通过 STS 校验凭证并返回所属账号
+func GetCallerIdentity(cfg *config.AWSConfig) (*CallerIdentity, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(cfg.Region),
+		Credentials: credentials.NewStaticCredentials(
+			cfg.AccessKeyID,
+			cfg.SecretAccessKey,
+			"",
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建 AWS session 失败: %w", err)
+	}
+
+	out, err := sts.New(sess).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("校验 AWS 凭证失败: %w", err)
+	}
+	return &CallerIdentity{
+		Account: aws.StringValue(out.Account),
+		ARN:     aws.StringValue(out.Arn),
+		UserID:  aws.StringValue(out.UserId),
+	}, nil
+}
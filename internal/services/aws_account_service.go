@@ -0,0 +1,200 @@
+package services
+
+import (
+	"aws_cdn/internal/config"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/aws"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// defaultAWSAccountRegion 未指定区域时使用的区域（ACM 证书必须在 us-east-1 才能用于 CloudFront）
+const defaultAWSAccountRegion = "us-east-1"
+
+// AWSAccountService AWS 账号管理，Access Key 由模型钩子加密后存储
+type AWSAccountService struct {
+	db *gorm.DB
+}
+
+// NewAWSAccountService 创建 AWS 账号服务
+func NewAWSAccountService(db *gorm.DB) *AWSAccountService {
+	return &AWSAccountService{db: db}
+}
+
+// ListAWSAccounts 列出所有 AWS 账号
+func (s *AWSAccountService) ListAWSAccounts() ([]models.AWSAccount, error) {
+	var accounts []models.AWSAccount
+	if err := s.db.Order("id DESC").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("获取AWS账号列表失败: %w", err)
+	}
+	return accounts, nil
+}
+
+// GetAWSAccount 获取 AWS 账号信息
+func (s *AWSAccountService) GetAWSAccount(id uint) (*models.AWSAccount, error) {
+	var account models.AWSAccount
+	if err := s.db.First(&account, id).Error; err != nil {
+		return nil, fmt.Errorf("AWS账号不存在: %w", err)
+	}
+	return &account, nil
+}
+
+// CreateAWSAccount 创建 AWS 账号
+func (s *AWSAccountService) CreateAWSAccount(name, accessKeyID, secretAccessKey, region, s3BucketName, note string) (*models.AWSAccount, error) {
+	if err := s.checkNameAvailable(name, 0); err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = defaultAWSAccountRegion
+	}
+
+	account := &models.AWSAccount{
+		Name:         name,
+		Region:       region,
+		S3BucketName: s3BucketName,
+		Note:         note,
+	}
+	if err := setAWSAccountCredentials(account, accessKeyID, secretAccessKey); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(account).Error; err != nil {
+		return nil, fmt.Errorf("创建AWS账号失败: %w", err)
+	}
+	return account, nil
+}
+
+// UpdateAWSAccount 更新 AWS 账号，Access Key 只有非空时才更新
+func (s *AWSAccountService) UpdateAWSAccount(id uint, name, accessKeyID, secretAccessKey, region, s3BucketName, note *string) (*models.AWSAccount, error) {
+	account, err := s.GetAWSAccount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil && *name != account.Name {
+		if err := s.checkNameAvailable(*name, id); err != nil {
+			return nil, err
+		}
+		account.Name = *name
+	}
+
+	// Access Key ID 和 Secret 必须成对更新
+	hasKeyID := accessKeyID != nil && *accessKeyID != ""
+	hasSecret := secretAccessKey != nil && *secretAccessKey != ""
+	if hasKeyID != hasSecret {
+		return nil, fmt.Errorf("Access Key ID 和 Secret Access Key 需要同时更新")
+	}
+	if hasKeyID {
+		if err := setAWSAccountCredentials(account, *accessKeyID, *secretAccessKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if region != nil && *region != "" {
+		account.Region = *region
+	}
+	if s3BucketName != nil {
+		account.S3BucketName = *s3BucketName
+	}
+	if note != nil {
+		account.Note = *note
+	}
+
+	if err := s.db.Save(account).Error; err != nil {
+		return nil, fmt.Errorf("更新AWS账号失败: %w", err)
+	}
+	return account, nil
+}
+
+// DeleteAWSAccount 删除 AWS 账号，仍有域名或下载包关联时拒绝删除
+func (s *AWSAccountService) DeleteAWSAccount(id uint) error {
+	account, err := s.GetAWSAccount(id)
+	if err != nil {
+		return err
+	}
+
+	var domainCount, packageCount int64
+	if err := s.db.Model(&models.Domain{}).Where("aws_account_id = ?", id).Count(&domainCount).Error; err != nil {
+		return fmt.Errorf("检查关联域名失败: %w", err)
+	}
+	if err := s.db.Model(&models.DownloadPackage{}).Where("aws_account_id = ?", id).Count(&packageCount).Error; err != nil {
+		return fmt.Errorf("检查关联下载包失败: %w", err)
+	}
+	if domainCount > 0 || packageCount > 0 {
+		return fmt.Errorf("该账号下存在 %d 个域名、%d 个下载包，请先迁移或删除后再删除账号", domainCount, packageCount)
+	}
+
+	if err := s.db.Delete(account).Error; err != nil {
+		return fmt.Errorf("删除AWS账号失败: %w", err)
+	}
+	return nil
+}
+
+// GetAWSConfig 返回可用于创建 AWS 客户端的配置
+func (s *AWSAccountService) GetAWSConfig(account *models.AWSAccount) (*config.AWSConfig, error) {
+	if account.SecretsError != "" {
+		return nil, fmt.Errorf("解密 AWS 账号 %s 的凭证失败: %s", account.Name, account.SecretsError)
+	}
+	if account.AccessKeyID == "" || account.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS 账号 %s 未配置 Access Key", account.Name)
+	}
+
+	region := account.Region
+	if region == "" {
+		region = defaultAWSAccountRegion
+	}
+	return &config.AWSConfig{
+		Region:          region,
+		AccessKeyID:     account.AccessKeyID,
+		SecretAccessKey: account.SecretAccessKey,
+		S3BucketName:    account.S3BucketName,
+	}, nil
+}
+
+// VerifyAWSAccount 通过 STS 校验账号凭证
+func (s *AWSAccountService) VerifyAWSAccount(id uint) (*aws.CallerIdentity, error) {
+	account, err := s.GetAWSAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.GetAWSConfig(account)
+	if err != nil {
+		return nil, err
+	}
+	return aws.GetCallerIdentity(cfg)
+}
+
+func (s *AWSAccountService) checkNameAvailable(name string, excludeID uint) error {
+	var count int64
+	if err := s.db.Model(&models.AWSAccount{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查账号名称失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("账号名称 %s 已存在", name)
+	}
+	return nil
+}
+
+// setAWSAccountCredentials 写入 Access Key 及其脱敏展示值
+func setAWSAccountCredentials(account *models.AWSAccount, accessKeyID, secretAccessKey string) error {
+	accessKeyID = strings.TrimSpace(accessKeyID)
+	secretAccessKey = strings.TrimSpace(secretAccessKey)
+	if accessKeyID == "" || secretAccessKey == "" {
+		return fmt.Errorf("Access Key ID 和 Secret Access Key 不能为空")
+	}
+
+	account.AccessKeyID = accessKeyID
+	account.SecretAccessKey = secretAccessKey
+	account.AccessKeyHint = maskAccessKeyID(accessKeyID)
+	return nil
+}
+
+// maskAccessKeyID 只保留 Access Key ID 的前 4 位和后 4 位
+func maskAccessKeyID(accessKeyID string) string {
+	if len(accessKeyID) <= 8 {
+		return strings.Repeat("*", len(accessKeyID))
+	}
+	return accessKeyID[:4] + strings.Repeat("*", len(accessKeyID)-8) + accessKeyID[len(accessKeyID)-4:]
+}
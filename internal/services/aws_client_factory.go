@@ -0,0 +1,110 @@
+package services
+
+import (
+	"aws_cdn/internal/config"
+	"aws_cdn/internal/services/aws"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// AWSClients 一个 AWS 账号下的客户端集合
+type AWSClients struct {
+	AccountID  *uint // 为空表示默认账号（环境变量配置）
+	Config     *config.AWSConfig
+	Route53    *aws.Route53Service
+	ACM        *aws.ACMService
+	CloudFront *aws.CloudFrontService
+	S3         *aws.S3Service
+
+	updatedAt time.Time // 构建客户端时账号的更新时间，账号被修改后重新构建
+}
+
+// EC2 创建该账号在指定区域的 EC2 客户端
+func (c *AWSClients) EC2(region string) (*ec2.EC2, error) {
+	return aws.NewEC2Client(c.Config, region)
+}
+
+// NewAWSClients 使用同一份配置创建所有 AWS 客户端
+func NewAWSClients(cfg *config.AWSConfig) (*AWSClients, error) {
+	route53Svc, err := aws.NewRoute53Service(cfg)
+	if err != nil {
+		return nil, err
+	}
+	acmSvc, err := aws.NewACMService(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cloudFrontSvc, err := aws.NewCloudFrontService(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s3Svc, err := aws.NewS3Service(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSClients{
+		Config:     cfg,
+		Route53:    route53Svc,
+		ACM:        acmSvc,
+		CloudFront: cloudFrontSvc,
+		S3:         s3Svc,
+	}, nil
+}
+
+// AWSClientFactory 按 AWS 账号创建并缓存客户端
+type AWSClientFactory struct {
+	accountService *AWSAccountService
+	defaults       *AWSClients
+
+	mu    sync.Mutex
+	cache map[uint]*AWSClients
+}
+
+// NewAWSClientFactory 创建客户端工厂，defaults 为默认账号的客户端（SetupRouter 中按环境变量创建）
+func NewAWSClientFactory(accountService *AWSAccountService, defaults *AWSClients) *AWSClientFactory {
+	return &AWSClientFactory{
+		accountService: accountService,
+		defaults:       defaults,
+		cache:          make(map[uint]*AWSClients),
+	}
+}
+
+// Default 默认账号的客户端
+func (f *AWSClientFactory) Default() *AWSClients {
+	return f.defaults
+}
+
+// ForAccount 获取账号的客户端，accountID 为空或 0 时返回默认账号的客户端
+func (f *AWSClientFactory) ForAccount(accountID *uint) (*AWSClients, error) {
+	if accountID == nil || *accountID == 0 {
+		return f.defaults, nil
+	}
+
+	account, err := f.accountService.GetAWSAccount(*accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if cached, ok := f.cache[account.ID]; ok && cached.updatedAt.Equal(account.UpdatedAt) {
+		return cached, nil
+	}
+
+	cfg, err := f.accountService.GetAWSConfig(account)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := NewAWSClients(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建 AWS 账号 %s 的客户端失败: %w", account.Name, err)
+	}
+	id := account.ID
+	clients.AccountID = &id
+	clients.updatedAt = account.UpdatedAt
+	f.cache[account.ID] = clients
+	return clients, nil
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/aws"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// certificateAlertInterval 问题未变化时重复告警的间隔
+const certificateAlertInterval = 24 * time.Hour
+
+// CertificateMonitorService 证书到期和续期监控服务
+// 定期从 ACM 读取证书的过期时间、续期状态和使用情况写回域名，
+// 证书即将过期、已过期、续期失败，或等待验证但验证 CNAME 已从 DNS 中消失时通过 Telegram 告警。
+type CertificateMonitorService struct {
+	db        *gorm.DB
+	domainSvc *DomainService
+	telegram  *TelegramService
+	alertDays int // 剩余天数不超过该值时告警
+}
+
+// NewCertificateMonitorService 创建证书监控服务
+func NewCertificateMonitorService(db *gorm.DB, domainSvc *DomainService, telegram *TelegramService, alertDays int) *CertificateMonitorService {
+	if alertDays <= 0 {
+		alertDays = 30
+	}
+	return &CertificateMonitorService{
+		db:        db,
+		domainSvc: domainSvc,
+		telegram:  telegram,
+		alertDays: alertDays,
+	}
+}
+
+// certificateIssue 证书监控发现的一个问题
+type certificateIssue struct {
+	Kind    string // expired、expiring、renewal_failed、validation_failed、validation_missing
+	Message string
+}
+
+// ExpiringCertificate 证书到期报表中的一项
+type ExpiringCertificate struct {
+	DomainID           uint       `json:"domain_id"`
+	DomainName         string     `json:"domain_name"`
+	CertificateARN     string     `json:"certificate_arn"`
+	CertificateStatus  string     `json:"certificate_status"`
+	NotAfter           *time.Time `json:"not_after"`
+	DaysLeft           *int       `json:"days_left"` // 过期后为负数，未签发时为空
+	RenewalEligibility string     `json:"renewal_eligibility"`
+	RenewalStatus      string     `json:"renewal_status"`
+	InUseBy            []string   `json:"in_use_by"`
+	Alert              string     `json:"alert"`
+	CheckedAt          *time.Time `json:"checked_at"`
+}
+
+// CheckAllCertificates 检查所有已申请证书的域名（定时任务入口）
+func (s *CertificateMonitorService) CheckAllCertificates() error {
+	log := logger.GetLogger()
+
+	var domains []models.Domain
+	if err := s.db.Where("certificate_arn <> ''").Find(&domains).Error; err != nil {
+		return fmt.Errorf("查询域名失败: %w", err)
+	}
+
+	now := time.Now()
+	var alerts []string
+	for i := range domains {
+		domain := &domains[i]
+		issues, err := s.checkDomain(domain, now)
+		if err != nil {
+			log.WithError(err).WithField("domain", domain.DomainName).Warn("证书监控检查失败")
+			continue
+		}
+		if s.shouldAlert(domain, issues, now) {
+			alerts = append(alerts, formatCertificateAlert(domain.DomainName, issues))
+			if err := s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Update("certificate_alerted_at", now).Error; err != nil {
+				log.WithError(err).WithField("domain", domain.DomainName).Warn("更新证书告警时间失败")
+			}
+		}
+	}
+
+	log.WithFields(map[string]interface{}{
+		"domains": len(domains),
+		"alerts":  len(alerts),
+	}).Info("证书监控检查完成")
+	s.notify(alerts)
+	return nil
+}
+
+// checkDomain 读取 ACM 证书详情并写回域名，返回发现的问题
+func (s *CertificateMonitorService) checkDomain(domain *models.Domain, now time.Time) ([]certificateIssue, error) {
+	// 证书在域名关联的 AWS 账号下
+	acmSvc, err := s.domainSvc.acmForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	details, err := acmSvc.DescribeCertificateDetails(domain.CertificateARN)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	if details.Status == "pending_validation" || details.RenewalStatus == "PENDING_VALIDATION" {
+		missing = s.missingValidationRecords(domain, details.ValidationRecords)
+	}
+	issues := evaluateCertificate(details, now, s.alertDays, missing)
+
+	inUseBy, _ := json.Marshal(append([]string{}, details.InUseBy...))
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	updates := map[string]interface{}{
+		"certificate_status":              details.Status,
+		"certificate_not_after":           details.NotAfter,
+		"certificate_renewal_eligibility": details.RenewalEligibility,
+		"certificate_renewal_status":      details.RenewalStatus,
+		"certificate_in_use_by":           string(inUseBy),
+		"certificate_checked_at":          now,
+		"certificate_alert":               strings.Join(messages, "；"),
+	}
+	if len(issues) == 0 {
+		updates["certificate_alert_key"] = ""
+		updates["certificate_alerted_at"] = nil
+	}
+	if err := s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新证书信息失败: %w", err)
+	}
+	return issues, nil
+}
+
+// missingValidationRecords 返回 DNS 中已不存在（或值不匹配）的验证 CNAME 名称
+// 无法查询 DNS 时不判定为缺失，避免提供商故障造成误报
+func (s *CertificateMonitorService) missingValidationRecords(domain *models.Domain, records []aws.CertificateValidationRecord) []string {
+	if domain.HostedZoneID == "" || len(records) == 0 {
+		return nil
+	}
+	log := logger.GetLogger()
+	provider, err := s.domainSvc.DNSProviderForDomain(domain)
+	if err != nil {
+		log.WithError(err).WithField("domain", domain.DomainName).Warn("创建 DNS 提供商失败，跳过验证记录检查")
+		return nil
+	}
+
+	var missing []string
+	checked := make(map[string]bool)
+	for _, record := range records {
+		expected := certificateValidationDNSRecord(record)
+		key := dnsRecordKey(expected.Name, expected.Type)
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+		ok, err := provider.CheckRecord(domain.HostedZoneID, expected)
+		if err != nil {
+			log.WithError(err).WithField("record", expected.Name).Warn("检查证书验证记录失败")
+			continue
+		}
+		if !ok {
+			missing = append(missing, normalizeDNSName(expected.Name))
+		}
+	}
+	return missing
+}
+
+// evaluateCertificate 根据证书详情判断需要告警的问题
+// missingValidation 为等待验证时 DNS 中缺失的验证 CNAME
+func evaluateCertificate(details *aws.CertificateDetails, now time.Time, alertDays int, missingValidation []string) []certificateIssue {
+	var issues []certificateIssue
+
+	switch {
+	case details.Status == "expired" || (details.NotAfter != nil && !details.NotAfter.After(now)):
+		issues = append(issues, certificateIssue{Kind: "expired", Message: "证书已过期"})
+	case details.NotAfter != nil:
+		days := certificateDaysLeft(*details.NotAfter, now)
+		if days <= alertDays {
+			message := fmt.Sprintf("证书将在 %d 天后过期（%s）", days, details.NotAfter.Format("2006-01-02"))
+			if details.RenewalEligibility != "" && details.RenewalEligibility != "ELIGIBLE" {
+				message += "，不满足 ACM 自动续期条件（证书未被使用），需要手动处理"
+			}
+			issues = append(issues, certificateIssue{Kind: "expiring", Message: message})
+		}
+	}
+
+	switch details.Status {
+	case "failed", "validation_timed_out", "revoked":
+		issues = append(issues, certificateIssue{Kind: "validation_failed", Message: fmt.Sprintf("证书状态异常: %s", details.Status)})
+	}
+	if details.RenewalStatus == "FAILED" {
+		message := "ACM 自动续期失败"
+		if details.RenewalReason != "" {
+			message += ": " + details.RenewalReason
+		}
+		issues = append(issues, certificateIssue{Kind: "renewal_failed", Message: message})
+	}
+
+	if len(missingValidation) > 0 {
+		stage := "证书签发"
+		if details.Status == "issued" {
+			stage = "证书续期"
+		}
+		issues = append(issues, certificateIssue{
+			Kind:    "validation_missing",
+			Message: fmt.Sprintf("%s等待 DNS 验证，但验证记录已不存在: %s", stage, strings.Join(missingValidation, ", ")),
+		})
+	}
+	return issues
+}
+
+// certificateDaysLeft 计算距离过期的天数（不足一天按 0 天计，过期后为负数）
+func certificateDaysLeft(notAfter, now time.Time) int {
+	return int(notAfter.Sub(now).Hours() / 24)
+}
+
+// shouldAlert 判断是否需要发送告警：问题类型变化时立即告警，未变化时每 certificateAlertInterval 重复一次
+func (s *CertificateMonitorService) shouldAlert(domain *models.Domain, issues []certificateIssue, now time.Time) bool {
+	if len(issues) == 0 {
+		return false
+	}
+	kinds := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	key := strings.Join(kinds, ",")
+	if key != domain.CertificateAlertKey {
+		if err := s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Update("certificate_alert_key", key).Error; err != nil {
+			logger.GetLogger().WithError(err).WithField("domain", domain.DomainName).Warn("更新证书告警类型失败")
+		}
+		return true
+	}
+	return domain.CertificateAlertedAt == nil || now.Sub(*domain.CertificateAlertedAt) >= certificateAlertInterval
+}
+
+// formatCertificateAlert 格式化单个域名的告警内容
+func formatCertificateAlert(domainName string, issues []certificateIssue) string {
+	var b strings.Builder
+	b.WriteString(domainName)
+	for _, issue := range issues {
+		b.WriteString("\n   • ")
+		b.WriteString(issue.Message)
+	}
+	return b.String()
+}
+
+// notify 通过 Telegram 推送证书告警
+func (s *CertificateMonitorService) notify(alerts []string) {
+	if s.telegram == nil || len(alerts) == 0 {
+		return
+	}
+
+	var message strings.Builder
+	if s.telegram.GetSitename() != "" {
+		message.WriteString(fmt.Sprintf("[%s] ", s.telegram.GetSitename()))
+	}
+	message.WriteString(fmt.Sprintf("🔐 证书监控告警（%d 个域名）\n\n", len(alerts)))
+	for i, alert := range alerts {
+		message.WriteString(fmt.Sprintf("%d. %s\n", i+1, alert))
+	}
+
+	if err := s.telegram.SendMessage(message.String()); err != nil {
+		logger.GetLogger().WithError(err).Warn("发送证书监控告警失败")
+	}
+}
+
+// ListExpiringCertificates 列出 days 天内过期的证书，以及监控发现其他问题的证书，按过期时间升序
+// 数据来自最近一次监控任务，未检查过的域名不会出现在报表中
+func (s *CertificateMonitorService) ListExpiringCertificates(days int) ([]ExpiringCertificate, error) {
+	if days <= 0 {
+		days = s.alertDays
+	}
+	now := time.Now()
+	deadline := now.Add(time.Duration(days) * 24 * time.Hour)
+
+	var domains []models.Domain
+	if err := s.db.Where("certificate_arn <> ''").
+		Where("(certificate_not_after IS NOT NULL AND certificate_not_after <= ?) OR certificate_alert <> ''", deadline).
+		Find(&domains).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]ExpiringCertificate, 0, len(domains))
+	for _, domain := range domains {
+		item := ExpiringCertificate{
+			DomainID:           domain.ID,
+			DomainName:         domain.DomainName,
+			CertificateARN:     domain.CertificateARN,
+			CertificateStatus:  domain.CertificateStatus,
+			NotAfter:           domain.CertificateNotAfter,
+			RenewalEligibility: domain.CertificateRenewalEligibility,
+			RenewalStatus:      domain.CertificateRenewalStatus,
+			InUseBy:            []string{},
+			Alert:              domain.CertificateAlert,
+			CheckedAt:          domain.CertificateCheckedAt,
+		}
+		if domain.CertificateNotAfter != nil {
+			daysLeft := certificateDaysLeft(*domain.CertificateNotAfter, now)
+			item.DaysLeft = &daysLeft
+		}
+		if domain.CertificateInUseBy != "" {
+			_ = json.Unmarshal([]byte(domain.CertificateInUseBy), &item.InUseBy)
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].NotAfter, items[j].NotAfter
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	return items, nil
+}
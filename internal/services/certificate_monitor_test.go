@@ -0,0 +1,77 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/aws"
+	"testing"
+	"time"
+)
+
+func issueKinds(issues []certificateIssue) []string {
+	kinds := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+// 按剩余天数、续期状态和缺失的验证记录判断告警类型。
+func TestEvaluateCertificate(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	at := func(days int) *time.Time {
+		v := now.Add(time.Duration(days) * 24 * time.Hour)
+		return &v
+	}
+
+	cases := []struct {
+		name    string
+		details aws.CertificateDetails
+		missing []string
+		want    []string
+	}{
+		{"healthy", aws.CertificateDetails{Status: "issued", NotAfter: at(200), RenewalEligibility: "ELIGIBLE"}, nil, nil},
+		{"expiring", aws.CertificateDetails{Status: "issued", NotAfter: at(10), RenewalEligibility: "INELIGIBLE"}, nil, []string{"expiring"}},
+		{"expired", aws.CertificateDetails{Status: "issued", NotAfter: at(-1)}, nil, []string{"expired"}},
+		{"renewal failed", aws.CertificateDetails{Status: "issued", NotAfter: at(50), RenewalStatus: "FAILED"}, nil, []string{"renewal_failed"}},
+		{"renewal missing cname", aws.CertificateDetails{Status: "issued", NotAfter: at(20), RenewalStatus: "PENDING_VALIDATION"}, []string{"_x.example.com"}, []string{"expiring", "validation_missing"}},
+		{"pending issue", aws.CertificateDetails{Status: "pending_validation"}, nil, nil},
+		{"timed out", aws.CertificateDetails{Status: "validation_timed_out"}, nil, []string{"validation_failed"}},
+	}
+	for _, tc := range cases {
+		got := issueKinds(evaluateCertificate(&tc.details, now, 30, tc.missing))
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s: got %v, want %v", tc.name, got, tc.want)
+			}
+		}
+	}
+}
+
+// 验证 CNAME 被删除或改值时判定为缺失，同名记录只检查一次。
+func TestMissingValidationRecords(t *testing.T) {
+	fake := NewFakeDNSProvider()
+	domainSvc := &DomainService{}
+	domainSvc.dnsProviderFactory = func(*models.Domain) (DNSProvider, error) { return fake, nil }
+	svc := &CertificateMonitorService{domainSvc: domainSvc}
+	domain := &models.Domain{DomainName: "example.com", HostedZoneID: "Z1"}
+
+	records := []aws.CertificateValidationRecord{
+		{Name: "_a.example.com.", Type: "CNAME", Value: "_a.acm-validations.aws."},
+		{Name: "_a.example.com.", Type: "CNAME", Value: "_a.acm-validations.aws."},
+		{Name: "_b.www.example.com.", Type: "CNAME", Value: "_b.acm-validations.aws."},
+	}
+	if err := fake.CreateRecord("Z1", certificateValidationDNSRecord(records[0])); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.CreateRecord("Z1", DNSRecord{Name: "_b.www.example.com", Type: "CNAME", Values: []string{"other.example.net"}, TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := svc.missingValidationRecords(domain, records)
+	if len(missing) != 1 || missing[0] != "_b.www.example.com" {
+		t.Fatalf("unexpected missing records: %v", missing)
+	}
+}
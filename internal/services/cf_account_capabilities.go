@@ -0,0 +1,239 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/cloudflare"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cfCapabilityProbe 探测一项功能权限的请求
+// 写接口发送空请求体：已通过鉴权时 Cloudflare 返回参数错误，不会产生任何变更。
+type cfCapabilityProbe struct {
+	capability models.CFCapability
+	method     string
+	path       string // %s 为 Zone ID 或 Account ID
+	zoneScoped bool
+}
+
+// cfPermissionProbeScript 探测 Workers 编辑权限时使用的脚本名，只修改它的设置且请求体无效，即使存在同名脚本也不会产生变更
+const cfPermissionProbeScript = "aws-cdn-permission-probe"
+
+// cfCapabilityProbeMaxZones Zone 级权限最多探测的 Zone 数
+const cfCapabilityProbeMaxZones = 20
+
+var cfCapabilityProbes = []cfCapabilityProbe{
+	{models.CFCapabilityDNS, http.MethodPost, "/zones/%s/dns_records", true},
+	{models.CFCapabilityRulesets, http.MethodPost, "/zones/%s/rulesets", true},
+	{models.CFCapabilityWorkers, http.MethodPatch, "/accounts/%s/workers/scripts/" + cfPermissionProbeScript + "/settings", false},
+	{models.CFCapabilityPages, http.MethodPost, "/accounts/%s/pages/projects", false},
+	{models.CFCapabilityKV, http.MethodPost, "/accounts/%s/storage/kv/namespaces", false},
+	{models.CFCapabilityR2, http.MethodPost, "/accounts/%s/r2/buckets", false},
+}
+
+// cfCapabilityPermissions 各功能需要在 Cloudflare 控制台为 Token 添加的权限
+var cfCapabilityPermissions = map[models.CFCapability]string{
+	models.CFCapabilityDNS:      "Zone - DNS - Edit",
+	models.CFCapabilityRulesets: "Zone - Transform Rules / Single Redirect / Cache Rules / Zone WAF - Edit",
+	models.CFCapabilityWorkers:  "Account - Workers Scripts - Edit",
+	models.CFCapabilityR2:       "Account - Workers R2 Storage - Edit（R2 API Token）",
+	models.CFCapabilityPages:    "Account - Cloudflare Pages - Edit",
+	models.CFCapabilityKV:       "Account - Workers KV Storage - Edit",
+}
+
+// CheckCapabilities 校验账号的 API Token 并探测各功能所需权限，结果写入账号（不保存）
+// R2 使用 R2 API Token（未配置时使用 API Token），其余功能使用 API Token。
+// Token 被 Cloudflare 拒绝时返回错误；网络错误等无法检测的情况记为 unknown，不返回错误。
+func (s *CFAccountService) CheckCapabilities(account *models.CFAccount) error {
+	checks := make(map[models.CFCapability]models.CFCapabilityCheck)
+	var apiProbes, r2Probes []cfCapabilityProbe
+	for _, probe := range cfCapabilityProbes {
+		if probe.capability == models.CFCapabilityR2 {
+			r2Probes = append(r2Probes, probe)
+		} else {
+			apiProbes = append(apiProbes, probe)
+		}
+	}
+
+	var invalid []string
+	apiStatus := probeCFToken(account.APIToken, account.AccountID, apiProbes, checks)
+	if tokenRejected(apiStatus) {
+		invalid = append(invalid, "API Token 无效: "+checks[apiProbes[0].capability].Message)
+	}
+	if account.R2APIToken == "" {
+		probeCFToken(account.APIToken, account.AccountID, r2Probes, checks)
+	} else if tokenRejected(probeCFToken(account.R2APIToken, account.AccountID, r2Probes, checks)) {
+		invalid = append(invalid, "R2 API Token 无效: "+checks[models.CFCapabilityR2].Message)
+	}
+
+	now := time.Now()
+	account.TokenStatus = apiStatus
+	account.Capabilities = checks
+	account.CapabilitiesCheckedAt = &now
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("Cloudflare %s", strings.Join(invalid, "；"))
+	}
+	return nil
+}
+
+// tokenRejected Token 是否已被 Cloudflare 拒绝（无效、停用或过期），未能校验时不算
+func tokenRejected(status string) bool {
+	return status != "" && status != cloudflare.TokenStatusActive
+}
+
+// RecheckCapabilities 重新检测账号 Token 权限并保存（Token 失效时也保存检测结果）
+func (s *CFAccountService) RecheckCapabilities(id uint) (*models.CFAccount, error) {
+	account, err := s.GetCFAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if account.APIToken == "" && account.R2APIToken == "" {
+		return nil, fmt.Errorf("该账号未配置 API Token")
+	}
+	if err := s.CheckCapabilities(account); err != nil {
+		logger.GetLogger().WithError(err).WithField("account_id", id).Warn("Cloudflare API Token 已失效")
+	}
+	if err := s.db.Save(account).Error; err != nil {
+		return nil, fmt.Errorf("保存权限检测结果失败: %w", err)
+	}
+	return account, nil
+}
+
+// probeCFToken 校验 Token 并探测权限，结果写入 checks，返回 Token 状态（未配置或无法校验时为空）
+func probeCFToken(apiToken, accountID string, probes []cfCapabilityProbe, checks map[models.CFCapability]models.CFCapabilityCheck) string {
+	mark := func(status models.CFCapabilityStatus, message string) {
+		for _, probe := range probes {
+			checks[probe.capability] = models.CFCapabilityCheck{Status: status, Message: message}
+		}
+	}
+	if apiToken == "" {
+		mark(models.CFCapabilityMissing, "未配置 API Token")
+		return ""
+	}
+
+	tokenAPI := cloudflare.NewTokenAPIService(apiToken)
+	verify, err := tokenAPI.Verify(accountID)
+	if err != nil {
+		mark(models.CFCapabilityUnknown, err.Error())
+		return ""
+	}
+	if verify.Status != cloudflare.TokenStatusActive {
+		message := verify.Message
+		if message == "" {
+			message = "Token 状态: " + verify.Status
+		}
+		mark(models.CFCapabilityMissing, message)
+		return verify.Status
+	}
+
+	var zones []cloudflare.TokenZone
+	var zoneTotal int
+	var zoneErr string
+	zoneLoaded := false
+	for _, probe := range probes {
+		if !probe.zoneScoped {
+			if accountID == "" {
+				checks[probe.capability] = models.CFCapabilityCheck{Status: models.CFCapabilityUnknown, Message: "未配置 Account ID"}
+				continue
+			}
+			checks[probe.capability] = probeCFCapability(tokenAPI, probe, accountID)
+			continue
+		}
+
+		// Token 可能只授权了部分 Zone，逐个探测可访问的 Zone
+		if !zoneLoaded {
+			zoneLoaded = true
+			var err error
+			zones, zoneTotal, err = tokenAPI.AccessibleZones(accountID, cfCapabilityProbeMaxZones)
+			switch {
+			case err != nil:
+				zoneErr = err.Error()
+			case len(zones) == 0:
+				zoneErr = "Token 没有可访问的 Zone，无法检测 Zone 级权限"
+			}
+		}
+		if len(zones) == 0 {
+			checks[probe.capability] = models.CFCapabilityCheck{Status: models.CFCapabilityUnknown, Message: zoneErr}
+			continue
+		}
+		results := make([]models.CFCapabilityCheck, len(zones))
+		for i, zone := range zones {
+			results[i] = probeCFCapability(tokenAPI, probe, zone.ID)
+		}
+		checks[probe.capability] = mergeZoneChecks(zones, results, zoneTotal)
+	}
+	return verify.Status
+}
+
+// probeCFCapability 在指定 Zone 或账号上探测一项权限
+func probeCFCapability(tokenAPI *cloudflare.TokenAPIService, probe cfCapabilityProbe, scopeID string) models.CFCapabilityCheck {
+	var payload any
+	if probe.method != http.MethodGet {
+		payload = map[string]any{}
+	}
+	result, err := tokenAPI.ProbePermission(probe.method, fmt.Sprintf(probe.path, url.PathEscape(scopeID)), payload)
+	switch {
+	case err != nil:
+		return models.CFCapabilityCheck{Status: models.CFCapabilityUnknown, Message: err.Error()}
+	case result.Granted:
+		return models.CFCapabilityCheck{Status: models.CFCapabilityGranted, Message: result.Message}
+	default:
+		return models.CFCapabilityCheck{Status: models.CFCapabilityMissing, Message: result.Message}
+	}
+}
+
+// mergeZoneChecks 汇总各 Zone 的探测结果：全部具备才算具备，全部缺少才算缺少；
+// 只有部分 Zone 缺少权限时记为 unknown 并列出这些 Zone，以实际调用结果为准。total 为 Token 可访问的 Zone 总数。
+func mergeZoneChecks(zones []cloudflare.TokenZone, results []models.CFCapabilityCheck, total int) models.CFCapabilityCheck {
+	var missing []string
+	var unknown *models.CFCapabilityCheck
+	for i, result := range results {
+		switch result.Status {
+		case models.CFCapabilityMissing:
+			missing = append(missing, zones[i].Name)
+		case models.CFCapabilityUnknown:
+			if unknown == nil {
+				unknown = &results[i]
+			}
+		}
+	}
+	switch {
+	case len(missing) == len(results):
+		return results[0]
+	case len(missing) > 0:
+		return models.CFCapabilityCheck{Status: models.CFCapabilityUnknown, Message: "以下 Zone 缺少权限: " + strings.Join(missing, "、")}
+	case unknown != nil:
+		return *unknown
+	}
+	check := models.CFCapabilityCheck{Status: models.CFCapabilityGranted}
+	if total > len(zones) {
+		check.Message = fmt.Sprintf("已检测 %d/%d 个 Zone", len(zones), total)
+	}
+	return check
+}
+
+// requireCFCapabilities 检测结果为缺少权限时提前返回明确的错误
+// 未检测过或无法检测（unknown）的功能放行，以实际调用结果为准。
+func requireCFCapabilities(account *models.CFAccount, capabilities ...models.CFCapability) error {
+	var missing []string
+	for _, capability := range capabilities {
+		check, ok := account.Capabilities[capability]
+		if !ok || check.Status != models.CFCapabilityMissing {
+			continue
+		}
+		item := cfCapabilityPermissions[capability]
+		if check.Message != "" {
+			item += "（" + check.Message + "）"
+		}
+		missing = append(missing, item)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Cloudflare 账号 %s 的 API Token 缺少权限: %s。请在 Cloudflare 控制台为 Token 添加权限后重新检测", account.Email, strings.Join(missing, "；"))
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/cloudflare"
+	"strings"
+	"testing"
+)
+
+// 只有检测为缺少的权限才拦截，未检测或无法检测的放行
+func TestRequireCFCapabilities(t *testing.T) {
+	account := &models.CFAccount{
+		Email: "ops@example.com",
+		Capabilities: map[models.CFCapability]models.CFCapabilityCheck{
+			models.CFCapabilityWorkers: {Status: models.CFCapabilityGranted},
+			models.CFCapabilityKV:      {Status: models.CFCapabilityMissing, Message: "Authentication error (code: 10000)"},
+			models.CFCapabilityPages:   {Status: models.CFCapabilityUnknown, Message: "未配置 Account ID"},
+		},
+	}
+
+	if err := requireCFCapabilities(account, models.CFCapabilityWorkers, models.CFCapabilityPages, models.CFCapabilityR2); err != nil {
+		t.Fatalf("不应拦截: %v", err)
+	}
+	err := requireCFCapabilities(account, workerCapabilities("下载")...)
+	if err == nil || !strings.Contains(err.Error(), "Workers KV Storage") || strings.Contains(err.Error(), "Workers Scripts") {
+		t.Fatalf("应只提示缺少 KV 权限，实际 %v", err)
+	}
+	if err := requireCFCapabilities(&models.CFAccount{}, models.CFCapabilityR2); err != nil {
+		t.Fatalf("未检测过的账号不应拦截: %v", err)
+	}
+}
+
+// Zone 级权限汇总所有探测的 Zone：全部具备才算具备，部分缺少时记为无法判断并列出缺少权限的 Zone
+func TestMergeZoneChecks(t *testing.T) {
+	zones := []cloudflare.TokenZone{{ID: "z1", Name: "a.com"}, {ID: "z2", Name: "b.com"}}
+	granted := models.CFCapabilityCheck{Status: models.CFCapabilityGranted}
+	missing := models.CFCapabilityCheck{Status: models.CFCapabilityMissing, Message: "Authentication error (code: 10000)"}
+
+	if got := mergeZoneChecks(zones, []models.CFCapabilityCheck{granted, granted}, 2); got.Status != models.CFCapabilityGranted || got.Message != "" {
+		t.Fatalf("全部具备: %+v", got)
+	}
+	if got := mergeZoneChecks(zones, []models.CFCapabilityCheck{granted, granted}, 30); got.Status != models.CFCapabilityGranted || !strings.Contains(got.Message, "2/30") {
+		t.Fatalf("只检测了部分 Zone 时应说明: %+v", got)
+	}
+	if got := mergeZoneChecks(zones, []models.CFCapabilityCheck{granted, missing}, 2); got.Status != models.CFCapabilityUnknown || !strings.Contains(got.Message, "b.com") {
+		t.Fatalf("部分缺少: %+v", got)
+	}
+	if got := mergeZoneChecks(zones, []models.CFCapabilityCheck{missing, missing}, 2); got.Status != models.CFCapabilityMissing {
+		t.Fatalf("全部缺少: %+v", got)
+	}
+}
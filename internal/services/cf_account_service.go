@@ -53,14 +53,20 @@ func (s *CFAccountService) CreateCFAccount(email, password, apiToken, r2APIToken
 	account := &models.CFAccount{
 		Email:             email,
 		Password:          string(hashedPassword),
-		APIToken:          apiToken,   // Cloudflare API Token（暂时明文存储，后续可以改进为加密存储）
-		R2APIToken:        r2APIToken, // R2 API Token（暂时明文存储，后续可以改进为加密存储）
+		APIToken:          apiToken,   // Cloudflare API Token
+		R2APIToken:        r2APIToken, // R2 API Token
 		AccountID:         accountID,
 		R2AccessKeyID:     r2AccessKeyID,     // R2 Access Key ID（账号维度）
 		R2SecretAccessKey: r2SecretAccessKey, // R2 Secret Access Key（账号维度）
 		Note:              note,
 	}
 
+	// 校验 Token 并记录各功能权限，Token 被拒绝时不保存
+	if err := s.CheckCapabilities(account); err != nil {
+		return nil, err
+	}
+
+	// 凭证由模型钩子加密后写入数据库
 	if err := s.db.Create(account).Error; err != nil {
 		return nil, fmt.Errorf("创建Cloudflare账号失败: %w", err)
 	}
@@ -95,18 +101,24 @@ func (s *CFAccountService) UpdateCFAccount(id uint, email, password, apiToken, r
 		account.Password = string(hashedPassword)
 	}
 
+	// Token 或 Account ID 变化后需要重新检测权限
+	recheck := false
+
 	// 如果更新 API Token（只有非空字符串才更新）
 	if apiToken != nil && *apiToken != "" {
-		account.APIToken = *apiToken // 暂时明文存储，后续可以改进为加密存储
+		recheck = recheck || *apiToken != account.APIToken
+		account.APIToken = *apiToken
 	}
 
 	// 如果更新 R2 API Token（只有非空字符串才更新）
 	if r2APIToken != nil && *r2APIToken != "" {
-		account.R2APIToken = *r2APIToken // 暂时明文存储，后续可以改进为加密存储
+		recheck = recheck || *r2APIToken != account.R2APIToken
+		account.R2APIToken = *r2APIToken
 	}
 
 	// 如果更新 Account ID
 	if accountID != nil {
+		recheck = recheck || *accountID != account.AccountID
 		account.AccountID = *accountID
 	}
 
@@ -125,6 +137,12 @@ func (s *CFAccountService) UpdateCFAccount(id uint, email, password, apiToken, r
 		account.Note = *note
 	}
 
+	if recheck {
+		if err := s.CheckCapabilities(account); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.db.Save(account).Error; err != nil {
 		return nil, fmt.Errorf("更新Cloudflare账号失败: %w", err)
 	}
@@ -164,13 +182,11 @@ func (s *CFAccountService) VerifyPassword(account *models.CFAccount, password st
 
 // GetAPIToken 获取 Cloudflare API Token（解密后返回）
 func (s *CFAccountService) GetAPIToken(account *models.CFAccount) string {
-	// 暂时直接返回，后续可以改进为解密
 	return account.APIToken
 }
 
 // GetR2APIToken 获取 R2 API Token（解密后返回）
 func (s *CFAccountService) GetR2APIToken(account *models.CFAccount) string {
-	// 暂时直接返回，后续可以改进为解密
 	// 如果 R2APIToken 为空，使用 APIToken（向后兼容）
 	if account.R2APIToken == "" {
 		return account.APIToken
@@ -178,12 +194,12 @@ func (s *CFAccountService) GetR2APIToken(account *models.CFAccount) string {
 	return account.R2APIToken
 }
 
-// GetR2AccessKeyID 获取 R2 Access Key ID
+// GetR2AccessKeyID 获取 R2 Access Key ID（解密后返回）
 func (s *CFAccountService) GetR2AccessKeyID(account *models.CFAccount) string {
 	return account.R2AccessKeyID
 }
 
-// GetR2SecretAccessKey 获取 R2 Secret Access Key
+// GetR2SecretAccessKey 获取 R2 Secret Access Key（解密后返回）
 func (s *CFAccountService) GetR2SecretAccessKey(account *models.CFAccount) string {
 	return account.R2SecretAccessKey
 }
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"aws_cdn/internal/config"
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/cloudflare"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DeleteManagedRule 的错误，handler 据此区分 404 和 403
+var (
+	ErrManagedRuleNotFound = errors.New("规则不存在")
+	ErrRuleNotManaged      = errors.New("规则不是由本系统创建的，请在 Cloudflare 控制台处理")
+)
+
+// ZoneManagedRule Zone 上由本系统创建的规则及其是否仍被引用
+type ZoneManagedRule struct {
+	cloudflare.ManagedRule
+	Orphaned bool `json:"orphaned"` // 系统中已没有使用该域名的 R2 自定义域名 / 下载包 / 302 重定向
+}
+
+// CFRulesetService 查看和清理 Zone 上由本系统创建的 Ruleset 规则
+// 删除 R2 自定义域名、下载包等记录时不会同步删除 Cloudflare 上的规则，这里列出后由用户确认清理。
+type CFRulesetService struct {
+	db               *gorm.DB
+	cfAccountService *CFAccountService
+}
+
+// NewCFRulesetService 创建 Ruleset 规则服务
+func NewCFRulesetService(db *gorm.DB, cfAccountService *CFAccountService) *CFRulesetService {
+	return &CFRulesetService{db: db, cfAccountService: cfAccountService}
+}
+
+// ListManagedRules 列出 Zone 上由本系统创建的规则，并标记已无引用的孤立规则
+func (s *CFRulesetService) ListManagedRules(cfAccountID uint, zoneID string) ([]ZoneManagedRule, error) {
+	cfSvc, err := s.getCFService(cfAccountID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cfSvc.ListManagedRules(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ZoneManagedRule, 0, len(rules))
+	for _, rule := range rules {
+		inUse, err := s.ruleInUse(zoneID, rule)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ZoneManagedRule{ManagedRule: rule, Orphaned: !inUse})
+	}
+	return result, nil
+}
+
+// DeleteManagedRule 删除 Zone 上由本系统创建的规则，拒绝删除其他来源的规则
+func (s *CFRulesetService) DeleteManagedRule(cfAccountID uint, zoneID string, phase cloudflare.RulesetPhase, ruleID string) error {
+	cfSvc, err := s.getCFService(cfAccountID)
+	if err != nil {
+		return err
+	}
+	client := cfSvc.Rulesets(zoneID, phase)
+	rules, err := client.List()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.ID != ruleID {
+			continue
+		}
+		kind, domain, ok := cloudflare.ClassifyManagedRule(phase, rule)
+		if !ok {
+			return fmt.Errorf("%s: %w", ruleID, ErrRuleNotManaged)
+		}
+		if err := client.Delete(ruleID); err != nil {
+			return err
+		}
+		logger.GetLogger().WithFields(map[string]interface{}{
+			"zone_id": zoneID,
+			"phase":   phase,
+			"rule_id": ruleID,
+			"kind":    kind,
+			"domain":  domain,
+		}).Info("已删除 Zone 规则")
+		return nil
+	}
+	return ErrManagedRuleNotFound
+}
+
+// ruleInUse 检查规则作用的域名是否仍被系统中的记录使用
+func (s *CFRulesetService) ruleInUse(zoneID string, rule cloudflare.ManagedRule) (bool, error) {
+	var count int64
+	var err error
+	switch rule.Kind {
+	case cloudflare.ManagedRuleDomainRedirect:
+		err = s.db.Model(&models.DomainRedirect{}).Where("zone_id = ? AND source_domain = ?", zoneID, rule.Domain).Count(&count).Error
+	case cloudflare.ManagedRuleCORS, cloudflare.ManagedRuleDefaultFile:
+		err = s.db.Model(&models.R2CustomDomain{}).Where("domain = ?", rule.Domain).Count(&count).Error
+	default:
+		// WAF 规则按根域名创建，根域名下任一 R2 自定义域名或下载包仍在使用即视为有引用
+		suffix := "%." + rule.Domain
+		err = s.db.Model(&models.R2CustomDomain{}).Where("domain = ? OR domain LIKE ?", rule.Domain, suffix).Count(&count).Error
+		if err == nil && count == 0 {
+			err = s.db.Model(&models.DownloadPackage{}).Where("domain_name = ? OR domain_name LIKE ?", rule.Domain, suffix).Count(&count).Error
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("检查规则引用失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (s *CFRulesetService) getCFService(cfAccountID uint) (*cloudflare.CloudflareService, error) {
+	account, err := s.cfAccountService.GetCFAccount(cfAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireCFCapabilities(account, models.CFCapabilityRulesets); err != nil {
+		return nil, err
+	}
+	token := s.cfAccountService.GetAPIToken(account)
+	if token == "" {
+		return nil, fmt.Errorf("该 CF 账号未配置 API Token")
+	}
+	return cloudflare.NewCloudflareService(&config.CloudflareConfig{APIToken: token})
+}
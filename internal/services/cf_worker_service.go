@@ -160,6 +160,14 @@ func (s *CFWorkerService) CreateWorker(req *CreateWorkerRequest) (*models.CFWork
 	return s.createWorkerPromotionMode(req)
 }
 
+// workerCapabilities Worker 操作需要的 Token 权限，下载模式还需要 KV
+func workerCapabilities(businessMode string) []models.CFCapability {
+	if businessMode == "下载" {
+		return []models.CFCapability{models.CFCapabilityWorkers, models.CFCapabilityKV}
+	}
+	return []models.CFCapability{models.CFCapabilityWorkers}
+}
+
 // createWorkerDownloadMode 创建「下载模式」Worker：一个 R2 桶 + 一个 KV，多域名每域名对应一个文件路径，Worker 代理 R2 对象
 func (s *CFWorkerService) createWorkerDownloadMode(req *CreateWorkerRequest) (*models.CFWorker, error) {
 	log := logger.GetLogger()
@@ -196,6 +204,9 @@ func (s *CFWorkerService) createWorkerDownloadMode(req *CreateWorkerRequest) (*m
 	if err := s.db.First(&cfAccount, bucket.CFAccountID).Error; err != nil {
 		return nil, fmt.Errorf("CF 账号不存在: %w", err)
 	}
+	if err := requireCFCapabilities(&cfAccount, workerCapabilities("下载")...); err != nil {
+		return nil, err
+	}
 	apiToken := cfAccount.APIToken
 	accountID := cfAccount.AccountID
 	cfService := cloudflare.NewWorkerAPIService(apiToken, accountID)
@@ -306,6 +317,9 @@ func (s *CFWorkerService) createWorkerPromotionMode(req *CreateWorkerRequest) (*
 	if err := s.db.First(&cfAccount, req.CFAccountID).Error; err != nil {
 		return nil, fmt.Errorf("CF 账号不存在: %w", err)
 	}
+	if err := requireCFCapabilities(&cfAccount, workerCapabilities("推广")...); err != nil {
+		return nil, err
+	}
 	apiToken := cfAccount.APIToken
 	cfService := cloudflare.NewWorkerAPIService(apiToken, cfAccount.AccountID)
 
@@ -477,6 +491,9 @@ func (s *CFWorkerService) UpdateWorker(id uint, req *UpdateWorkerRequest) (*mode
 	if err := s.db.First(&cfAccount, worker.CFAccountID).Error; err != nil {
 		return nil, fmt.Errorf("CF 账号不存在: %w", err)
 	}
+	if err := requireCFCapabilities(&cfAccount, workerCapabilities(worker.BusinessMode)...); err != nil {
+		return nil, err
+	}
 
 	currentTargets := worker.TargetsList()
 	needScriptUpdate := false
@@ -710,6 +727,9 @@ func (s *CFWorkerService) BindWorkerDomain(workerID uint, domain, filePath strin
 	if err := s.db.First(&cfAccount, worker.CFAccountID).Error; err != nil {
 		return nil, fmt.Errorf("CF 账号不存在: %w", err)
 	}
+	if err := requireCFCapabilities(&cfAccount, workerCapabilities(worker.BusinessMode)...); err != nil {
+		return nil, err
+	}
 	cfService := cloudflare.NewWorkerAPIService(cfAccount.APIToken, cfAccount.AccountID)
 
 	// 下载模式：写入 KV 域名→路径
@@ -797,6 +817,9 @@ func (s *CFWorkerService) UnbindWorkerDomain(workerID uint, domain string) (*mod
 	if err := s.db.First(cfAccount, worker.CFAccountID).Error; err != nil {
 		return nil, fmt.Errorf("CF 账号不存在: %w", err)
 	}
+	if err := requireCFCapabilities(cfAccount, workerCapabilities(worker.BusinessMode)...); err != nil {
+		return nil, err
+	}
 	cfService := cloudflare.NewWorkerAPIService(cfAccount.APIToken, cfAccount.AccountID)
 
 	log := logger.GetLogger()
@@ -158,6 +158,13 @@ func (s *CFWorkpageSiteService) Deploy(id uint) (*models.CFWorkpageSite, error)
 		}).Error
 		return nil, err
 	}
+	if err := requireCFCapabilities(account, models.CFCapabilityPages); err != nil {
+		_ = s.db.Model(&models.CFWorkpageSite{}).Where("id = ?", id).Updates(map[string]any{
+			"status":     "failed",
+			"last_error": err.Error(),
+		}).Error
+		return nil, err
+	}
 	apiToken := s.cfAccountService.GetAPIToken(account)
 	if apiToken == "" {
 		err := fmt.Errorf("该 CF 账号未配置 API Token（需要 Pages Write 权限）")
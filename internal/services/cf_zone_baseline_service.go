@@ -0,0 +1,555 @@
+package services
+
+import (
+	"aws_cdn/internal/config"
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/cloudflare"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// zoneBaselineSetting 基线可以管理的 Zone 设置
+// 期望值与 CloudflareService 中对应的 Enable*/Disable* 方法写入的值一致，应用基线时直接调用这些方法。
+type zoneBaselineSetting struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Expected string `json:"expected"`
+	apply    func(*cloudflare.CloudflareService, string) error
+}
+
+var zoneBaselineSettings = []zoneBaselineSetting{
+	{"http3", "HTTP/3 (QUIC)", "on", (*cloudflare.CloudflareService).EnableHTTP3},
+	{"0rtt", "0-RTT 连接恢复", "on", (*cloudflare.CloudflareService).Enable0RTT},
+	{"ipv6", "IPv6", "on", (*cloudflare.CloudflareService).EnableIPv6},
+	{"min_tls_version", "最低 TLS 版本", "1.3", (*cloudflare.CloudflareService).EnableMinTLS13},
+	{"brotli", "Brotli 压缩", "on", (*cloudflare.CloudflareService).EnableBrotli},
+	{"always_use_https", "Always Use HTTPS", "on", (*cloudflare.CloudflareService).EnableAlwaysUseHTTPS},
+	{"rocket_loader", "Rocket Loader", "off", (*cloudflare.CloudflareService).DisableRocketLoader},
+	{"minify", "Auto Minify", "off", (*cloudflare.CloudflareService).DisableAutoMinify},
+	{"smart_tiered_cache", "智能分层缓存", "on", (*cloudflare.CloudflareService).EnableSmartTieredCache},
+}
+
+// smartTieredCacheSetting 智能分层缓存不在 Zone 设置接口中，需要单独读取
+const smartTieredCacheSetting = "smart_tiered_cache"
+
+func findZoneBaselineSetting(key string) (zoneBaselineSetting, bool) {
+	for _, setting := range zoneBaselineSettings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return zoneBaselineSetting{}, false
+}
+
+// ZoneSettingState 一项设置的期望值与当前值
+type ZoneSettingState struct {
+	Setting     string `json:"setting"`
+	Label       string `json:"label"`
+	Expected    string `json:"expected"`
+	Actual      string `json:"actual"`
+	InSync      bool   `json:"in_sync"`
+	Unavailable bool   `json:"unavailable,omitempty"` // Cloudflare 未返回该设置（如套餐不支持或已下线），不计入漂移
+}
+
+// ZoneBaselineReport 检测或应用基线的结果
+type ZoneBaselineReport struct {
+	Attachment *models.CFZoneBaselineAttachment `json:"attachment"`
+	Settings   []ZoneSettingState               `json:"settings"`
+	Applied    []string                         `json:"applied,omitempty"` // 本次重新应用的设置
+	Failed     map[string]string                `json:"failed,omitempty"`  // 应用失败的设置及原因
+}
+
+// CFZoneBaselineService Cloudflare Zone 设置基线与漂移检测
+// 自定义域名创建时会一次性开启 HTTP/3、TLS 1.3 等优化，之后在控制台被改动无从得知；
+// 基线把这些设置声明下来，关联到 Zone 后定时比对并在出现漂移时告警，可以一键恢复。
+type CFZoneBaselineService struct {
+	db               *gorm.DB
+	cfAccountService *CFAccountService
+	telegram         *TelegramService
+}
+
+// NewCFZoneBaselineService 创建 Zone 基线服务
+func NewCFZoneBaselineService(db *gorm.DB, cfAccountService *CFAccountService, telegram *TelegramService) *CFZoneBaselineService {
+	return &CFZoneBaselineService{
+		db:               db,
+		cfAccountService: cfAccountService,
+		telegram:         telegram,
+	}
+}
+
+// SupportedSettings 基线支持的设置项及其期望值
+func (s *CFZoneBaselineService) SupportedSettings() []zoneBaselineSetting {
+	return zoneBaselineSettings
+}
+
+// ListBaselines 列出所有基线
+func (s *CFZoneBaselineService) ListBaselines() ([]models.CFZoneBaseline, error) {
+	var baselines []models.CFZoneBaseline
+	if err := s.db.Order("id").Find(&baselines).Error; err != nil {
+		return nil, fmt.Errorf("获取基线列表失败: %w", err)
+	}
+	return baselines, nil
+}
+
+// GetBaseline 获取基线
+func (s *CFZoneBaselineService) GetBaseline(id uint) (*models.CFZoneBaseline, error) {
+	var baseline models.CFZoneBaseline
+	if err := s.db.First(&baseline, id).Error; err != nil {
+		return nil, fmt.Errorf("基线不存在: %w", err)
+	}
+	return &baseline, nil
+}
+
+// CreateBaseline 创建基线，settings 为空时包含全部支持的设置
+func (s *CFZoneBaselineService) CreateBaseline(name string, settings []string, note string) (*models.CFZoneBaseline, error) {
+	if err := s.checkNameAvailable(name, 0); err != nil {
+		return nil, err
+	}
+	if len(settings) == 0 {
+		for _, setting := range zoneBaselineSettings {
+			settings = append(settings, setting.Key)
+		}
+	}
+	settings, err := normalizeBaselineSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &models.CFZoneBaseline{Name: name, Settings: settings, Note: note}
+	if err := s.db.Create(baseline).Error; err != nil {
+		return nil, fmt.Errorf("创建基线失败: %w", err)
+	}
+	return baseline, nil
+}
+
+// UpdateBaseline 更新基线，settings 为 nil 时不修改
+func (s *CFZoneBaselineService) UpdateBaseline(id uint, name *string, settings []string, note *string) (*models.CFZoneBaseline, error) {
+	baseline, err := s.GetBaseline(id)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil && *name != baseline.Name {
+		if err := s.checkNameAvailable(*name, id); err != nil {
+			return nil, err
+		}
+		baseline.Name = *name
+	}
+	if settings != nil {
+		normalized, err := normalizeBaselineSettings(settings)
+		if err != nil {
+			return nil, err
+		}
+		baseline.Settings = normalized
+	}
+	if note != nil {
+		baseline.Note = *note
+	}
+
+	if err := s.db.Save(baseline).Error; err != nil {
+		return nil, fmt.Errorf("更新基线失败: %w", err)
+	}
+	return baseline, nil
+}
+
+// DeleteBaseline 删除基线，仍有 Zone 关联时拒绝删除
+func (s *CFZoneBaselineService) DeleteBaseline(id uint) error {
+	baseline, err := s.GetBaseline(id)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.CFZoneBaselineAttachment{}).Where("baseline_id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查关联 Zone 失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("该基线仍关联了 %d 个 Zone，请先取消关联后再删除", count)
+	}
+	if err := s.db.Delete(baseline).Error; err != nil {
+		return fmt.Errorf("删除基线失败: %w", err)
+	}
+	return nil
+}
+
+// ListAttachments 列出 Zone 与基线的关联及漂移状态，driftStatus 为空时不过滤
+func (s *CFZoneBaselineService) ListAttachments(cfAccountID uint, driftStatus string) ([]models.CFZoneBaselineAttachment, error) {
+	query := s.db.Preload("Baseline")
+	if cfAccountID != 0 {
+		query = query.Where("cf_account_id = ?", cfAccountID)
+	}
+	if driftStatus != "" {
+		query = query.Where("drift_status = ?", driftStatus)
+	}
+	var attachments []models.CFZoneBaselineAttachment
+	if err := query.Order("id").Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("获取基线关联失败: %w", err)
+	}
+	return attachments, nil
+}
+
+// AttachBaseline 为 Zone 关联基线（已关联时替换为新基线），关联后立即检测一次
+func (s *CFZoneBaselineService) AttachBaseline(cfAccountID uint, zoneID string, baselineID uint) (*ZoneBaselineReport, error) {
+	account, err := s.cfAccountService.GetCFAccount(cfAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetBaseline(baselineID); err != nil {
+		return nil, err
+	}
+	cfSvc, err := s.cloudflareFor(account)
+	if err != nil {
+		return nil, err
+	}
+	zoneName, err := cfSvc.GetZoneByID(zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Zone 失败: %w", err)
+	}
+
+	var attachment models.CFZoneBaselineAttachment
+	err = s.db.Where("zone_id = ?", zoneID).First(&attachment).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("查询基线关联失败: %w", err)
+	}
+	attachment.CFAccountID = cfAccountID
+	attachment.ZoneID = zoneID
+	attachment.ZoneName = zoneName
+	attachment.BaselineID = baselineID
+	attachment.Baseline = nil
+	attachment.DriftStatus = models.ZoneDriftStatusUnchecked
+	attachment.Drift = nil
+	attachment.LastError = ""
+	if err := s.db.Save(&attachment).Error; err != nil {
+		return nil, fmt.Errorf("保存基线关联失败: %w", err)
+	}
+
+	return s.CheckZone(cfAccountID, zoneID)
+}
+
+// DetachBaseline 取消 Zone 的基线关联（不会修改 Zone 当前设置）
+func (s *CFZoneBaselineService) DetachBaseline(cfAccountID uint, zoneID string) error {
+	result := s.db.Where("cf_account_id = ? AND zone_id = ?", cfAccountID, zoneID).Delete(&models.CFZoneBaselineAttachment{})
+	if result.Error != nil {
+		return fmt.Errorf("取消基线关联失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("该 Zone 未关联基线")
+	}
+	return nil
+}
+
+// CheckAllZones 检测所有关联了基线的 Zone（定时任务入口），新出现漂移的 Zone 通过 Telegram 告警
+func (s *CFZoneBaselineService) CheckAllZones() error {
+	log := logger.GetLogger()
+
+	var attachments []models.CFZoneBaselineAttachment
+	if err := s.db.Preload("Baseline").Find(&attachments).Error; err != nil {
+		return fmt.Errorf("查询基线关联失败: %w", err)
+	}
+
+	accounts := make(map[uint]*models.CFAccount)
+	var newlyDrifted []*models.CFZoneBaselineAttachment
+	driftedCount := 0
+	for i := range attachments {
+		attachment := &attachments[i]
+		previous := attachment.DriftStatus
+
+		account, ok := accounts[attachment.CFAccountID]
+		if !ok {
+			var err error
+			if account, err = s.cfAccountService.GetCFAccount(attachment.CFAccountID); err != nil {
+				log.WithError(err).WithField("zone", attachment.ZoneName).Warn("Zone 基线检测：获取 CF 账号失败")
+				continue
+			}
+			accounts[attachment.CFAccountID] = account
+		}
+
+		if _, err := s.checkAttachment(account, attachment); err != nil {
+			log.WithError(err).WithField("zone", attachment.ZoneName).Warn("Zone 基线检测失败")
+			continue
+		}
+		if attachment.DriftStatus == models.ZoneDriftStatusDrifted {
+			driftedCount++
+			if previous != models.ZoneDriftStatusDrifted {
+				newlyDrifted = append(newlyDrifted, attachment)
+			}
+		}
+	}
+
+	log.WithFields(map[string]interface{}{
+		"zones":         len(attachments),
+		"drifted":       driftedCount,
+		"newly_drifted": len(newlyDrifted),
+	}).Info("Zone 基线漂移检测完成")
+	s.notify(newlyDrifted)
+	return nil
+}
+
+// CheckZone 立即检测指定 Zone 与基线的差异
+func (s *CFZoneBaselineService) CheckZone(cfAccountID uint, zoneID string) (*ZoneBaselineReport, error) {
+	account, attachment, err := s.getAttachment(cfAccountID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	previous := attachment.DriftStatus
+	report, err := s.checkAttachment(account, attachment)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.DriftStatus == models.ZoneDriftStatusDrifted && previous != models.ZoneDriftStatusDrifted {
+		s.notify([]*models.CFZoneBaselineAttachment{attachment})
+	}
+	return report, nil
+}
+
+// ApplyBaseline 重新应用 Zone 关联的基线：只修改不一致的设置（读取失败时全部应用），完成后重新检测
+func (s *CFZoneBaselineService) ApplyBaseline(cfAccountID uint, zoneID string) (*ZoneBaselineReport, error) {
+	log := logger.GetLogger()
+	account, attachment, err := s.getAttachment(cfAccountID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	cfSvc, err := s.cloudflareFor(account)
+	if err != nil {
+		return nil, err
+	}
+
+	var toApply []string
+	if states, readErr := s.readStates(cfSvc, attachment); readErr == nil {
+		for _, state := range states {
+			if !state.InSync && !state.Unavailable {
+				toApply = append(toApply, state.Setting)
+			}
+		}
+	} else {
+		log.WithError(readErr).WithField("zone", attachment.ZoneName).Warn("读取 Zone 设置失败，将应用基线中的全部设置")
+		toApply = attachment.Baseline.Settings
+	}
+
+	var applied []string
+	failed := make(map[string]string)
+	for _, key := range toApply {
+		setting, ok := findZoneBaselineSetting(key)
+		if !ok {
+			continue
+		}
+		if err := setting.apply(cfSvc, zoneID); err != nil {
+			failed[key] = err.Error()
+			continue
+		}
+		applied = append(applied, key)
+	}
+
+	now := time.Now()
+	attachment.AppliedAt = &now
+	report, err := s.checkAttachment(account, attachment)
+	if err != nil {
+		return nil, err
+	}
+	report.Applied = applied
+	if len(failed) > 0 {
+		report.Failed = failed
+	}
+
+	log.WithFields(map[string]interface{}{
+		"zone":    attachment.ZoneName,
+		"applied": applied,
+		"failed":  len(failed),
+	}).Info("Zone 基线已重新应用")
+	return report, nil
+}
+
+// getAttachment 获取 Zone 的基线关联及所属账号
+func (s *CFZoneBaselineService) getAttachment(cfAccountID uint, zoneID string) (*models.CFAccount, *models.CFZoneBaselineAttachment, error) {
+	account, err := s.cfAccountService.GetCFAccount(cfAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var attachment models.CFZoneBaselineAttachment
+	if err := s.db.Preload("Baseline").Where("cf_account_id = ? AND zone_id = ?", cfAccountID, zoneID).First(&attachment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("该 Zone 未关联基线")
+		}
+		return nil, nil, fmt.Errorf("查询基线关联失败: %w", err)
+	}
+	return account, &attachment, nil
+}
+
+// checkAttachment 读取 Zone 当前设置并与基线比对，结果写回关联记录
+// 读取失败时记为 error 状态并返回 nil error 的报告，只有保存失败才返回 error。
+func (s *CFZoneBaselineService) checkAttachment(account *models.CFAccount, attachment *models.CFZoneBaselineAttachment) (*ZoneBaselineReport, error) {
+	if attachment.Baseline == nil {
+		return nil, fmt.Errorf("Zone %s 关联的基线不存在", attachment.ZoneName)
+	}
+
+	var states []ZoneSettingState
+	cfSvc, err := s.cloudflareFor(account)
+	if err == nil {
+		states, err = s.readStates(cfSvc, attachment)
+	}
+
+	now := time.Now()
+	attachment.CheckedAt = &now
+	if err != nil {
+		attachment.DriftStatus = models.ZoneDriftStatusError
+		attachment.LastError = err.Error()
+	} else {
+		attachment.Drift = zoneSettingDrift(states)
+		attachment.LastError = ""
+		attachment.DriftStatus = models.ZoneDriftStatusInSync
+		if len(attachment.Drift) > 0 {
+			attachment.DriftStatus = models.ZoneDriftStatusDrifted
+		}
+	}
+
+	if err := s.db.Model(attachment).Select("drift_status", "drift", "last_error", "checked_at", "applied_at").Updates(attachment).Error; err != nil {
+		return nil, fmt.Errorf("保存检测结果失败: %w", err)
+	}
+	return &ZoneBaselineReport{Attachment: attachment, Settings: states}, nil
+}
+
+// readStates 读取 Zone 当前设置并与基线比对
+func (s *CFZoneBaselineService) readStates(cfSvc *cloudflare.CloudflareService, attachment *models.CFZoneBaselineAttachment) ([]ZoneSettingState, error) {
+	settings, err := cfSvc.GetZoneSettings(attachment.ZoneID)
+	if err != nil {
+		return nil, err
+	}
+	actual := make(map[string]string, len(settings)+1)
+	for id, setting := range settings {
+		actual[id] = zoneSettingValue(setting.Value)
+	}
+	for _, key := range attachment.Baseline.Settings {
+		if key != smartTieredCacheSetting {
+			continue
+		}
+		enabled, err := cfSvc.GetSmartTieredCacheStatus(attachment.ZoneID)
+		if err != nil {
+			return nil, err
+		}
+		actual[smartTieredCacheSetting] = "off"
+		if enabled {
+			actual[smartTieredCacheSetting] = "on"
+		}
+	}
+	return compareZoneSettings(attachment.Baseline.Settings, actual), nil
+}
+
+func (s *CFZoneBaselineService) cloudflareFor(account *models.CFAccount) (*cloudflare.CloudflareService, error) {
+	apiToken := s.cfAccountService.GetAPIToken(account)
+	if apiToken == "" {
+		return nil, fmt.Errorf("Cloudflare 账号 %s 未配置 API Token", account.Email)
+	}
+	return cloudflare.NewCloudflareService(&config.CloudflareConfig{APIToken: apiToken})
+}
+
+func (s *CFZoneBaselineService) checkNameAvailable(name string, excludeID uint) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("基线名称不能为空")
+	}
+	var count int64
+	if err := s.db.Model(&models.CFZoneBaseline{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查基线名称失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("基线名称 %s 已存在", name)
+	}
+	return nil
+}
+
+func (s *CFZoneBaselineService) notify(attachments []*models.CFZoneBaselineAttachment) {
+	if s.telegram == nil || len(attachments) == 0 {
+		return
+	}
+
+	var message strings.Builder
+	if s.telegram.GetSitename() != "" {
+		message.WriteString(fmt.Sprintf("[%s] ", s.telegram.GetSitename()))
+	}
+	message.WriteString(fmt.Sprintf("⚠️ Cloudflare Zone 设置偏离基线（%d 个）\n\n", len(attachments)))
+	for i, attachment := range attachments {
+		items := make([]string, 0, len(attachment.Drift))
+		for _, drift := range attachment.Drift {
+			items = append(items, fmt.Sprintf("%s: %s → %s", drift.Setting, drift.Expected, drift.Actual))
+		}
+		message.WriteString(fmt.Sprintf("%d. %s\n   %s\n", i+1, attachment.ZoneName, strings.Join(items, "，")))
+	}
+
+	if err := s.telegram.SendMessage(message.String()); err != nil {
+		logger.GetLogger().WithError(err).Warn("发送 Zone 基线漂移通知失败")
+	}
+}
+
+// normalizeBaselineSettings 校验设置项并去重，保持 zoneBaselineSettings 中的顺序
+func normalizeBaselineSettings(settings []string) ([]string, error) {
+	wanted := make(map[string]bool, len(settings))
+	for _, key := range settings {
+		key = strings.TrimSpace(key)
+		if _, ok := findZoneBaselineSetting(key); !ok {
+			return nil, fmt.Errorf("不支持的 Zone 设置: %s", key)
+		}
+		wanted[key] = true
+	}
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("基线至少需要包含一项设置")
+	}
+	normalized := make([]string, 0, len(wanted))
+	for _, setting := range zoneBaselineSettings {
+		if wanted[setting.Key] {
+			normalized = append(normalized, setting.Key)
+		}
+	}
+	return normalized, nil
+}
+
+// compareZoneSettings 将基线中的设置与当前值比对
+func compareZoneSettings(baseline []string, actual map[string]string) []ZoneSettingState {
+	states := make([]ZoneSettingState, 0, len(baseline))
+	for _, key := range baseline {
+		setting, ok := findZoneBaselineSetting(key)
+		if !ok {
+			continue
+		}
+		state := ZoneSettingState{Setting: key, Label: setting.Label, Expected: setting.Expected}
+		value, ok := actual[key]
+		if !ok {
+			state.Unavailable = true
+		} else {
+			state.Actual = value
+			state.InSync = value == setting.Expected
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// zoneSettingDrift 提取不一致的设置
+func zoneSettingDrift(states []ZoneSettingState) []models.ZoneSettingDrift {
+	var drift []models.ZoneSettingDrift
+	for _, state := range states {
+		if !state.InSync && !state.Unavailable {
+			drift = append(drift, models.ZoneSettingDrift{Setting: state.Setting, Expected: state.Expected, Actual: state.Actual})
+		}
+	}
+	return drift
+}
+
+// zoneSettingValue 将设置值转为字符串；minify 等对象值全部为 off 时视为 off，否则为 on
+func zoneSettingValue(raw json.RawMessage) string {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+	var object map[string]string
+	if err := json.Unmarshal(raw, &object); err == nil {
+		for _, v := range object {
+			if v != "off" {
+				return "on"
+			}
+		}
+		return "off"
+	}
+	return string(raw)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Cloudflare 未返回的设置不计入漂移，minify 对象全部关闭时视为 off
+func TestCompareZoneSettings(t *testing.T) {
+	actual := map[string]string{
+		"http3":           "on",
+		"min_tls_version": "1.2",
+		"minify":          zoneSettingValue(json.RawMessage(`{"css":"off","html":"off","js":"off"}`)),
+	}
+	states := compareZoneSettings([]string{"http3", "min_tls_version", "minify", "0rtt"}, actual)
+	if len(states) != 4 {
+		t.Fatalf("应返回 4 项设置，实际 %d", len(states))
+	}
+	if !states[0].InSync || states[1].InSync || !states[2].InSync || !states[3].Unavailable {
+		t.Fatalf("比对结果不符合预期: %+v", states)
+	}
+	drift := zoneSettingDrift(states)
+	if len(drift) != 1 || drift[0].Setting != "min_tls_version" || drift[0].Actual != "1.2" {
+		t.Fatalf("应只有 min_tls_version 漂移，实际 %+v", drift)
+	}
+	if zoneSettingValue(json.RawMessage(`{"css":"on","html":"off","js":"off"}`)) != "on" {
+		t.Fatal("minify 任一项开启时应视为 on")
+	}
+}
+
+// 设置项去重并按注册顺序排列，未知设置报错
+func TestNormalizeBaselineSettings(t *testing.T) {
+	settings, err := normalizeBaselineSettings([]string{"minify", "http3", "minify"})
+	if err != nil || len(settings) != 2 || settings[0] != "http3" || settings[1] != "minify" {
+		t.Fatalf("normalizeBaselineSettings = %v, %v", settings, err)
+	}
+	if _, err := normalizeBaselineSettings([]string{"websockets"}); err == nil {
+		t.Fatal("未知设置应报错")
+	}
+	if _, err := normalizeBaselineSettings([]string{}); err == nil {
+		t.Fatal("空设置应报错")
+	}
+}
@@ -1455,978 +1455,59 @@ func (s *CloudflareService) ConfigureCORS(accountID, bucketName string, corsConf
 // domain: 要匹配的域名（例如：test111.wkljm.com）
 // allowOrigin: 允许的来源（例如："*" 或 "https://yourdomain.com"）
 func (s *CloudflareService) CreateCORSTransformRule(zoneID, domain, allowOrigin string) (string, error) {
-	log := logger.GetLogger()
-
-	// 构建匹配表达式：覆盖主域名和所有子域名
-	expression := fmt.Sprintf(`(http.host eq "%s" or http.host contains ".%s")`, domain, domain)
-	description := fmt.Sprintf("Add CORS headers for R2 domain %s及所有子域名", domain)
-
-	// 构建响应头设置 - 按照 Cloudflare API 规范
-	headers := map[string]interface{}{
-		"Access-Control-Allow-Origin": map[string]interface{}{
-			"operation": "set",
-			"value":     allowOrigin,
-		},
-		"Access-Control-Allow-Methods": map[string]interface{}{
-			"operation": "set",
-			"value":     "GET, HEAD, OPTIONS",
-		},
-		"Access-Control-Allow-Headers": map[string]interface{}{
-			"operation": "set",
-			"value":     "*",
-		},
-		"Access-Control-Expose-Headers": map[string]interface{}{
-			"operation": "set",
-			"value":     "ETag, Content-Length, Content-Type, Content-Range, Content-Disposition",
-		},
-		"Access-Control-Max-Age": map[string]interface{}{
-			"operation": "set",
-			"value":     "3600",
-		},
-	}
-
-	// 构建规则 - action 必须是字符串 "rewrite"
-	rule := map[string]interface{}{
-		"expression": expression,
-		"action":     "rewrite",
-		"action_parameters": map[string]interface{}{
-			"headers": headers,
-		},
-		"description": description,
-		"enabled":     true,
-	}
-
-	// 步骤1: 获取或创建 http_response_header_transformation ruleset
-	rulesetID, err := s.getOrCreateTransformRuleset(zoneID)
-	if err != nil {
-		return "", fmt.Errorf("获取或创建 ruleset 失败: %w", err)
-	}
-
-	// 步骤2: 获取该 ruleset 的所有 rules，检查是否已存在相同 domain 的 rule
-	existingRuleID, err := s.findRuleByExpression(zoneID, rulesetID, expression)
-	if err != nil {
-		log.WithError(err).WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"domain":     domain,
-		}).Warn("查找现有 rule 失败，尝试创建新 rule")
-		existingRuleID = ""
-	}
-
-	// 步骤3: 如果存在，使用 PATCH 更新；否则使用 POST 添加
-	var ruleID string
-	if existingRuleID != "" {
-		// 更新已存在的 rule - 必须包含 id 字段
-		rule["id"] = existingRuleID
-		// 添加 ref 字段（如果不存在）
-		if _, exists := rule["ref"]; !exists {
-			rule["ref"] = fmt.Sprintf("cors_%s", domain)
-		}
-		ruleID, err = s.updateRule(zoneID, rulesetID, existingRuleID, rule)
-		if err != nil {
-			return "", fmt.Errorf("更新 rule 失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-		}).Info("CORS Transform Rule 更新成功")
-	} else {
-		// 添加新 rule - 创建不包含 id 的副本，并添加 ref 字段
-		newRule := make(map[string]interface{})
-		for k, v := range rule {
-			newRule[k] = v
-		}
-		// 添加 ref 字段（用户定义的引用，用于保持一致性）
-		newRule["ref"] = fmt.Sprintf("cors_%s", domain)
-		// 确保不包含 id 字段（由服务器生成）
-		delete(newRule, "id")
-
-		ruleID, err = s.addRule(zoneID, rulesetID, newRule)
-		if err != nil {
-			return "", fmt.Errorf("添加 rule 失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-		}).Info("CORS Transform Rule 创建成功")
-	}
-
-	return ruleID, nil
-}
-
-// getOrCreateTransformRuleset 获取或创建 http_response_header_transformation ruleset
-func (s *CloudflareService) getOrCreateTransformRuleset(zoneID string) (string, error) {
-	log := logger.GetLogger()
-
-	// 先尝试获取现有的 ruleset
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err == nil {
-			var rulesetsResp struct {
-				Success bool `json:"success"`
-				Result  []struct {
-					ID   string `json:"id"`
-					Name string `json:"name"`
-					Kind string `json:"kind"`
-				} `json:"result"`
-			}
-			log.WithFields(map[string]interface{}{
-				"body": string(body),
-			}).Info("获取 ruleset 响应")
-			if err := json.Unmarshal(body, &rulesetsResp); err == nil && rulesetsResp.Success {
-				// 查找 http_response_header_transformation ruleset
-				for _, rs := range rulesetsResp.Result {
-					if rs.Kind == "zone" && rs.Name == "http_response_header_transformation" {
-						return rs.ID, nil
-					}
-				}
-			}
-		}
-	}
-
-	// 如果不存在，创建新的 ruleset
-	log.WithFields(map[string]interface{}{
-		"zone_id": zoneID,
-	}).Info("未找到 http_response_header_transformation ruleset，尝试创建")
-
-	payload := map[string]interface{}{
-		"name":  "http_response_header_transformation",
-		"kind":  "zone",
-		"phase": "http_response_headers_transform",
-		"rules": []map[string]interface{}{},
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	url = fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err = http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err = s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		log.WithFields(map[string]interface{}{
-			"body":    string(body),
-			"zoneID":  zoneID,
-			"payload": payload,
-			"headers": s.getAuthHeaders(),
-		}).Error("创建 ruleset 失败")
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("创建 ruleset 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("创建 ruleset 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-	log.WithFields(map[string]interface{}{
-		"body":    string(body),
-		"zoneID":  zoneID,
-		"payload": payload,
-	}).Info("创建 ruleset 响应")
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("创建 ruleset 失败")
-	}
-
-	return result.Result.ID, nil
-}
-
-// findRuleByExpression 根据 expression 查找 rule ID
-func (s *CloudflareService) findRuleByExpression(zoneID, rulesetID, expression string) (string, error) {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s", zoneID, rulesetID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取 ruleset 失败 (状态码: %d)", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var rulesetResp struct {
-		Success bool `json:"success"`
-		Result  struct {
-			Rules []struct {
-				ID         string `json:"id"`
-				Expression string `json:"expression"`
-			} `json:"rules"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &rulesetResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !rulesetResp.Success {
-		return "", fmt.Errorf("获取 ruleset 失败")
-	}
-
-	// 查找匹配的 rule
-	for _, rule := range rulesetResp.Result.Rules {
-		if rule.Expression == expression {
-			return rule.ID, nil
-		}
-	}
-
-	return "", nil // 未找到
-}
-
-// addRule 添加 rule 到 ruleset
-func (s *CloudflareService) addRule(zoneID, rulesetID string, rule map[string]interface{}) (string, error) {
-	jsonData, err := json.Marshal(rule)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s/rules", zoneID, rulesetID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("添加 rule 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("添加 rule 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("添加 rule 失败")
-	}
-
-	return result.Result.ID, nil
-}
-
-// updateRule 使用 PATCH 更新 rule
-func (s *CloudflareService) updateRule(zoneID, rulesetID, ruleID string, rule map[string]interface{}) (string, error) {
-	jsonData, err := json.Marshal(rule)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s/rules/%s", zoneID, rulesetID, ruleID)
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("更新 rule 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("更新 rule 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("更新 rule 失败")
-	}
-
-	return result.Result.ID, nil
-}
-
-// CreateWAFSecurityRule 创建 WAF 安全规则（VPN 白名单 + IDM 高频下载豁免）
-// zoneID: 域名所在的 Zone ID
-// domain: 要保护的域名（例如：test111.wkljm.com）
-// fileExtensions: 要豁免的文件扩展名列表（例如：[]string{"apk", "exe", "zip"}）
-func (s *CloudflareService) CreateWAFSecurityRule(zoneID, domain string, fileExtensions []string) (string, error) {
-	log := logger.GetLogger()
-
-	// 如果没有指定文件扩展名，默认使用 apk
-	if len(fileExtensions) == 0 {
-		fileExtensions = []string{"apk"}
-	}
-	domain = extractRootDomain(domain)
-
-	// 构建文件扩展名匹配表达式
-	var extensionExpr string
-	if len(fileExtensions) == 1 {
-		extensionExpr = fmt.Sprintf(`http.request.uri.path.extension eq "%s"`, fileExtensions[0])
-	} else {
-		// 多个扩展名使用 in 操作符
-		exts := make([]string, len(fileExtensions))
-		for i, ext := range fileExtensions {
-			exts[i] = fmt.Sprintf(`"%s"`, ext)
-		}
-		extensionExpr = fmt.Sprintf(`http.request.uri.path.extension in {%s}`, strings.Join(exts, " "))
-	}
-
-	// 构建完整的匹配表达式
-	// (cf.threat_score le 50) and (http.host eq "domain" or http.host contains ".domain") and (http.request.uri.path.extension eq "apk")
-	// 覆盖主域名和所有子域名
-	expression := fmt.Sprintf(`(cf.threat_score le 50) and (http.host eq "%s" or http.host contains ".%s") and (%s)`, domain, domain, extensionExpr)
-	description := fmt.Sprintf("VPN白名单+IDM高频下载豁免: %s及所有子域名 (%s)", domain, strings.Join(fileExtensions, ", "))
-
-	// 构建 WAF 规则
-	rule := map[string]interface{}{
-		"expression":  expression,
-		"action":      "skip",
-		"description": description,
-		"enabled":     true,
-		"action_parameters": map[string]interface{}{
-			"phases": []string{
-				"http_ratelimit",
-				"http_request_sbfm",
-				"http_request_firewall_managed",
-			},
-		},
-	}
-
-	// 步骤1: 获取或创建 http_request_firewall_custom ruleset
-	rulesetID, err := s.getOrCreateWAFRuleset(zoneID)
-	if err != nil {
-		return "", fmt.Errorf("获取或创建 WAF ruleset 失败: %w", err)
-	}
-
-	log.WithFields(map[string]interface{}{
-		"zone_id":    zoneID,
-		"ruleset_id": rulesetID,
-		"domain":     domain,
-		"extensions": fileExtensions,
-	}).Info("准备创建 WAF 安全规则")
-
-	// 步骤2: 检查是否已存在相同域名的规则
-	existingRuleID, err := s.findWAFRuleByDomain(zoneID, rulesetID, domain)
-	if err != nil {
-		log.WithError(err).WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"domain":     domain,
-		}).Warn("查找现有 WAF rule 失败，尝试创建新 rule")
-		existingRuleID = ""
-	}
-
-	// 步骤3: 如果存在，使用 PATCH 更新；否则使用 POST 添加
-	var ruleID string
-	if existingRuleID != "" {
-		// 更新已存在的 rule
-		rule["id"] = existingRuleID
-		rule["ref"] = fmt.Sprintf("waf_security_%s", domain)
-		ruleID, err = s.updateWAFRule(zoneID, rulesetID, existingRuleID, rule)
-		if err != nil {
-			return "", fmt.Errorf("更新 WAF rule 失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-		}).Info("WAF 安全规则更新成功")
-	} else {
-		// 添加新 rule
-		newRule := make(map[string]interface{})
-		for k, v := range rule {
-			newRule[k] = v
-		}
-		newRule["ref"] = fmt.Sprintf("waf_security_%s", domain)
-		delete(newRule, "id")
-
-		ruleID, err = s.addWAFRule(zoneID, rulesetID, newRule)
-		if err != nil {
-			return "", fmt.Errorf("添加 WAF rule 失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-		}).Info("WAF 安全规则创建成功")
-	}
-
-	return ruleID, nil
-}
-
-// CreateWAFVIPDownloadRule 创建 WAF "免检金牌" VIP 下载规则（00_Allow_APK_Download_VIP）
-// 这是整个下载站的核心规则，优先级最高，跳过所有防火墙检查
-// zoneID: 域名所在的 Zone ID
-// domain: 要保护的域名（例如：dl1.example.com）
-func (s *CloudflareService) CreateWAFVIPDownloadRule(zoneID, domain string) (string, error) {
-	log := logger.GetLogger()
-	domain = extractRootDomain(domain)
-	// 构建匹配表达式：.apk 或 .obb 或 /download/ 路径
-	// 这是最宽松的规则，只要是下载相关的，统统放行！
-	// 覆盖主域名和所有子域名
-	expression := fmt.Sprintf(
-		`(http.host eq "%s" or http.host contains ".%s") and (`+
-			`http.request.uri.path.extension eq "apk" or `+
-			`http.request.uri.path.extension eq "obb" or `+
-			`http.request.uri.path contains "/download/"`+
-			`)`,
-		domain, domain,
-	)
-
-	description := fmt.Sprintf("00_Allow_APK_Download_VIP: %s及所有子域名 - 免检金牌，最高优先级，跳过所有防火墙", domain)
-
-	// 构建 WAF 规则
-	// action: skip - 跳过所有防火墙检查
-	// phases: 跳过限速、机器人检测、托管防火墙规则
-	rule := map[string]interface{}{
-		"expression":  expression,
-		"action":      "skip",
-		"description": description,
-		"enabled":     true,
-		"action_parameters": map[string]interface{}{
-			"phases": []string{
-				"http_ratelimit",                // 跳过限速
-				"http_request_sbfm",             // 跳过超级机器人对抗模式
-				"http_request_firewall_managed", // 跳过托管防火墙规则
-			},
-		},
-	}
-
-	// 步骤1: 获取或创建 http_request_firewall_custom ruleset
-	rulesetID, err := s.getOrCreateWAFRuleset(zoneID)
-	if err != nil {
-		return "", fmt.Errorf("获取或创建 WAF ruleset 失败: %w", err)
-	}
-
-	log.WithFields(map[string]interface{}{
-		"zone_id":    zoneID,
-		"ruleset_id": rulesetID,
-		"domain":     domain,
-	}).Info("准备创建 WAF VIP 下载规则（00_Allow_APK_Download_VIP）")
-
-	// 步骤2: 检查是否已存在 VIP 规则
-	existingRuleID, err := s.findWAFRuleByRef(zoneID, rulesetID, fmt.Sprintf("00_vip_download_%s", domain))
-	if err != nil {
-		log.WithError(err).WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"domain":     domain,
-		}).Warn("查找现有 VIP 规则失败，尝试创建新规则")
-		existingRuleID = ""
-	}
-
-	// 步骤3: 如果存在，使用 PATCH 更新；否则使用 POST 添加
-	var ruleID string
-	if existingRuleID != "" {
-		// 更新已存在的 rule
-		rule["id"] = existingRuleID
-		rule["ref"] = fmt.Sprintf("00_vip_download_%s", domain)
-		ruleID, err = s.updateWAFRule(zoneID, rulesetID, existingRuleID, rule)
-		if err != nil {
-			return "", fmt.Errorf("更新 VIP 规则失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-		}).Info("WAF VIP 下载规则更新成功（免检金牌已更新）")
-	} else {
-		// 添加新 rule
-		newRule := make(map[string]interface{})
-		for k, v := range rule {
-			newRule[k] = v
-		}
-		// 使用 "00_" 前缀确保最高优先级
-		newRule["ref"] = fmt.Sprintf("00_vip_download_%s", domain)
-		delete(newRule, "id")
-
-		ruleID, err = s.addWAFRule(zoneID, rulesetID, newRule)
-		if err != nil {
-			return "", fmt.Errorf("添加 VIP 规则失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id":    zoneID,
-			"ruleset_id": rulesetID,
-			"rule_id":    ruleID,
-			"domain":     domain,
-			"expression": expression,
-		}).Info("🎉 WAF VIP 下载规则创建成功！免检金牌已启用，所有 APK/OBB 下载将直接放行")
-	}
-
-	return ruleID, nil
-}
-
-// getOrCreateWAFRuleset 获取或创建 http_request_firewall_custom ruleset
-func (s *CloudflareService) getOrCreateWAFRuleset(zoneID string) (string, error) {
-	log := logger.GetLogger()
-
-	// 先尝试获取现有的 ruleset
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err == nil {
-			var rulesetsResp struct {
-				Success bool `json:"success"`
-				Result  []struct {
-					ID    string `json:"id"`
-					Name  string `json:"name"`
-					Kind  string `json:"kind"`
-					Phase string `json:"phase"`
-				} `json:"result"`
-			}
-			if err := json.Unmarshal(body, &rulesetsResp); err == nil && rulesetsResp.Success {
-				// 查找 http_request_firewall_custom ruleset
-				for _, rs := range rulesetsResp.Result {
-					if rs.Phase == "http_request_firewall_custom" {
-						log.WithFields(map[string]interface{}{
-							"zone_id":    zoneID,
-							"ruleset_id": rs.ID,
-						}).Info("找到现有的 WAF ruleset")
-						return rs.ID, nil
-					}
-				}
-			}
-		}
-	}
-
-	// 如果不存在，创建新的 ruleset
-	log.WithFields(map[string]interface{}{
-		"zone_id": zoneID,
-	}).Info("未找到 http_request_firewall_custom ruleset，尝试创建")
-
-	payload := map[string]interface{}{
-		"name":  "http_request_firewall_custom",
-		"kind":  "zone",
-		"phase": "http_request_firewall_custom",
-		"rules": []map[string]interface{}{},
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	url = fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err = http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err = s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("创建 WAF ruleset 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("创建 WAF ruleset 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("创建 WAF ruleset 失败")
-	}
-
-	log.WithFields(map[string]interface{}{
-		"zone_id":    zoneID,
-		"ruleset_id": result.Result.ID,
-	}).Info("WAF ruleset 创建成功")
-
-	return result.Result.ID, nil
-}
-
-// findWAFRuleByDomain 根据域名查找 WAF rule ID
-func (s *CloudflareService) findWAFRuleByDomain(zoneID, rulesetID, domain string) (string, error) {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s", zoneID, rulesetID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取 WAF ruleset 失败 (状态码: %d)", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var rulesetResp struct {
-		Success bool `json:"success"`
-		Result  struct {
-			Rules []struct {
-				ID         string `json:"id"`
-				Expression string `json:"expression"`
-				Ref        string `json:"ref"`
-			} `json:"rules"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &rulesetResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !rulesetResp.Success {
-		return "", fmt.Errorf("获取 WAF ruleset 失败")
-	}
-
-	// 查找匹配的 rule（只通过 ref 精确匹配，避免误匹配 VIP 规则）
-	refPattern := fmt.Sprintf("waf_security_%s", domain)
-	for _, rule := range rulesetResp.Result.Rules {
-		if rule.Ref == refPattern {
-			return rule.ID, nil
-		}
-	}
-
-	return "", nil // 未找到
-}
-
-// findWAFRuleByRef 根据 ref 查找 WAF rule ID
-func (s *CloudflareService) findWAFRuleByRef(zoneID, rulesetID, ref string) (string, error) {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s", zoneID, rulesetID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取 WAF ruleset 失败 (状态码: %d)", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	var rulesetResp struct {
-		Success bool `json:"success"`
-		Result  struct {
-			Rules []struct {
-				ID  string `json:"id"`
-				Ref string `json:"ref"`
-			} `json:"rules"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &rulesetResp); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !rulesetResp.Success {
-		return "", fmt.Errorf("获取 WAF ruleset 失败")
-	}
-
-	// 查找匹配的 rule
-	for _, rule := range rulesetResp.Result.Rules {
-		if rule.Ref == ref {
-			return rule.ID, nil
-		}
-	}
-
-	return "", nil // 未找到
-}
-
-// addWAFRule 添加 WAF rule 到 ruleset
-func (s *CloudflareService) addWAFRule(zoneID, rulesetID string, rule map[string]interface{}) (string, error) {
-	jsonData, err := json.Marshal(rule)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s/rules", zoneID, rulesetID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	ruleID, err := s.Rulesets(zoneID, PhaseResponseHeadersTransform).Upsert(NewCORSHeadersRule(domain, allowOrigin))
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("添加 WAF rule 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("添加 WAF rule 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("添加 WAF rule 失败")
+		return "", fmt.Errorf("写入 CORS Transform Rule 失败: %w", err)
 	}
-
-	return result.Result.ID, nil
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"zone_id": zoneID,
+		"rule_id": ruleID,
+		"domain":  domain,
+	}).Info("CORS Transform Rule 已创建或更新")
+	return ruleID, nil
 }
 
-// updateWAFRule 使用 PATCH 更新 WAF rule
-func (s *CloudflareService) updateWAFRule(zoneID, rulesetID, ruleID string, rule map[string]interface{}) (string, error) {
-	jsonData, err := json.Marshal(rule)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
+// CreateWAFSecurityRule 创建 WAF 安全规则（VPN 白名单 + IDM 高频下载豁免）
+// zoneID: 域名所在的 Zone ID
+// domain: 要保护的域名（例如：test111.wkljm.com），规则作用于其根域名及所有子域名
+// fileExtensions: 要豁免的文件扩展名列表（例如：[]string{"apk", "exe", "zip"}）
+func (s *CloudflareService) CreateWAFSecurityRule(zoneID, domain string, fileExtensions []string) (string, error) {
+	// 如果没有指定文件扩展名，默认使用 apk
+	if len(fileExtensions) == 0 {
+		fileExtensions = []string{"apk"}
 	}
+	domain = extractRootDomain(domain)
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s/rules/%s", zoneID, rulesetID, ruleID)
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
+	ruleID, err := s.Rulesets(zoneID, PhaseFirewallCustom).Upsert(NewWAFSecurityRule(domain, fileExtensions))
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
+		return "", fmt.Errorf("写入 WAF 安全规则失败: %w", err)
 	}
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"zone_id":    zoneID,
+		"rule_id":    ruleID,
+		"domain":     domain,
+		"extensions": fileExtensions,
+	}).Info("WAF 安全规则已创建或更新")
+	return ruleID, nil
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
+// CreateWAFVIPDownloadRule 创建 WAF "免检金牌" VIP 下载规则（00_Allow_APK_Download_VIP）
+// 这是整个下载站的核心规则，APK/OBB 和 /download/ 路径跳过所有防火墙检查
+// zoneID: 域名所在的 Zone ID
+// domain: 要保护的域名（例如：dl1.example.com），规则作用于其根域名及所有子域名
+func (s *CloudflareService) CreateWAFVIPDownloadRule(zoneID, domain string) (string, error) {
+	domain = extractRootDomain(domain)
 
-	body, err := io.ReadAll(resp.Body)
+	ruleID, err := s.Rulesets(zoneID, PhaseFirewallCustom).Upsert(NewWAFVIPDownloadRule(domain))
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errorResp struct {
-			Success bool `json:"success"`
-			Errors  []struct {
-				Message string `json:"message"`
-				Code    int    `json:"code"`
-			} `json:"errors"`
-		}
-		if err := json.Unmarshal(body, &errorResp); err == nil && len(errorResp.Errors) > 0 {
-			return "", fmt.Errorf("更新 WAF rule 失败: %s (Code: %d)", errorResp.Errors[0].Message, errorResp.Errors[0].Code)
-		}
-		return "", fmt.Errorf("更新 WAF rule 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !result.Success {
-		return "", fmt.Errorf("更新 WAF rule 失败")
+		return "", fmt.Errorf("写入 VIP 下载规则失败: %w", err)
 	}
-
-	return result.Result.ID, nil
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"zone_id": zoneID,
+		"rule_id": ruleID,
+		"domain":  domain,
+	}).Info("WAF VIP 下载规则已创建或更新")
+	return ruleID, nil
 }
 
 // CreatePageRule 创建 Page Rule（页面规则）用于缓存优化
@@ -3376,8 +2457,6 @@ func (s *CloudflareService) DisableAutoMinify(zoneID string) error {
 // CreateDefaultFileRedirect 创建默认文件重定向规则
 // 当访问域名根路径（/）时，自动重定向到指定的默认文件
 func (s *CloudflareService) CreateDefaultFileRedirect(zoneID, domain, defaultFilePath string) (string, error) {
-	log := logger.GetLogger()
-
 	// 如果默认文件路径为空，不创建规则
 	if defaultFilePath == "" {
 		return "", nil
@@ -3388,316 +2467,64 @@ func (s *CloudflareService) CreateDefaultFileRedirect(zoneID, domain, defaultFil
 		defaultFilePath = "/" + defaultFilePath
 	}
 
-	// 构建规则表达式：只匹配根路径访问，覆盖主域名和所有子域名
-	expression := fmt.Sprintf(`((http.host eq "%s" or http.host contains ".%s") and http.request.uri.path eq "/")`, domain, domain)
-
-	// 获取或创建 URL Redirect Ruleset
-	rulesetID, err := s.getOrCreateURLRedirectRuleset(zoneID)
-	if err != nil {
-		return "", fmt.Errorf("获取或创建 URL Redirect Ruleset 失败: %w", err)
-	}
-
-	// 检查是否已存在相同的规则
-	existingRuleID, err := s.findRuleByExpression(zoneID, rulesetID, expression)
-	if err == nil && existingRuleID != "" {
-		log.WithFields(map[string]interface{}{
-			"zone_id":   zoneID,
-			"domain":    domain,
-			"rule_id":   existingRuleID,
-			"file_path": defaultFilePath,
-		}).Info("默认文件重定向规则已存在，将更新")
-
-		// 更新现有规则
-		rule := map[string]interface{}{
-			"expression": expression,
-			"action":     "redirect",
-			"action_parameters": map[string]interface{}{
-				"from_value": map[string]interface{}{
-					"status_code": 302,
-					"target_url": map[string]interface{}{
-						"expression": fmt.Sprintf(`concat("https://", http.host, "%s")`, defaultFilePath),
-					},
-					"preserve_query_string": true,
-				},
-			},
-			"description": fmt.Sprintf("默认文件重定向: %s及所有子域名 -> %s", domain, defaultFilePath),
-			"enabled":     true,
-		}
-
-		_, err := s.updateRule(zoneID, rulesetID, existingRuleID, rule)
-		if err != nil {
-			return "", fmt.Errorf("更新默认文件重定向规则失败: %w", err)
-		}
-
-		log.WithFields(map[string]interface{}{
-			"zone_id":   zoneID,
-			"domain":    domain,
-			"rule_id":   existingRuleID,
-			"file_path": defaultFilePath,
-		}).Info("默认文件重定向规则已更新")
-
-		return existingRuleID, nil
-	}
-
-	// 创建新规则
-	rule := map[string]interface{}{
-		"expression": expression,
-		"action":     "redirect",
-		"action_parameters": map[string]interface{}{
-			"from_value": map[string]interface{}{
-				"status_code": 302,
-				"target_url": map[string]interface{}{
-					"expression": fmt.Sprintf(`concat("https://", http.host, "%s")`, defaultFilePath),
-				},
-				"preserve_query_string": true,
-			},
-		},
-		"description": fmt.Sprintf("默认文件重定向: %s及所有子域名 -> %s", domain, defaultFilePath),
-		"enabled":     true,
-	}
-
-	ruleID, err := s.addRule(zoneID, rulesetID, rule)
+	ruleID, err := s.Rulesets(zoneID, PhaseDynamicRedirect).Upsert(NewDefaultFileRedirectRule(domain, defaultFilePath))
 	if err != nil {
-		return "", fmt.Errorf("创建默认文件重定向规则失败: %w", err)
+		return "", fmt.Errorf("写入默认文件重定向规则失败: %w", err)
 	}
-
-	log.WithFields(map[string]interface{}{
+	logger.GetLogger().WithFields(map[string]interface{}{
 		"zone_id":   zoneID,
 		"domain":    domain,
 		"rule_id":   ruleID,
 		"file_path": defaultFilePath,
-	}).Info("默认文件重定向规则已创建")
-
+	}).Info("默认文件重定向规则已创建或更新")
 	return ruleID, nil
 }
 
-// getOrCreateURLRedirectRuleset 获取或创建 URL Redirect Ruleset
-func (s *CloudflareService) getOrCreateURLRedirectRuleset(zoneID string) (string, error) {
-	log := logger.GetLogger()
-
-	// 1. 尝试获取现有的 http_request_dynamic_redirect ruleset
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取 Ruleset 失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-
-	var result struct {
-		Success bool `json:"success"`
-		Result  []struct {
-			ID    string `json:"id"`
-			Phase string `json:"phase"`
-			Kind  string `json:"kind"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	// 查找现有的 http_request_dynamic_redirect ruleset
-	for _, ruleset := range result.Result {
-		if ruleset.Phase == "http_request_dynamic_redirect" {
-			log.WithFields(map[string]interface{}{
-				"zone_id":    zoneID,
-				"ruleset_id": ruleset.ID,
-			}).Info("找到现有的 URL Redirect Ruleset")
-			return ruleset.ID, nil
-		}
-	}
-
-	// 2. 如果不存在，创建新的 ruleset
-	log.WithField("zone_id", zoneID).Info("未找到 URL Redirect Ruleset，将创建新的")
-
-	payload := map[string]interface{}{
-		"name":        "URL Redirect Ruleset",
-		"description": "自动创建的 URL 重定向规则集",
-		"kind":        "zone",
-		"phase":       "http_request_dynamic_redirect",
-		"rules":       []interface{}{},
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求失败: %w", err)
-	}
-
-	createReq, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	for k, v := range s.getAuthHeaders() {
-		createReq.Header.Set(k, v)
-	}
-
-	createResp, err := s.client.Do(createReq)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer createResp.Body.Close()
-
-	createBody, err := io.ReadAll(createResp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if createResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("创建 Ruleset 失败 (状态码: %d): %s", createResp.StatusCode, string(createBody))
-	}
-
-	var createResult struct {
-		Success bool `json:"success"`
-		Result  struct {
-			ID string `json:"id"`
-		} `json:"result"`
-	}
-
-	if err := json.Unmarshal(createBody, &createResult); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	log.WithFields(map[string]interface{}{
-		"zone_id":    zoneID,
-		"ruleset_id": createResult.Result.ID,
-	}).Info("URL Redirect Ruleset 创建成功")
-
-	return createResult.Result.ID, nil
+// normalizeRedirectTarget 去掉目标域名的协议与尾部斜杠（保留 path 和 query）
+func normalizeRedirectTarget(targetDomain string) string {
+	targetDomain = strings.TrimPrefix(targetDomain, "https://")
+	targetDomain = strings.TrimPrefix(targetDomain, "http://")
+	return strings.TrimSuffix(targetDomain, "/")
 }
 
-// CreateDomainRedirectRule 创建域名 302 重定向规则（主域名 -> 目标域名）
+// CreateDomainRedirectRule 创建域名 302 重定向规则（主域名 -> 目标域名），已存在时更新
 // zoneID: Zone ID, sourceDomain: 主域名（源）, targetDomain: 目标域名（不含协议）, preservePath: 是否保留路径与查询串
 func (s *CloudflareService) CreateDomainRedirectRule(zoneID, sourceDomain, targetDomain string, preservePath bool) (string, error) {
-	log := logger.GetLogger()
-
-	// 规范目标：去掉协议与尾部斜杠（保留 path 和 query）
-	targetDomain = strings.TrimPrefix(targetDomain, "https://")
-	targetDomain = strings.TrimPrefix(targetDomain, "http://")
-	targetDomain = strings.TrimSuffix(targetDomain, "/")
-
-	rulesetID, err := s.getOrCreateURLRedirectRuleset(zoneID)
-	if err != nil {
-		return "", fmt.Errorf("获取或创建 URL Redirect Ruleset 失败: %w", err)
-	}
-
-	// 匹配主域名（仅根域名，不含子域名）
-	expression := fmt.Sprintf(`(http.host eq "%s")`, sourceDomain)
-
-	existingRuleID, err := s.findRuleByExpression(zoneID, rulesetID, expression)
-	if err != nil {
-		return "", fmt.Errorf("查找现有规则失败: %w", err)
-	}
-
-	var targetURLParam map[string]interface{}
-	if preservePath {
-		targetURLExpr := fmt.Sprintf(`concat("https://", "%s", http.request.uri.path)`, escapeCFString(targetDomain))
-		targetURLParam = map[string]interface{}{"expression": targetURLExpr}
-	} else {
-		// 静态 URL 必须用 value，不能用 expression（Cloudflare 会把 expression 当表达式解析，纯 URL 会报错）
-		fullURL := "https://" + targetDomain
-		targetURLParam = map[string]interface{}{"value": fullURL}
-	}
-
-	fromValue := map[string]interface{}{
-		"status_code": 302,
-		"target_url":  targetURLParam,
-	}
-	if preservePath {
-		fromValue["preserve_query_string"] = true
-	}
-
-	rule := map[string]interface{}{
-		"expression": expression,
-		"action":     "redirect",
-		"action_parameters": map[string]interface{}{
-			"from_value": fromValue,
-		},
-		"description": fmt.Sprintf("域名302重定向: %s -> https://%s", sourceDomain, targetDomain),
-		"enabled":     true,
-	}
-
-	if existingRuleID != "" {
-		_, err = s.updateRule(zoneID, rulesetID, existingRuleID, rule)
-		if err != nil {
-			return "", fmt.Errorf("更新域名重定向规则失败: %w", err)
-		}
-		log.WithFields(map[string]interface{}{
-			"zone_id": zoneID,
-			"source":  sourceDomain,
-			"target":  targetDomain,
-			"rule_id": existingRuleID,
-		}).Info("域名302重定向规则已更新")
-		return existingRuleID, nil
-	}
+	targetDomain = normalizeRedirectTarget(targetDomain)
 
-	ruleID, err := s.addRule(zoneID, rulesetID, rule)
+	ruleID, err := s.Rulesets(zoneID, PhaseDynamicRedirect).Upsert(NewDomainRedirectRule(sourceDomain, targetDomain, preservePath))
 	if err != nil {
-		return "", fmt.Errorf("创建域名重定向规则失败: %w", err)
+		return "", fmt.Errorf("写入域名重定向规则失败: %w", err)
 	}
-	log.WithFields(map[string]interface{}{
+	logger.GetLogger().WithFields(map[string]interface{}{
 		"zone_id": zoneID,
 		"source":  sourceDomain,
 		"target":  targetDomain,
 		"rule_id": ruleID,
-	}).Info("域名302重定向规则已创建")
+	}).Info("域名302重定向规则已创建或更新")
 	return ruleID, nil
 }
 
-// UpdateDomainRedirectRule 更新域名 302 重定向规则（目标域名或是否保留路径）
-func (s *CloudflareService) UpdateDomainRedirectRule(zoneID, ruleID, sourceDomain, targetDomain string, preservePath bool) error {
-	targetDomain = strings.TrimPrefix(targetDomain, "https://")
-	targetDomain = strings.TrimPrefix(targetDomain, "http://")
-	targetDomain = strings.TrimSuffix(targetDomain, "/")
-
-	rulesetID, err := s.GetURLRedirectRulesetID(zoneID)
-	if err != nil || rulesetID == "" {
-		return fmt.Errorf("获取 Redirect Ruleset 失败: %w", err)
-	}
+// UpdateDomainRedirectRule 更新域名 302 重定向规则（目标域名或是否保留路径），返回当前规则 ID
+// 规则按 ref 匹配，Cloudflare 上已被删除时会重新创建
+func (s *CloudflareService) UpdateDomainRedirectRule(zoneID, sourceDomain, targetDomain string, preservePath bool) (string, error) {
+	return s.CreateDomainRedirectRule(zoneID, sourceDomain, targetDomain, preservePath)
+}
 
-	expression := fmt.Sprintf(`(http.host eq "%s")`, sourceDomain)
-	var targetURLParam map[string]interface{}
-	if preservePath {
-		targetURLExpr := fmt.Sprintf(`concat("https://", "%s", http.request.uri.path)`, escapeCFString(targetDomain))
-		targetURLParam = map[string]interface{}{"expression": targetURLExpr}
-	} else {
-		fullURL := "https://" + targetDomain
-		targetURLParam = map[string]interface{}{"value": fullURL}
-	}
-	fromValue := map[string]interface{}{
-		"status_code": 302,
-		"target_url":  targetURLParam,
+// DeleteDomainRedirectRule 删除域名 302 重定向规则，按 ref 查找，找不到时使用记录的 ruleID
+func (s *CloudflareService) DeleteDomainRedirectRule(zoneID, sourceDomain, ruleID string) error {
+	client := s.Rulesets(zoneID, PhaseDynamicRedirect)
+	existing, err := client.Find(NewDomainRedirectRule(sourceDomain, "", false))
+	if err != nil {
+		return err
 	}
-	if preservePath {
-		fromValue["preserve_query_string"] = true
+	if existing != nil {
+		ruleID = existing.ID
 	}
-	rule := map[string]interface{}{
-		"expression":        expression,
-		"action":            "redirect",
-		"action_parameters": map[string]interface{}{"from_value": fromValue},
-		"description":       fmt.Sprintf("域名302重定向: %s -> https://%s", sourceDomain, targetDomain),
-		"enabled":           true,
+	if ruleID == "" {
+		return nil
 	}
-	_, err = s.updateRule(zoneID, rulesetID, ruleID, rule)
-	return err
+	return client.Delete(ruleID)
 }
 
 // escapeCFString 转义 Cloudflare 表达式中的字符串字面量（\ 和 "）
@@ -3706,65 +2533,3 @@ func escapeCFString(s string) string {
 	s = strings.ReplaceAll(s, `"`, `\"`)
 	return s
 }
-
-// GetURLRedirectRulesetID 获取 Zone 的 URL Redirect Ruleset ID（仅查找，不创建）。不存在时返回空字符串。
-func (s *CloudflareService) GetURLRedirectRulesetID(zoneID string) (string, error) {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets", zoneID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("获取 Ruleset 失败 (状态码: %d)", resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
-	}
-	var result struct {
-		Success bool `json:"success"`
-		Result  []struct {
-			ID    string `json:"id"`
-			Phase string `json:"phase"`
-		} `json:"result"`
-	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("解析响应失败: %w", err)
-	}
-	for _, ruleset := range result.Result {
-		if ruleset.Phase == "http_request_dynamic_redirect" {
-			return ruleset.ID, nil
-		}
-	}
-	return "", nil
-}
-
-// DeleteRedirectRule 从 Ruleset 中删除一条规则
-func (s *CloudflareService) DeleteRedirectRule(zoneID, rulesetID, ruleID string) error {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/rulesets/%s/rules/%s", zoneID, rulesetID, ruleID)
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
-	}
-	for k, v := range s.getAuthHeaders() {
-		req.Header.Set(k, v)
-	}
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("删除规则失败 (状态码: %d): %s", resp.StatusCode, string(body))
-	}
-	return nil
-}
@@ -0,0 +1,147 @@
+package cloudflare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type dnsEnvelope[T any] struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     T `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+// DNSRecord Cloudflare DNS 记录
+// TTL 为 1 表示自动；MX 记录的优先级在 Priority 中；CAA 等结构化记录的内容在 Data 中
+type DNSRecord struct {
+	ID       string         `json:"id,omitempty"`
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Content  string         `json:"content,omitempty"`
+	TTL      int            `json:"ttl,omitempty"`
+	Proxied  *bool          `json:"proxied,omitempty"`
+	Priority *int           `json:"priority,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+}
+
+// doDNSRequest 调用 DNS 记录接口并解析统一的响应结构
+func doDNSRequest[T any](s *CloudflareService, method, apiURL string, payload any) (*dnsEnvelope[T], error) {
+	var reqBody io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, apiURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	for k, v := range s.getAuthHeaders() {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var env dnsEnvelope[T]
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("解析响应失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	}
+	if !env.Success || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if len(env.Errors) > 0 {
+			return nil, fmt.Errorf("Cloudflare API错误: %s (code: %d)", env.Errors[0].Message, env.Errors[0].Code)
+		}
+		return nil, fmt.Errorf("Cloudflare API请求失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	}
+	return &env, nil
+}
+
+// ListDNSRecords 列出 Zone 的 DNS 记录（自动翻页），name 和 recordType 为空时不过滤
+func (s *CloudflareService) ListDNSRecords(zoneID, name, recordType string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", "100")
+		if name != "" {
+			query.Set("name", name)
+		}
+		if recordType != "" {
+			query.Set("type", recordType)
+		}
+		apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records?%s", zoneID, query.Encode())
+		env, err := doDNSRequest[[]DNSRecord](s, "GET", apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("列出DNS记录失败: %w", err)
+		}
+		records = append(records, env.Result...)
+		if env.ResultInfo.TotalPages <= page {
+			return records, nil
+		}
+	}
+}
+
+// CreateDNSRecord 创建 DNS 记录
+func (s *CloudflareService) CreateDNSRecord(zoneID string, record DNSRecord) (*DNSRecord, error) {
+	record.ID = ""
+	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", zoneID)
+	env, err := doDNSRequest[DNSRecord](s, "POST", apiURL, record)
+	if err != nil {
+		return nil, fmt.Errorf("创建%s记录 %s 失败: %w", record.Type, record.Name, err)
+	}
+	return &env.Result, nil
+}
+
+// UpdateDNSRecord 覆盖更新 DNS 记录
+func (s *CloudflareService) UpdateDNSRecord(zoneID, recordID string, record DNSRecord) (*DNSRecord, error) {
+	record.ID = ""
+	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s", zoneID, recordID)
+	env, err := doDNSRequest[DNSRecord](s, "PUT", apiURL, record)
+	if err != nil {
+		return nil, fmt.Errorf("更新%s记录 %s 失败: %w", record.Type, record.Name, err)
+	}
+	return &env.Result, nil
+}
+
+// DeleteDNSRecord 删除 DNS 记录
+func (s *CloudflareService) DeleteDNSRecord(zoneID, recordID string) error {
+	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s", zoneID, recordID)
+	if _, err := doDNSRequest[struct {
+		ID string `json:"id"`
+	}](s, "DELETE", apiURL, nil); err != nil {
+		return fmt.Errorf("删除DNS记录 %s 失败: %w", recordID, err)
+	}
+	return nil
+}
+
+// GetZoneNameServers 获取 Cloudflare 为 Zone 分配的 NS 服务器
+func (s *CloudflareService) GetZoneNameServers(zoneID string) ([]string, error) {
+	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s", zoneID)
+	env, err := doDNSRequest[struct {
+		NameServers []string `json:"name_servers"`
+	}](s, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Zone NS 服务器失败: %w", err)
+	}
+	return env.Result.NameServers, nil
+}
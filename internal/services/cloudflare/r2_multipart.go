@@ -0,0 +1,97 @@
+package cloudflare
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// CreateMultipartUpload 发起分片上传，返回 UploadId
+func (s *R2S3Service) CreateMultipartUpload(key, contentType string) (string, error) {
+	output, err := s.client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", fmt.Errorf("发起分片上传失败: %w", err)
+	}
+	return aws.StringValue(output.UploadId), nil
+}
+
+// PresignUploadPart 生成上传单个分片的预签名 PUT 地址
+func (s *R2S3Service) PresignUploadPart(key, uploadID string, partNumber int64, expires time.Duration) (string, error) {
+	req, _ := s.client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(s.bucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(partNumber),
+	})
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("生成分片预签名地址失败: %w", err)
+	}
+	return url, nil
+}
+
+// UploadPart 上传单个分片
+func (s *R2S3Service) UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) error {
+	_, err := s.client.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String(s.bucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(partNumber),
+		Body:       body,
+	})
+	if err != nil {
+		return fmt.Errorf("上传分片 %d 失败: %w", partNumber, err)
+	}
+	return nil
+}
+
+// ListUploadedParts 列出已上传的分片（自动翻页）
+func (s *R2S3Service) ListUploadedParts(key, uploadID string) ([]*s3.Part, error) {
+	var parts []*s3.Part
+	err := s.client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		parts = append(parts, page.Parts...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出已上传分片失败: %w", err)
+	}
+	return parts, nil
+}
+
+// CompleteMultipartUpload 合并分片，返回对象 ETag
+func (s *R2S3Service) CompleteMultipartUpload(key, uploadID string, parts []*s3.CompletedPart) (string, error) {
+	output, err := s.client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return "", fmt.Errorf("合并分片失败: %w", err)
+	}
+	return aws.StringValue(output.ETag), nil
+}
+
+// AbortMultipartUpload 取消分片上传并释放已上传的分片
+func (s *R2S3Service) AbortMultipartUpload(key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("取消分片上传失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cloudflare
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// PresignPutObject 生成直传对象的预签名 PUT 地址，Content-Type 参与签名
+func (s *R2S3Service) PresignPutObject(key, contentType string, expires time.Duration) (string, error) {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	url, err := req.Presign(expires)
+	if err != nil {
+		return "", fmt.Errorf("生成预签名上传地址失败: %w", err)
+	}
+	return url, nil
+}
+
+// HeadObject 获取对象元数据（大小、ETag、Content-Type）
+func (s *R2S3Service) HeadObject(key string) (*s3.HeadObjectOutput, error) {
+	output, err := s.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取对象 %s 信息失败: %w", key, err)
+	}
+	return output, nil
+}
+
+// GetObject 读取对象内容，调用方负责关闭
+func (s *R2S3Service) GetObject(key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取对象 %s 失败: %w", key, err)
+	}
+	return output.Body, nil
+}
@@ -0,0 +1,401 @@
+package cloudflare
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RulesetPhase 规则集阶段，每个 Zone 每个阶段只有一个 zone 级入口规则集
+type RulesetPhase string
+
+const (
+	PhaseResponseHeadersTransform RulesetPhase = "http_response_headers_transform" // 响应头转换（CORS）
+	PhaseFirewallCustom           RulesetPhase = "http_request_firewall_custom"    // WAF 自定义规则
+	PhaseDynamicRedirect          RulesetPhase = "http_request_dynamic_redirect"   // 单条重定向规则
+)
+
+// rulesetNames 创建入口规则集时使用的名称（沿用之前创建的名称，便于在控制台辨认）
+var rulesetNames = map[RulesetPhase]string{
+	PhaseResponseHeadersTransform: "http_response_header_transformation",
+	PhaseFirewallCustom:           "http_request_firewall_custom",
+	PhaseDynamicRedirect:          "URL Redirect Ruleset",
+}
+
+// RuleHeaderOperation 响应头转换操作
+type RuleHeaderOperation struct {
+	Operation string `json:"operation"`
+	Value     string `json:"value,omitempty"`
+}
+
+// RedirectTargetURL 重定向目标，静态 URL 用 Value，动态拼接用 Expression
+type RedirectTargetURL struct {
+	Value      string `json:"value,omitempty"`
+	Expression string `json:"expression,omitempty"`
+}
+
+// RedirectFromValue 重定向参数
+type RedirectFromValue struct {
+	StatusCode          int               `json:"status_code"`
+	TargetURL           RedirectTargetURL `json:"target_url"`
+	PreserveQueryString bool              `json:"preserve_query_string,omitempty"`
+}
+
+// RuleActionParameters 规则动作参数，只包含本系统用到的字段
+type RuleActionParameters struct {
+	Headers   map[string]RuleHeaderOperation `json:"headers,omitempty"`    // rewrite
+	Phases    []string                       `json:"phases,omitempty"`     // skip
+	FromValue *RedirectFromValue             `json:"from_value,omitempty"` // redirect
+}
+
+// RulesetRule 规则集中的一条规则
+// Ref 是本系统写入的稳定标识，创建和更新都按 Ref 幂等匹配；Cloudflare 生成的 ID 在规则重建后会变化。
+type RulesetRule struct {
+	ID               string                `json:"id,omitempty"`
+	Ref              string                `json:"ref,omitempty"`
+	Expression       string                `json:"expression"`
+	Action           string                `json:"action"`
+	ActionParameters *RuleActionParameters `json:"action_parameters,omitempty"`
+	Description      string                `json:"description,omitempty"`
+	Enabled          bool                  `json:"enabled"`
+	LastUpdated      string                `json:"last_updated,omitempty"`
+}
+
+// Ruleset 规则集
+type Ruleset struct {
+	ID    string        `json:"id"`
+	Name  string        `json:"name"`
+	Kind  string        `json:"kind"`
+	Phase RulesetPhase  `json:"phase"`
+	Rules []RulesetRule `json:"rules"`
+}
+
+// RulesetClient 操作 Zone 某个阶段的入口规则集
+type RulesetClient struct {
+	s         *CloudflareService
+	zoneID    string
+	phase     RulesetPhase
+	rulesetID string
+}
+
+// Rulesets 获取 Zone 指定阶段的规则集客户端
+func (s *CloudflareService) Rulesets(zoneID string, phase RulesetPhase) *RulesetClient {
+	return &RulesetClient{s: s, zoneID: zoneID, phase: phase}
+}
+
+// ID 返回入口规则集 ID；不存在时 create 为 true 则创建，否则返回空字符串
+func (c *RulesetClient) ID(create bool) (string, error) {
+	if c.rulesetID != "" {
+		return c.rulesetID, nil
+	}
+
+	env, err := doDNSRequest[[]Ruleset](c.s, "GET", fmt.Sprintf("%s/zones/%s/rulesets", apiBaseURL, c.zoneID), nil)
+	if err != nil {
+		return "", fmt.Errorf("获取 %s 规则集失败: %w", c.phase, err)
+	}
+	for _, rs := range env.Result {
+		if rs.Kind == "zone" && rs.Phase == c.phase {
+			c.rulesetID = rs.ID
+			return c.rulesetID, nil
+		}
+	}
+	if !create {
+		return "", nil
+	}
+
+	payload := map[string]interface{}{
+		"name":  rulesetNames[c.phase],
+		"kind":  "zone",
+		"phase": c.phase,
+		"rules": []RulesetRule{},
+	}
+	created, err := doDNSRequest[Ruleset](c.s, "POST", fmt.Sprintf("%s/zones/%s/rulesets", apiBaseURL, c.zoneID), payload)
+	if err != nil {
+		return "", fmt.Errorf("创建 %s 规则集失败: %w", c.phase, err)
+	}
+	c.rulesetID = created.Result.ID
+	return c.rulesetID, nil
+}
+
+// List 列出规则集中的全部规则，规则集不存在时返回空
+func (c *RulesetClient) List() ([]RulesetRule, error) {
+	rulesetID, err := c.ID(false)
+	if err != nil || rulesetID == "" {
+		return nil, err
+	}
+	env, err := doDNSRequest[Ruleset](c.s, "GET", c.rulesetURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 规则失败: %w", c.phase, err)
+	}
+	return env.Result.Rules, nil
+}
+
+// Find 查找与 rule 对应的已有规则：优先按 Ref 匹配，其次匹配没有 Ref 且表达式相同的旧规则
+func (c *RulesetClient) Find(rule RulesetRule) (*RulesetRule, error) {
+	rules, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	return findRulesetRule(rules, rule), nil
+}
+
+// Upsert 按 Ref 幂等写入规则：已存在则更新，否则追加到规则集末尾，返回规则 ID
+func (c *RulesetClient) Upsert(rule RulesetRule) (string, error) {
+	if rule.Ref == "" {
+		return "", fmt.Errorf("规则缺少 ref，无法幂等写入")
+	}
+	if _, err := c.ID(true); err != nil {
+		return "", err
+	}
+	existing, err := c.Find(rule)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		if err := c.Update(existing.ID, rule); err != nil {
+			return "", err
+		}
+		return existing.ID, nil
+	}
+
+	rule.ID = ""
+	env, err := doDNSRequest[Ruleset](c.s, "POST", c.rulesetURL()+"/rules", rule)
+	if err != nil {
+		return "", fmt.Errorf("添加规则失败: %w", err)
+	}
+	// 接口返回整个规则集，按 Ref 取出新规则的 ID
+	if created := findRulesetRule(env.Result.Rules, rule); created != nil {
+		return created.ID, nil
+	}
+	return "", fmt.Errorf("添加规则后未在规则集中找到 ref=%s", rule.Ref)
+}
+
+// Update 更新指定规则
+func (c *RulesetClient) Update(ruleID string, rule RulesetRule) error {
+	if _, err := c.ID(false); err != nil {
+		return err
+	}
+	if c.rulesetID == "" {
+		return fmt.Errorf("Zone 没有 %s 规则集", c.phase)
+	}
+	rule.ID = ""
+	if _, err := doDNSRequest[Ruleset](c.s, "PATCH", c.rulesetURL()+"/rules/"+ruleID, rule); err != nil {
+		return fmt.Errorf("更新规则失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除指定规则
+func (c *RulesetClient) Delete(ruleID string) error {
+	if _, err := c.ID(false); err != nil {
+		return err
+	}
+	if c.rulesetID == "" {
+		return fmt.Errorf("Zone 没有 %s 规则集", c.phase)
+	}
+	if _, err := doDNSRequest[Ruleset](c.s, "DELETE", c.rulesetURL()+"/rules/"+ruleID, nil); err != nil {
+		return fmt.Errorf("删除规则失败: %w", err)
+	}
+	return nil
+}
+
+func (c *RulesetClient) rulesetURL() string {
+	return fmt.Sprintf("%s/zones/%s/rulesets/%s", apiBaseURL, c.zoneID, c.rulesetID)
+}
+
+func findRulesetRule(rules []RulesetRule, rule RulesetRule) *RulesetRule {
+	for i := range rules {
+		if rule.Ref != "" && rules[i].Ref == rule.Ref {
+			return &rules[i]
+		}
+	}
+	for i := range rules {
+		if rules[i].Ref == "" && rules[i].Expression == rule.Expression {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// hostExpression 匹配域名及其所有子域名
+func hostExpression(domain string) string {
+	return fmt.Sprintf(`(http.host eq "%s" or http.host contains ".%s")`, domain, domain)
+}
+
+// NewCORSHeadersRule R2 自定义域名的 CORS 响应头规则
+func NewCORSHeadersRule(domain, allowOrigin string) RulesetRule {
+	return RulesetRule{
+		Ref:        "cors_" + domain,
+		Expression: hostExpression(domain),
+		Action:     "rewrite",
+		ActionParameters: &RuleActionParameters{
+			Headers: map[string]RuleHeaderOperation{
+				"Access-Control-Allow-Origin":   {Operation: "set", Value: allowOrigin},
+				"Access-Control-Allow-Methods":  {Operation: "set", Value: "GET, HEAD, OPTIONS"},
+				"Access-Control-Allow-Headers":  {Operation: "set", Value: "*"},
+				"Access-Control-Expose-Headers": {Operation: "set", Value: "ETag, Content-Length, Content-Type, Content-Range, Content-Disposition"},
+				"Access-Control-Max-Age":        {Operation: "set", Value: "3600"},
+			},
+		},
+		Description: fmt.Sprintf("Add CORS headers for R2 domain %s及所有子域名", domain),
+		Enabled:     true,
+	}
+}
+
+// wafSkipPhases 下载规则跳过的防火墙阶段：限速、超级机器人对抗模式、托管防火墙规则
+var wafSkipPhases = []string{"http_ratelimit", "http_request_sbfm", "http_request_firewall_managed"}
+
+// NewWAFSecurityRule VPN 白名单 + IDM 高频下载豁免规则，domain 应为根域名
+func NewWAFSecurityRule(domain string, fileExtensions []string) RulesetRule {
+	var extensionExpr string
+	if len(fileExtensions) == 1 {
+		extensionExpr = fmt.Sprintf(`http.request.uri.path.extension eq "%s"`, fileExtensions[0])
+	} else {
+		exts := make([]string, len(fileExtensions))
+		for i, ext := range fileExtensions {
+			exts[i] = fmt.Sprintf(`"%s"`, ext)
+		}
+		extensionExpr = fmt.Sprintf(`http.request.uri.path.extension in {%s}`, strings.Join(exts, " "))
+	}
+
+	return RulesetRule{
+		Ref:              "waf_security_" + domain,
+		Expression:       fmt.Sprintf(`(cf.threat_score le 50) and %s and (%s)`, hostExpression(domain), extensionExpr),
+		Action:           "skip",
+		ActionParameters: &RuleActionParameters{Phases: wafSkipPhases},
+		Description:      fmt.Sprintf("VPN白名单+IDM高频下载豁免: %s及所有子域名 (%s)", domain, strings.Join(fileExtensions, ", ")),
+		Enabled:          true,
+	}
+}
+
+// NewWAFVIPDownloadRule "免检金牌" VIP 下载规则：.apk/.obb 或 /download/ 路径跳过所有防火墙，domain 应为根域名
+// ref 使用 "00_" 前缀，与其他规则区分
+func NewWAFVIPDownloadRule(domain string) RulesetRule {
+	return RulesetRule{
+		Ref: "00_vip_download_" + domain,
+		Expression: hostExpression(domain) + ` and (` +
+			`http.request.uri.path.extension eq "apk" or ` +
+			`http.request.uri.path.extension eq "obb" or ` +
+			`http.request.uri.path contains "/download/"` +
+			`)`,
+		Action:           "skip",
+		ActionParameters: &RuleActionParameters{Phases: wafSkipPhases},
+		Description:      fmt.Sprintf("00_Allow_APK_Download_VIP: %s及所有子域名 - 免检金牌，最高优先级，跳过所有防火墙", domain),
+		Enabled:          true,
+	}
+}
+
+// NewDefaultFileRedirectRule 访问根路径时 302 到默认文件，defaultFilePath 需以 / 开头
+func NewDefaultFileRedirectRule(domain, defaultFilePath string) RulesetRule {
+	return RulesetRule{
+		Ref:        "default_file_" + domain,
+		Expression: fmt.Sprintf(`(%s and http.request.uri.path eq "/")`, hostExpression(domain)),
+		Action:     "redirect",
+		ActionParameters: &RuleActionParameters{
+			FromValue: &RedirectFromValue{
+				StatusCode:          302,
+				TargetURL:           RedirectTargetURL{Expression: fmt.Sprintf(`concat("https://", http.host, "%s")`, defaultFilePath)},
+				PreserveQueryString: true,
+			},
+		},
+		Description: fmt.Sprintf("默认文件重定向: %s及所有子域名 -> %s", domain, defaultFilePath),
+		Enabled:     true,
+	}
+}
+
+// NewDomainRedirectRule 主域名 302 到目标域名（仅匹配主域名本身），targetDomain 不含协议
+func NewDomainRedirectRule(sourceDomain, targetDomain string, preservePath bool) RulesetRule {
+	fromValue := &RedirectFromValue{StatusCode: 302}
+	if preservePath {
+		fromValue.TargetURL.Expression = fmt.Sprintf(`concat("https://", "%s", http.request.uri.path)`, escapeCFString(targetDomain))
+		fromValue.PreserveQueryString = true
+	} else {
+		// 静态 URL 必须用 value，不能用 expression（Cloudflare 会把 expression 当表达式解析，纯 URL 会报错）
+		fromValue.TargetURL.Value = "https://" + targetDomain
+	}
+
+	return RulesetRule{
+		Ref:              "domain_redirect_" + sourceDomain,
+		Expression:       fmt.Sprintf(`(http.host eq "%s")`, sourceDomain),
+		Action:           "redirect",
+		ActionParameters: &RuleActionParameters{FromValue: fromValue},
+		Description:      fmt.Sprintf("域名302重定向: %s -> https://%s", sourceDomain, targetDomain),
+		Enabled:          true,
+	}
+}
+
+// ManagedRuleKind 本系统创建的规则类型
+type ManagedRuleKind string
+
+const (
+	ManagedRuleCORS           ManagedRuleKind = "cors"
+	ManagedRuleVIPDownload    ManagedRuleKind = "vip_download"
+	ManagedRuleWAFSecurity    ManagedRuleKind = "waf_security"
+	ManagedRuleDefaultFile    ManagedRuleKind = "default_file"
+	ManagedRuleDomainRedirect ManagedRuleKind = "domain_redirect"
+)
+
+// managedRuleTypes 各类规则的阶段与 ref 前缀；早期的重定向规则没有 ref，按描述前缀识别
+var managedRuleTypes = []struct {
+	kind              ManagedRuleKind
+	phase             RulesetPhase
+	refPrefix         string
+	descriptionPrefix string
+}{
+	{ManagedRuleCORS, PhaseResponseHeadersTransform, "cors_", ""},
+	{ManagedRuleVIPDownload, PhaseFirewallCustom, "00_vip_download_", ""},
+	{ManagedRuleWAFSecurity, PhaseFirewallCustom, "waf_security_", ""},
+	{ManagedRuleDefaultFile, PhaseDynamicRedirect, "default_file_", "默认文件重定向: "},
+	{ManagedRuleDomainRedirect, PhaseDynamicRedirect, "domain_redirect_", "域名302重定向: "},
+}
+
+// ManagedPhases 本系统会写入规则的阶段
+var ManagedPhases = []RulesetPhase{PhaseResponseHeadersTransform, PhaseFirewallCustom, PhaseDynamicRedirect}
+
+// ManagedRule Zone 上由本系统创建的规则
+type ManagedRule struct {
+	Phase     RulesetPhase    `json:"phase"`
+	RulesetID string          `json:"ruleset_id"`
+	Kind      ManagedRuleKind `json:"kind"`
+	Domain    string          `json:"domain"` // 规则作用的域名（WAF 规则为根域名）
+	Rule      RulesetRule     `json:"rule"`
+}
+
+// ClassifyManagedRule 判断规则是否由本系统创建，返回类型和作用的域名
+func ClassifyManagedRule(phase RulesetPhase, rule RulesetRule) (ManagedRuleKind, string, bool) {
+	for _, t := range managedRuleTypes {
+		if t.phase != phase {
+			continue
+		}
+		if strings.HasPrefix(rule.Ref, t.refPrefix) {
+			return t.kind, strings.TrimPrefix(rule.Ref, t.refPrefix), true
+		}
+		if rule.Ref == "" && t.descriptionPrefix != "" && strings.HasPrefix(rule.Description, t.descriptionPrefix) {
+			domain := strings.TrimPrefix(rule.Description, t.descriptionPrefix)
+			if i := strings.Index(domain, " -> "); i >= 0 {
+				domain = domain[:i]
+			}
+			return t.kind, strings.TrimSuffix(domain, "及所有子域名"), true
+		}
+	}
+	return "", "", false
+}
+
+// ListManagedRules 列出 Zone 上由本系统创建的全部规则
+func (s *CloudflareService) ListManagedRules(zoneID string) ([]ManagedRule, error) {
+	var managed []ManagedRule
+	for _, phase := range ManagedPhases {
+		client := s.Rulesets(zoneID, phase)
+		rules, err := client.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			kind, domain, ok := ClassifyManagedRule(phase, rule)
+			if !ok {
+				continue
+			}
+			managed = append(managed, ManagedRule{Phase: phase, RulesetID: client.rulesetID, Kind: kind, Domain: domain, Rule: rule})
+		}
+	}
+	return managed, nil
+}
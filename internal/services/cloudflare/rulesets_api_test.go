@@ -0,0 +1,104 @@
+package cloudflare
+
+import (
+	"aws_cdn/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 规则集不存在时自动创建；按 ref 幂等写入，没有 ref 的旧规则按表达式匹配后补上 ref
+func TestRulesetClientUpsert(t *testing.T) {
+	var ruleset *Ruleset
+	nextID := 0
+	writeRuleset := func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": ruleset})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/z1/rulesets":
+			list := []Ruleset{{ID: "managed", Kind: "managed", Phase: PhaseDynamicRedirect}}
+			if ruleset != nil {
+				list = append(list, Ruleset{ID: ruleset.ID, Kind: "zone", Phase: ruleset.Phase})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"success": true, "result": list})
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/z1/rulesets":
+			var body Ruleset
+			json.NewDecoder(r.Body).Decode(&body)
+			ruleset = &Ruleset{ID: "rs1", Kind: "zone", Phase: body.Phase}
+			writeRuleset(w)
+		case r.Method == http.MethodGet && r.URL.Path == "/zones/z1/rulesets/rs1":
+			writeRuleset(w)
+		case r.Method == http.MethodPost && r.URL.Path == "/zones/z1/rulesets/rs1/rules":
+			var rule RulesetRule
+			json.NewDecoder(r.Body).Decode(&rule)
+			nextID++
+			rule.ID = fmt.Sprintf("r%d", nextID)
+			ruleset.Rules = append(ruleset.Rules, rule)
+			writeRuleset(w)
+		case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/zones/z1/rulesets/rs1/rules/"):
+			var rule RulesetRule
+			json.NewDecoder(r.Body).Decode(&rule)
+			rule.ID = strings.TrimPrefix(r.URL.Path, "/zones/z1/rulesets/rs1/rules/")
+			for i := range ruleset.Rules {
+				if ruleset.Rules[i].ID == rule.ID {
+					ruleset.Rules[i] = rule
+				}
+			}
+			writeRuleset(w)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":7003,"message":"not found"}],"result":null}`))
+		}
+	}))
+	defer server.Close()
+	defer func(original string) { apiBaseURL = original }(apiBaseURL)
+	apiBaseURL = server.URL
+
+	svc, err := NewCloudflareService(&config.CloudflareConfig{APIToken: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := svc.CreateDomainRedirectRule("z1", "a.example.com", "https://b.example.com/", false)
+	if err != nil || first != "r1" {
+		t.Fatalf("首次写入应创建规则集和规则: %q, %v", first, err)
+	}
+	second, err := svc.CreateDomainRedirectRule("z1", "a.example.com", "c.example.com", true)
+	if err != nil || second != first || len(ruleset.Rules) != 1 {
+		t.Fatalf("重复写入应更新原规则: %q, %v, %d 条", second, err, len(ruleset.Rules))
+	}
+	if target := ruleset.Rules[0].ActionParameters.FromValue.TargetURL; target.Value != "" || !strings.Contains(target.Expression, "c.example.com") {
+		t.Fatalf("规则未更新: %+v", target)
+	}
+
+	legacy := NewDefaultFileRedirectRule("d.example.com", "/app.apk")
+	legacy.ID, legacy.Ref = "old", ""
+	ruleset.Rules = append(ruleset.Rules, legacy)
+	if id, err := svc.CreateDefaultFileRedirect("z1", "d.example.com", "app.apk"); err != nil || id != "old" || ruleset.Rules[1].Ref != "default_file_d.example.com" {
+		t.Fatalf("旧规则应按表达式匹配并补上 ref: %q, %v, %+v", id, err, ruleset.Rules[1])
+	}
+
+	managed, err := svc.ListManagedRules("z1")
+	if err != nil || len(managed) != 2 || managed[0].Kind != ManagedRuleDomainRedirect || managed[0].Domain != "a.example.com" {
+		t.Fatalf("ListManagedRules = %+v, %v", managed, err)
+	}
+}
+
+// 没有 ref 的旧规则按描述识别类型和域名，其他来源的规则不识别
+func TestClassifyManagedRule(t *testing.T) {
+	legacy := RulesetRule{Description: "默认文件重定向: d.example.com及所有子域名 -> /app.apk"}
+	if kind, domain, ok := ClassifyManagedRule(PhaseDynamicRedirect, legacy); !ok || kind != ManagedRuleDefaultFile || domain != "d.example.com" {
+		t.Fatalf("ClassifyManagedRule = %v, %q, %v", kind, domain, ok)
+	}
+	if _, _, ok := ClassifyManagedRule(PhaseFirewallCustom, RulesetRule{Ref: "cors_a.example.com"}); ok {
+		t.Fatal("阶段不匹配时不应识别")
+	}
+	if _, _, ok := ClassifyManagedRule(PhaseFirewallCustom, RulesetRule{Ref: "manual_block"}); ok {
+		t.Fatal("其他来源的规则不应识别")
+	}
+}
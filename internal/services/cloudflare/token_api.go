@@ -0,0 +1,207 @@
+package cloudflare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// apiBaseURL Cloudflare API 地址（测试中替换为本地服务）
+var apiBaseURL = "https://api.cloudflare.com/client/v4"
+
+// Token 状态
+const (
+	TokenStatusActive  = "active"
+	TokenStatusInvalid = "invalid" // Token 不存在、已撤销或已过期
+)
+
+// 权限不足时 Cloudflare 返回的错误码
+var permissionErrorCodes = map[int]bool{
+	9109:  true, // Unauthorized to access requested resource
+	10000: true, // Authentication error
+}
+
+// r2NotEnabledCode 账号未开通 R2 时返回的错误码（已通过鉴权）
+const r2NotEnabledCode = 10042
+
+// TokenAPIService Cloudflare API Token 校验与权限探测
+type TokenAPIService struct {
+	APIToken string
+	client   *http.Client
+}
+
+// NewTokenAPIService 创建 Token 校验服务
+func NewTokenAPIService(APIToken string) *TokenAPIService {
+	return &TokenAPIService{
+		APIToken: APIToken,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// TokenVerifyResult Token 校验结果
+type TokenVerifyResult struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"` // active、disabled、expired，被拒绝时为 invalid
+	ExpiresOn string `json:"expires_on,omitempty"`
+	Message   string `json:"message,omitempty"` // 被拒绝时 Cloudflare 返回的原因
+}
+
+// PermissionProbeResult 权限探测结果
+type PermissionProbeResult struct {
+	Granted bool
+	Message string
+}
+
+type tokenEnvelope struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo *struct {
+		TotalCount int `json:"total_count"`
+	} `json:"result_info"`
+}
+
+func (e *tokenEnvelope) errorMessage() string {
+	if len(e.Errors) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (code: %d)", e.Errors[0].Message, e.Errors[0].Code)
+}
+
+// do 发送请求并解析统一的响应结构，只在网络错误或响应无法解析时返回 error
+func (s *TokenAPIService) do(method, path string, payload any) (int, *tokenEnvelope, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, apiBaseURL+path, reqBody)
+	if err != nil {
+		return 0, nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var env tokenEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("解析响应失败 (状态码: %d): %s", resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, &env, nil
+}
+
+// Verify 校验 Token 是否有效
+// 用户级 Token 使用 /user/tokens/verify；账号级 Token 在该接口会被拒绝，需要 accountID 调用 /accounts/{id}/tokens/verify。
+func (s *TokenAPIService) Verify(accountID string) (*TokenVerifyResult, error) {
+	result, err := s.verify("/user/tokens/verify")
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != TokenStatusInvalid || accountID == "" {
+		return result, nil
+	}
+	accountResult, err := s.verify("/accounts/" + url.PathEscape(accountID) + "/tokens/verify")
+	if err != nil || accountResult.Status == TokenStatusInvalid {
+		return result, nil
+	}
+	return accountResult, nil
+}
+
+func (s *TokenAPIService) verify(path string) (*TokenVerifyResult, error) {
+	statusCode, env, err := s.do(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("校验 API Token 失败: %w", err)
+	}
+	if statusCode >= 500 {
+		return nil, fmt.Errorf("校验 API Token 失败 (状态码: %d): %s", statusCode, env.errorMessage())
+	}
+	if !env.Success {
+		return &TokenVerifyResult{Status: TokenStatusInvalid, Message: env.errorMessage()}, nil
+	}
+	var result TokenVerifyResult
+	if err := json.Unmarshal(env.Result, &result); err != nil {
+		return nil, fmt.Errorf("解析 Token 校验结果失败: %w", err)
+	}
+	return &result, nil
+}
+
+// TokenZone Token 可访问的 Zone
+type TokenZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AccessibleZones 返回 Token 可访问的 Zone（最多 limit 个，用于探测 Zone 级权限），total 为可访问的 Zone 总数
+func (s *TokenAPIService) AccessibleZones(accountID string, limit int) (zones []TokenZone, total int, err error) {
+	query := url.Values{"per_page": {strconv.Itoa(limit)}}
+	if accountID != "" {
+		query.Set("account.id", accountID)
+	}
+	statusCode, env, err := s.do(http.MethodGet, "/zones?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取 Zone 列表失败: %w", err)
+	}
+	if !env.Success {
+		return nil, 0, fmt.Errorf("获取 Zone 列表失败 (状态码: %d): %s", statusCode, env.errorMessage())
+	}
+	if err := json.Unmarshal(env.Result, &zones); err != nil {
+		return nil, 0, fmt.Errorf("解析 Zone 列表失败: %w", err)
+	}
+	total = len(zones)
+	if env.ResultInfo != nil && env.ResultInfo.TotalCount > total {
+		total = env.ResultInfo.TotalCount
+	}
+	return zones, total, nil
+}
+
+// ProbePermission 请求指定接口判断 Token 是否具备对应权限
+// Cloudflare 先鉴权后校验参数，写接口可以发送空请求体探测：返回参数错误说明已通过鉴权，且不会产生任何变更。
+func (s *TokenAPIService) ProbePermission(method, path string, payload any) (*PermissionProbeResult, error) {
+	statusCode, env, err := s.do(method, path, payload)
+	if err != nil {
+		return nil, err
+	}
+	return classifyProbe(statusCode, env)
+}
+
+// classifyProbe 根据状态码和错误码判断是否具备权限
+func classifyProbe(statusCode int, env *tokenEnvelope) (*PermissionProbeResult, error) {
+	for _, e := range env.Errors {
+		if permissionErrorCodes[e.Code] {
+			return &PermissionProbeResult{Granted: false, Message: env.errorMessage()}, nil
+		}
+		if e.Code == r2NotEnabledCode {
+			return &PermissionProbeResult{Granted: true, Message: env.errorMessage()}, nil
+		}
+	}
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &PermissionProbeResult{Granted: false, Message: env.errorMessage()}, nil
+	case statusCode >= 500:
+		return nil, fmt.Errorf("Cloudflare API请求失败 (状态码: %d): %s", statusCode, env.errorMessage())
+	default:
+		return &PermissionProbeResult{Granted: true}, nil
+	}
+}
@@ -0,0 +1,76 @@
+package cloudflare
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 用户级校验失败时回退到账号级校验；鉴权失败判定为缺少权限，参数错误判定为具备权限
+func TestTokenVerifyAndProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/user/tokens/verify":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"success":false,"errors":[{"code":1000,"message":"Invalid API Token"}],"result":null}`))
+		case "/accounts/acc1/tokens/verify":
+			w.Write([]byte(`{"success":true,"errors":[],"result":{"id":"tok1","status":"active"}}`))
+		case "/accounts/acc1/storage/kv/namespaces":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10019,"message":"title is required"}],"result":null}`))
+		case "/accounts/acc1/pages/projects":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10000,"message":"Authentication error"}],"result":null}`))
+		case "/accounts/acc1/workers/scripts/probe/settings":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10007,"message":"This Worker does not exist on your account."}],"result":null}`))
+		case "/zones":
+			w.Write([]byte(`{"success":true,"errors":[],"result":[{"id":"z1","name":"a.com"},{"id":"z2","name":"b.com"}],"result_info":{"total_count":5}}`))
+		case "/accounts/acc1/r2/buckets":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"success":false,"errors":[{"code":10042,"message":"Please enable R2 through the Cloudflare Dashboard."}],"result":null}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"success":false,"errors":[],"result":null}`))
+		}
+	}))
+	defer server.Close()
+	defer func(original string) { apiBaseURL = original }(apiBaseURL)
+	apiBaseURL = server.URL
+
+	svc := NewTokenAPIService("token")
+	result, err := svc.Verify("acc1")
+	if err != nil || result.Status != TokenStatusActive || result.ID != "tok1" {
+		t.Fatalf("Verify = %+v, %v", result, err)
+	}
+	if result, err := svc.Verify(""); err != nil || result.Status != TokenStatusInvalid || result.Message == "" {
+		t.Fatalf("未提供 Account ID 时应返回用户级校验结果: %+v, %v", result, err)
+	}
+
+	cases := []struct {
+		path    string
+		granted bool
+	}{
+		{"/accounts/acc1/storage/kv/namespaces", true},
+		{"/accounts/acc1/pages/projects", false},
+		{"/accounts/acc1/r2/buckets", true},
+	}
+	if probe, err := svc.ProbePermission(http.MethodPatch, "/accounts/acc1/workers/scripts/probe/settings", map[string]any{}); err != nil || !probe.Granted {
+		t.Fatalf("编辑不存在的脚本返回不存在说明已通过鉴权: %+v, %v", probe, err)
+	}
+	for _, tc := range cases {
+		probe, err := svc.ProbePermission(http.MethodPost, tc.path, map[string]any{})
+		if err != nil || probe.Granted != tc.granted {
+			t.Fatalf("%s: ProbePermission = %+v, %v，期望 granted=%v", tc.path, probe, err, tc.granted)
+		}
+	}
+	if _, err := svc.ProbePermission(http.MethodGet, "/accounts/acc1/workers/scripts", nil); err == nil {
+		t.Fatal("服务端错误应返回 error 而不是判定权限")
+	}
+
+	zones, total, err := svc.AccessibleZones("acc1", 2)
+	if err != nil || len(zones) != 2 || zones[1].Name != "b.com" || total != 5 {
+		t.Fatalf("AccessibleZones = %+v, %d, %v", zones, total, err)
+	}
+}
@@ -0,0 +1,27 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ZoneSetting Zone 设置项，Value 可能是字符串或对象（如 minify）
+type ZoneSetting struct {
+	ID       string          `json:"id"`
+	Value    json.RawMessage `json:"value"`
+	Editable bool            `json:"editable"`
+}
+
+// GetZoneSettings 获取 Zone 的全部设置，按设置 ID 返回
+func (s *CloudflareService) GetZoneSettings(zoneID string) (map[string]ZoneSetting, error) {
+	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/settings", zoneID)
+	env, err := doDNSRequest[[]ZoneSetting](s, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Zone 设置失败: %w", err)
+	}
+	settings := make(map[string]ZoneSetting, len(env.Result))
+	for _, setting := range env.Result {
+		settings[setting.ID] = setting
+	}
+	return settings, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DNSRecord 与提供商无关的 DNS 记录集（同名同类型的所有值）
+//
+// 值的格式统一为：
+//   - A/AAAA：IP 地址
+//   - CNAME/NS：目标域名，不带末尾的点
+//   - TXT：原始文本，不带引号
+//   - MX："优先级 目标域名"，如 "10 mail.example.com"
+//   - CAA：`flags tag "value"`，如 `0 issue "amazon.com"`
+type DNSRecord struct {
+	Name        string   `json:"name"`                    // 完整域名，小写，不带末尾的点
+	Type        string   `json:"type"`                    // A、AAAA、CNAME、TXT、MX、CAA、NS、SOA
+	Values      []string `json:"values"`                  // Alias 记录为空
+	TTL         int64    `json:"ttl"`                     // 秒；Cloudflare 中 1 表示自动
+	Proxied     bool     `json:"proxied"`                 // Cloudflare 代理（橙色云朵），仅 A/AAAA/CNAME 有效
+	AliasTarget string   `json:"alias_target,omitempty"`  // Route 53 Alias 目标
+	AliasZoneID string   `json:"alias_zone_id,omitempty"` // Route 53 Alias 目标所在的托管区域
+}
+
+// DNSProvider DNS 提供商（Route 53、Cloudflare）的统一接口
+// zoneID 为 Route 53 托管区域 ID 或 Cloudflare Zone ID（即 Domain.HostedZoneID）
+type DNSProvider interface {
+	// ListRecords 列出区域内的所有记录集
+	ListRecords(zoneID string) ([]DNSRecord, error)
+	// GetRecord 获取指定名称和类型的记录集，不存在时返回 nil, nil
+	GetRecord(zoneID, name, recordType string) (*DNSRecord, error)
+	// CreateRecord 创建记录集，同名同类型已存在时返回错误
+	CreateRecord(zoneID string, record DNSRecord) error
+	// UpdateRecord 以 record 覆盖同名同类型的记录集，不存在时创建
+	UpdateRecord(zoneID string, record DNSRecord) error
+	// DeleteRecord 删除指定名称和类型的记录集
+	DeleteRecord(zoneID, name, recordType string) error
+	// CheckRecord 检查记录集是否存在且包含 expected 的所有值（Alias 记录比较目标）
+	CheckRecord(zoneID string, expected DNSRecord) (bool, error)
+	// GetNameServers 获取提供商为区域分配的 NS 服务器
+	GetNameServers(zoneID string) ([]string, error)
+	// CloudFrontRecord 返回将 name 指向 CloudFront 分发的记录：Route 53 为 A 记录 Alias，其他提供商为 CNAME
+	CloudFrontRecord(name, cloudFrontDomain string) DNSRecord
+}
+
+// defaultDNSRecordTTL 未指定 TTL 时使用的默认值（5 分钟）
+const defaultDNSRecordTTL = 300
+
+// normalizeDNSName 统一记录名称：小写、去掉末尾的点，还原 Route 53 转义的通配符
+func normalizeDNSName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	return strings.ReplaceAll(name, `\052`, "*")
+}
+
+// normalizeDNSValue 统一记录值以便比较：域名类的值小写并去掉末尾的点
+func normalizeDNSValue(recordType, value string) string {
+	value = strings.TrimSpace(value)
+	switch strings.ToUpper(recordType) {
+	case "CNAME", "NS":
+		return strings.ToLower(strings.TrimSuffix(value, "."))
+	case "MX":
+		priority, target, ok := strings.Cut(value, " ")
+		if !ok {
+			return strings.ToLower(strings.TrimSuffix(value, "."))
+		}
+		return priority + " " + strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), "."))
+	}
+	return value
+}
+
+// normalizeDNSRecord 统一记录的名称、类型和值
+func normalizeDNSRecord(record DNSRecord) DNSRecord {
+	record.Name = normalizeDNSName(record.Name)
+	record.Type = strings.ToUpper(strings.TrimSpace(record.Type))
+	values := make([]string, 0, len(record.Values))
+	for _, value := range record.Values {
+		values = append(values, normalizeDNSValue(record.Type, value))
+	}
+	record.Values = values
+	record.AliasTarget = normalizeDNSName(record.AliasTarget)
+	if record.TTL <= 0 && record.AliasTarget == "" {
+		record.TTL = defaultDNSRecordTTL
+	}
+	return record
+}
+
+// dnsRecordSatisfies 判断现有记录集是否满足预期：Alias 记录比较目标（目标为空时只比较托管区域），普通记录要求包含所有预期值
+func dnsRecordSatisfies(actual *DNSRecord, expected DNSRecord) bool {
+	if actual == nil {
+		return false
+	}
+	expected = normalizeDNSRecord(expected)
+	if expected.AliasZoneID != "" || expected.AliasTarget != "" {
+		if actual.AliasZoneID != expected.AliasZoneID {
+			return false
+		}
+		return expected.AliasTarget == "" || normalizeDNSName(actual.AliasTarget) == expected.AliasTarget
+	}
+	present := make(map[string]bool, len(actual.Values))
+	for _, value := range actual.Values {
+		present[normalizeDNSValue(actual.Type, value)] = true
+	}
+	for _, value := range expected.Values {
+		if !present[value] {
+			return false
+		}
+	}
+	return len(expected.Values) > 0
+}
+
+// checkDNSRecord CheckRecord 的通用实现
+func checkDNSRecord(p DNSProvider, zoneID string, expected DNSRecord) (bool, error) {
+	expected = normalizeDNSRecord(expected)
+	actual, err := p.GetRecord(zoneID, expected.Name, expected.Type)
+	if err != nil {
+		return false, err
+	}
+	return dnsRecordSatisfies(actual, expected), nil
+}
+
+// dnsRecordKey 记录集的唯一标识（名称 + 类型）
+func dnsRecordKey(name, recordType string) string {
+	return normalizeDNSName(name) + "|" + strings.ToUpper(recordType)
+}
+
+// quoteTXTValue 将 TXT 文本转换为区域文件格式：转义引号和反斜杠，超过 255 字节时拆分为多个字符串
+func quoteTXTValue(value string) string {
+	escape := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	if len(value) <= 255 {
+		return escape(value)
+	}
+	var parts []string
+	for len(value) > 255 {
+		parts = append(parts, escape(value[:255]))
+		value = value[255:]
+	}
+	parts = append(parts, escape(value))
+	return strings.Join(parts, " ")
+}
+
+// unquoteTXTValue 解析区域文件格式的 TXT 值：拼接多个带引号的字符串，不带引号时原样返回
+func unquoteTXTValue(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, `"`) {
+		return value
+	}
+	var b strings.Builder
+	inQuote, escaped := false, false
+	for _, r := range value {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parseCAAValue 解析 `flags tag "value"` 格式的 CAA 值
+func parseCAAValue(value string) (flags int, tag, caaValue string, err error) {
+	fields := strings.SplitN(strings.TrimSpace(value), " ", 3)
+	if len(fields) != 3 {
+		return 0, "", "", fmt.Errorf("CAA 记录值格式错误，应为 `0 issue \"amazon.com\"`: %s", value)
+	}
+	if _, err := fmt.Sscanf(fields[0], "%d", &flags); err != nil {
+		return 0, "", "", fmt.Errorf("CAA flags 无效: %s", fields[0])
+	}
+	return flags, fields[1], unquoteTXTValue(fields[2]), nil
+}
+
+// parseMXValue 解析 "优先级 目标域名" 格式的 MX 值
+func parseMXValue(value string) (priority int, target string, err error) {
+	priorityStr, target, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return 0, "", fmt.Errorf("MX 记录值格式错误，应为 \"10 mail.example.com\": %s", value)
+	}
+	if _, err := fmt.Sscanf(priorityStr, "%d", &priority); err != nil {
+		return 0, "", fmt.Errorf("MX 优先级无效: %s", priorityStr)
+	}
+	return priority, strings.TrimSuffix(strings.TrimSpace(target), "."), nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"aws_cdn/internal/services/cloudflare"
+	"fmt"
+	"strings"
+)
+
+// CloudflareDNSProvider 基于 Cloudflare Zone 的 DNSProvider
+// Cloudflare 每个值是一条独立记录，这里按名称和类型聚合为记录集
+type CloudflareDNSProvider struct {
+	cloudflareSvc *cloudflare.CloudflareService
+}
+
+func NewCloudflareDNSProvider(cloudflareSvc *cloudflare.CloudflareService) *CloudflareDNSProvider {
+	return &CloudflareDNSProvider{cloudflareSvc: cloudflareSvc}
+}
+
+func (p *CloudflareDNSProvider) ListRecords(zoneID string) ([]DNSRecord, error) {
+	items, err := p.cloudflareSvc.ListDNSRecords(zoneID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return groupCloudflareRecords(items), nil
+}
+
+func (p *CloudflareDNSProvider) GetRecord(zoneID, name, recordType string) (*DNSRecord, error) {
+	items, err := p.cloudflareSvc.ListDNSRecords(zoneID, normalizeDNSName(name), strings.ToUpper(recordType))
+	if err != nil {
+		return nil, err
+	}
+	records := groupCloudflareRecords(items)
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+func (p *CloudflareDNSProvider) CreateRecord(zoneID string, record DNSRecord) error {
+	record = normalizeDNSRecord(record)
+	existing, err := p.cloudflareSvc.ListDNSRecords(zoneID, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("记录已存在: %s %s", record.Name, record.Type)
+	}
+	return p.UpdateRecord(zoneID, record)
+}
+
+// UpdateRecord 覆盖记录集：值相同的记录原地更新，其余记录按顺序改写，多余的删除，不足的创建
+func (p *CloudflareDNSProvider) UpdateRecord(zoneID string, record DNSRecord) error {
+	record = normalizeDNSRecord(record)
+	if record.AliasTarget != "" {
+		return fmt.Errorf("Cloudflare 不支持 Alias 记录，请使用 CNAME")
+	}
+	if len(record.Values) == 0 {
+		return fmt.Errorf("记录 %s %s 没有值", record.Name, record.Type)
+	}
+	desired := make([]cloudflare.DNSRecord, 0, len(record.Values))
+	for _, value := range record.Values {
+		item, err := toCloudflareRecord(record, value)
+		if err != nil {
+			return err
+		}
+		desired = append(desired, item)
+	}
+
+	existing, err := p.cloudflareSvc.ListDNSRecords(zoneID, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+
+	// 先按值配对，避免改写时与同名记录的值冲突
+	matched := make([]bool, len(existing))
+	var pending []cloudflare.DNSRecord
+	for _, item := range desired {
+		found := false
+		for i, current := range existing {
+			if !matched[i] && cloudflareRecordValue(current) == cloudflareRecordValue(item) {
+				matched[i] = true
+				found = true
+				if _, err := p.cloudflareSvc.UpdateDNSRecord(zoneID, current.ID, item); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if !found {
+			pending = append(pending, item)
+		}
+	}
+	for i, current := range existing {
+		if matched[i] {
+			continue
+		}
+		if len(pending) > 0 {
+			if _, err := p.cloudflareSvc.UpdateDNSRecord(zoneID, current.ID, pending[0]); err != nil {
+				return err
+			}
+			pending = pending[1:]
+			continue
+		}
+		if err := p.cloudflareSvc.DeleteDNSRecord(zoneID, current.ID); err != nil {
+			return err
+		}
+	}
+	for _, item := range pending {
+		if _, err := p.cloudflareSvc.CreateDNSRecord(zoneID, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *CloudflareDNSProvider) DeleteRecord(zoneID, name, recordType string) error {
+	existing, err := p.cloudflareSvc.ListDNSRecords(zoneID, normalizeDNSName(name), strings.ToUpper(recordType))
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return fmt.Errorf("记录不存在: %s %s", normalizeDNSName(name), strings.ToUpper(recordType))
+	}
+	for _, item := range existing {
+		if err := p.cloudflareSvc.DeleteDNSRecord(zoneID, item.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *CloudflareDNSProvider) CheckRecord(zoneID string, expected DNSRecord) (bool, error) {
+	return checkDNSRecord(p, zoneID, expected)
+}
+
+func (p *CloudflareDNSProvider) GetNameServers(zoneID string) ([]string, error) {
+	return p.cloudflareSvc.GetZoneNameServers(zoneID)
+}
+
+func (p *CloudflareDNSProvider) CloudFrontRecord(name, cloudFrontDomain string) DNSRecord {
+	return DNSRecord{
+		Name:   normalizeDNSName(name),
+		Type:   "CNAME",
+		Values: []string{normalizeDNSName(cloudFrontDomain)},
+		TTL:    defaultDNSRecordTTL,
+	}
+}
+
+// groupCloudflareRecords 按名称和类型聚合 Cloudflare 记录，保持首次出现的顺序
+func groupCloudflareRecords(items []cloudflare.DNSRecord) []DNSRecord {
+	var records []DNSRecord
+	index := make(map[string]int)
+	for _, item := range items {
+		key := dnsRecordKey(item.Name, item.Type)
+		i, ok := index[key]
+		if !ok {
+			i = len(records)
+			index[key] = i
+			records = append(records, DNSRecord{
+				Name:    normalizeDNSName(item.Name),
+				Type:    item.Type,
+				Values:  []string{},
+				TTL:     int64(item.TTL),
+				Proxied: item.Proxied != nil && *item.Proxied,
+			})
+		}
+		records[i].Values = append(records[i].Values, cloudflareRecordValue(item))
+	}
+	return records
+}
+
+// cloudflareRecordValue 将 Cloudflare 记录转换为统一格式的值
+func cloudflareRecordValue(item cloudflare.DNSRecord) string {
+	switch item.Type {
+	case "MX":
+		priority := 0
+		if item.Priority != nil {
+			priority = *item.Priority
+		}
+		return normalizeDNSValue("MX", fmt.Sprintf("%d %s", priority, item.Content))
+	case "CAA":
+		if item.Data != nil {
+			return fmt.Sprintf("%v %v %s", item.Data["flags"], item.Data["tag"], quoteTXTValue(fmt.Sprint(item.Data["value"])))
+		}
+	case "TXT":
+		return unquoteTXTValue(item.Content)
+	}
+	return normalizeDNSValue(item.Type, item.Content)
+}
+
+// toCloudflareRecord 将记录集中的一个值转换为 Cloudflare 记录
+func toCloudflareRecord(record DNSRecord, value string) (cloudflare.DNSRecord, error) {
+	item := cloudflare.DNSRecord{
+		Type:    record.Type,
+		Name:    record.Name,
+		Content: value,
+		TTL:     int(record.TTL),
+	}
+	switch record.Type {
+	case "A", "AAAA", "CNAME":
+		proxied := record.Proxied
+		item.Proxied = &proxied
+		if proxied {
+			// 代理记录的 TTL 只能为自动
+			item.TTL = 1
+		}
+	case "MX":
+		priority, target, err := parseMXValue(value)
+		if err != nil {
+			return item, err
+		}
+		item.Content = target
+		item.Priority = &priority
+	case "CAA":
+		flags, tag, caaValue, err := parseCAAValue(value)
+		if err != nil {
+			return item, err
+		}
+		item.Content = ""
+		item.Data = map[string]any{"flags": flags, "tag": tag, "value": caaValue}
+	}
+	return item, nil
+}
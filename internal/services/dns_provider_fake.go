@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FakeDNSProvider 内存中的 DNSProvider，用于测试和本地开发，不访问任何云端接口
+type FakeDNSProvider struct {
+	mu          sync.Mutex
+	zones       map[string]map[string]DNSRecord
+	nameServers map[string][]string
+}
+
+func NewFakeDNSProvider() *FakeDNSProvider {
+	return &FakeDNSProvider{
+		zones:       make(map[string]map[string]DNSRecord),
+		nameServers: make(map[string][]string),
+	}
+}
+
+// SetNameServers 设置区域的 NS 服务器
+func (p *FakeDNSProvider) SetNameServers(zoneID string, nameServers []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nameServers[zoneID] = append([]string(nil), nameServers...)
+}
+
+// ListRecords 按名称和类型排序返回记录集
+func (p *FakeDNSProvider) ListRecords(zoneID string) ([]DNSRecord, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	records := make([]DNSRecord, 0, len(p.zones[zoneID]))
+	for _, record := range p.zones[zoneID] {
+		records = append(records, copyDNSRecord(record))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].Type < records[j].Type
+	})
+	return records, nil
+}
+
+func (p *FakeDNSProvider) GetRecord(zoneID, name, recordType string) (*DNSRecord, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	record, ok := p.zones[zoneID][dnsRecordKey(name, recordType)]
+	if !ok {
+		return nil, nil
+	}
+	record = copyDNSRecord(record)
+	return &record, nil
+}
+
+func (p *FakeDNSProvider) CreateRecord(zoneID string, record DNSRecord) error {
+	record = normalizeDNSRecord(record)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := dnsRecordKey(record.Name, record.Type)
+	if _, ok := p.zones[zoneID][key]; ok {
+		return fmt.Errorf("记录已存在: %s %s", record.Name, record.Type)
+	}
+	p.put(zoneID, key, record)
+	return nil
+}
+
+func (p *FakeDNSProvider) UpdateRecord(zoneID string, record DNSRecord) error {
+	record = normalizeDNSRecord(record)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.put(zoneID, dnsRecordKey(record.Name, record.Type), record)
+	return nil
+}
+
+func (p *FakeDNSProvider) DeleteRecord(zoneID, name, recordType string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := dnsRecordKey(name, recordType)
+	if _, ok := p.zones[zoneID][key]; !ok {
+		return fmt.Errorf("记录不存在: %s %s", normalizeDNSName(name), recordType)
+	}
+	delete(p.zones[zoneID], key)
+	return nil
+}
+
+func (p *FakeDNSProvider) CheckRecord(zoneID string, expected DNSRecord) (bool, error) {
+	return checkDNSRecord(p, zoneID, expected)
+}
+
+func (p *FakeDNSProvider) GetNameServers(zoneID string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.nameServers[zoneID]...), nil
+}
+
+func (p *FakeDNSProvider) CloudFrontRecord(name, cloudFrontDomain string) DNSRecord {
+	return DNSRecord{
+		Name:   normalizeDNSName(name),
+		Type:   "CNAME",
+		Values: []string{normalizeDNSName(cloudFrontDomain)},
+		TTL:    defaultDNSRecordTTL,
+	}
+}
+
+func (p *FakeDNSProvider) put(zoneID, key string, record DNSRecord) {
+	if p.zones[zoneID] == nil {
+		p.zones[zoneID] = make(map[string]DNSRecord)
+	}
+	p.zones[zoneID][key] = copyDNSRecord(record)
+}
+
+func copyDNSRecord(record DNSRecord) DNSRecord {
+	record.Values = append([]string{}, record.Values...)
+	return record
+}
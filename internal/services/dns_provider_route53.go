@@ -0,0 +1,154 @@
+package services
+
+import (
+	"aws_cdn/internal/services/aws"
+	"fmt"
+	"strings"
+)
+
+// Route53DNSProvider 基于 Route 53 托管区域的 DNSProvider
+type Route53DNSProvider struct {
+	route53Svc *aws.Route53Service
+}
+
+func NewRoute53DNSProvider(route53Svc *aws.Route53Service) *Route53DNSProvider {
+	return &Route53DNSProvider{route53Svc: route53Svc}
+}
+
+// ListRecords 列出托管区域的所有记录集（加权、地理等路由策略记录不在统一模型中，跳过）
+func (p *Route53DNSProvider) ListRecords(zoneID string) ([]DNSRecord, error) {
+	sets, err := p.route53Svc.ListResourceRecords(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]DNSRecord, 0, len(sets))
+	for _, set := range sets {
+		if set.SetIdentifier != "" {
+			continue
+		}
+		records = append(records, fromRoute53Record(set))
+	}
+	return records, nil
+}
+
+func (p *Route53DNSProvider) GetRecord(zoneID, name, recordType string) (*DNSRecord, error) {
+	set, err := p.findRecord(zoneID, name, recordType)
+	if err != nil || set == nil {
+		return nil, err
+	}
+	record := fromRoute53Record(*set)
+	return &record, nil
+}
+
+func (p *Route53DNSProvider) CreateRecord(zoneID string, record DNSRecord) error {
+	set, err := toRoute53Record(record)
+	if err != nil {
+		return err
+	}
+	return p.route53Svc.ChangeResourceRecord(zoneID, "CREATE", set)
+}
+
+func (p *Route53DNSProvider) UpdateRecord(zoneID string, record DNSRecord) error {
+	set, err := toRoute53Record(record)
+	if err != nil {
+		return err
+	}
+	return p.route53Svc.ChangeResourceRecord(zoneID, "UPSERT", set)
+}
+
+// DeleteRecord 删除记录集（Route 53 要求 DELETE 的内容与现有记录集完全一致，因此先查询）
+func (p *Route53DNSProvider) DeleteRecord(zoneID, name, recordType string) error {
+	set, err := p.findRecord(zoneID, name, recordType)
+	if err != nil {
+		return err
+	}
+	if set == nil {
+		return fmt.Errorf("记录不存在: %s %s", normalizeDNSName(name), strings.ToUpper(recordType))
+	}
+	return p.route53Svc.ChangeResourceRecord(zoneID, "DELETE", *set)
+}
+
+func (p *Route53DNSProvider) CheckRecord(zoneID string, expected DNSRecord) (bool, error) {
+	return checkDNSRecord(p, zoneID, expected)
+}
+
+func (p *Route53DNSProvider) GetNameServers(zoneID string) ([]string, error) {
+	return p.route53Svc.GetNameServers(zoneID)
+}
+
+func (p *Route53DNSProvider) CloudFrontRecord(name, cloudFrontDomain string) DNSRecord {
+	return DNSRecord{
+		Name:        normalizeDNSName(name),
+		Type:        "A",
+		AliasTarget: normalizeDNSName(cloudFrontDomain),
+		AliasZoneID: aws.CloudFrontHostedZoneID,
+	}
+}
+
+// findRecord 查找同名同类型的简单路由记录集
+func (p *Route53DNSProvider) findRecord(zoneID, name, recordType string) (*aws.ResourceRecord, error) {
+	sets, err := p.route53Svc.ListResourceRecords(zoneID)
+	if err != nil {
+		return nil, err
+	}
+	key := dnsRecordKey(name, recordType)
+	for i := range sets {
+		if sets[i].SetIdentifier == "" && dnsRecordKey(sets[i].Name, sets[i].Type) == key {
+			return &sets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func fromRoute53Record(set aws.ResourceRecord) DNSRecord {
+	record := DNSRecord{
+		Name:        normalizeDNSName(set.Name),
+		Type:        set.Type,
+		Values:      []string{},
+		TTL:         set.TTL,
+		AliasTarget: normalizeDNSName(set.AliasDNSName),
+		AliasZoneID: set.AliasZoneID,
+	}
+	for _, value := range set.Values {
+		if set.Type == "TXT" {
+			value = unquoteTXTValue(value)
+		}
+		record.Values = append(record.Values, normalizeDNSValue(set.Type, value))
+	}
+	return record
+}
+
+func toRoute53Record(record DNSRecord) (aws.ResourceRecord, error) {
+	record = normalizeDNSRecord(record)
+	set := aws.ResourceRecord{
+		Name:         record.Name,
+		Type:         record.Type,
+		TTL:          record.TTL,
+		AliasDNSName: record.AliasTarget,
+		AliasZoneID:  record.AliasZoneID,
+	}
+	if set.AliasDNSName != "" {
+		return set, nil
+	}
+	if len(record.Values) == 0 {
+		return set, fmt.Errorf("记录 %s %s 没有值", record.Name, record.Type)
+	}
+	for _, value := range record.Values {
+		switch record.Type {
+		case "TXT":
+			value = quoteTXTValue(value)
+		case "CAA":
+			flags, tag, caaValue, err := parseCAAValue(value)
+			if err != nil {
+				return set, err
+			}
+			value = fmt.Sprintf("%d %s %s", flags, tag, quoteTXTValue(caaValue))
+		case "MX":
+			if _, _, err := parseMXValue(value); err != nil {
+				return set, err
+			}
+		}
+		set.Values = append(set.Values, value)
+	}
+	return set, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/cloudflare"
+	"strings"
+	"testing"
+)
+
+// DomainService 的 CloudFront 和 www 记录流程通过 DNSProvider 完成，名称和值的大小写、末尾点不影响检查结果。
+func TestDomainServiceRecordsWithFakeProvider(t *testing.T) {
+	fake := NewFakeDNSProvider()
+	svc := &DomainService{}
+	svc.dnsProviderFactory = func(*models.Domain) (DNSProvider, error) { return fake, nil }
+	domain := &models.Domain{DomainName: "Example.com", HostedZoneID: "Z1"}
+
+	if ok, err := svc.CheckCloudFrontCNAMERecord(domain, "d111.cloudfront.net"); err != nil || ok {
+		t.Fatalf("record should not exist yet: %v %v", ok, err)
+	}
+	if err := svc.CreateCloudFrontCNAMERecord(domain, "d111.cloudfront.net."); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := svc.CheckCloudFrontCNAMERecord(domain, "D111.cloudfront.net"); !ok {
+		t.Fatal("cloudfront record should match")
+	}
+	if ok, _ := svc.CheckCloudFrontCNAMERecord(domain, "d222.cloudfront.net"); ok {
+		t.Fatal("different distribution should not match")
+	}
+
+	if err := svc.CreateWWWCNAMERecord(domain); err != nil {
+		t.Fatal(err)
+	}
+	record, _ := fake.GetRecord("Z1", "www.example.com.", "cname")
+	if record == nil || record.Values[0] != "example.com" || record.TTL != defaultDNSRecordTTL {
+		t.Fatalf("unexpected www record: %+v", record)
+	}
+	if ok, _ := svc.CheckWWWCNAMERecord(domain); !ok {
+		t.Fatal("www record should match")
+	}
+
+	if err := fake.CreateRecord("Z1", DNSRecord{Name: "www.example.com", Type: "CNAME", Values: []string{"x.example.com"}}); err == nil {
+		t.Fatal("create should fail when record exists")
+	}
+}
+
+// 子域名的 CloudFront 记录同样经过 DNSProvider（Cloudflare 为 CNAME），不指定分发时只要求指向任意 CloudFront。
+func TestCloudFrontAliasRecordWithFakeProvider(t *testing.T) {
+	fake := NewFakeDNSProvider()
+	svc := &DomainService{}
+	svc.dnsProviderFactory = func(*models.Domain) (DNSProvider, error) { return fake, nil }
+	domain := &models.Domain{DomainName: "example.com", HostedZoneID: "Z1", DNSProvider: models.DNSProviderCloudflare}
+
+	if err := svc.CreateCloudFrontAliasRecord(domain, "dl.example.com", "d111.cloudfront.net"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := svc.CheckCloudFrontAliasRecord(domain, "dl.example.com", "d222.cloudfront.net"); ok {
+		t.Fatal("different distribution should not match")
+	}
+	if ok, _ := svc.CheckCloudFrontAliasRecord(domain, "dl.example.com", ""); !ok {
+		t.Fatal("empty target should match any cloudfront record")
+	}
+	if ok, _ := svc.CheckCloudFrontAliasRecord(domain, "other.example.com", ""); ok {
+		t.Fatal("missing record should not match")
+	}
+	route53Alias := (&Route53DNSProvider{}).CloudFrontRecord("example.com", "d111.cloudfront.net")
+	if !pointsToCloudFront(&route53Alias) {
+		t.Fatal("route 53 alias should point to cloudfront")
+	}
+}
+
+// Route 53 Alias 记录按托管区域和目标比较，目标为空时只要求指向 CloudFront。
+func TestDNSRecordSatisfiesAlias(t *testing.T) {
+	provider := &Route53DNSProvider{}
+	actual := provider.CloudFrontRecord("example.com", "d111.cloudfront.net")
+	if !dnsRecordSatisfies(&actual, provider.CloudFrontRecord("example.com.", "D111.cloudfront.net.")) {
+		t.Fatal("same alias should match")
+	}
+	if !dnsRecordSatisfies(&actual, provider.CloudFrontRecord("example.com", "")) {
+		t.Fatal("empty target should match any cloudfront alias")
+	}
+	if dnsRecordSatisfies(&actual, provider.CloudFrontRecord("example.com", "d222.cloudfront.net")) {
+		t.Fatal("different target should not match")
+	}
+}
+
+// 超过 255 字节的 TXT 拆分为多个字符串，引号和反斜杠转义后可以还原。
+func TestTXTValueQuoting(t *testing.T) {
+	value := `v=DKIM1; k="rsa"; p=` + strings.Repeat("A", 300) + `\`
+	quoted := quoteTXTValue(value)
+	if strings.Count(quoted, `" "`) != 1 {
+		t.Fatalf("expected two strings: %s", quoted)
+	}
+	if got := unquoteTXTValue(quoted); got != value {
+		t.Fatalf("round trip mismatch: %s", got)
+	}
+	if got := unquoteTXTValue("plain text"); got != "plain text" {
+		t.Fatalf("unquoted value changed: %s", got)
+	}
+}
+
+// Cloudflare 的 MX、CAA 记录与统一格式互相转换。
+func TestCloudflareRecordConversion(t *testing.T) {
+	mx, err := toCloudflareRecord(DNSRecord{Name: "example.com", Type: "MX"}, "10 mail.example.com")
+	if err != nil || mx.Content != "mail.example.com" || mx.Priority == nil || *mx.Priority != 10 {
+		t.Fatalf("unexpected mx: %+v %v", mx, err)
+	}
+	caa, err := toCloudflareRecord(DNSRecord{Name: "example.com", Type: "CAA"}, `0 issue "amazon.com"`)
+	if err != nil || caa.Data["tag"] != "issue" || caa.Data["value"] != "amazon.com" {
+		t.Fatalf("unexpected caa: %+v %v", caa, err)
+	}
+
+	proxied := true
+	priority := 20
+	records := groupCloudflareRecords([]cloudflare.DNSRecord{
+		{ID: "1", Type: "A", Name: "example.com", Content: "192.0.2.1", TTL: 1, Proxied: &proxied},
+		{ID: "2", Type: "MX", Name: "example.com", Content: "Mail.example.com", Priority: &priority},
+		{ID: "3", Type: "A", Name: "example.com", Content: "192.0.2.2", TTL: 1, Proxied: &proxied},
+		{ID: "4", Type: "CAA", Name: "example.com", Data: map[string]any{"flags": float64(0), "tag": "issue", "value": "amazon.com"}},
+	})
+	if len(records) != 3 || len(records[0].Values) != 2 || !records[0].Proxied {
+		t.Fatalf("unexpected grouping: %+v", records)
+	}
+	if records[1].Values[0] != "20 mail.example.com" || records[2].Values[0] != `0 issue "amazon.com"` {
+		t.Fatalf("unexpected values: %+v", records)
+	}
+}
+
+// 记录名称支持相对名称和 "@"，校验类型、代理、TTL 和值格式。
+func TestPrepareDNSRecord(t *testing.T) {
+	awsDomain := &models.Domain{DomainName: "example.com", DNSProvider: models.DNSProviderAWS}
+	cf := &models.Domain{DomainName: "example.com", DNSProvider: models.DNSProviderCloudflare}
+
+	record, err := prepareDNSRecord(awsDomain, DNSRecord{Name: "_verify", Type: "txt", Values: []string{" token ", ""}})
+	if err != nil || record.Name != "_verify.example.com" || record.Type != "TXT" || len(record.Values) != 1 || record.TTL != defaultDNSRecordTTL {
+		t.Fatalf("unexpected record: %+v %v", record, err)
+	}
+	if record, _ := prepareDNSRecord(cf, DNSRecord{Name: "@", Type: "CNAME", Values: []string{"x.example.net"}, TTL: 1, Proxied: true}); record.Name != "example.com" {
+		t.Fatalf("unexpected apex name: %+v", record)
+	}
+
+	invalid := []struct {
+		domain *models.Domain
+		record DNSRecord
+	}{
+		{awsDomain, DNSRecord{Name: "@", Type: "NS", Values: []string{"ns1.example.net"}}},
+		{awsDomain, DNSRecord{Name: "a", Type: "A", Values: []string{"2001:db8::1"}}},
+		{awsDomain, DNSRecord{Name: "a", Type: "A", Values: []string{"192.0.2.1"}, Proxied: true}},
+		{cf, DNSRecord{Name: "a", Type: "TXT", Values: []string{"x"}, Proxied: true}},
+		{awsDomain, DNSRecord{Name: "a", Type: "A", Values: []string{"192.0.2.1"}, TTL: 1}},
+		{awsDomain, DNSRecord{Name: "@", Type: "CNAME", Values: []string{"x.example.net"}}},
+		{awsDomain, DNSRecord{Name: "a", Type: "CNAME", Values: []string{"x.example.net", "y.example.net"}}},
+		{awsDomain, DNSRecord{Name: "other.com.", Type: "A", Values: []string{"192.0.2.1"}}},
+		{awsDomain, DNSRecord{Name: "@", Type: "MX", Values: []string{"mail.example.com"}}},
+		{awsDomain, DNSRecord{Name: "@", Type: "CAA", Values: []string{`0 policy "x"`}}},
+	}
+	for i, tc := range invalid {
+		if _, err := prepareDNSRecord(tc.domain, tc.record); err == nil {
+			t.Errorf("case %d should fail: %+v", i, tc.record)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 可通过 DNS 记录管理接口维护的记录类型（NS、SOA 由提供商管理）
+var managedDNSRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"TXT":   true,
+	"MX":    true,
+	"CAA":   true,
+}
+
+// ListDNSRecords 列出域名托管区域中的所有 DNS 记录
+func (s *DomainService) ListDNSRecords(domainID uint) ([]DNSRecord, error) {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.ListRecords(domain.HostedZoneID)
+}
+
+// CreateDNSRecord 创建 DNS 记录，同名同类型的记录已存在时返回错误
+func (s *DomainService) CreateDNSRecord(domainID uint, record DNSRecord) (*DNSRecord, error) {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return nil, err
+	}
+	record, err = prepareDNSRecord(domain, record)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.CreateRecord(domain.HostedZoneID, record); err != nil {
+		return nil, err
+	}
+	s.logDNSRecordChange(domain, "create", record)
+	return provider.GetRecord(domain.HostedZoneID, record.Name, record.Type)
+}
+
+// UpdateDNSRecord 以 record 覆盖同名同类型的 DNS 记录，记录不存在时返回错误
+func (s *DomainService) UpdateDNSRecord(domainID uint, record DNSRecord) (*DNSRecord, error) {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return nil, err
+	}
+	record, err = prepareDNSRecord(domain, record)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := provider.GetRecord(domain.HostedZoneID, record.Name, record.Type)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("记录不存在: %s %s", record.Name, record.Type)
+	}
+	if existing.AliasTarget != "" {
+		return nil, fmt.Errorf("%s %s 是 Alias 记录，由系统维护，不能通过该接口修改", record.Name, record.Type)
+	}
+	if err := provider.UpdateRecord(domain.HostedZoneID, record); err != nil {
+		return nil, err
+	}
+	s.logDNSRecordChange(domain, "update", record)
+	return provider.GetRecord(domain.HostedZoneID, record.Name, record.Type)
+}
+
+// DeleteDNSRecord 删除指定名称和类型的 DNS 记录，Alias 记录由系统维护，不能删除
+func (s *DomainService) DeleteDNSRecord(domainID uint, name, recordType string) error {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return err
+	}
+	recordType = strings.ToUpper(strings.TrimSpace(recordType))
+	if !managedDNSRecordTypes[recordType] {
+		return fmt.Errorf("不支持的记录类型: %s", recordType)
+	}
+	name, err = qualifyDNSName(domain.DomainName, name)
+	if err != nil {
+		return err
+	}
+	existing, err := provider.GetRecord(domain.HostedZoneID, name, recordType)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("记录不存在: %s %s", name, recordType)
+	}
+	if existing.AliasTarget != "" {
+		return fmt.Errorf("%s %s 是 Alias 记录，由系统维护，不能通过该接口删除", name, recordType)
+	}
+	if err := provider.DeleteRecord(domain.HostedZoneID, name, recordType); err != nil {
+		return err
+	}
+	s.logDNSRecordChange(domain, "delete", DNSRecord{Name: name, Type: recordType})
+	return nil
+}
+
+// dnsRecordContext 获取域名及其 DNS 提供商
+func (s *DomainService) dnsRecordContext(domainID uint) (*models.Domain, DNSProvider, error) {
+	domain, err := s.GetDomain(domainID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取域名信息失败: %w", err)
+	}
+	if domain.HostedZoneID == "" {
+		return nil, nil, fmt.Errorf("域名尚未创建托管区域")
+	}
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	return domain, provider, nil
+}
+
+func (s *DomainService) logDNSRecordChange(domain *models.Domain, action string, record DNSRecord) {
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"domain_id":    domain.ID,
+		"dns_provider": domain.DNSProvider,
+		"action":       action,
+		"name":         record.Name,
+		"type":         record.Type,
+		"values":       record.Values,
+		"ttl":          record.TTL,
+		"proxied":      record.Proxied,
+	}).Info("DNS记录已变更")
+}
+
+// qualifyDNSName 将记录名称补全为完整域名："@" 或空表示根域名，不以根域名结尾的视为相对名称，以点结尾的视为绝对名称
+func qualifyDNSName(zoneName, name string) (string, error) {
+	zoneName = normalizeDNSName(zoneName)
+	absolute := strings.HasSuffix(strings.TrimSpace(name), ".")
+	name = normalizeDNSName(name)
+	switch {
+	case name == "" || name == "@":
+		return zoneName, nil
+	case name == zoneName || strings.HasSuffix(name, "."+zoneName):
+		return name, nil
+	case absolute:
+		return "", fmt.Errorf("记录 %s 不属于区域 %s", name, zoneName)
+	}
+	return name + "." + zoneName, nil
+}
+
+// prepareDNSRecord 校验并规范化待写入的记录
+func prepareDNSRecord(domain *models.Domain, record DNSRecord) (DNSRecord, error) {
+	record.Type = strings.ToUpper(strings.TrimSpace(record.Type))
+	if !managedDNSRecordTypes[record.Type] {
+		return record, fmt.Errorf("不支持的记录类型: %s，仅支持 A、AAAA、CNAME、TXT、MX、CAA", record.Type)
+	}
+	name, err := qualifyDNSName(domain.DomainName, record.Name)
+	if err != nil {
+		return record, err
+	}
+	record.Name = name
+	record.AliasTarget = ""
+	record.AliasZoneID = ""
+
+	var values []string
+	for _, value := range record.Values {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return record, fmt.Errorf("记录值不能为空")
+	}
+	record.Values = values
+
+	isCloudflare := domain.DNSProvider == models.DNSProviderCloudflare
+	if record.Proxied {
+		if !isCloudflare {
+			return record, fmt.Errorf("只有 Cloudflare 托管的域名支持代理（proxied）")
+		}
+		if record.Type != "A" && record.Type != "AAAA" && record.Type != "CNAME" {
+			return record, fmt.Errorf("%s 记录不支持代理（proxied）", record.Type)
+		}
+	}
+	switch {
+	case record.TTL == 0:
+		record.TTL = defaultDNSRecordTTL
+	case record.TTL == 1 && isCloudflare:
+		// Cloudflare 自动 TTL
+	case record.TTL < 60 || record.TTL > 86400:
+		return record, fmt.Errorf("TTL 必须在 60 到 86400 秒之间")
+	}
+
+	for _, value := range record.Values {
+		if err := validateDNSValue(record.Type, value); err != nil {
+			return record, err
+		}
+	}
+	if record.Type == "CNAME" {
+		if len(record.Values) != 1 {
+			return record, fmt.Errorf("CNAME 记录只能有一个值")
+		}
+		if record.Name == normalizeDNSName(domain.DomainName) && !isCloudflare {
+			return record, fmt.Errorf("Route 53 不允许在根域名上创建 CNAME 记录，请使用 A 记录")
+		}
+	}
+	return record, nil
+}
+
+// validateDNSValue 校验单个记录值的格式
+func validateDNSValue(recordType, value string) error {
+	switch recordType {
+	case "A":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("无效的 IPv4 地址: %s", value)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("无效的 IPv6 地址: %s", value)
+		}
+	case "CNAME":
+		if strings.ContainsAny(value, " /:") {
+			return fmt.Errorf("无效的 CNAME 目标: %s", value)
+		}
+	case "TXT":
+		if len(value) > 4000 {
+			return fmt.Errorf("TXT 记录值过长")
+		}
+	case "MX":
+		if _, _, err := parseMXValue(value); err != nil {
+			return err
+		}
+	case "CAA":
+		_, tag, _, err := parseCAAValue(value)
+		if err != nil {
+			return err
+		}
+		if tag != "issue" && tag != "issuewild" && tag != "iodef" {
+			return fmt.Errorf("CAA tag 必须是 issue、issuewild 或 iodef: %s", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/aws"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NS 委派检查结果
+const (
+	NSDelegationCorrect      = "correct"       // 公网 NS 与预期完全一致
+	NSDelegationPartial      = "partial"       // 部分 NS 正确（注册商只改了一部分，或仍在传播）
+	NSDelegationWrong        = "wrong"         // NS 指向其他服务商
+	NSDelegationNotDelegated = "not_delegated" // 查不到 NS 记录
+)
+
+// NSDelegationResult 单个域名的 NS 委派检查结果
+type NSDelegationResult struct {
+	DomainID   uint                `json:"domain_id"`
+	DomainName string              `json:"domain_name"`
+	Status     models.DomainStatus `json:"status"`     // 检查后的域名状态
+	Delegation string              `json:"delegation"` // correct、partial、wrong、not_delegated
+	Expected   []string            `json:"expected"`
+	Actual     []string            `json:"actual"`
+	Missing    []string            `json:"missing,omitempty"` // 预期中但公网查不到的 NS
+	Extra      []string            `json:"extra,omitempty"`   // 公网中多出的 NS
+	CheckedAt  time.Time           `json:"checked_at"`
+}
+
+// SetNSResolver 设置 NS 委派检查使用的查询器
+func (s *DomainService) SetNSResolver(resolver NSResolver) {
+	s.nsResolver = resolver
+}
+
+// CheckPendingDelegations 检查所有待转入域名的 NS 委派（定时任务入口）
+func (s *DomainService) CheckPendingDelegations() error {
+	log := logger.GetLogger()
+
+	var domains []models.Domain
+	if err := s.db.Where("status IN ? AND hosted_zone_id <> ''", []models.DomainStatus{models.DomainStatusPending, models.DomainStatusInProgress}).
+		Find(&domains).Error; err != nil {
+		return fmt.Errorf("查询待转入域名失败: %w", err)
+	}
+
+	completed := 0
+	for i := range domains {
+		result, err := s.CheckNSDelegation(&domains[i])
+		if err != nil {
+			log.WithError(err).WithField("domain", domains[i].DomainName).Warn("NS 委派检查失败")
+			continue
+		}
+		if result.Status == models.DomainStatusCompleted {
+			completed++
+		}
+	}
+
+	log.WithFields(map[string]interface{}{
+		"domains":   len(domains),
+		"completed": completed,
+	}).Info("NS 委派检查完成")
+	return nil
+}
+
+// CheckNSDelegationByID 立即检查指定域名的 NS 委派
+func (s *DomainService) CheckNSDelegationByID(id uint) (*NSDelegationResult, error) {
+	domain, err := s.GetDomain(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.CheckNSDelegation(domain)
+}
+
+// CheckNSDelegation 查询域名在公网上的 NS 委派并与 Domain.NServers 比较
+// 委派正确时域名状态改为 completed 并自动申请证书；部分正确时为 in_progress，错误或未委派时保持 pending。
+// 已完成的域名只更新检查结果，不会回退状态。
+func (s *DomainService) CheckNSDelegation(domain *models.Domain) (*NSDelegationResult, error) {
+	log := logger.GetLogger()
+	if s.nsResolver == nil {
+		return nil, fmt.Errorf("未配置 NS 查询器")
+	}
+
+	expected, err := s.expectedNameServers(domain)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := s.nsResolver.LookupNS(context.Background(), domain.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	delegation, missing, extra := evaluateNSDelegation(expected, actual)
+	now := time.Now()
+	result := &NSDelegationResult{
+		DomainID:   domain.ID,
+		DomainName: domain.DomainName,
+		Status:     domain.Status,
+		Delegation: delegation,
+		Expected:   expected,
+		Actual:     actual,
+		Missing:    missing,
+		Extra:      extra,
+		CheckedAt:  now,
+	}
+	if domain.Status != models.DomainStatusCompleted {
+		switch delegation {
+		case NSDelegationCorrect:
+			result.Status = models.DomainStatusCompleted
+		case NSDelegationPartial:
+			result.Status = models.DomainStatusInProgress
+		default:
+			result.Status = models.DomainStatusPending
+		}
+	}
+
+	if err := s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Updates(map[string]interface{}{
+		"ns_delegation_status": delegation,
+		"ns_delegation_detail": describeNSDelegation(result),
+		"ns_checked_at":        now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("更新 NS 委派状态失败: %w", err)
+	}
+
+	if delegation != domain.NSDelegationStatus {
+		log.WithFields(map[string]interface{}{
+			"domain_id":   domain.ID,
+			"domain_name": domain.DomainName,
+			"delegation":  delegation,
+			"missing":     missing,
+			"extra":       extra,
+		}).Info("NS 委派状态变化")
+	}
+
+	if result.Status == domain.Status {
+		return result, nil
+	}
+	// 只在状态未被其他检查改动时切换，避免定时任务和手动查询同时触发证书申请
+	update := s.db.Model(&models.Domain{}).Where("id = ? AND status = ?", domain.ID, domain.Status).Update("status", result.Status)
+	if update.Error != nil {
+		return nil, fmt.Errorf("更新域名状态失败: %w", update.Error)
+	}
+	if update.RowsAffected == 0 {
+		return result, nil
+	}
+	domain.Status = result.Status
+	if result.Status == models.DomainStatusCompleted && domain.CertificateStatus != "issued" {
+		log.WithFields(map[string]interface{}{
+			"domain_id":   domain.ID,
+			"domain_name": domain.DomainName,
+		}).Info("NS 委派已生效，开始异步请求证书")
+		go s.requestCertificateAsync(domain)
+	}
+	return result, nil
+}
+
+// expectedNameServers 返回域名应委派到的 NS
+// Cloudflare 域名在转入时未能记录 NS 的，从 Zone 信息中补齐并保存
+func (s *DomainService) expectedNameServers(domain *models.Domain) ([]string, error) {
+	var expected []string
+	if domain.NServers != "" {
+		servers, err := aws.ParseNServersJSON(domain.NServers)
+		if err != nil {
+			return nil, fmt.Errorf("解析 NS 服务器失败: %w", err)
+		}
+		expected = servers
+	}
+	if len(expected) == 0 {
+		provider, err := s.dnsProviderFactory(domain)
+		if err != nil {
+			return nil, err
+		}
+		servers, err := provider.GetNameServers(domain.HostedZoneID)
+		if err != nil {
+			return nil, fmt.Errorf("获取区域 NS 服务器失败: %w", err)
+		}
+		if len(servers) == 0 {
+			return nil, fmt.Errorf("域名 %s 没有可比较的 NS 服务器", domain.DomainName)
+		}
+		// 保存失败不影响本次检查，下次检查会重新获取
+		if nsServersJSON, err := aws.FormatNServersJSON(servers); err == nil {
+			if err := s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Update("n_servers", nsServersJSON).Error; err != nil {
+				logger.GetLogger().WithError(err).WithField("domain", domain.DomainName).Warn("保存区域 NS 服务器失败")
+			}
+		}
+		expected = servers
+	}
+
+	normalized := make([]string, 0, len(expected))
+	for _, server := range expected {
+		normalized = append(normalized, normalizeDNSName(server))
+	}
+	return normalized, nil
+}
+
+// evaluateNSDelegation 比较预期和公网的 NS 集合（忽略大小写、末尾点和顺序）
+func evaluateNSDelegation(expected, actual []string) (delegation string, missing, extra []string) {
+	want := make(map[string]bool, len(expected))
+	for _, server := range expected {
+		want[normalizeDNSName(server)] = true
+	}
+	got := make(map[string]bool, len(actual))
+	for _, server := range actual {
+		got[normalizeDNSName(server)] = true
+	}
+
+	matched := 0
+	for server := range want {
+		if got[server] {
+			matched++
+		} else {
+			missing = append(missing, server)
+		}
+	}
+	for server := range got {
+		if !want[server] {
+			extra = append(extra, server)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	switch {
+	case len(got) == 0:
+		return NSDelegationNotDelegated, missing, extra
+	case matched == 0:
+		return NSDelegationWrong, missing, extra
+	case len(missing) == 0 && len(extra) == 0:
+		return NSDelegationCorrect, missing, extra
+	}
+	return NSDelegationPartial, missing, extra
+}
+
+// describeNSDelegation 生成保存到 ns_delegation_detail 的说明
+func describeNSDelegation(result *NSDelegationResult) string {
+	if len(result.Actual) == 0 {
+		return "公网查不到 NS 记录，请在注册商处修改 NS"
+	}
+	detail := "公网 NS: " + strings.Join(result.Actual, ", ")
+	if len(result.Missing) > 0 {
+		detail += "；缺少: " + strings.Join(result.Missing, ", ")
+	}
+	if len(result.Extra) > 0 {
+		detail += "；多余: " + strings.Join(result.Extra, ", ")
+	}
+	return detail
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// 公网 NS 与预期比较：忽略大小写、末尾点和顺序，区分完全正确、部分正确、错误和未委派。
+func TestEvaluateNSDelegation(t *testing.T) {
+	expected := []string{"ns-1.awsdns-01.com", "ns-2.awsdns-02.net."}
+
+	cases := []struct {
+		name    string
+		actual  []string
+		want    string
+		missing int
+		extra   int
+	}{
+		{"correct", []string{"NS-2.awsdns-02.net.", "ns-1.awsdns-01.com."}, NSDelegationCorrect, 0, 0},
+		{"missing one", []string{"ns-1.awsdns-01.com"}, NSDelegationPartial, 1, 0},
+		{"mixed", []string{"ns-1.awsdns-01.com", "dns1.registrar.com"}, NSDelegationPartial, 1, 1},
+		{"wrong", []string{"dns1.registrar.com", "dns2.registrar.com"}, NSDelegationWrong, 2, 2},
+		{"not delegated", nil, NSDelegationNotDelegated, 2, 0},
+	}
+	for _, tc := range cases {
+		got, missing, extra := evaluateNSDelegation(expected, tc.actual)
+		if got != tc.want || len(missing) != tc.missing || len(extra) != tc.extra {
+			t.Fatalf("%s: got %s missing=%v extra=%v", tc.name, got, missing, extra)
+		}
+	}
+}
+
+// FakeNSResolver 按规范化后的域名返回预设结果或错误。
+func TestFakeNSResolver(t *testing.T) {
+	resolver := NewFakeNSResolver()
+	resolver.SetNS("Example.com.", "A.NS.example.net.")
+
+	hosts, err := resolver.LookupNS(context.Background(), "example.com")
+	if err != nil || len(hosts) != 1 || hosts[0] != "a.ns.example.net" {
+		t.Fatalf("unexpected lookup result: %v %v", hosts, err)
+	}
+	if hosts, _ := resolver.LookupNS(context.Background(), "other.com"); len(hosts) != 0 {
+		t.Fatalf("unknown domain should have no NS: %v", hosts)
+	}
+
+	resolver.SetError("example.com", errors.New("timeout"))
+	if _, err := resolver.LookupNS(context.Background(), "example.com"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// newDryRunDB 只生成 SQL、没有数据库连接，用于覆盖带数据库写入的流程
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// CheckNSDelegation 通过 FakeNSResolver 查询公网 NS：未记录 NS 时从 DNS 提供商补齐；部分正确为 in_progress，查询失败时返回错误。
+func TestCheckNSDelegation(t *testing.T) {
+	provider := NewFakeDNSProvider()
+	provider.SetNameServers("Z1", []string{"ns-1.awsdns-01.com", "ns-2.awsdns-02.net"})
+	resolver := NewFakeNSResolver()
+	svc := &DomainService{db: newDryRunDB(t), nsResolver: resolver}
+	svc.dnsProviderFactory = func(*models.Domain) (DNSProvider, error) { return provider, nil }
+
+	domain := &models.Domain{ID: 1, DomainName: "example.com", HostedZoneID: "Z1", Status: models.DomainStatusPending}
+	resolver.SetNS("example.com", "NS-1.awsdns-01.com.", "dns1.registrar.com")
+	result, err := svc.CheckNSDelegation(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Delegation != NSDelegationPartial || result.Status != models.DomainStatusInProgress || len(result.Missing) != 1 || len(result.Extra) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	completed := &models.Domain{ID: 2, DomainName: "done.com", NServers: `["ns-1.awsdns-01.com"]`, Status: models.DomainStatusCompleted}
+	result, err = svc.CheckNSDelegation(completed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Delegation != NSDelegationNotDelegated || result.Status != models.DomainStatusCompleted {
+		t.Fatalf("completed domain should keep its status: %+v", result)
+	}
+
+	resolver.SetError("example.com", errors.New("timeout"))
+	if _, err := svc.CheckNSDelegation(domain); err == nil {
+		t.Fatal("expected lookup error")
+	}
+}
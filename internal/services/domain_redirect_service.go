@@ -166,15 +166,16 @@ func (s *DomainRedirectService) Update(id uint, targetDomain string, preservePat
 	if err != nil {
 		return nil, err
 	}
-	if dr.CFRuleID != "" {
-		if err := cfSvc.UpdateDomainRedirectRule(dr.ZoneID, dr.CFRuleID, dr.SourceDomain, dr.TargetDomain, dr.PreservePath); err != nil {
-			return nil, fmt.Errorf("更新 Cloudflare 规则失败: %w", err)
-		}
+	ruleID, err := cfSvc.UpdateDomainRedirectRule(dr.ZoneID, dr.SourceDomain, dr.TargetDomain, dr.PreservePath)
+	if err != nil {
+		return nil, fmt.Errorf("更新 Cloudflare 规则失败: %w", err)
 	}
+	dr.CFRuleID = ruleID
 	// 显式 Select 包含 PreservePath，否则 GORM 会跳过零值(false)，更新不生效
-	if err := s.db.Model(dr).Select("TargetDomain", "PreservePath").Updates(map[string]interface{}{
+	if err := s.db.Model(dr).Select("TargetDomain", "PreservePath", "CFRuleID").Updates(map[string]interface{}{
 		"target_domain": dr.TargetDomain,
 		"preserve_path": dr.PreservePath,
+		"cf_rule_id":    dr.CFRuleID,
 	}).Error; err != nil {
 		return nil, fmt.Errorf("保存失败: %w", err)
 	}
@@ -204,14 +205,18 @@ func (s *DomainRedirectService) Delete(id uint) error {
 	if err != nil {
 		return err
 	}
-	if dr.CFRuleID != "" {
-		cfSvc, err := s.getCFService(dr.CFAccountID)
-		if err == nil {
-			rulesetID, _ := cfSvc.GetURLRedirectRulesetID(dr.ZoneID)
-			if rulesetID != "" {
-				_ = cfSvc.DeleteRedirectRule(dr.ZoneID, rulesetID, dr.CFRuleID)
-			}
-		}
+	// CF 规则删除失败不阻止删除记录，残留的规则可通过 Zone 规则清理接口删除
+	cfSvc, err := s.getCFService(dr.CFAccountID)
+	if err == nil {
+		err = cfSvc.DeleteDomainRedirectRule(dr.ZoneID, dr.SourceDomain, dr.CFRuleID)
+	}
+	if err != nil {
+		logger.GetLogger().WithError(err).WithFields(map[string]interface{}{
+			"id":            dr.ID,
+			"zone_id":       dr.ZoneID,
+			"source_domain": dr.SourceDomain,
+			"cf_rule_id":    dr.CFRuleID,
+		}).Warn("删除 Cloudflare 重定向规则失败")
 	}
 	return s.db.Delete(dr).Error
 }
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// reputationCheckConcurrency 同时检测的域名数
+	reputationCheckConcurrency = 5
+	// reputationCheckRetention 检测记录保留时长
+	reputationCheckRetention = 30 * 24 * time.Hour
+)
+
+// DomainReputationService 域名拦截/信誉检测服务
+// 对已完成转入的域名依次运行各检测器（多解析器 DNS 对比、HTTP 可达性、恶意网址库），
+// 每个检测器的结果作为时间序列写入 domain_reputation_checks，综合结果写回 Domain.Reputation；
+// 域名变为疑似被拦截时通过 Telegram 提醒，以便在用户察觉前替换重定向源域名。
+type DomainReputationService struct {
+	db        *gorm.DB
+	telegram  *TelegramService
+	detectors []ReputationDetector
+}
+
+// NewDomainReputationService 创建域名信誉检测服务
+func NewDomainReputationService(db *gorm.DB, telegram *TelegramService, detectors ...ReputationDetector) *DomainReputationService {
+	return &DomainReputationService{
+		db:        db,
+		telegram:  telegram,
+		detectors: detectors,
+	}
+}
+
+// DomainReputationReport 单个域名的检测结果
+type DomainReputationReport struct {
+	DomainID   uint                           `json:"domain_id"`
+	DomainName string                         `json:"domain_name"`
+	Reputation models.DomainReputation        `json:"reputation"`
+	Detail     string                         `json:"detail"`
+	Checks     []models.DomainReputationCheck `json:"checks"`
+}
+
+// CheckAllDomains 检测所有已完成转入的域名（定时任务入口）
+func (s *DomainReputationService) CheckAllDomains() error {
+	log := logger.GetLogger()
+
+	var domains []models.Domain
+	if err := s.db.Where("status = ?", models.DomainStatusCompleted).Find(&domains).Error; err != nil {
+		return fmt.Errorf("查询域名失败: %w", err)
+	}
+
+	var mu sync.Mutex
+	var newlyBlocked []*DomainReputationReport
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, reputationCheckConcurrency)
+	for i := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain *models.Domain) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			report, err := s.checkDomain(domain)
+			if err != nil {
+				log.WithError(err).WithField("domain", domain.DomainName).Warn("域名信誉检测失败")
+				return
+			}
+			if report.Reputation == models.DomainReputationSuspectedBlocked && domain.Reputation != models.DomainReputationSuspectedBlocked {
+				mu.Lock()
+				newlyBlocked = append(newlyBlocked, report)
+				mu.Unlock()
+			}
+		}(&domains[i])
+	}
+	wg.Wait()
+
+	if err := s.db.Where("checked_at < ?", time.Now().Add(-reputationCheckRetention)).Delete(&models.DomainReputationCheck{}).Error; err != nil {
+		log.WithError(err).Warn("清理过期的信誉检测记录失败")
+	}
+
+	log.WithFields(map[string]interface{}{
+		"domains":       len(domains),
+		"newly_blocked": len(newlyBlocked),
+	}).Info("域名信誉检测完成")
+	s.notify(newlyBlocked)
+	return nil
+}
+
+// CheckDomainByID 立即检测指定域名
+func (s *DomainReputationService) CheckDomainByID(id uint) (*DomainReputationReport, error) {
+	var domain models.Domain
+	if err := s.db.First(&domain, id).Error; err != nil {
+		return nil, fmt.Errorf("域名不存在")
+	}
+	report, err := s.checkDomain(&domain)
+	if err != nil {
+		return nil, err
+	}
+	if report.Reputation == models.DomainReputationSuspectedBlocked && domain.Reputation != models.DomainReputationSuspectedBlocked {
+		s.notify([]*DomainReputationReport{report})
+	}
+	return report, nil
+}
+
+// ListChecks 分页查询域名的检测记录（按时间倒序）
+func (s *DomainReputationService) ListChecks(domainID uint, detector string, page, pageSize int) ([]models.DomainReputationCheck, int64, error) {
+	var checks []models.DomainReputationCheck
+	var total int64
+
+	query := s.db.Model(&models.DomainReputationCheck{}).Where("domain_id = ?", domainID)
+	if detector != "" {
+		query = query.Where("detector = ?", detector)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("checked_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&checks).Error; err != nil {
+		return nil, 0, err
+	}
+	return checks, total, nil
+}
+
+// checkDomain 运行所有检测器，保存检测记录并更新域名的综合信誉
+func (s *DomainReputationService) checkDomain(domain *models.Domain) (*DomainReputationReport, error) {
+	now := time.Now()
+	checks := make([]models.DomainReputationCheck, 0, len(s.detectors))
+	for _, detector := range s.detectors {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		signal := detector.Check(ctx, domain.DomainName)
+		cancel()
+		checks = append(checks, models.DomainReputationCheck{
+			DomainID:  domain.ID,
+			Detector:  detector.Name(),
+			Result:    signal.Result,
+			Detail:    signal.Detail,
+			CheckedAt: now,
+		})
+	}
+	reputation, detail := aggregateReputation(checks)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(checks) > 0 {
+			if err := tx.Create(&checks).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Domain{}).Where("id = ?", domain.ID).Updates(map[string]interface{}{
+			"reputation":            reputation,
+			"reputation_detail":     detail,
+			"reputation_checked_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存信誉检测结果失败: %w", err)
+	}
+
+	return &DomainReputationReport{
+		DomainID:   domain.ID,
+		DomainName: domain.DomainName,
+		Reputation: reputation,
+		Detail:     detail,
+		Checks:     checks,
+	}, nil
+}
+
+// aggregateReputation 综合各检测器的结果：任一检测器判定拦截即为疑似被拦截，
+// 有警告为 warning，全部正常为 ok；检测器出错不参与判断，全部出错为 unknown
+func aggregateReputation(checks []models.DomainReputationCheck) (models.DomainReputation, string) {
+	var blocked, warnings []string
+	valid := 0
+	for _, check := range checks {
+		switch check.Result {
+		case models.ReputationResultBlocked:
+			blocked = append(blocked, fmt.Sprintf("[%s] %s", check.Detector, check.Detail))
+		case models.ReputationResultWarning:
+			warnings = append(warnings, fmt.Sprintf("[%s] %s", check.Detector, check.Detail))
+		case models.ReputationResultError:
+			continue
+		}
+		valid++
+	}
+
+	switch {
+	case len(blocked) > 0:
+		return models.DomainReputationSuspectedBlocked, strings.Join(append(blocked, warnings...), "；")
+	case len(warnings) > 0:
+		return models.DomainReputationWarning, strings.Join(warnings, "；")
+	case valid == 0:
+		return models.DomainReputationUnknown, ""
+	}
+	return models.DomainReputationOK, ""
+}
+
+// notify 通过 Telegram 推送新出现的疑似被拦截域名，标出正在作为重定向源域名使用的
+func (s *DomainReputationService) notify(reports []*DomainReputationReport) {
+	if s.telegram == nil || len(reports) == 0 {
+		return
+	}
+
+	var message strings.Builder
+	if s.telegram.GetSitename() != "" {
+		message.WriteString(fmt.Sprintf("[%s] ", s.telegram.GetSitename()))
+	}
+	message.WriteString(fmt.Sprintf("🚫 域名疑似被拦截（%d 个）\n\n", len(reports)))
+	for i, report := range reports {
+		var redirectCount int64
+		s.db.Model(&models.RedirectRule{}).Where("source_domain = ?", report.DomainName).Count(&redirectCount)
+		usage := ""
+		if redirectCount > 0 {
+			usage = "（重定向源域名，建议尽快替换）"
+		}
+		message.WriteString(fmt.Sprintf("%d. %s%s\n   %s\n", i+1, report.DomainName, usage, report.Detail))
+	}
+
+	if err := s.telegram.SendMessage(message.String()); err != nil {
+		logger.GetLogger().WithError(err).Warn("发送域名拦截通知失败")
+	}
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"gorm.io/gorm"
+)
+
+// DomainRotationService 备用域名池和重定向源域名轮换
+// 备用域名：同分组内已完成转入、证书已签发（默认 AWS 账号下）、未被重定向和下载包使用、未下线且未被判定为疑似拦截的域名。
+// 轮换时把规则的源域名换成下一个备用域名（上传 HTML、创建 DNS 记录、切换 CloudFront 别名/证书/源路径），
+// 旧域名标记为下线并在备注中记录原因，不会再进入备用池。
+type DomainRotationService struct {
+	db          *gorm.DB
+	redirectSvc *RedirectService
+	domainSvc   *DomainService
+	telegram    *TelegramService
+	cloudFront  rotationCloudFront // 重定向规则的 CloudFront 分发所在的默认账号客户端
+}
+
+// rotationCloudFront 源域名轮换用到的 CloudFront 操作，由 *aws.CloudFrontService 实现，测试中替换为内存实现
+type rotationCloudFront interface {
+	GetDistribution(distributionID string) (*cloudfront.Distribution, error)
+	UpdateDistribution(distributionID string, aliases []string, certificateARN string, enabled *bool) error
+	UpdateDistributionOriginPath(distributionID string, originPath string) error
+}
+
+// NewDomainRotationService 创建域名轮换服务
+func NewDomainRotationService(db *gorm.DB, redirectSvc *RedirectService, domainSvc *DomainService, telegram *TelegramService) *DomainRotationService {
+	return &DomainRotationService{
+		db:          db,
+		redirectSvc: redirectSvc,
+		domainSvc:   domainSvc,
+		telegram:    telegram,
+		cloudFront:  redirectSvc.cloudFrontSvc,
+	}
+}
+
+// DomainRotationResult 一次源域名轮换的结果
+type DomainRotationResult struct {
+	RuleID    uint     `json:"rule_id"`
+	OldDomain string   `json:"old_domain"`
+	NewDomain string   `json:"new_domain"`
+	Reason    string   `json:"reason"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// ListSpareDomains 列出分组的备用域名（groupID 为空时列出所有分组），按 ID 升序，第一个即为下一个轮换目标
+func (s *DomainRotationService) ListSpareDomains(groupID *uint) ([]models.Domain, error) {
+	query := s.db.Where("status = ? AND certificate_status = ? AND certificate_arn <> ''", models.DomainStatusCompleted, "issued").
+		Where("retired_at IS NULL").
+		Where("aws_account_id IS NULL"). // 重定向的 CloudFront 分发在默认账号下，只能使用同账号的证书
+		Where("reputation IS NULL OR reputation <> ?", models.DomainReputationSuspectedBlocked).
+		Where("domain_name NOT IN (?)", s.db.Model(&models.RedirectRule{}).Select("source_domain")).
+		Where("domain_name NOT IN (?)", s.db.Model(&models.DownloadPackage{}).Select("domain_name"))
+	if groupID != nil {
+		query = query.Where("group_id = ?", *groupID)
+	}
+
+	var spares []models.Domain
+	if err := query.Order("id ASC").Find(&spares).Error; err != nil {
+		return nil, err
+	}
+	return spares, nil
+}
+
+// RotateSourceDomain 将规则的源域名换成备用域名
+// newDomain 为空时使用规则所在分组的下一个备用域名，否则必须是该分组备用池中的域名
+func (s *DomainRotationService) RotateSourceDomain(ruleID uint, newDomain, reason, operator string) (*DomainRotationResult, error) {
+	log := logger.GetLogger()
+	rule, err := s.redirectSvc.GetRedirectRule(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.CloudFrontID == "" {
+		return nil, fmt.Errorf("规则未绑定 CloudFront 分发，无法轮换源域名")
+	}
+	if reason == "" {
+		reason = "手动轮换"
+	}
+
+	spare, err := s.pickSpare(rule, newDomain)
+	if err != nil {
+		return nil, err
+	}
+	// source_domain 有唯一索引，软删除的规则仍会占用域名
+	var occupied int64
+	if err := s.db.Unscoped().Model(&models.RedirectRule{}).Where("source_domain = ?", spare.DomainName).Count(&occupied).Error; err != nil {
+		return nil, err
+	}
+	if occupied > 0 {
+		return nil, fmt.Errorf("域名 %s 仍被已删除的重定向规则占用", spare.DomainName)
+	}
+
+	oldDomain := rule.SourceDomain
+	result := &DomainRotationResult{RuleID: rule.ID, OldDomain: oldDomain, NewDomain: spare.DomainName, Reason: reason}
+	note := fmt.Sprintf("轮换源域名 %s → %s（%s）", oldDomain, spare.DomainName, reason)
+
+	// 先把 HTML 上传到新域名的目录，旧目录保留以便回退
+	rotated := *rule
+	rotated.SourceDomain = spare.DomainName
+	if err := s.redirectSvc.uploadHTMLOnly(&rotated, operator, note); err != nil {
+		return nil, fmt.Errorf("上传新域名的 HTML 失败: %w", err)
+	}
+
+	if err := s.switchSourceDomain(rule, spare); err != nil {
+		return nil, err
+	}
+
+	if err := s.redirectSvc.invalidateCloudFrontCache(rule.CloudFrontID); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("刷新 CloudFront 缓存失败: %v", err))
+	}
+	if err := s.retireDomain(oldDomain, rule.ID, spare.DomainName, reason); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("标记旧域名下线失败: %v", err))
+	}
+
+	log.WithFields(map[string]interface{}{
+		"rule_id":    rule.ID,
+		"old_domain": oldDomain,
+		"new_domain": spare.DomainName,
+		"reason":     reason,
+		"warnings":   result.Warnings,
+	}).Info("重定向源域名已轮换")
+	s.notify(result)
+	return result, nil
+}
+
+// switchSourceDomain 为备用域名创建并确认 DNS 记录，切换 CloudFront 别名、证书和源路径，最后更新规则的源域名
+// DNS 记录未就绪时不修改 CloudFront；切换源路径或更新规则失败时回退分发到切换前的别名、证书和源路径
+func (s *DomainRotationService) switchSourceDomain(rule *models.RedirectRule, spare *models.Domain) error {
+	dist, err := s.cloudFront.GetDistribution(rule.CloudFrontID)
+	if err != nil {
+		return fmt.Errorf("获取 CloudFront 分发信息失败: %w", err)
+	}
+	previous, err := distributionBinding(dist)
+	if err != nil {
+		return err
+	}
+
+	// 先创建并确认新域名的 DNS 记录，记录未就绪时不切换 CloudFront，避免切换后新域名无法解析
+	if err := s.ensureSpareRecord(spare, previous.domainName); err != nil {
+		return fmt.Errorf("备用域名 DNS 记录未就绪: %w", err)
+	}
+
+	// 旧证书不覆盖新域名，别名和证书一起切换
+	if err := s.cloudFront.UpdateDistribution(rule.CloudFrontID, []string{spare.DomainName}, spare.CertificateARN, nil); err != nil {
+		return fmt.Errorf("更新 CloudFront 别名和证书失败: %w", err)
+	}
+	if err := s.cloudFront.UpdateDistributionOriginPath(rule.CloudFrontID, "redirects/"+spare.DomainName); err != nil {
+		s.rollbackCloudFront(rule, previous, false)
+		return fmt.Errorf("更新 CloudFront 源路径失败: %w", err)
+	}
+
+	if err := s.db.Model(&models.RedirectRule{}).Where("id = ?", rule.ID).Update("source_domain", spare.DomainName).Error; err != nil {
+		s.rollbackCloudFront(rule, previous, true)
+		return fmt.Errorf("更新规则源域名失败: %w", err)
+	}
+	return nil
+}
+
+// cloudFrontBinding 分发当前绑定的别名、证书和源路径
+type cloudFrontBinding struct {
+	domainName     string // 分发的 CloudFront 域名（xxx.cloudfront.net）
+	aliases        []string
+	certificateARN string
+	originPath     string
+}
+
+// distributionBinding 从分发配置中读取别名、证书和第一个源的源路径
+func distributionBinding(dist *cloudfront.Distribution) (*cloudFrontBinding, error) {
+	if dist == nil || dist.DomainName == nil || dist.DistributionConfig == nil {
+		return nil, fmt.Errorf("CloudFront 分发没有域名信息")
+	}
+	config := dist.DistributionConfig
+	binding := &cloudFrontBinding{domainName: *dist.DomainName, aliases: []string{}}
+	if config.Aliases != nil {
+		binding.aliases = awsSDK.StringValueSlice(config.Aliases.Items)
+	}
+	if config.ViewerCertificate != nil {
+		binding.certificateARN = awsSDK.StringValue(config.ViewerCertificate.ACMCertificateArn)
+	}
+	if config.Origins != nil {
+		for _, origin := range config.Origins.Items {
+			if origin != nil && origin.OriginPath != nil {
+				binding.originPath = *origin.OriginPath
+				break
+			}
+		}
+	}
+	return binding, nil
+}
+
+// ensureSpareRecord 为备用域名创建指向 CloudFront 分发的 DNS 记录，并重新查询确认记录已生效
+func (s *DomainRotationService) ensureSpareRecord(spare *models.Domain, cloudFrontDomain string) error {
+	if err := s.domainSvc.CreateCloudFrontCNAMERecord(spare, cloudFrontDomain); err != nil {
+		return err
+	}
+	exists, err := s.domainSvc.CheckCloudFrontCNAMERecord(spare, cloudFrontDomain)
+	if err != nil {
+		return fmt.Errorf("校验 DNS 记录失败: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("DNS 记录未指向 %s", cloudFrontDomain)
+	}
+	if err := s.domainSvc.CreateWWWCNAMERecord(spare); err != nil {
+		// www 记录不是必需的，不阻止轮换
+		logger.GetLogger().WithError(err).WithField("domain_name", spare.DomainName).Warn("创建 www CNAME 记录失败")
+	}
+	return nil
+}
+
+// rollbackCloudFront 回退 CloudFront 别名和证书；restoreOriginPath 为 true 时同时回退源路径
+func (s *DomainRotationService) rollbackCloudFront(rule *models.RedirectRule, previous *cloudFrontBinding, restoreOriginPath bool) {
+	log := logger.GetLogger()
+	if restoreOriginPath {
+		if err := s.cloudFront.UpdateDistributionOriginPath(rule.CloudFrontID, previous.originPath); err != nil {
+			log.WithError(err).WithField("rule_id", rule.ID).Error("回退 CloudFront 源路径失败")
+		}
+	}
+	if err := s.cloudFront.UpdateDistribution(rule.CloudFrontID, previous.aliases, previous.certificateARN, nil); err != nil {
+		log.WithError(err).WithField("rule_id", rule.ID).Error("回退 CloudFront 别名和证书失败")
+	}
+}
+
+// RotateBlockedSources 轮换所有源域名被判定为疑似拦截的规则（定时任务入口）
+func (s *DomainRotationService) RotateBlockedSources() error {
+	log := logger.GetLogger()
+
+	var rules []models.RedirectRule
+	if err := s.db.Where("cloudfront_id <> ''").
+		Where("source_domain IN (?)", s.db.Model(&models.Domain{}).Select("domain_name").Where("reputation = ?", models.DomainReputationSuspectedBlocked)).
+		Find(&rules).Error; err != nil {
+		return fmt.Errorf("查询需要轮换的规则失败: %w", err)
+	}
+
+	for _, rule := range rules {
+		if _, err := s.RotateSourceDomain(rule.ID, "", "信誉检测疑似被拦截", "system"); err != nil {
+			log.WithError(err).WithFields(map[string]interface{}{
+				"rule_id":       rule.ID,
+				"source_domain": rule.SourceDomain,
+			}).Warn("自动轮换源域名失败")
+		}
+	}
+	return nil
+}
+
+// pickSpare 从规则所在分组的备用池中选择域名
+func (s *DomainRotationService) pickSpare(rule *models.RedirectRule, domainName string) (*models.Domain, error) {
+	spares, err := s.ListSpareDomains(rule.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if domainName == "" {
+		if len(spares) == 0 {
+			return nil, fmt.Errorf("规则所在分组没有可用的备用域名")
+		}
+		return &spares[0], nil
+	}
+	for i := range spares {
+		if strings.EqualFold(spares[i].DomainName, domainName) {
+			return &spares[i], nil
+		}
+	}
+	return nil, fmt.Errorf("域名 %s 不在规则所在分组的备用域名池中", domainName)
+}
+
+// retireDomain 标记旧源域名下线并在备注中追加记录
+func (s *DomainRotationService) retireDomain(domainName string, ruleID uint, replacement, reason string) error {
+	var domain models.Domain
+	if err := s.db.Where("domain_name = ?", domainName).First(&domain).Error; err != nil {
+		// 旧源域名不在域名表中（外部托管），无需处理
+		return nil
+	}
+
+	now := time.Now()
+	line := fmt.Sprintf("[%s] 从重定向规则 #%d 轮换下线（%s），由 %s 替换", now.Format("2006-01-02 15:04"), ruleID, reason, replacement)
+	note := line
+	if domain.Note != "" {
+		note = domain.Note + "\n" + line
+	}
+	return s.db.Model(&models.Domain{}).Where("id = ?", domain.ID).Updates(map[string]interface{}{
+		"retired_at": now,
+		"note":       note,
+	}).Error
+}
+
+// notify 通过 Telegram 推送轮换结果
+func (s *DomainRotationService) notify(result *DomainRotationResult) {
+	if s.telegram == nil {
+		return
+	}
+
+	var message strings.Builder
+	if s.telegram.GetSitename() != "" {
+		message.WriteString(fmt.Sprintf("[%s] ", s.telegram.GetSitename()))
+	}
+	message.WriteString(fmt.Sprintf("🔄 重定向源域名已轮换\n\n规则: #%d\n%s → %s\n原因: %s\n", result.RuleID, result.OldDomain, result.NewDomain, result.Reason))
+	for _, warning := range result.Warnings {
+		message.WriteString("⚠️ " + warning + "\n")
+	}
+
+	if err := s.telegram.SendMessage(message.String()); err != nil {
+		logger.GetLogger().WithError(err).WithField("rule_id", result.RuleID).Warn("发送源域名轮换通知失败")
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"errors"
+	"strings"
+	"testing"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"gorm.io/gorm"
+)
+
+// fakeRotationCloudFront 内存中的单个分发，failOriginPath 非空时切换到该源路径失败
+type fakeRotationCloudFront struct {
+	aliases        []string
+	certificateARN string
+	originPath     string
+	failOriginPath string
+}
+
+func (f *fakeRotationCloudFront) GetDistribution(distributionID string) (*cloudfront.Distribution, error) {
+	return &cloudfront.Distribution{
+		Id:         awsSDK.String(distributionID),
+		DomainName: awsSDK.String("d111.cloudfront.net"),
+		DistributionConfig: &cloudfront.DistributionConfig{
+			Aliases:           &cloudfront.Aliases{Items: awsSDK.StringSlice(f.aliases)},
+			ViewerCertificate: &cloudfront.ViewerCertificate{ACMCertificateArn: awsSDK.String(f.certificateARN)},
+			Origins:           &cloudfront.Origins{Items: []*cloudfront.Origin{{OriginPath: awsSDK.String(f.originPath)}}},
+		},
+	}, nil
+}
+
+func (f *fakeRotationCloudFront) UpdateDistribution(distributionID string, aliases []string, certificateARN string, enabled *bool) error {
+	if aliases != nil {
+		f.aliases = aliases
+	}
+	if certificateARN != "" {
+		f.certificateARN = certificateARN
+	}
+	return nil
+}
+
+func (f *fakeRotationCloudFront) UpdateDistributionOriginPath(distributionID string, originPath string) error {
+	path := "/" + strings.TrimPrefix(originPath, "/")
+	if path == f.failOriginPath {
+		return errors.New("PreconditionFailed")
+	}
+	f.originPath = path
+	return nil
+}
+
+// unpropagatedDNSProvider 写入成功但查询不到记录，模拟 DNS 记录未生效
+type unpropagatedDNSProvider struct {
+	*FakeDNSProvider
+}
+
+func (p unpropagatedDNSProvider) UpdateRecord(zoneID string, record DNSRecord) error {
+	return nil
+}
+
+// 切换源域名：DNS 记录就绪后切换别名、证书和源路径；DNS 未就绪时不修改分发，切换源路径或更新规则失败时回退到切换前的状态
+func TestSwitchSourceDomain(t *testing.T) {
+	cases := []struct {
+		name           string
+		dnsReady       bool
+		failOriginPath bool
+		failDB         bool
+		wantErr        string
+		wantSwitched   bool
+	}{
+		{name: "success", dnsReady: true, wantSwitched: true},
+		{name: "dns not ready", wantErr: "DNS 记录未就绪"},
+		{name: "origin path fails", dnsReady: true, failOriginPath: true, wantErr: "源路径"},
+		{name: "db update fails", dnsReady: true, failDB: true, wantErr: "更新规则源域名失败"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := NewFakeDNSProvider()
+			var provider DNSProvider = fake
+			if !tc.dnsReady {
+				provider = unpropagatedDNSProvider{fake}
+			}
+			cf := &fakeRotationCloudFront{aliases: []string{"old.com"}, certificateARN: "arn:old", originPath: "/redirects/old.com"}
+			if tc.failOriginPath {
+				cf.failOriginPath = "/redirects/new.com"
+			}
+			db := newDryRunDB(t)
+			if tc.failDB {
+				if err := db.Callback().Update().Before("gorm:update").Register("test:fail_update", func(tx *gorm.DB) {
+					tx.AddError(errors.New("connection refused"))
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			domainSvc := &DomainService{db: db}
+			domainSvc.dnsProviderFactory = func(*models.Domain) (DNSProvider, error) { return provider, nil }
+			svc := &DomainRotationService{db: db, domainSvc: domainSvc, cloudFront: cf}
+
+			rule := &models.RedirectRule{ID: 1, SourceDomain: "old.com", CloudFrontID: "E1"}
+			spare := &models.Domain{ID: 2, DomainName: "new.com", HostedZoneID: "Z2", CertificateARN: "arn:new"}
+			err := svc.switchSourceDomain(rule, spare)
+			if tc.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+
+			want := fakeRotationCloudFront{aliases: []string{"old.com"}, certificateARN: "arn:old", originPath: "/redirects/old.com"}
+			if tc.wantSwitched {
+				want = fakeRotationCloudFront{aliases: []string{"new.com"}, certificateARN: "arn:new", originPath: "/redirects/new.com"}
+				if ok, _ := fake.CheckRecord("Z2", fake.CloudFrontRecord("new.com", "d111.cloudfront.net")); !ok {
+					t.Fatal("备用域名应指向分发")
+				}
+			}
+			if strings.Join(cf.aliases, ",") != strings.Join(want.aliases, ",") || cf.certificateARN != want.certificateARN || cf.originPath != want.originPath {
+				t.Fatalf("distribution = %+v, want %+v", *cf, want)
+			}
+		})
+	}
+}
@@ -22,10 +22,17 @@ type DomainService struct {
 	s3Svc            *aws.S3Service
 	cloudflareSvc    *cloudflare.CloudflareService
 	cfAccountService *CFAccountService
+
+	// dnsProviderFactory 根据域名选择 DNS 提供商，测试中可替换为 FakeDNSProvider
+	dnsProviderFactory func(domain *models.Domain) (DNSProvider, error)
+	// nsResolver 查询公网 NS 委派，测试中可替换为 FakeNSResolver
+	nsResolver NSResolver
+	// awsClients 按域名关联的 AWS 账号创建客户端，默认账号的客户端也从这里获取
+	awsClients *AWSClientFactory
 }
 
 func NewDomainService(db *gorm.DB, route53Svc *aws.Route53Service, acmSvc *aws.ACMService, cloudFrontSvc *aws.CloudFrontService, s3Svc *aws.S3Service, cloudflareSvc *cloudflare.CloudflareService, cfAccountService *CFAccountService) *DomainService {
-	return &DomainService{
+	s := &DomainService{
 		db:               db,
 		route53Svc:       route53Svc,
 		acmSvc:           acmSvc,
@@ -34,6 +41,72 @@ func NewDomainService(db *gorm.DB, route53Svc *aws.Route53Service, acmSvc *aws.A
 		cloudflareSvc:    cloudflareSvc,
 		cfAccountService: cfAccountService,
 	}
+	s.dnsProviderFactory = s.defaultDNSProvider
+	s.nsResolver = NewUpstreamNSResolver(nil)
+	return s
+}
+
+// SetAWSClientFactory 设置多 AWS 账号的客户端工厂
+func (s *DomainService) SetAWSClientFactory(factory *AWSClientFactory) {
+	s.awsClients = factory
+}
+
+// AWSClientsForAccount 获取 AWS 账号的客户端，accountID 为空时返回默认账号的客户端
+func (s *DomainService) AWSClientsForAccount(accountID *uint) (*AWSClients, error) {
+	if s.awsClients == nil {
+		return nil, fmt.Errorf("AWS 客户端工厂未初始化")
+	}
+	return s.awsClients.ForAccount(accountID)
+}
+
+// awsClientsForDomain 获取域名所在 AWS 账号的客户端
+func (s *DomainService) awsClientsForDomain(domain *models.Domain) (*AWSClients, error) {
+	return s.AWSClientsForAccount(domain.AWSAccountID)
+}
+
+// acmForDomain 获取域名所在 AWS 账号的 ACM 客户端
+func (s *DomainService) acmForDomain(domain *models.Domain) (*aws.ACMService, error) {
+	clients, err := s.awsClientsForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if clients.ACM == nil {
+		return nil, fmt.Errorf("ACM 服务未初始化")
+	}
+	return clients.ACM, nil
+}
+
+// route53ForDomain 获取域名所在 AWS 账号的 Route 53 客户端
+func (s *DomainService) route53ForDomain(domain *models.Domain) (*aws.Route53Service, error) {
+	clients, err := s.awsClientsForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if clients.Route53 == nil {
+		return nil, fmt.Errorf("Route 53 服务未初始化")
+	}
+	return clients.Route53, nil
+}
+
+// DNSProviderForDomain 获取域名所在的 DNS 提供商
+func (s *DomainService) DNSProviderForDomain(domain *models.Domain) (DNSProvider, error) {
+	return s.dnsProviderFactory(domain)
+}
+
+// defaultDNSProvider 按域名的 DNSProvider 字段创建 Route 53 或 Cloudflare 实现
+func (s *DomainService) defaultDNSProvider(domain *models.Domain) (DNSProvider, error) {
+	if domain.DNSProvider == models.DNSProviderCloudflare {
+		cloudflareSvc, err := s.createCloudflareService(domain.CFAccountID)
+		if err != nil {
+			return nil, fmt.Errorf("创建 CloudflareService 失败: %w", err)
+		}
+		return NewCloudflareDNSProvider(cloudflareSvc), nil
+	}
+	route53Svc, err := s.route53ForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	return NewRoute53DNSProvider(route53Svc), nil
 }
 
 // createCloudflareService 根据 CF 账号 ID 创建 CloudflareService
@@ -85,7 +158,8 @@ func (s *DomainService) GetCloudflareServiceForDomain(domain *models.Domain) (*c
 }
 
 // TransferDomain 转入域名到 AWS 或 Cloudflare
-func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider models.DNSProvider, cfAccountID *uint, groupID *uint) (*models.Domain, error) {
+// awsAccountID 指定证书和 Route 53 托管区域所在的 AWS 账号，为空时使用默认账号
+func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider models.DNSProvider, cfAccountID *uint, awsAccountID *uint, groupID *uint) (*models.Domain, error) {
 	log := logger.GetLogger()
 	log.WithFields(map[string]interface{}{
 		"domain_name":  domainName,
@@ -103,6 +177,11 @@ func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider
 		return nil, fmt.Errorf("域名 %s 已存在", domainName)
 	}
 
+	awsClients, err := s.AWSClientsForAccount(awsAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+	}
+
 	var hostedZoneID string
 	var nsServers []string
 	var nsServersJSON string
@@ -127,8 +206,16 @@ func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider
 			return nil, fmt.Errorf("获取Cloudflare Zone ID失败: %w", err)
 		}
 		hostedZoneID = zoneID
-		nsServers = []string{} // Cloudflare不需要NS服务器配置
-		nsServersJSON = "[]"
+		// 记录 Zone 分配的 NS，用于检查注册商处的 NS 委派；获取失败时由委派检查补齐
+		nsServers, err = cloudflareSvc.GetZoneNameServers(zoneID)
+		if err != nil {
+			log.WithError(err).WithField("domain_name", domainName).Warn("获取Cloudflare Zone NS服务器失败")
+			nsServers = []string{}
+		}
+		nsServersJSON, err = aws.FormatNServersJSON(nsServers)
+		if err != nil {
+			return nil, fmt.Errorf("格式化 NS 服务器失败: %w", err)
+		}
 		log.WithFields(map[string]interface{}{
 			"domain_name":    domainName,
 			"hosted_zone_id": hostedZoneID,
@@ -137,7 +224,7 @@ func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider
 		// AWS Route53: 创建托管区域
 		log.WithField("domain_name", domainName).Info("开始创建Route53托管区域")
 		var err error
-		hostedZoneID, nsServers, err = s.route53Svc.CreateHostedZone(domainName)
+		hostedZoneID, nsServers, err = awsClients.Route53.CreateHostedZone(domainName)
 		if err != nil {
 			log.WithError(err).WithField("domain_name", domainName).Error("创建托管区域失败")
 			return nil, fmt.Errorf("创建托管区域失败: %w", err)
@@ -176,8 +263,9 @@ func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider
 		Registrar:    registrar, // 保留字段以保持向后兼容，但不再强制要求
 		GroupID:      finalGroupID,
 		CFAccountID:  cfAccountID, // 关联的 CF 账号 ID
+		AWSAccountID: awsAccountID,
 		DNSProvider:  dnsProvider,
-		Status:       models.DomainStatusCompleted,
+		Status:       models.DomainStatusPending, // NS 委派检查通过后改为 completed
 		NServers:     nsServersJSON,
 		HostedZoneID: hostedZoneID,
 	}
@@ -197,14 +285,13 @@ func (s *DomainService) TransferDomain(domainName, registrar string, dnsProvider
 		"dns_provider":   dnsProvider,
 	}).Info("域名记录创建成功")
 
-	// 所有DNS提供商都使用AWS Certificate Manager请求证书
-	log.WithFields(map[string]interface{}{
-		"domain_id":    domain.ID,
-		"domain_name":  domainName,
-		"dns_provider": dnsProvider,
-	}).Info("开始异步请求证书")
-	// 异步请求证书（证书验证不影响域名转入状态）
-	go s.requestCertificateAsync(domain)
+	// 证书在 NS 委派生效后由委派检查自动申请（DNS 验证记录需要公网可解析）
+	// 注册商处的 NS 可能在转入前就已指向目标，这里先检查一次
+	go func(domain models.Domain) {
+		if _, err := s.CheckNSDelegation(&domain); err != nil {
+			log.WithError(err).WithField("domain_name", domain.DomainName).Warn("NS 委派检查失败")
+		}
+	}(*domain)
 
 	return domain, nil
 }
@@ -218,6 +305,12 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 		"domain_name":  domain.DomainName,
 		"dns_provider": domain.DNSProvider,
 	}).Info("开始异步请求证书")
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		log.WithError(err).WithField("domain_name", domain.DomainName).Error("获取 ACM 客户端失败")
+		s.db.Model(domain).Update("certificate_status", "failed")
+		return
+	}
 	rootDomain := extractRootDomain(domain.DomainName)
 
 	requestCetificateDomain := ""
@@ -237,7 +330,7 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 	var certificateARN string
 	var isNewCertificate bool
 
-	existingCertARN, found, err := s.acmSvc.FindCertificateByDomain(requestCetificateDomain)
+	existingCertARN, found, err := acmSvc.FindCertificateByDomain(requestCetificateDomain)
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_id":   domain.ID,
@@ -263,7 +356,7 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 		})
 
 		// 检查证书状态
-		status, err := s.acmSvc.GetCertificateStatus(existingCertARN)
+		status, err := acmSvc.GetCertificateStatus(existingCertARN)
 		if err == nil {
 			s.db.Model(domain).Update("certificate_status", status)
 
@@ -280,7 +373,7 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 	} else {
 		// 没有找到现有证书，创建新证书
 		var err error
-		certificateARN, err = s.acmSvc.RequestCertificate(requestCetificateDomain)
+		certificateARN, err = acmSvc.RequestCertificate(requestCetificateDomain)
 		if err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"domain_id":   domain.ID,
@@ -311,7 +404,7 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 		var validationRecords []aws.CertificateValidationRecord
 		maxRetries := 10
 		for i := 0; i < maxRetries; i++ {
-			records, err := s.acmSvc.GetCertificateValidationRecords(certificateARN)
+			records, err := acmSvc.GetCertificateValidationRecords(certificateARN)
 			if err == nil && len(records) > 0 {
 				validationRecords = records
 				break
@@ -341,115 +434,62 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 			skippedCount := 0
 
 			// 将验证记录添加到 DNS 提供商
-			// 如果是 Cloudflare，先创建 CloudflareService
-			var cloudflareSvc *cloudflare.CloudflareService
-			if domain.DNSProvider == models.DNSProviderCloudflare {
-				var err error
-				cloudflareSvc, err = s.createCloudflareService(domain.CFAccountID)
-				if err != nil {
-					log.WithError(err).WithFields(map[string]interface{}{
-						"domain_id":     domain.ID,
-						"cf_account_id": domain.CFAccountID,
-					}).Error("创建 CloudflareService 失败")
-					// 继续执行，但会在后续操作中失败
-				}
+			provider, providerErr := s.DNSProviderForDomain(domain)
+			if providerErr != nil {
+				log.WithError(providerErr).WithFields(map[string]interface{}{
+					"domain_id":     domain.ID,
+					"cf_account_id": domain.CFAccountID,
+					"dns_provider":  domain.DNSProvider,
+				}).Error("创建 DNS 提供商失败")
+				// 继续执行，但会在后续操作中失败
 			}
 
 			for _, record := range validationRecords {
-				if record.Type == "CNAME" {
-					var err error
-					recordName := record.Name
-					var exists bool
-					var checkErr error
-
-					if domain.DNSProvider == models.DNSProviderCloudflare {
-						// 对于 Cloudflare，如果记录名称是完整域名，提取相对域名部分
-						// AWS ACM 返回的格式可能是：_abc123.example.com. 或 _abc123.example.com
-						// Cloudflare 需要：_abc123（相对于根域名）
-						recordName = extractRelativeDomainName(record.Name, domain.DomainName)
-
-						// 先检查记录是否已存在
-						if cloudflareSvc != nil {
-							exists, checkErr = cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, recordName, record.Value)
-						} else {
-							checkErr = fmt.Errorf("CloudflareService 未创建")
-						}
-						if checkErr != nil {
-							log.WithError(checkErr).WithFields(map[string]interface{}{
-								"domain_id":    domain.ID,
-								"record_name":  record.Name,
-								"dns_provider": domain.DNSProvider,
-							}).Warn("检查验证记录是否存在时出错，将尝试创建")
-						}
-
-						if exists {
-							skippedCount++
-							log.WithFields(map[string]interface{}{
-								"domain_id":     domain.ID,
-								"record_name":   record.Name,
-								"relative_name": recordName,
-								"record_value":  record.Value,
-								"dns_provider":  domain.DNSProvider,
-							}).Info("CNAME验证记录已存在，跳过创建")
-							continue
-						}
-
-						log.WithFields(map[string]interface{}{
-							"domain_id":     domain.ID,
-							"original_name": record.Name,
-							"relative_name": recordName,
-							"record_value":  record.Value,
-							"zone_id":       domain.HostedZoneID,
-							"root_domain":   domain.DomainName,
-						}).Info("添加验证记录到Cloudflare")
-
-						if cloudflareSvc != nil {
-							err = cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, recordName, record.Value)
-						} else {
-							err = fmt.Errorf("CloudflareService 未创建")
-						}
-					} else {
-						// 先检查记录是否已存在
-						exists, checkErr = s.route53Svc.CheckCertificateValidationCNAME(domain.HostedZoneID, record.Name, record.Value)
-						if checkErr != nil {
-							log.WithError(checkErr).WithFields(map[string]interface{}{
-								"domain_id":    domain.ID,
-								"record_name":  record.Name,
-								"dns_provider": domain.DNSProvider,
-							}).Warn("检查验证记录是否存在时出错，将尝试创建")
-						}
+				if record.Type != "CNAME" {
+					continue
+				}
+				dnsRecord := certificateValidationDNSRecord(record)
+				if provider == nil {
+					failedCount++
+					continue
+				}
 
-						if exists {
-							skippedCount++
-							log.WithFields(map[string]interface{}{
-								"domain_id":    domain.ID,
-								"record_name":  record.Name,
-								"record_value": record.Value,
-								"dns_provider": domain.DNSProvider,
-							}).Info("CNAME验证记录已存在，跳过创建")
-							continue
-						}
+				// 先检查记录是否已存在
+				exists, checkErr := provider.CheckRecord(domain.HostedZoneID, dnsRecord)
+				if checkErr != nil {
+					log.WithError(checkErr).WithFields(map[string]interface{}{
+						"domain_id":    domain.ID,
+						"record_name":  record.Name,
+						"dns_provider": domain.DNSProvider,
+					}).Warn("检查验证记录是否存在时出错，将尝试创建")
+				}
+				if exists {
+					skippedCount++
+					log.WithFields(map[string]interface{}{
+						"domain_id":    domain.ID,
+						"record_name":  record.Name,
+						"record_value": record.Value,
+						"dns_provider": domain.DNSProvider,
+					}).Info("CNAME验证记录已存在，跳过创建")
+					continue
+				}
 
-						err = s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-					}
-					if err != nil {
-						failedCount++
-						log.WithError(err).WithFields(map[string]interface{}{
-							"domain_id":     domain.ID,
-							"record_name":   recordName,
-							"original_name": record.Name,
-							"record_value":  record.Value,
-							"dns_provider":  domain.DNSProvider,
-						}).Error("添加验证记录失败")
-					} else {
-						successCount++
-						log.WithFields(map[string]interface{}{
-							"domain_id":    domain.ID,
-							"record_name":  recordName,
-							"record_value": record.Value,
-							"dns_provider": domain.DNSProvider,
-						}).Info("验证记录添加成功")
-					}
+				if err := provider.UpdateRecord(domain.HostedZoneID, dnsRecord); err != nil {
+					failedCount++
+					log.WithError(err).WithFields(map[string]interface{}{
+						"domain_id":    domain.ID,
+						"record_name":  record.Name,
+						"record_value": record.Value,
+						"dns_provider": domain.DNSProvider,
+					}).Error("添加验证记录失败")
+				} else {
+					successCount++
+					log.WithFields(map[string]interface{}{
+						"domain_id":    domain.ID,
+						"record_name":  record.Name,
+						"record_value": record.Value,
+						"dns_provider": domain.DNSProvider,
+					}).Info("验证记录添加成功")
 				}
 			}
 
@@ -488,7 +528,7 @@ func (s *DomainService) requestCertificateAsync(domain *models.Domain) {
 		"certificate_arn": certificateARN,
 		"timeout":         "1小时",
 	}).Info("开始等待证书验证")
-	if err := s.acmSvc.WaitForCertificateValidation(certificateARN, 1*time.Hour); err != nil {
+	if err := acmSvc.WaitForCertificateValidation(certificateARN, 1*time.Hour); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_id":       domain.ID,
 			"certificate_arn": certificateARN,
@@ -558,7 +598,8 @@ func (s *DomainService) CheckDomainUsage(domainName string) (usedByRedirect bool
 
 // ListDomains 列出所有域名，支持按分组筛选和搜索
 // usedStatus: nil 表示全部, "used" 表示已使用, "unused" 表示未使用
-func (s *DomainService) ListDomains(page, pageSize int, groupID *uint, search *string, cfAccountID *uint, usedStatus *string) ([]DomainWithUsage, int64, error) {
+// reputation: nil 表示全部, 否则为 ok、warning、suspected_blocked、unknown，"unchecked" 表示尚未检测
+func (s *DomainService) ListDomains(page, pageSize int, groupID *uint, search *string, cfAccountID *uint, usedStatus *string, reputation *string) ([]DomainWithUsage, int64, error) {
 	var domains []models.Domain
 	var total int64
 
@@ -608,6 +649,15 @@ func (s *DomainService) ListDomains(page, pageSize int, groupID *uint, search *s
 		}
 	}
 
+	// 信誉筛选
+	if reputation != nil {
+		if *reputation == "unchecked" {
+			query = query.Where("domains.reputation IS NULL OR domains.reputation = ''")
+		} else {
+			query = query.Where("domains.reputation = ?", *reputation)
+		}
+	}
+
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -640,8 +690,9 @@ func (s *DomainService) ListDomains(page, pageSize int, groupID *uint, search *s
 
 				// 并发查询证书状态
 				var certStatus string
-				if domain.CertificateARN != "" {
-					status, err := s.acmSvc.GetCertificateStatus(domain.CertificateARN)
+				acmSvc, acmErr := s.acmForDomain(&domain)
+				if domain.CertificateARN != "" && acmErr == nil {
+					status, err := acmSvc.GetCertificateStatus(domain.CertificateARN)
 					if err == nil {
 						certStatus = status
 						// 更新证书状态（如果状态有变化，也更新数据库）
@@ -762,16 +813,23 @@ func (s *DomainService) GetNServers(id uint) ([]string, error) {
 		return nil, err
 	}
 
-	// Cloudflare 托管的域名不需要 NS 服务器配置
+	// Cloudflare 托管的域名使用转入时记录的 Zone NS
 	if domain.DNSProvider == models.DNSProviderCloudflare {
-		return []string{}, nil
+		if domain.NServers == "" {
+			return []string{}, nil
+		}
+		return aws.ParseNServersJSON(domain.NServers)
 	}
 
 	if domain.HostedZoneID == "" {
 		return nil, fmt.Errorf("域名尚未创建托管区域")
 	}
+	route53Svc, err := s.route53ForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
 
-	nsServers, err := s.route53Svc.GetNameServers(domain.HostedZoneID)
+	nsServers, err := route53Svc.GetNameServers(domain.HostedZoneID)
 	if err != nil {
 		// 如果获取失败，尝试从数据库解析
 		return aws.ParseNServersJSON(domain.NServers)
@@ -790,6 +848,10 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 		log.WithError(err).WithField("domain_id", id).Error("获取域名信息失败")
 		return err
 	}
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		return err
+	}
 
 	var certificateARN string
 	if domain.CertificateARN != "" {
@@ -800,7 +862,7 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 			"domain_name":     domain.DomainName,
 			"certificate_arn": certificateARN,
 		}).Info("证书已存在，检查状态")
-		status, err := s.acmSvc.GetCertificateStatus(certificateARN)
+		status, err := acmSvc.GetCertificateStatus(certificateARN)
 		if err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"domain_id":       id,
@@ -820,7 +882,7 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 			}
 
 			// 获取证书验证记录
-			validationRecords, err := s.acmSvc.GetCertificateValidationRecords(certificateARN)
+			validationRecords, err := acmSvc.GetCertificateValidationRecords(certificateARN)
 			if err != nil {
 				log.WithError(err).WithFields(map[string]interface{}{
 					"domain_id":       id,
@@ -842,18 +904,14 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 			skippedCount := 0
 			successCount := 0
 
-			// 如果是 Cloudflare，先创建 CloudflareService
-			var cloudflareSvc *cloudflare.CloudflareService
-			if domain.DNSProvider == models.DNSProviderCloudflare {
-				var err error
-				cloudflareSvc, err = s.createCloudflareService(domain.CFAccountID)
-				if err != nil {
-					log.WithError(err).WithFields(map[string]interface{}{
-						"domain_id":     id,
-						"cf_account_id": domain.CFAccountID,
-					}).Error("创建 CloudflareService 失败")
-					return fmt.Errorf("创建 CloudflareService 失败: %w", err)
-				}
+			provider, err := s.DNSProviderForDomain(domain)
+			if err != nil {
+				log.WithError(err).WithFields(map[string]interface{}{
+					"domain_id":     id,
+					"cf_account_id": domain.CFAccountID,
+					"dns_provider":  domain.DNSProvider,
+				}).Error("创建 DNS 提供商失败")
+				return err
 			}
 
 			// 将验证记录添加到 DNS 提供商
@@ -872,21 +930,8 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 					cnameCount++
 
 					// 先检查记录是否已存在
-					var exists bool
-					var checkErr error
-					recordName := record.Name
-
-					if domain.DNSProvider == models.DNSProviderCloudflare {
-						// 对于 Cloudflare，提取相对域名部分
-						recordName = extractRelativeDomainName(record.Name, domain.DomainName)
-						if cloudflareSvc != nil {
-							exists, checkErr = cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, recordName, record.Value)
-						} else {
-							checkErr = fmt.Errorf("CloudflareService 未创建")
-						}
-					} else {
-						exists, checkErr = s.route53Svc.CheckCertificateValidationCNAME(domain.HostedZoneID, record.Name, record.Value)
-					}
+					dnsRecord := certificateValidationDNSRecord(record)
+					exists, checkErr := provider.CheckRecord(domain.HostedZoneID, dnsRecord)
 
 					if checkErr != nil {
 						log.WithError(checkErr).WithFields(map[string]interface{}{
@@ -919,30 +964,7 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 						"hosted_zone_id": domain.HostedZoneID,
 					}).Info("开始添加CNAME验证记录")
 
-					var err error
-					if domain.DNSProvider == models.DNSProviderCloudflare {
-						log.WithFields(map[string]interface{}{
-							"domain_id":     id,
-							"record_name":   record.Name,
-							"relative_name": recordName,
-							"record_value":  record.Value,
-							"zone_id":       domain.HostedZoneID,
-						}).Info("调用Cloudflare API创建CNAME记录")
-						if cloudflareSvc != nil {
-							err = cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, recordName, record.Value)
-						} else {
-							err = fmt.Errorf("CloudflareService 未创建")
-						}
-					} else {
-						log.WithFields(map[string]interface{}{
-							"domain_id":      id,
-							"record_name":    record.Name,
-							"record_value":   record.Value,
-							"hosted_zone_id": domain.HostedZoneID,
-						}).Info("调用Route53 API创建CNAME记录")
-						err = s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-					}
-					if err != nil {
+					if err := provider.UpdateRecord(domain.HostedZoneID, dnsRecord); err != nil {
 						log.WithError(err).WithFields(map[string]interface{}{
 							"domain_id":      id,
 							"record_index":   i + 1,
@@ -1016,7 +1038,7 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 		"domain_id":   id,
 		"domain_name": domain.DomainName,
 	}).Info("证书不存在，开始创建新证书")
-	certificateARN, err = s.acmSvc.RequestCertificate(domain.DomainName)
+	certificateARN, err = acmSvc.RequestCertificate(domain.DomainName)
 	if err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_id":   id,
@@ -1044,40 +1066,26 @@ func (s *DomainService) GenerateCertificate(id uint) error {
 		// 等待一下让 AWS 生成验证记录
 		time.Sleep(2 * time.Second)
 
-		validationRecords, err := s.acmSvc.GetCertificateValidationRecords(certificateARN)
+		validationRecords, err := acmSvc.GetCertificateValidationRecords(certificateARN)
 		if err == nil && len(validationRecords) > 0 {
 			log.WithFields(map[string]interface{}{
 				"domain_id":    id,
 				"record_count": len(validationRecords),
 			}).Info("开始添加验证记录到DNS提供商")
-			// 如果是 Cloudflare，先创建 CloudflareService
-			var cloudflareSvc *cloudflare.CloudflareService
-			if domain.DNSProvider == models.DNSProviderCloudflare {
-				var err error
-				cloudflareSvc, err = s.createCloudflareService(domain.CFAccountID)
-				if err != nil {
-					log.WithError(err).WithFields(map[string]interface{}{
-						"domain_id":     id,
-						"cf_account_id": domain.CFAccountID,
-					}).Error("创建 CloudflareService 失败")
-					return fmt.Errorf("创建 CloudflareService 失败: %w", err)
-				}
+			provider, err := s.DNSProviderForDomain(domain)
+			if err != nil {
+				log.WithError(err).WithFields(map[string]interface{}{
+					"domain_id":     id,
+					"cf_account_id": domain.CFAccountID,
+					"dns_provider":  domain.DNSProvider,
+				}).Error("创建 DNS 提供商失败")
+				return err
 			}
 
 			// 将验证记录添加到 DNS 提供商
 			for _, record := range validationRecords {
 				if record.Type == "CNAME" {
-					var err error
-					if domain.DNSProvider == models.DNSProviderCloudflare {
-						if cloudflareSvc != nil {
-							err = cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-						} else {
-							err = fmt.Errorf("CloudflareService 未创建")
-						}
-					} else {
-						err = s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-					}
-					if err != nil {
+					if err := provider.UpdateRecord(domain.HostedZoneID, certificateValidationDNSRecord(record)); err != nil {
 						log.WithError(err).WithFields(map[string]interface{}{
 							"domain_id":    id,
 							"record_name":  record.Name,
@@ -1106,8 +1114,12 @@ func (s *DomainService) GetCertificateStatus(id uint) (string, error) {
 	if domain.CertificateARN == "" {
 		return "not_requested", nil
 	}
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		return domain.CertificateStatus, nil
+	}
 
-	status, err := s.acmSvc.GetCertificateStatus(domain.CertificateARN)
+	status, err := acmSvc.GetCertificateStatus(domain.CertificateARN)
 	if err != nil {
 		return domain.CertificateStatus, nil
 	}
@@ -1119,42 +1131,23 @@ func (s *DomainService) GetCertificateStatus(id uint) (string, error) {
 }
 
 // GetDomainStatus 获取域名转入状态
-// 如果 Hosted Zone 存在，则返回 completed（域名已成功转入）
+// 未完成的域名会立即检查一次 NS 委派，委派正确时返回 completed（并自动申请证书）
 func (s *DomainService) GetDomainStatus(id uint) (models.DomainStatus, error) {
 	domain, err := s.GetDomain(id)
 	if err != nil {
 		return "", err
 	}
 
-	// Cloudflare 托管的域名，如果 HostedZoneID 存在，说明已成功转入
-	if domain.DNSProvider == models.DNSProviderCloudflare {
-		if domain.HostedZoneID != "" {
-			if domain.Status != models.DomainStatusCompleted {
-				// 更新数据库中的状态
-				s.db.Model(domain).Update("status", models.DomainStatusCompleted)
-			}
-			return models.DomainStatusCompleted, nil
-		}
+	if domain.Status == models.DomainStatusCompleted || domain.HostedZoneID == "" {
 		return domain.Status, nil
 	}
 
-	// AWS 托管的域名：如果 Hosted Zone 存在，说明域名已成功转入，应该返回 completed
-	// 这样可以修复之前因为证书验证失败而状态未更新的问题
-	if domain.HostedZoneID != "" {
-		// 验证 Hosted Zone 是否真的存在
-		_, err := s.route53Svc.GetHostedZone(domain.HostedZoneID)
-		if err == nil {
-			// Hosted Zone 存在，域名已成功转入
-			if domain.Status != models.DomainStatusCompleted {
-				// 更新数据库中的状态
-				s.db.Model(domain).Update("status", models.DomainStatusCompleted)
-			}
-			return models.DomainStatusCompleted, nil
-		}
-		// 如果 Hosted Zone 不存在，可能是被删除了，保持原状态
+	result, err := s.CheckNSDelegation(domain)
+	if err != nil {
+		logger.GetLogger().WithError(err).WithField("domain_name", domain.DomainName).Warn("NS 委派检查失败")
+		return domain.Status, nil
 	}
-
-	return domain.Status, nil
+	return result.Status, nil
 }
 
 // UpdateDomainStatus 更新域名状态
@@ -1295,7 +1288,11 @@ func (s *DomainService) DeleteDomain(id uint) error {
 			"domain_id":      id,
 			"hosted_zone_id": domain.HostedZoneID,
 		}).Info("开始删除Route53托管区域")
-		if err := s.route53Svc.DeleteHostedZone(domain.HostedZoneID); err != nil {
+		route53Svc, err := s.route53ForDomain(domain)
+		if err == nil {
+			err = route53Svc.DeleteHostedZone(domain.HostedZoneID)
+		}
+		if err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"domain_id":      id,
 				"hosted_zone_id": domain.HostedZoneID,
@@ -1323,107 +1320,115 @@ func (s *DomainService) DeleteDomain(id uint) error {
 	return nil
 }
 
-// CreateCloudFrontCNAMERecord 创建指向 CloudFront 的 CNAME 记录
-// 根据DNS提供商选择不同的实现
+// CreateCloudFrontCNAMERecord 创建指向 CloudFront 的记录
+// Route 53 使用 A 记录（Alias），Cloudflare 使用 CNAME，由 DNSProvider.CloudFrontRecord 决定
 func (s *DomainService) CreateCloudFrontCNAMERecord(domain *models.Domain, cloudFrontDomainName string) error {
-	// 确保cloudFrontDomainName以点结尾
-	cloudFrontValue := cloudFrontDomainName
-	if cloudFrontValue != "" && !strings.HasSuffix(cloudFrontValue, ".") {
-		cloudFrontValue = cloudFrontValue + "."
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return err
 	}
+	return provider.UpdateRecord(domain.HostedZoneID, provider.CloudFrontRecord(domain.DomainName, cloudFrontDomainName))
+}
 
-	if domain.DNSProvider == models.DNSProviderCloudflare {
-		cloudflareSvc, err := s.createCloudflareService(domain.CFAccountID)
-		if err != nil {
-			return fmt.Errorf("创建 CloudflareService 失败: %w", err)
-		}
-		return cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, domain.DomainName, cloudFrontValue)
-	} else {
-		// AWS Route53: 使用Alias记录（A记录）
-		return s.route53Svc.CreateAliasRecord(domain.HostedZoneID, domain.DomainName, cloudFrontDomainName)
+// CreateCloudFrontAliasRecord 创建将 domainName（可为子域名）指向 CloudFront 的记录，记录类型由 DNSProvider.CloudFrontRecord 决定
+func (s *DomainService) CreateCloudFrontAliasRecord(domain *models.Domain, domainName, cloudFrontDomainName string) error {
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return err
 	}
+	return provider.UpdateRecord(domain.HostedZoneID, provider.CloudFrontRecord(domainName, cloudFrontDomainName))
 }
 
-// CreateCloudFrontAliasRecord 创建指向 CloudFront 的 A 记录（Alias）- 仅用于AWS
-func (s *DomainService) CreateCloudFrontAliasRecord(hostedZoneID, domainName, cloudFrontDomainName string) error {
-	return s.route53Svc.CreateAliasRecord(hostedZoneID, domainName, cloudFrontDomainName)
+// CheckCloudFrontAliasRecord 检查 domainName 是否指向指定的 CloudFront 分发，cloudFrontDomainName 为空时检查是否指向任意 CloudFront 分发
+func (s *DomainService) CheckCloudFrontAliasRecord(domain *models.Domain, domainName, cloudFrontDomainName string) (bool, error) {
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return false, err
+	}
+	expected := provider.CloudFrontRecord(domainName, cloudFrontDomainName)
+	if cloudFrontDomainName != "" {
+		return provider.CheckRecord(domain.HostedZoneID, expected)
+	}
+	actual, err := provider.GetRecord(domain.HostedZoneID, expected.Name, expected.Type)
+	if err != nil {
+		return false, err
+	}
+	return pointsToCloudFront(actual), nil
 }
 
-// CheckCloudFrontAliasRecord 检查是否存在指向指定 CloudFront 分发的 A 记录（Alias）- 仅用于AWS
-func (s *DomainService) CheckCloudFrontAliasRecord(hostedZoneID, domainName, cloudFrontDomainName string) (bool, error) {
-	return s.route53Svc.CheckCloudFrontAliasRecord(hostedZoneID, domainName, cloudFrontDomainName)
+// pointsToCloudFront 判断记录是否指向 CloudFront：Alias 目标在 CloudFront 托管区域，或 CNAME 值为 *.cloudfront.net
+func pointsToCloudFront(record *DNSRecord) bool {
+	if record == nil {
+		return false
+	}
+	if record.AliasZoneID == aws.CloudFrontHostedZoneID {
+		return true
+	}
+	for _, value := range record.Values {
+		if strings.HasSuffix(normalizeDNSValue("CNAME", value), ".cloudfront.net") {
+			return true
+		}
+	}
+	return false
 }
 
-// CheckCloudFrontCNAMERecord 检查是否存在指向 CloudFront 的 CNAME 记录
+// CheckCloudFrontCNAMERecord 检查是否存在指向 CloudFront 的记录
 func (s *DomainService) CheckCloudFrontCNAMERecord(domain *models.Domain, cloudFrontDomainName string) (bool, error) {
-	// 确保cloudFrontDomainName以点结尾
-	cloudFrontValue := cloudFrontDomainName
-	if cloudFrontValue != "" && !strings.HasSuffix(cloudFrontValue, ".") {
-		cloudFrontValue = cloudFrontValue + "."
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return false, err
 	}
+	return provider.CheckRecord(domain.HostedZoneID, provider.CloudFrontRecord(domain.DomainName, cloudFrontDomainName))
+}
 
-	if domain.DNSProvider == models.DNSProviderCloudflare {
-		cloudflareSvc, err := s.createCloudflareService(domain.CFAccountID)
-		if err != nil {
-			return false, fmt.Errorf("创建 CloudflareService 失败: %w", err)
-		}
-		return cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, domain.DomainName, cloudFrontValue)
-	} else {
-		// AWS Route53: 检查Alias记录
-		return s.route53Svc.CheckCloudFrontAliasRecord(domain.HostedZoneID, domain.DomainName, cloudFrontDomainName)
-	}
+// wwwCNAMERecord 返回 www 子域名指向根域名的 CNAME 记录，域名本身是 www 子域名时返回 false
+func wwwCNAMERecord(domain *models.Domain) (DNSRecord, bool) {
+	rootDomain := domain.DomainName
+	if strings.HasPrefix(rootDomain, "www.") {
+		return DNSRecord{}, false
+	}
+	return DNSRecord{
+		Name:   "www." + rootDomain,
+		Type:   "CNAME",
+		Values: []string{rootDomain},
+		TTL:    defaultDNSRecordTTL,
+	}, true
 }
 
 // CreateWWWCNAMERecord 为根域名创建 www 子域名的 CNAME 记录指向根域名
 func (s *DomainService) CreateWWWCNAMERecord(domain *models.Domain) error {
-	rootDomain := domain.DomainName
-	// 确保 rootDomain 不是 www 子域名
-	if strings.HasPrefix(rootDomain, "www.") {
+	record, ok := wwwCNAMERecord(domain)
+	if !ok {
 		return nil // 如果已经是 www 子域名，不需要创建
 	}
-
-	// 构建 www 子域名
-	wwwDomain := "www." + rootDomain
-
-	// 确保 rootDomain 以点结尾（CNAME 值需要）
-	rootDomainValue := rootDomain
-	if rootDomainValue != "" && !strings.HasSuffix(rootDomainValue, ".") {
-		rootDomainValue = rootDomainValue + "."
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return err
 	}
-
 	// 创建 CNAME 记录：www.example.com -> example.com
-	if domain.DNSProvider == models.DNSProviderCloudflare {
-		cloudflareSvc, err := s.createCloudflareService(domain.CFAccountID)
-		if err != nil {
-			return fmt.Errorf("创建 CloudflareService 失败: %w", err)
-		}
-		return cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, wwwDomain, rootDomainValue)
-	} else {
-		return s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, wwwDomain, rootDomainValue)
-	}
+	return provider.UpdateRecord(domain.HostedZoneID, record)
 }
 
 // CheckWWWCNAMERecord 检查是否存在 www 子域名的 CNAME 记录指向根域名
 func (s *DomainService) CheckWWWCNAMERecord(domain *models.Domain) (bool, error) {
-	rootDomain := domain.DomainName
-	if strings.HasPrefix(rootDomain, "www.") {
+	record, ok := wwwCNAMERecord(domain)
+	if !ok {
 		return true, nil // 如果已经是 www 子域名，不需要检查
 	}
-
-	wwwDomain := "www." + rootDomain
-	rootDomainValue := rootDomain
-	if rootDomainValue != "" && !strings.HasSuffix(rootDomainValue, ".") {
-		rootDomainValue = rootDomainValue + "."
+	provider, err := s.DNSProviderForDomain(domain)
+	if err != nil {
+		return false, err
 	}
+	return provider.CheckRecord(domain.HostedZoneID, record)
+}
 
-	if domain.DNSProvider == models.DNSProviderCloudflare {
-		cloudflareSvc, err := s.createCloudflareService(domain.CFAccountID)
-		if err != nil {
-			return false, fmt.Errorf("创建 CloudflareService 失败: %w", err)
-		}
-		return cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, wwwDomain, rootDomainValue)
-	} else {
-		return s.route53Svc.CheckWWWCNAMERecord(domain.HostedZoneID, rootDomain)
+// certificateValidationDNSRecord 将 ACM 证书验证记录转换为 DNS 记录
+func certificateValidationDNSRecord(record aws.CertificateValidationRecord) DNSRecord {
+	return DNSRecord{
+		Name:   record.Name,
+		Type:   record.Type,
+		Values: []string{record.Value},
+		TTL:    defaultDNSRecordTTL,
 	}
 }
 
@@ -1460,9 +1465,13 @@ func (s *DomainService) CheckCertificate(id uint) (*CertificateCheckResult, erro
 	}
 
 	result.CertificateExists = true
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取证书状态
-	status, err := s.acmSvc.GetCertificateStatus(domain.CertificateARN)
+	status, err := acmSvc.GetCertificateStatus(domain.CertificateARN)
 	if err != nil {
 		result.HasIssues = true
 		result.Issues = append(result.Issues, fmt.Sprintf("获取证书状态失败: %v", err))
@@ -1479,7 +1488,7 @@ func (s *DomainService) CheckCertificate(id uint) (*CertificateCheckResult, erro
 	// 如果证书状态是pending_validation或pending，需要检查验证记录
 	if status == "pending_validation" || status == "pending" {
 		// 获取证书验证记录
-		validationRecords, err := s.acmSvc.GetCertificateValidationRecords(domain.CertificateARN)
+		validationRecords, err := acmSvc.GetCertificateValidationRecords(domain.CertificateARN)
 		if err != nil {
 			result.HasIssues = true
 			result.Issues = append(result.Issues, fmt.Sprintf("获取证书验证记录失败: %v", err))
@@ -1499,16 +1508,11 @@ func (s *DomainService) CheckCertificate(id uint) (*CertificateCheckResult, erro
 			return result, nil
 		}
 
-		// 如果是 Cloudflare，先创建 CloudflareService
-		var cloudflareSvc *cloudflare.CloudflareService
-		if domain.DNSProvider == models.DNSProviderCloudflare {
-			var err error
-			cloudflareSvc, err = s.createCloudflareService(domain.CFAccountID)
-			if err != nil {
-				result.HasIssues = true
-				result.Issues = append(result.Issues, fmt.Sprintf("创建 CloudflareService 失败: %v", err))
-				return result, nil
-			}
+		provider, err := s.DNSProviderForDomain(domain)
+		if err != nil {
+			result.HasIssues = true
+			result.Issues = append(result.Issues, err.Error())
+			return result, nil
 		}
 
 		// 检查每个验证记录的CNAME是否存在于DNS提供商
@@ -1518,17 +1522,7 @@ func (s *DomainService) CheckCertificate(id uint) (*CertificateCheckResult, erro
 				result.ValidationRecords = append(result.ValidationRecords, recordDesc)
 
 				// 检查CNAME记录是否存在
-				var exists bool
-				var err error
-				if domain.DNSProvider == models.DNSProviderCloudflare {
-					if cloudflareSvc != nil {
-						exists, err = cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-					} else {
-						err = fmt.Errorf("CloudflareService 未创建")
-					}
-				} else {
-					exists, err = s.route53Svc.CheckCertificateValidationCNAME(domain.HostedZoneID, record.Name, record.Value)
-				}
+				exists, err := provider.CheckRecord(domain.HostedZoneID, certificateValidationDNSRecord(record))
 				if err != nil {
 					result.HasIssues = true
 					result.Issues = append(result.Issues, fmt.Sprintf("检查CNAME记录失败 (%s): %v", record.Name, err))
@@ -1559,9 +1553,13 @@ func (s *DomainService) FixCertificate(id uint) error {
 	if domain.CertificateARN == "" {
 		return s.GenerateCertificate(id)
 	}
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		return err
+	}
 
 	// 获取证书状态
-	status, err := s.acmSvc.GetCertificateStatus(domain.CertificateARN)
+	status, err := acmSvc.GetCertificateStatus(domain.CertificateARN)
 	if err != nil {
 		return fmt.Errorf("获取证书状态失败: %w", err)
 	}
@@ -1579,68 +1577,27 @@ func (s *DomainService) FixCertificate(id uint) error {
 		}
 
 		// 获取证书验证记录
-		validationRecords, err := s.acmSvc.GetCertificateValidationRecords(domain.CertificateARN)
+		validationRecords, err := acmSvc.GetCertificateValidationRecords(domain.CertificateARN)
 		if err != nil {
 			return fmt.Errorf("获取证书验证记录失败: %w", err)
 		}
 
-		// 如果是 Cloudflare，先创建 CloudflareService
-		var cloudflareSvc *cloudflare.CloudflareService
-		if domain.DNSProvider == models.DNSProviderCloudflare {
-			var err error
-			cloudflareSvc, err = s.createCloudflareService(domain.CFAccountID)
-			if err != nil {
-				return fmt.Errorf("创建 CloudflareService 失败: %w", err)
-			}
+		provider, err := s.DNSProviderForDomain(domain)
+		if err != nil {
+			return err
 		}
 
-		// 添加缺失的CNAME记录
+		// 添加缺失的CNAME记录（检查失败时也尝试直接写入）
 		for _, record := range validationRecords {
-			if record.Type == "CNAME" {
-				var exists bool
-				var err error
-				if domain.DNSProvider == models.DNSProviderCloudflare {
-					if cloudflareSvc != nil {
-						exists, err = cloudflareSvc.CheckCNAMERecord(domain.HostedZoneID, record.Name, record.Value)
-					} else {
-						err = fmt.Errorf("CloudflareService 未创建")
-					}
-					if err != nil {
-						// 如果检查失败，尝试直接创建（可能是记录不存在）
-						if cloudflareSvc != nil {
-							if err := cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value); err != nil {
-								return fmt.Errorf("创建CNAME记录失败 (%s): %w", record.Name, err)
-							}
-						} else {
-							return fmt.Errorf("CloudflareService 未创建，无法创建CNAME记录 (%s)", record.Name)
-						}
-						continue
-					}
-					if !exists {
-						if cloudflareSvc != nil {
-							if err := cloudflareSvc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value); err != nil {
-								return fmt.Errorf("创建CNAME记录失败 (%s): %w", record.Name, err)
-							}
-						} else {
-							return fmt.Errorf("CloudflareService 未创建，无法创建CNAME记录 (%s)", record.Name)
-						}
-					}
-				} else {
-					exists, err = s.route53Svc.CheckCertificateValidationCNAME(domain.HostedZoneID, record.Name, record.Value)
-					if err != nil {
-						// 如果检查失败，尝试直接创建（可能是记录不存在）
-						if err := s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value); err != nil {
-							return fmt.Errorf("创建CNAME记录失败 (%s): %w", record.Name, err)
-						}
-						continue
-					}
-					if !exists {
-						// 记录不存在，创建它
-						if err := s.route53Svc.CreateCNAMERecord(domain.HostedZoneID, record.Name, record.Value); err != nil {
-							return fmt.Errorf("创建CNAME记录失败 (%s): %w", record.Name, err)
-						}
-					}
-				}
+			if record.Type != "CNAME" {
+				continue
+			}
+			dnsRecord := certificateValidationDNSRecord(record)
+			if exists, err := provider.CheckRecord(domain.HostedZoneID, dnsRecord); err == nil && exists {
+				continue
+			}
+			if err := provider.UpdateRecord(domain.HostedZoneID, dnsRecord); err != nil {
+				return fmt.Errorf("创建CNAME记录失败 (%s): %w", record.Name, err)
 			}
 		}
 
@@ -1659,9 +1616,14 @@ func (s *DomainService) waitForCertificateValidationAsync(domain *models.Domain)
 	if domain.CertificateARN == "" {
 		return
 	}
+	acmSvc, err := s.acmForDomain(domain)
+	if err != nil {
+		s.db.Model(domain).Update("certificate_status", "failed")
+		return
+	}
 
 	// 等待证书验证（最多等待 1 小时）
-	if err := s.acmSvc.WaitForCertificateValidation(domain.CertificateARN, 1*time.Hour); err != nil {
+	if err := acmSvc.WaitForCertificateValidation(domain.CertificateARN, 1*time.Hour); err != nil {
 		s.db.Model(domain).Update("certificate_status", "failed")
 		return
 	}
@@ -1681,29 +1643,6 @@ func extractRootDomain(domain string) string {
 	return domain
 }
 
-// extractRelativeDomainName 从完整域名中提取相对域名部分
-// 例如: _abc123.example.com. -> _abc123, _abc123.example.com -> _abc123
-// 如果 recordName 已经是相对域名，直接返回
-func extractRelativeDomainName(recordName, rootDomain string) string {
-	// 去掉末尾的点
-	recordName = strings.TrimSuffix(recordName, ".")
-	rootDomain = strings.TrimSuffix(rootDomain, ".")
-
-	// 如果 recordName 以 rootDomain 结尾，提取前缀部分
-	if strings.HasSuffix(recordName, "."+rootDomain) {
-		relativeName := strings.TrimSuffix(recordName, "."+rootDomain)
-		return relativeName
-	}
-
-	// 如果 recordName 等于 rootDomain，返回 @（根域名记录）
-	if recordName == rootDomain {
-		return "@"
-	}
-
-	// 如果 recordName 不包含 rootDomain，可能是相对域名，直接返回
-	return recordName
-}
-
 // FindCertificateARNForDomain 查找适合域名的证书ARN
 // 对于子域名（如 dl.95058.cc），优先查找根域名的泛域名证书（*.95058.cc），如果找不到则使用根域名的证书
 // 对于根域名（如 95058.cc），直接使用该域名的证书
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ZoneImportItem 区域文件导入中一个记录集的处理结果
+type ZoneImportItem struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Action   string   `json:"action"`             // create、update、unchanged、skip、untouched、error
+	Current  []string `json:"current,omitempty"`  // 区域中现有的值
+	Intended []string `json:"intended,omitempty"` // 区域文件中的值
+	Changes  []string `json:"changes,omitempty"`  // 变更说明
+	Reason   string   `json:"reason,omitempty"`   // 跳过或失败的原因
+}
+
+// ZoneImportResult 区域文件导入结果（dry_run 时为差异预览）
+// 导入只创建和覆盖文件中出现的记录集，区域中已有而文件中没有的记录以 untouched 列出，不会被删除
+type ZoneImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Skipped   int              `json:"skipped"`
+	Failed    int              `json:"failed"`
+	Warnings  []string         `json:"warnings,omitempty"` // 解析警告
+	Items     []ZoneImportItem `json:"items"`
+}
+
+// ExportZoneFile 将域名当前的 DNS 记录导出为 BIND 格式的区域文件
+func (s *DomainService) ExportZoneFile(domainID uint) (string, error) {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return "", err
+	}
+	records, err := provider.ListRecords(domain.HostedZoneID)
+	if err != nil {
+		return "", err
+	}
+	return RenderZoneFile(domain.DomainName, records), nil
+}
+
+// ImportZoneFile 将 BIND 格式的区域文件导入域名的 Route 53 托管区域或 Cloudflare Zone
+// dryRun 为 true 时只与现有记录比较并返回差异，不做任何修改。NS、SOA 由提供商管理，不会导入。
+func (s *DomainService) ImportZoneFile(domainID uint, data string, dryRun bool) (*ZoneImportResult, error) {
+	domain, provider, err := s.dnsRecordContext(domainID)
+	if err != nil {
+		return nil, err
+	}
+	parsed, warnings, err := ParseZoneFile(data, domain.DomainName)
+	if err != nil {
+		return nil, fmt.Errorf("解析区域文件失败: %w", err)
+	}
+	existingRecords, err := provider.ListRecords(domain.HostedZoneID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]DNSRecord, len(existingRecords))
+	for _, record := range existingRecords {
+		existing[dnsRecordKey(record.Name, record.Type)] = record
+	}
+
+	result := &ZoneImportResult{DryRun: dryRun, Warnings: warnings, Items: []ZoneImportItem{}}
+	seen := make(map[string]bool, len(parsed))
+	for _, record := range parsed {
+		key := dnsRecordKey(record.Name, record.Type)
+		seen[key] = true
+		var current *DNSRecord
+		if r, ok := existing[key]; ok {
+			current = &r
+		}
+		record, item := planZoneImportItem(domain, record, current)
+		if item.Action == "error" || item.Action == "skip" {
+			result.add(item)
+			continue
+		}
+		if !dryRun && item.Action != "unchanged" {
+			if item.Action == "create" {
+				err = provider.CreateRecord(domain.HostedZoneID, record)
+			} else {
+				err = provider.UpdateRecord(domain.HostedZoneID, record)
+			}
+			if err != nil {
+				item.Action = "error"
+				item.Reason = err.Error()
+			} else {
+				s.logDNSRecordChange(domain, "import_"+item.Action, record)
+			}
+		}
+		result.add(item)
+	}
+
+	var untouched []ZoneImportItem
+	for key, record := range existing {
+		if seen[key] || !managedDNSRecordTypes[record.Type] {
+			continue
+		}
+		untouched = append(untouched, ZoneImportItem{
+			Name:    record.Name,
+			Type:    record.Type,
+			Action:  "untouched",
+			Current: zoneImportValues(record),
+			Reason:  "区域中已有、文件中没有，导入不会删除",
+		})
+	}
+	sort.Slice(untouched, func(i, j int) bool {
+		return dnsRecordKey(untouched[i].Name, untouched[i].Type) < dnsRecordKey(untouched[j].Name, untouched[j].Type)
+	})
+	result.Items = append(result.Items, untouched...)
+	return result, nil
+}
+
+// planZoneImportItem 比较区域文件中的记录集和现有记录集，决定导入动作，返回校验后的待写入记录
+// 区域文件无法表达 Cloudflare 代理状态，现有记录已开启代理时保持开启（TTL 为自动）
+func planZoneImportItem(domain *models.Domain, record DNSRecord, current *DNSRecord) (DNSRecord, ZoneImportItem) {
+	item := ZoneImportItem{Name: record.Name, Type: record.Type, Intended: record.Values}
+	if current != nil {
+		item.Current = zoneImportValues(*current)
+	}
+	if !managedDNSRecordTypes[record.Type] {
+		item.Action = "skip"
+		item.Reason = fmt.Sprintf("%s 记录由 DNS 提供商管理或不支持导入", record.Type)
+		return record, item
+	}
+	if current != nil && current.AliasTarget != "" {
+		item.Action = "skip"
+		item.Reason = "现有记录是 Route 53 Alias 记录，由系统维护"
+		return record, item
+	}
+	if current != nil && current.Proxied {
+		record.Proxied = true
+		record.TTL = 1
+	}
+	prepared, err := prepareDNSRecord(domain, record)
+	if err != nil {
+		item.Action = "error"
+		item.Reason = err.Error()
+		return record, item
+	}
+	item.Intended = prepared.Values
+	if current == nil {
+		item.Action = "create"
+		return prepared, item
+	}
+
+	if !sameDNSValues(current.Type, current.Values, prepared.Values) {
+		item.Changes = append(item.Changes, fmt.Sprintf("值: %s → %s", strings.Join(current.Values, ", "), strings.Join(prepared.Values, ", ")))
+	}
+	if current.TTL != prepared.TTL {
+		item.Changes = append(item.Changes, fmt.Sprintf("TTL: %d → %d", current.TTL, prepared.TTL))
+	}
+	if len(item.Changes) == 0 {
+		item.Action = "unchanged"
+	} else {
+		item.Action = "update"
+	}
+	return prepared, item
+}
+
+func (r *ZoneImportResult) add(item ZoneImportItem) {
+	switch item.Action {
+	case "create":
+		r.Created++
+	case "update":
+		r.Updated++
+	case "unchanged":
+		r.Unchanged++
+	case "skip":
+		r.Skipped++
+	case "error":
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// sameDNSValues 比较两组值（忽略顺序）
+func sameDNSValues(recordType string, a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	normalize := func(values []string) []string {
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			out = append(out, normalizeDNSValue(recordType, v))
+		}
+		sort.Strings(out)
+		return out
+	}
+	na, nb := normalize(a), normalize(b)
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// zoneImportValues 返回用于展示的记录值，Alias 记录显示目标
+func zoneImportValues(record DNSRecord) []string {
+	if record.AliasTarget != "" {
+		return []string{"ALIAS " + record.AliasTarget}
+	}
+	return record.Values
+}
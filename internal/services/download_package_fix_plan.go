@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+)
+
+// PlanFixDownloadPackage 生成下载包的修复计划（dry-run）
+// 基于 CheckDownloadPackage 相同的检查项，按 FixDownloadPackage 的执行顺序列出每一步的当前状态、预期变更和将调用的接口，
+// 不修改数据库和任何云端资源。
+func (s *DownloadPackageService) PlanFixDownloadPackage(id uint) (*FixPlan, error) {
+	pkg, err := s.GetDownloadPackage(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取下载包失败: %w", err)
+	}
+	domain, err := s.domainService.GetDomain(pkg.DomainID)
+	if err != nil {
+		return nil, fmt.Errorf("获取域名信息失败: %w", err)
+	}
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.CheckDownloadPackage(id)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &FixPlan{
+		Resource: "download_package",
+		ID:       pkg.ID,
+		Domain:   pkg.DomainName,
+		Issues:   status.Issues,
+	}
+	if domain.CertificateStatus != "issued" {
+		plan.Blockers = append(plan.Blockers, fmt.Sprintf("域名证书未签发，当前状态: %s", domain.CertificateStatus))
+	}
+
+	cloudFrontID := pkg.CloudFrontID
+	if cloudFrontID != "" && !status.CloudFrontExists {
+		plan.addStep("清除失效的 CloudFront 分发 ID",
+			fmt.Sprintf("分发 %s 不存在或无法访问: %s", cloudFrontID, status.CloudFrontError),
+			"清除下载包中的 CloudFront ID，随后重新创建分发",
+			FixActionDelete, "")
+		cloudFrontID = ""
+	}
+
+	expectedOriginPath := fmt.Sprintf("/downloads/%s", pkg.DomainName)
+	if cloudFrontID != "" {
+		if status.CloudFrontEnabled {
+			plan.addStep("启用 CloudFront 分发", "已启用", "保持不变", FixActionNone, "")
+		} else {
+			plan.addStep("启用 CloudFront 分发", describeCheckError(status.CloudFrontEnabledError, "已禁用"), "启用分发",
+				FixActionUpdate, fmt.Sprintf("cloudfront:UpdateDistribution(Id=%s, Enabled=true)", cloudFrontID))
+		}
+
+		switch {
+		case status.CloudFrontOriginPathMatch:
+			plan.addStep("校正 CloudFront OriginPath", expectedOriginPath, "保持不变", FixActionNone, "")
+		case status.CloudFrontOriginPathCurrent == "" && status.CloudFrontOriginPathError != "":
+			// FixDownloadPackage 获取 OriginPath 失败时直接返回错误
+			plan.Blockers = append(plan.Blockers, status.CloudFrontOriginPathError)
+		default:
+			plan.addStep("校正 CloudFront OriginPath", status.CloudFrontOriginPathCurrent, expectedOriginPath,
+				FixActionUpdate, fmt.Sprintf("cloudfront:UpdateDistribution(Id=%s, OriginPath=%s)", cloudFrontID, expectedOriginPath))
+		}
+	}
+
+	if clients.Config.S3BucketName != "" {
+		configured, err := clients.S3.CheckBucketPolicyForDownloads(clients.Config.S3BucketName)
+		switch {
+		case err != nil:
+			plan.addStep("配置 S3 bucket policy", fmt.Sprintf("检查失败: %v", err), "确保 downloads/* 可公开读取",
+				FixActionEnsure, fmt.Sprintf("s3:PutBucketPolicy(Bucket=%s)", clients.Config.S3BucketName))
+		case configured:
+			plan.addStep("配置 S3 bucket policy", "downloads/* 已允许公开读取", "保持不变", FixActionNone, "")
+		default:
+			plan.addStep("配置 S3 bucket policy", "未配置", "合并 downloads/* 公开读取策略",
+				FixActionUpdate, fmt.Sprintf("s3:PutBucketPolicy(Bucket=%s)", clients.Config.S3BucketName))
+		}
+	}
+
+	if pkg.S3Key != "" && clients.Config.S3BucketName != "" && !status.S3FileExists {
+		plan.Blockers = append(plan.Blockers, describeCheckError(status.S3FileError, "S3文件不存在，无法修复。请重新上传文件"))
+	}
+
+	if cloudFrontID == "" {
+		plan.addStep("创建 CloudFront 分发", "不存在",
+			fmt.Sprintf("创建分发（别名 %s，源路径 %s），CNAME 已被占用时按别名查找已有分发", pkg.DomainName, expectedOriginPath),
+			FixActionCreate, fmt.Sprintf("cloudfront:CreateDistribution(Alias=%s, Certificate=%s)", pkg.DomainName, domain.CertificateARN))
+	}
+
+	if domain.HostedZoneID != "" {
+		switch {
+		case cloudFrontID == "":
+			plan.addStep(dnsStepName(domain.DNSProvider), "分发尚未创建", "指向新分发",
+				FixActionCreate, dnsUpsertAPICall(domain.DNSProvider, pkg.DomainName, ""))
+		case status.Route53DNSConfigured:
+			plan.addStep(dnsStepName(domain.DNSProvider), fmt.Sprintf("已指向 %s", pkg.CloudFrontDomain), "保持不变", FixActionNone, "")
+		case pkg.CloudFrontDomain != "":
+			plan.addStep(dnsStepName(domain.DNSProvider), describeCheckError(status.Route53DNSError, "未配置"), fmt.Sprintf("指向 %s", pkg.CloudFrontDomain),
+				FixActionCreate, dnsUpsertAPICall(domain.DNSProvider, pkg.DomainName, ""))
+		}
+	} else {
+		plan.Warnings = append(plan.Warnings, "域名未配置托管区域，不会创建DNS记录")
+	}
+
+	downloadURL := fmt.Sprintf("https://%s/%s", pkg.DomainName, pkg.FileName)
+	if pkg.DownloadURL == downloadURL && status.DownloadURLAccessible {
+		plan.addStep("更新下载地址和状态", downloadURL, "保持不变", FixActionNone, "")
+	} else {
+		plan.addStep("更新下载地址和状态", describeCheckError(pkg.DownloadURL, "未配置"), fmt.Sprintf("%s，状态设为 completed", downloadURL), FixActionUpdate, "")
+	}
+
+	return plan.finish(), nil
+}
@@ -16,13 +16,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// defaultCloudFrontMaxTTL CloudFront 默认的最长缓存时间（一年）
+const defaultCloudFrontMaxTTL = 365 * 24 * time.Hour
+
 type DownloadPackageService struct {
 	db            *gorm.DB
 	db3           *gorm.DB
 	domainService *DomainService
 	cloudFrontSvc *aws.CloudFrontService
 	s3Svc         *aws.S3Service
-	route53Svc    *aws.Route53Service
 	config        *config.AWSConfig
 }
 
@@ -32,7 +34,6 @@ func NewDownloadPackageService(
 	domainService *DomainService,
 	cloudFrontSvc *aws.CloudFrontService,
 	s3Svc *aws.S3Service,
-	route53Svc *aws.Route53Service,
 	cfg *config.AWSConfig,
 ) *DownloadPackageService {
 	return &DownloadPackageService{
@@ -41,11 +42,15 @@ func NewDownloadPackageService(
 		domainService: domainService,
 		cloudFrontSvc: cloudFrontSvc,
 		s3Svc:         s3Svc,
-		route53Svc:    route53Svc,
 		config:        cfg,
 	}
 }
 
+// awsClientsForPackage 获取下载包所在 AWS 账号的客户端，未关联账号时使用默认账号（统一由客户端工厂创建）
+func (s *DownloadPackageService) awsClientsForPackage(pkg *models.DownloadPackage) (*AWSClients, error) {
+	return s.domainService.AWSClientsForAccount(pkg.AWSAccountID)
+}
+
 // CheckDomainUsedByRedirect 检查域名是否被重定向规则使用（排除软删除的记录）
 func (s *DownloadPackageService) CheckDomainUsedByRedirect(domainName string) (bool, error) {
 	var count int64
@@ -57,23 +62,21 @@ func (s *DownloadPackageService) CheckDomainUsedByRedirect(domainName string) (b
 	return count > 0, nil
 }
 
-// CreateDownloadPackage 创建下载包
-// 1. 上传文件到S3
-// 2. 创建CloudFront分发
-// 3. 将域名绑定到CloudFront
-func (s *DownloadPackageService) CreateDownloadPackage(domainID uint, fileName string, fileReader io.ReadSeeker, fileSize int64) (*models.DownloadPackage, error) {
+// PrepareDownloadPackage 校验域名、证书和 AWS 账号，生成尚未保存的下载包记录
+func (s *DownloadPackageService) PrepareDownloadPackage(domainID uint, awsAccountID *uint, fileName string, fileSize int64) (*models.DownloadPackage, *models.Domain, error) {
 	log := logger.GetLogger()
-	log.WithFields(map[string]interface{}{
-		"domain_id": domainID,
-		"file_name": fileName,
-		"file_size": fileSize,
-	}).Info("开始创建下载包")
+
+	// 文件名只取最后一段，与表单上传一致，避免 JSON 传入的路径改变 S3 键
+	fileName, err := sanitizeFileName(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// 验证域名是否存在
 	domain, err := s.domainService.GetDomain(domainID)
 	if err != nil {
 		log.WithError(err).WithField("domain_id", domainID).Error("域名不存在")
-		return nil, fmt.Errorf("域名不存在: %w", err)
+		return nil, nil, fmt.Errorf("域名不存在: %w", err)
 	}
 
 	// 检查域名证书状态
@@ -84,7 +87,7 @@ func (s *DownloadPackageService) CreateDownloadPackage(domainID uint, fileName s
 			"dns_provider":       domain.DNSProvider,
 			"certificate_status": domain.CertificateStatus,
 		}).Error("域名证书未签发")
-		return nil, fmt.Errorf("域名证书未签发，当前状态: %s", domain.CertificateStatus)
+		return nil, nil, fmt.Errorf("域名证书未签发，当前状态: %s", domain.CertificateStatus)
 	}
 
 	// 记录DNS提供商信息（用于调试）
@@ -97,32 +100,68 @@ func (s *DownloadPackageService) CreateDownloadPackage(domainID uint, fileName s
 	// 使用域名的domain_name作为下载域名
 	domainName := domain.DomainName
 
+	if awsAccountID == nil || *awsAccountID == 0 {
+		awsAccountID = domain.AWSAccountID
+	} else if domain.AWSAccountID == nil || *domain.AWSAccountID != *awsAccountID {
+		return nil, nil, fmt.Errorf("下载包的 AWS 账号必须与域名 %s 证书所在的账号一致", domainName)
+	}
+	clients, err := s.domainService.AWSClientsForAccount(awsAccountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+	}
+	if awsAccountID != nil && clients.Config.S3BucketName == "" {
+		return nil, nil, fmt.Errorf("AWS 账号未配置 S3 存储桶，无法创建下载包")
+	}
+
 	// 检查域名是否已被重定向规则使用
 	isUsed, err := s.CheckDomainUsedByRedirect(domainName)
 	if err != nil {
 		log.WithError(err).WithField("domain_name", domainName).Error("检查域名使用状态失败")
-		return nil, fmt.Errorf("检查域名使用状态失败: %w", err)
+		return nil, nil, fmt.Errorf("检查域名使用状态失败: %w", err)
 	}
 	if isUsed {
 		log.WithField("domain_name", domainName).Error("域名已被重定向规则使用")
-		return nil, fmt.Errorf("域名 %s 已被重定向规则使用，请先删除重定向规则后再使用", domainName)
+		return nil, nil, fmt.Errorf("域名 %s 已被重定向规则使用，请先删除重定向规则后再使用", domainName)
 	}
 
 	// 生成S3键（使用downloads/前缀）
 	s3Key := fmt.Sprintf("downloads/%s/%s", domainName, fileName)
 
-	// 创建下载包记录（使用域名的分组）
-	downloadPackage := &models.DownloadPackage{
-		DomainID:   domainID,
-		GroupID:    domain.GroupID, // 使用域名的分组
-		DomainName: domainName,
-		FileName:   fileName,
-		FileSize:   fileSize,
-		FileType:   mime.TypeByExtension(filepath.Ext(fileName)),
-		S3Key:      s3Key,
-		Status:     models.DownloadPackageStatusUploading,
+	// 生成下载包记录（使用域名的分组）
+	pkg := &models.DownloadPackage{
+		DomainID:     domainID,
+		GroupID:      domain.GroupID, // 使用域名的分组
+		AWSAccountID: awsAccountID,
+		DomainName:   domainName,
+		FileName:     fileName,
+		FileSize:     fileSize,
+		FileType:     mime.TypeByExtension(filepath.Ext(fileName)),
+		S3Key:        s3Key,
+		Status:       models.DownloadPackageStatusUploading,
 	}
 
+	return pkg, domain, nil
+}
+
+// CreateDownloadPackage 创建下载包
+// 1. 上传文件到S3
+// 2. 创建CloudFront分发
+// 3. 将域名绑定到CloudFront
+// awsAccountID 为空时使用域名关联的 AWS 账号；CloudFront 只能使用同一账号下的 ACM 证书，因此指定的账号必须与域名一致
+func (s *DownloadPackageService) CreateDownloadPackage(domainID uint, awsAccountID *uint, fileName string, fileReader io.ReadSeeker, fileSize int64) (*models.DownloadPackage, error) {
+	log := logger.GetLogger()
+	log.WithFields(map[string]interface{}{
+		"domain_id": domainID,
+		"file_name": fileName,
+		"file_size": fileSize,
+	}).Info("开始创建下载包")
+
+	downloadPackage, domain, err := s.PrepareDownloadPackage(domainID, awsAccountID, fileName, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	s3Key := downloadPackage.S3Key
+
 	if err := s.db.Create(downloadPackage).Error; err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"domain_id": domainID,
@@ -145,6 +184,26 @@ func (s *DownloadPackageService) CreateDownloadPackage(domainID uint, fileName s
 	return downloadPackage, nil
 }
 
+// CreateUploadedDownloadPackage 为已上传到 S3 的文件创建下载包（分片上传完成后调用），跳过上传步骤直接配置 CloudFront
+func (s *DownloadPackageService) CreateUploadedDownloadPackage(domainID uint, awsAccountID *uint, fileName string, fileSize int64) (*models.DownloadPackage, error) {
+	pkg, domain, err := s.PrepareDownloadPackage(domainID, awsAccountID, fileName, fileSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(pkg).Error; err != nil {
+		return nil, fmt.Errorf("创建下载包记录失败: %w", err)
+	}
+
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"package_id": pkg.ID,
+		"domain_id":  domainID,
+		"s3_key":     pkg.S3Key,
+	}).Info("文件已上传，下载包记录创建成功，开始异步处理")
+
+	go s.processDownloadPackageAsync(pkg, nil, domain)
+	return pkg, nil
+}
+
 // processDownloadPackageAsync 异步处理下载包
 func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.DownloadPackage, fileReader io.ReadSeeker, domain *models.Domain) {
 	log := logger.GetLogger()
@@ -159,13 +218,24 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 	// 更新状态为上传中
 	s.db.Model(pkg).Update("status", models.DownloadPackageStatusUploading)
 
+	// S3 文件和 CloudFront 分发都在下载包关联的 AWS 账号下
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		log.WithError(err).WithField("package_id", pkg.ID).Error("获取 AWS 账号客户端失败")
+		s.db.Model(pkg).Updates(map[string]interface{}{
+			"status":        models.DownloadPackageStatusFailed,
+			"error_message": fmt.Sprintf("获取 AWS 账号客户端失败: %v", err),
+		})
+		return
+	}
+
 	// 确保 S3 bucket policy 允许公开访问 downloads/* 路径
-	if s.config.S3BucketName != "" {
-		log.WithField("bucket_name", s.config.S3BucketName).Info("检查S3 bucket policy配置")
-		if err := s.s3Svc.EnsureBucketPolicyForDownloads(s.config.S3BucketName); err != nil {
+	if clients.Config.S3BucketName != "" {
+		log.WithField("bucket_name", clients.Config.S3BucketName).Info("检查S3 bucket policy配置")
+		if err := clients.S3.EnsureBucketPolicyForDownloads(clients.Config.S3BucketName); err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"package_id":  pkg.ID,
-				"bucket_name": s.config.S3BucketName,
+				"bucket_name": clients.Config.S3BucketName,
 			}).Error("配置 S3 bucket policy 失败")
 			s.db.Model(pkg).Updates(map[string]interface{}{
 				"status":        models.DownloadPackageStatusFailed,
@@ -173,47 +243,49 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 			})
 			return
 		}
-		log.WithField("bucket_name", s.config.S3BucketName).Info("S3 bucket policy配置成功")
-	}
-
-	// 1. 上传文件到S3
-	// 确保文件读取器位置在开始
-	if seeker, ok := fileReader.(io.Seeker); ok {
-		seeker.Seek(0, io.SeekStart)
+		log.WithField("bucket_name", clients.Config.S3BucketName).Info("S3 bucket policy配置成功")
 	}
 
-	// 确定Content-Type
-	contentType := pkg.FileType
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
+	// 1. 上传文件到S3（fileReader 为空表示文件已通过分片上传写入 S3）
+	if fileReader != nil {
+		// 确保文件读取器位置在开始
+		if seeker, ok := fileReader.(io.Seeker); ok {
+			seeker.Seek(0, io.SeekStart)
+		}
 
-	log.WithFields(map[string]interface{}{
-		"package_id":   pkg.ID,
-		"s3_key":       pkg.S3Key,
-		"bucket_name":  s.config.S3BucketName,
-		"content_type": contentType,
-		"file_size":    pkg.FileSize,
-	}).Info("开始上传文件到S3")
+		// 确定Content-Type
+		contentType := pkg.FileType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
 
-	// 上传文件到S3（使用public-read ACL以便CloudFront访问）
-	if err := s.s3Svc.UploadFileWithACL(s.config.S3BucketName, pkg.S3Key, fileReader, contentType, "public-read"); err != nil {
-		log.WithError(err).WithFields(map[string]interface{}{
-			"package_id":  pkg.ID,
-			"s3_key":      pkg.S3Key,
-			"bucket_name": s.config.S3BucketName,
-		}).Error("上传文件到S3失败")
-		s.db.Model(pkg).Updates(map[string]interface{}{
-			"status":        models.DownloadPackageStatusFailed,
-			"error_message": fmt.Sprintf("上传文件到S3失败: %v", err),
-		})
-		return
+		log.WithFields(map[string]interface{}{
+			"package_id":   pkg.ID,
+			"s3_key":       pkg.S3Key,
+			"bucket_name":  clients.Config.S3BucketName,
+			"content_type": contentType,
+			"file_size":    pkg.FileSize,
+		}).Info("开始上传文件到S3")
+
+		// 上传文件到S3（使用public-read ACL以便CloudFront访问）
+		if err := clients.S3.UploadFileWithACL(clients.Config.S3BucketName, pkg.S3Key, fileReader, contentType, "public-read"); err != nil {
+			log.WithError(err).WithFields(map[string]interface{}{
+				"package_id":  pkg.ID,
+				"s3_key":      pkg.S3Key,
+				"bucket_name": clients.Config.S3BucketName,
+			}).Error("上传文件到S3失败")
+			s.db.Model(pkg).Updates(map[string]interface{}{
+				"status":        models.DownloadPackageStatusFailed,
+				"error_message": fmt.Sprintf("上传文件到S3失败: %v", err),
+			})
+			return
+		}
 	}
 
 	log.WithFields(map[string]interface{}{
 		"package_id":  pkg.ID,
 		"s3_key":      pkg.S3Key,
-		"bucket_name": s.config.S3BucketName,
+		"bucket_name": clients.Config.S3BucketName,
 	}).Info("文件上传到S3成功，开始验证文件存在性")
 
 	// 验证文件是否真的存在于S3中（使用重试机制，因为S3可能有最终一致性延迟）
@@ -245,7 +317,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 			time.Sleep(retryInterval)
 		}
 
-		exists, lastErr = s.s3Svc.ObjectExists(s.config.S3BucketName, pkg.S3Key)
+		exists, lastErr = clients.S3.ObjectExists(clients.Config.S3BucketName, pkg.S3Key)
 		if lastErr != nil {
 			log.WithError(lastErr).WithFields(map[string]interface{}{
 				"package_id":    pkg.ID,
@@ -315,12 +387,19 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 		return
 	}
 
+	// 记录文件 SHA-256 和 APK 元数据（不影响下载包处理，使用副本避免与后续更新并发读写）
+	inspected := *pkg
+	go func() {
+		defer recoverInspection(map[string]interface{}{"package_id": inspected.ID})
+		s.inspectPackageFile(&inspected, fileReader)
+	}()
+
 	// 更新状态为处理中
 	s.db.Model(pkg).Update("status", models.DownloadPackageStatusProcessing)
 	log.WithField("package_id", pkg.ID).Info("文件验证成功，开始处理CloudFront配置")
 
 	// 2. 获取S3域名
-	s3Origin := s.s3Svc.GetBucketDomain(s.config.S3BucketName)
+	s3Origin := clients.S3.GetBucketDomain(clients.Config.S3BucketName)
 	log.WithFields(map[string]interface{}{
 		"package_id": pkg.ID,
 		"s3_origin":  s3Origin,
@@ -329,7 +408,6 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 	// 3. 检查该域名是否已有CloudFront分发（用于支持同一域名下多个文件）
 	var cloudFrontID string
 	var cloudFrontDomain string
-	var err error
 
 	// 查找该域名下是否已有其他下载包（已完成状态）
 	var existingPackage models.DownloadPackage
@@ -386,7 +464,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 			}).Info("找到合适的证书")
 		}
 
-		cloudFrontID, err = s.cloudFrontSvc.CreateDistributionForLargeFileDownload(
+		cloudFrontID, err = clients.CloudFront.CreateDistributionForLargeFileDownload(
 			pkg.DomainName,
 			certificateARN,
 			s3Origin,
@@ -411,7 +489,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 				"domain_name": pkg.DomainName,
 			}).Warn("CNAME 已存在但未找到对应的 CloudFront 分发，尝试通过列表查找")
 			// 尝试通过列出所有分发来查找
-			distList, listErr := s.cloudFrontSvc.ListDistributions()
+			distList, listErr := clients.CloudFront.ListDistributions()
 			if listErr == nil && distList != nil && distList.Items != nil {
 				for _, dist := range distList.Items {
 					log.WithFields(map[string]interface{}{
@@ -454,7 +532,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 		}).Info("CloudFront分发创建成功，获取域名")
 
 		// 获取CloudFront域名
-		cloudFrontDomain, err = s.cloudFrontSvc.GetDistributionDomain(cloudFrontID)
+		cloudFrontDomain, err = clients.CloudFront.GetDistributionDomain(cloudFrontID)
 		if err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"package_id":    pkg.ID,
@@ -473,14 +551,14 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 			"cloudfront_domain": cloudFrontDomain,
 		}).Info("CloudFront域名获取成功")
 	}
-	// 4. 将域名绑定到CloudFront（创建Route53 A记录）
+	// 4. 将域名绑定到CloudFront（按域名的 DNS 提供商创建记录：Route 53 为 A 记录 Alias，Cloudflare 为 CNAME）
 	if domain.HostedZoneID != "" {
 		log.WithFields(map[string]interface{}{
 			"package_id":        pkg.ID,
 			"domain_name":       pkg.DomainName,
 			"hosted_zone_id":    domain.HostedZoneID,
 			"cloudfront_domain": cloudFrontDomain,
-		}).Info("开始配置Route53 DNS记录")
+		}).Info("开始配置DNS记录")
 		// 等待一下让CloudFront分发完全部署
 		time.Sleep(5 * time.Second)
 
@@ -525,7 +603,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 		log.WithFields(map[string]interface{}{
 			"package_id":  pkg.ID,
 			"domain_name": pkg.DomainName,
-		}).Warn("域名未配置HostedZoneID，跳过Route53 DNS配置")
+		}).Warn("域名未配置HostedZoneID，跳过DNS配置")
 	}
 
 	// 5. 构建下载URL
@@ -580,7 +658,7 @@ func (s *DownloadPackageService) processDownloadPackageAsync(pkg *models.Downloa
 		"cloudfront_id": cloudFrontID,
 	}).Info("启用CloudFront分发")
 	enabled := true
-	if err := s.cloudFrontSvc.UpdateDistribution(cloudFrontID, nil, "", &enabled); err != nil {
+	if err := clients.CloudFront.UpdateDistribution(cloudFrontID, nil, "", &enabled); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"package_id":    pkg.ID,
 			"cloudfront_id": cloudFrontID,
@@ -621,12 +699,16 @@ func (s *DownloadPackageService) GetDownloadPackage(id uint) (*models.DownloadPa
 }
 
 // GetCloudFrontStatus 获取CloudFront分发状态
-func (s *DownloadPackageService) GetCloudFrontStatus(cloudFrontID string) (string, error) {
-	if cloudFrontID == "" {
+func (s *DownloadPackageService) GetCloudFrontStatus(pkg *models.DownloadPackage) (string, error) {
+	if pkg.CloudFrontID == "" {
 		return "", nil
 	}
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return "", err
+	}
 
-	dist, err := s.cloudFrontSvc.GetDistribution(cloudFrontID)
+	dist, err := clients.CloudFront.GetDistribution(pkg.CloudFrontID)
 	if err != nil {
 		return "", err
 	}
@@ -639,12 +721,16 @@ func (s *DownloadPackageService) GetCloudFrontStatus(cloudFrontID string) (strin
 }
 
 // GetCloudFrontEnabled 获取CloudFront分发启用状态
-func (s *DownloadPackageService) GetCloudFrontEnabled(cloudFrontID string) (bool, error) {
-	if cloudFrontID == "" {
+func (s *DownloadPackageService) GetCloudFrontEnabled(pkg *models.DownloadPackage) (bool, error) {
+	if pkg.CloudFrontID == "" {
 		return false, nil
 	}
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return false, err
+	}
 
-	dist, err := s.cloudFrontSvc.GetDistribution(cloudFrontID)
+	dist, err := clients.CloudFront.GetDistribution(pkg.CloudFrontID)
 	if err != nil {
 		return false, err
 	}
@@ -667,7 +753,11 @@ func (s *DownloadPackageService) GetCloudFrontOriginPathInfo(pkg *models.Downloa
 
 	// 获取当前的 OriginPath
 	if pkg.CloudFrontID != "" {
-		currentPath, err = s.cloudFrontSvc.GetDistributionOriginPath(pkg.CloudFrontID)
+		clients, clientErr := s.awsClientsForPackage(pkg)
+		if clientErr != nil {
+			return "", expectedPath, clientErr
+		}
+		currentPath, err = clients.CloudFront.GetDistributionOriginPath(pkg.CloudFrontID)
 		if err != nil {
 			return "", expectedPath, err
 		}
@@ -752,15 +842,40 @@ func (s *DownloadPackageService) DeleteDownloadPackage(id uint) error {
 	if err != nil {
 		return err
 	}
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return err
+	}
+
+	// 先查出历史版本，查询失败时不删除任何文件，避免版本文件和记录成为孤儿
+	var versions []models.DownloadPackageVersion
+	if err := s.db.Where("package_id = ?", pkg.ID).Find(&versions).Error; err != nil {
+		return fmt.Errorf("查询下载包版本失败: %w", err)
+	}
 
 	// 删除S3文件
 	if pkg.S3Key != "" {
-		if err := s.s3Svc.DeleteObject(s.config.S3BucketName, pkg.S3Key); err != nil {
+		if err := clients.S3.DeleteObject(clients.Config.S3BucketName, pkg.S3Key); err != nil {
 			// 记录错误但不阻止删除，因为文件可能已经被删除
 			// 可以记录日志，这里简化处理
 		}
 	}
 
+	// 删除历史版本文件和记录
+	for _, version := range versions {
+		if err := clients.S3.DeleteObject(clients.Config.S3BucketName, version.S3Key); err != nil {
+			logger.GetLogger().WithError(err).WithFields(map[string]interface{}{
+				"package_id": pkg.ID,
+				"s3_key":     version.S3Key,
+			}).Warn("删除下载包版本文件失败，需要手动清理")
+		}
+	}
+	if len(versions) > 0 {
+		if err := s.db.Where("package_id = ?", pkg.ID).Delete(&models.DownloadPackageVersion{}).Error; err != nil {
+			return fmt.Errorf("删除下载包版本记录失败: %w", err)
+		}
+	}
+
 	// 检查该CloudFront分发下是否还有其他文件
 	// 如果这是该域名下最后一个文件，可以选择保留CloudFront分发（因为可能还会添加新文件）
 	// 或者删除CloudFront分发（这里选择保留，因为删除CloudFront分发需要先禁用，然后等待，比较复杂）
@@ -815,6 +930,10 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 		return nil, fmt.Errorf("获取下载包失败: %w", err)
 	}
 	status.PackageExists = true
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取域名信息
 	domain, err := s.domainService.GetDomain(pkg.DomainID)
@@ -826,8 +945,8 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 	}
 
 	// 检查S3文件是否存在
-	if s.config.S3BucketName != "" && pkg.S3Key != "" {
-		exists, err := s.s3Svc.ObjectExists(s.config.S3BucketName, pkg.S3Key)
+	if clients.Config.S3BucketName != "" && pkg.S3Key != "" {
+		exists, err := clients.S3.ObjectExists(clients.Config.S3BucketName, pkg.S3Key)
 		if err != nil {
 			status.S3FileError = err.Error()
 			status.Issues = append(status.Issues, fmt.Sprintf("检查S3文件失败: %v", err))
@@ -840,14 +959,14 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 		if pkg.S3Key == "" {
 			status.Issues = append(status.Issues, "S3键未配置")
 		}
-		if s.config.S3BucketName == "" {
+		if clients.Config.S3BucketName == "" {
 			status.Issues = append(status.Issues, "S3存储桶名称未配置")
 		}
 	}
 
 	// 检查CloudFront分发是否存在
 	if pkg.CloudFrontID != "" {
-		_, err := s.cloudFrontSvc.GetDistribution(pkg.CloudFrontID)
+		_, err := clients.CloudFront.GetDistribution(pkg.CloudFrontID)
 		if err != nil {
 			status.CloudFrontError = err.Error()
 			status.Issues = append(status.Issues, fmt.Sprintf("CloudFront分发不存在或无法访问: %v", err))
@@ -855,7 +974,7 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 			status.CloudFrontExists = true
 
 			// 检查 CloudFront 是否已启用
-			enabled, err := s.GetCloudFrontEnabled(pkg.CloudFrontID)
+			enabled, err := s.GetCloudFrontEnabled(pkg)
 			if err != nil {
 				status.CloudFrontEnabledError = fmt.Sprintf("检查CloudFront启用状态失败: %v", err)
 				status.Issues = append(status.Issues, "检查CloudFront启用状态失败")
@@ -872,7 +991,7 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 			status.CloudFrontOriginPathExpected = expectedOriginPath
 
 			// 获取当前的 OriginPath
-			currentOriginPath, err := s.cloudFrontSvc.GetDistributionOriginPath(pkg.CloudFrontID)
+			currentOriginPath, err := clients.CloudFront.GetDistributionOriginPath(pkg.CloudFrontID)
 			if err != nil {
 				status.CloudFrontOriginPathError = fmt.Sprintf("获取 CloudFront OriginPath 失败: %v", err)
 				status.Issues = append(status.Issues, "检查 CloudFront OriginPath 失败")
@@ -886,21 +1005,21 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 				}
 			}
 
-			// 检查 Route 53 DNS 记录是否指向正确的 CloudFront
+			// 检查 DNS 记录（Route 53 或 Cloudflare）是否指向正确的 CloudFront
 			if domain.HostedZoneID != "" && pkg.CloudFrontDomain != "" {
 				exists, err := s.domainService.CheckCloudFrontCNAMERecord(domain, pkg.CloudFrontDomain)
 				if err != nil {
-					status.Route53DNSError = fmt.Sprintf("检查Route 53 DNS记录失败: %v", err)
-					status.Issues = append(status.Issues, "检查Route 53 DNS记录失败")
+					status.Route53DNSError = fmt.Sprintf("检查DNS记录失败: %v", err)
+					status.Issues = append(status.Issues, "检查DNS记录失败")
 				} else if !exists {
-					status.Route53DNSError = "未配置Route 53 DNS记录或指向错误的CloudFront分发"
-					status.Issues = append(status.Issues, "Route 53 DNS记录未配置或指向错误")
+					status.Route53DNSError = "未配置DNS记录或指向错误的CloudFront分发"
+					status.Issues = append(status.Issues, "DNS记录未配置或指向错误")
 				} else {
 					status.Route53DNSConfigured = true
 				}
 			} else {
 				if domain.HostedZoneID == "" {
-					status.Issues = append(status.Issues, "域名未配置Route 53托管区域")
+					status.Issues = append(status.Issues, "域名未配置托管区域")
 				}
 				if pkg.CloudFrontDomain == "" {
 					status.Issues = append(status.Issues, "CloudFront域名未配置")
@@ -939,7 +1058,7 @@ func (s *DownloadPackageService) CheckDownloadPackage(id uint) (*DownloadPackage
 	}
 
 	// 判断是否可以修复
-	status.CanFix = len(status.Issues) > 0 && s.config.S3BucketName != ""
+	status.CanFix = len(status.Issues) > 0 && clients.Config.S3BucketName != ""
 
 	return status, nil
 }
@@ -951,6 +1070,10 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 	if err != nil {
 		return fmt.Errorf("获取下载包失败: %w", err)
 	}
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return err
+	}
 
 	// 获取域名信息
 	domain, err := s.domainService.GetDomain(pkg.DomainID)
@@ -965,7 +1088,7 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 
 	// 如果已有CloudFront ID，先检查是否存在和是否已启用
 	if pkg.CloudFrontID != "" {
-		dist, err := s.cloudFrontSvc.GetDistribution(pkg.CloudFrontID)
+		dist, err := clients.CloudFront.GetDistribution(pkg.CloudFrontID)
 		if err != nil {
 			// CloudFront不存在，清除ID，重新创建
 			s.db.Model(pkg).Update("cloudfront_id", "")
@@ -976,7 +1099,7 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 				enabled := dist.DistributionConfig.Enabled
 				if enabled == nil || !*enabled {
 					enabledValue := true
-					if err := s.cloudFrontSvc.UpdateDistribution(pkg.CloudFrontID, nil, "", &enabledValue); err != nil {
+					if err := clients.CloudFront.UpdateDistribution(pkg.CloudFrontID, nil, "", &enabledValue); err != nil {
 						return fmt.Errorf("启用CloudFront分发失败: %w", err)
 					}
 				}
@@ -987,14 +1110,14 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 			expectedOriginPath := fmt.Sprintf("/downloads/%s", pkg.DomainName)
 
 			// 获取当前的 OriginPath
-			currentOriginPath, err := s.cloudFrontSvc.GetDistributionOriginPath(pkg.CloudFrontID)
+			currentOriginPath, err := clients.CloudFront.GetDistributionOriginPath(pkg.CloudFrontID)
 			if err != nil {
 				return fmt.Errorf("获取 CloudFront OriginPath 失败: %w", err)
 			}
 
 			// 如果路径不匹配，更新它
 			if currentOriginPath != expectedOriginPath {
-				if err := s.cloudFrontSvc.UpdateDistributionOriginPath(pkg.CloudFrontID, expectedOriginPath); err != nil {
+				if err := clients.CloudFront.UpdateDistributionOriginPath(pkg.CloudFrontID, expectedOriginPath); err != nil {
 					return fmt.Errorf("更新 CloudFront OriginPath 失败: %w", err)
 				}
 			}
@@ -1002,15 +1125,15 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 	}
 
 	// 确保 S3 bucket policy 允许公开访问 downloads/* 路径
-	if s.config.S3BucketName != "" {
-		if err := s.s3Svc.EnsureBucketPolicyForDownloads(s.config.S3BucketName); err != nil {
+	if clients.Config.S3BucketName != "" {
+		if err := clients.S3.EnsureBucketPolicyForDownloads(clients.Config.S3BucketName); err != nil {
 			return fmt.Errorf("配置 S3 bucket policy 失败: %w", err)
 		}
 	}
 
 	// 检查S3文件是否存在
-	if pkg.S3Key != "" && s.config.S3BucketName != "" {
-		exists, err := s.s3Svc.ObjectExists(s.config.S3BucketName, pkg.S3Key)
+	if pkg.S3Key != "" && clients.Config.S3BucketName != "" {
+		exists, err := clients.S3.ObjectExists(clients.Config.S3BucketName, pkg.S3Key)
 		if err != nil {
 			return fmt.Errorf("检查S3文件失败: %w", err)
 		}
@@ -1022,13 +1145,13 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 	// 如果CloudFront分发不存在，重新创建
 	if pkg.CloudFrontID == "" {
 		// 获取S3域名
-		s3Origin := s.s3Svc.GetBucketDomain(s.config.S3BucketName)
+		s3Origin := clients.S3.GetBucketDomain(clients.Config.S3BucketName)
 
 		// 计算originPath：同一域名下的所有文件都使用相同的目录路径 downloads/{domain_name}/
 		originPath := fmt.Sprintf("/downloads/%s", pkg.DomainName)
 
 		// 创建CloudFront分发
-		cloudFrontID, err := s.cloudFrontSvc.CreateDistributionForLargeFileDownload(
+		cloudFrontID, err := clients.CloudFront.CreateDistributionForLargeFileDownload(
 			pkg.DomainName,
 			domain.CertificateARN,
 			s3Origin,
@@ -1054,7 +1177,7 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 				}).Warn("CNAME 已存在但未找到对应的 CloudFront 分发，尝试通过列表查找")
 			}
 			// 尝试通过列出所有分发来查找
-			distList, listErr := s.cloudFrontSvc.ListDistributions()
+			distList, listErr := clients.CloudFront.ListDistributions()
 			if listErr == nil && distList != nil && distList.Items != nil {
 				for _, dist := range distList.Items {
 					log.WithFields(map[string]interface{}{
@@ -1096,7 +1219,7 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 		}
 
 		// 获取CloudFront域名
-		cloudFrontDomain, err := s.cloudFrontSvc.GetDistributionDomain(cloudFrontID)
+		cloudFrontDomain, err := clients.CloudFront.GetDistributionDomain(cloudFrontID)
 		if err != nil {
 			return fmt.Errorf("获取CloudFront域名失败: %w", err)
 		}
@@ -1131,7 +1254,7 @@ func (s *DownloadPackageService) FixDownloadPackage(id uint) error {
 
 	// 确保 CloudFront 分发已启用
 	enabled := true
-	if err := s.cloudFrontSvc.UpdateDistribution(pkg.CloudFrontID, nil, "", &enabled); err != nil {
+	if err := clients.CloudFront.UpdateDistribution(pkg.CloudFrontID, nil, "", &enabled); err != nil {
 		return fmt.Errorf("启用CloudFront分发失败: %w", err)
 	}
 
@@ -1149,15 +1272,126 @@ func (s *DownloadPackageService) GetDomain(domainID uint) (*models.Domain, error
 	return s.domainService.GetDomain(domainID)
 }
 
-// CheckS3BucketPolicyForDownloads 检查 S3 Bucket Policy 是否已配置
-func (s *DownloadPackageService) CheckS3BucketPolicyForDownloads() (bool, error) {
-	if s.config.S3BucketName == "" {
+// CheckS3BucketPolicyForDownloads 检查下载包所在存储桶的 S3 Bucket Policy 是否已配置
+func (s *DownloadPackageService) CheckS3BucketPolicyForDownloads(pkg *models.DownloadPackage) (bool, error) {
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return false, err
+	}
+	if clients.Config.S3BucketName == "" {
 		return false, nil
 	}
-	return s.s3Svc.CheckBucketPolicyForDownloads(s.config.S3BucketName)
+	return clients.S3.CheckBucketPolicyForDownloads(clients.Config.S3BucketName)
 }
 
 // UpdateDownloadPackageNote 更新下载包备注
 func (s *DownloadPackageService) UpdateDownloadPackageNote(id uint, note string) error {
 	return s.db.Model(&models.DownloadPackage{}).Where("id = ?", id).Update("note", note).Error
 }
+
+// InvalidationCompleted 最近一次发布版本的 CloudFront 缓存失效是否已完成（没有发布过版本视为已完成）
+// 处理中时向 CloudFront 查询并保存最新状态；创建失效失败或状态停留在待失效时，发布后超过分发的 MaxTTL 视为已完成（旧文件的缓存必然已过期）
+func (s *DownloadPackageService) InvalidationCompleted(pkg *models.DownloadPackage) (bool, error) {
+	switch pkg.InvalidationStatus {
+	case "", models.InvalidationStatusCompleted:
+		return true, nil
+	case models.InvalidationStatusInProgress:
+	default:
+		return s.cacheExpiredSincePromote(pkg), nil
+	}
+
+	clients, err := s.awsClientsForPackage(pkg)
+	if err != nil {
+		return false, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+	}
+	status, err := clients.CloudFront.GetInvalidationStatus(pkg.CloudFrontID, pkg.InvalidationID)
+	if err != nil {
+		return false, err
+	}
+	if status != models.InvalidationStatusCompleted {
+		return false, nil
+	}
+	// 只在失效 ID 未变化时更新，避免覆盖期间新发布的版本
+	if err := s.db.Model(&models.DownloadPackage{}).
+		Where("id = ? AND invalidation_id = ?", pkg.ID, pkg.InvalidationID).
+		Update("invalidation_status", models.InvalidationStatusCompleted).Error; err != nil {
+		return false, fmt.Errorf("保存缓存失效状态失败: %w", err)
+	}
+	pkg.InvalidationStatus = models.InvalidationStatusCompleted
+	return true, nil
+}
+
+// cacheExpiredSincePromote 发布版本后是否已超过分发的 MaxTTL；发布时间未知时视为已过期，查询不到 MaxTTL 时按 CloudFront 默认的一年计算
+func (s *DownloadPackageService) cacheExpiredSincePromote(pkg *models.DownloadPackage) bool {
+	if pkg.InvalidationSince == nil {
+		return true
+	}
+	maxTTL := defaultCloudFrontMaxTTL
+	clients, err := s.awsClientsForPackage(pkg)
+	if err == nil {
+		maxTTL, err = clients.CloudFront.GetDistributionMaxTTL(pkg.CloudFrontID)
+	}
+	if err != nil {
+		logger.GetLogger().WithError(err).WithField("package_id", pkg.ID).Warn("获取分发 MaxTTL 失败，按默认值计算")
+		maxTTL = defaultCloudFrontMaxTTL
+	}
+	return time.Since(*pkg.InvalidationSince) >= maxTTL
+}
+
+// InspectDownloadPackage 重新计算下载包文件的 SHA-256 并解析 APK 元数据（从 S3 读取）
+func (s *DownloadPackageService) InspectDownloadPackage(id uint) (*models.DownloadPackage, error) {
+	pkg, err := s.GetDownloadPackage(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.inspectPackageFile(pkg, nil); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// inspectPackageFile 检查下载包文件并保存结果；fileReader 为空时从 S3 读取
+func (s *DownloadPackageService) inspectPackageFile(pkg *models.DownloadPackage, fileReader io.Reader) error {
+	log := logger.GetLogger()
+	if fileReader == nil {
+		clients, err := s.awsClientsForPackage(pkg)
+		if err != nil {
+			return fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+		}
+		body, err := clients.S3.GetObject(clients.Config.S3BucketName, pkg.S3Key)
+		if err != nil {
+			log.WithError(err).WithField("package_id", pkg.ID).Warn("读取下载包文件失败，无法记录文件哈希")
+			return err
+		}
+		defer body.Close()
+		fileReader = body
+	}
+
+	result := inspectFile(fileReader, pkg.FileSize, pkg.FileName)
+	if err := s.db.Model(&models.DownloadPackage{}).Where("id = ?", pkg.ID).Updates(inspectionUpdates(result)).Error; err != nil {
+		return fmt.Errorf("保存文件检查结果失败: %w", err)
+	}
+	pkg.FileInspection = result
+
+	fields := map[string]interface{}{
+		"package_id":  pkg.ID,
+		"sha256":      result.FileSHA256,
+		"apk_package": result.APK.PackageName,
+		"apk_version": result.APK.VersionName,
+	}
+	if problems := apkReleaseProblems(result, models.FileInspection{}, pkg.FileName); len(problems) > 0 {
+		log.WithFields(fields).WithField("problems", problems).Warn("下载包 APK 检查发现问题")
+	} else {
+		log.WithFields(fields).Info("下载包文件检查完成")
+	}
+	return nil
+}
+
+// sanitizeFileName 只保留文件名的最后一段（兼容 Windows 路径分隔符）
+func sanitizeFileName(name string) (string, error) {
+	name = filepath.Base(strings.ReplaceAll(strings.TrimSpace(name), `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		return "", fmt.Errorf("文件名无效")
+	}
+	return name, nil
+}
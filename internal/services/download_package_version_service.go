@@ -0,0 +1,494 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DownloadPackageVersionService 下载包版本管理
+// 新版本上传到 versions/<域名>/<时间戳>/<文件名>，发布时复制到下载包的 S3Key 并刷新 CloudFront 缓存，下载地址不变。
+// 同一域名下的下载包共用一个 CloudFront 分发（源路径 /downloads/<域名>），修改源路径会影响同域名的其他文件，因此通过复制对象切换版本。
+type DownloadPackageVersionService struct {
+	db                     *gorm.DB
+	downloadPackageService *DownloadPackageService
+	mu                     sync.Mutex // 串行化本进程内的版本登记和发布；多个副本之间通过条件更新 current_version_id 检测并发发布
+}
+
+// errCurrentVersionChanged 条件更新 current_version_id 时当前版本已被其他请求（其他副本）修改
+var errCurrentVersionChanged = errors.New("当前版本已被其他请求修改")
+
+// NewDownloadPackageVersionService 创建下载包版本服务
+func NewDownloadPackageVersionService(db *gorm.DB, downloadPackageService *DownloadPackageService) *DownloadPackageVersionService {
+	return &DownloadPackageVersionService{
+		db:                     db,
+		downloadPackageService: downloadPackageService,
+	}
+}
+
+// PromoteVersionResult 发布结果
+type PromoteVersionResult struct {
+	Package        *models.DownloadPackage        `json:"package"`
+	Version        *models.DownloadPackageVersion `json:"version"`
+	InvalidationID string                         `json:"invalidation_id,omitempty"`
+	Warning        string                         `json:"warning,omitempty"` // 文件已切换但刷新缓存失败时的提示
+}
+
+// ListVersions 列出下载包的全部版本（新版本在前）
+func (s *DownloadPackageVersionService) ListVersions(packageID uint) ([]models.DownloadPackageVersion, error) {
+	var versions []models.DownloadPackageVersion
+	if err := s.db.Where("package_id = ?", packageID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("获取下载包版本失败: %w", err)
+	}
+	return versions, nil
+}
+
+// NewVersionKey 校验下载包可以添加版本，并为新版本生成 S3 键
+func (s *DownloadPackageVersionService) NewVersionKey(packageID uint) (*models.DownloadPackage, string, error) {
+	pkg, _, err := s.getPackage(packageID)
+	if err != nil {
+		return nil, "", err
+	}
+	return pkg, versionS3Key(pkg.DomainName, pkg.FileName), nil
+}
+
+// UploadVersion 上传新版本（不会自动发布）
+func (s *DownloadPackageVersionService) UploadVersion(packageID uint, fileName string, fileReader io.ReadSeeker, fileSize int64, note, operator string) (*models.DownloadPackageVersion, error) {
+	pkg, clients, err := s.getPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := pkg.FileType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	key := versionS3Key(pkg.DomainName, pkg.FileName)
+	if err := clients.S3.UploadFileWithACL(clients.Config.S3BucketName, key, fileReader, contentType, "private"); err != nil {
+		return nil, fmt.Errorf("上传版本文件失败: %w", err)
+	}
+	version, err := s.registerVersion(packageID, key, fileName, fileSize, note, operator)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveInspection(version, inspectFile(fileReader, fileSize, pkg.FileName)); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// RegisterVersion 登记已上传到 key 的新版本（上传会话完成后调用），并在后台从 S3 读取文件进行检查
+func (s *DownloadPackageVersionService) RegisterVersion(packageID uint, key, fileName string, fileSize int64, note, operator string) (*models.DownloadPackageVersion, error) {
+	version, err := s.registerVersion(packageID, key, fileName, fileSize, note, operator)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer recoverInspection(map[string]interface{}{"package_id": packageID, "version_id": version.ID})
+		s.InspectVersion(packageID, version.ID)
+	}()
+	return version, nil
+}
+
+// InspectVersion 从 S3 读取版本文件，计算 SHA-256 并解析 APK 元数据
+func (s *DownloadPackageVersionService) InspectVersion(packageID, versionID uint) (*models.DownloadPackageVersion, error) {
+	pkg, clients, err := s.getPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	var version models.DownloadPackageVersion
+	if err := s.db.Where("id = ? AND package_id = ?", versionID, packageID).First(&version).Error; err != nil {
+		return nil, fmt.Errorf("版本不存在: %w", err)
+	}
+
+	body, err := clients.S3.GetObject(clients.Config.S3BucketName, version.S3Key)
+	if err != nil {
+		logger.GetLogger().WithError(err).WithField("version_id", version.ID).Warn("读取版本文件失败，无法检查")
+		return nil, err
+	}
+	defer body.Close()
+	if err := s.saveInspection(&version, inspectFile(body, version.FileSize, pkg.FileName)); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (s *DownloadPackageVersionService) saveInspection(version *models.DownloadPackageVersion, result models.FileInspection) error {
+	if err := s.db.Model(&models.DownloadPackageVersion{}).Where("id = ?", version.ID).Updates(inspectionUpdates(result)).Error; err != nil {
+		return fmt.Errorf("保存文件检查结果失败: %w", err)
+	}
+	version.FileInspection = result
+	return nil
+}
+
+// registerVersion 登记新版本，首次登记时将线上文件保存为 v1
+func (s *DownloadPackageVersionService) registerVersion(packageID uint, key, fileName string, fileSize int64, note, operator string) (*models.DownloadPackageVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, clients, err := s.getPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureInitialVersion(pkg, clients); err != nil {
+		return nil, err
+	}
+
+	version := &models.DownloadPackageVersion{
+		PackageID: pkg.ID,
+		S3Key:     key,
+		FileName:  fileName,
+		FileSize:  fileSize,
+		Note:      note,
+		CreatedBy: operator,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.DownloadPackageVersion{}).Where("package_id = ?", pkg.ID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		return tx.Create(version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存下载包版本失败: %w", err)
+	}
+
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"package_id": pkg.ID,
+		"version":    version.Version,
+		"s3_key":     key,
+	}).Info("下载包新版本已上传")
+	return version, nil
+}
+
+// PromoteVersion 发布指定版本：复制到下载包的 S3Key（对象替换是原子的），更新当前版本并刷新 CloudFront 缓存
+// APK 未签名、包名或签名证书与线上版本不一致时拒绝发布，force 为 true 时跳过检查
+func (s *DownloadPackageVersionService) PromoteVersion(packageID, versionID uint, force bool, operator string) (*PromoteVersionResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var version models.DownloadPackageVersion
+	if err := s.db.Where("id = ? AND package_id = ?", versionID, packageID).First(&version).Error; err != nil {
+		return nil, fmt.Errorf("版本不存在: %w", err)
+	}
+	if !force {
+		pkg, err := s.downloadPackageService.GetDownloadPackage(packageID)
+		if err != nil {
+			return nil, err
+		}
+		current, err := s.currentVersion(packageID)
+		if err != nil {
+			return nil, err
+		}
+		if problems := apkReleaseProblems(version.FileInspection, current.FileInspection, pkg.FileName); len(problems) > 0 {
+			return nil, fmt.Errorf("v%d 发布前检查未通过：%s（确认无误可强制发布）", version.Version, strings.Join(problems, "；"))
+		}
+	}
+	return s.promote(packageID, &version, operator)
+}
+
+// RollbackVersion 回滚到当前版本之前最近一次发布的版本（按发布时间而不是版本号，不做发布前检查）
+func (s *DownloadPackageVersionService) RollbackVersion(packageID uint, operator string) (*PromoteVersionResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.currentVersion(packageID)
+	if err != nil {
+		return nil, err
+	}
+	var promoted []models.DownloadPackageVersion
+	if err := s.db.Where("package_id = ? AND promoted_at IS NOT NULL", packageID).Find(&promoted).Error; err != nil {
+		return nil, fmt.Errorf("查询历史版本失败: %w", err)
+	}
+	previous := previousPromotedVersion(promoted, current.ID)
+	if previous == nil {
+		return nil, fmt.Errorf("当前版本 v%d 之前没有发布过其他版本，无法回滚", current.Version)
+	}
+	return s.promote(packageID, previous, operator)
+}
+
+// previousPromotedVersion 除当前版本外最近一次发布的版本（按发布时间而不是版本号），没有时返回 nil
+func previousPromotedVersion(versions []models.DownloadPackageVersion, currentID uint) *models.DownloadPackageVersion {
+	var previous *models.DownloadPackageVersion
+	for i := range versions {
+		version := &versions[i]
+		if version.ID == currentID || version.PromotedAt == nil {
+			continue
+		}
+		if previous == nil || version.PromotedAt.After(*previous.PromotedAt) {
+			previous = version
+		}
+	}
+	return previous
+}
+
+// checkPromotable 发布前检查：版本必须属于该下载包且不是当前版本
+func checkPromotable(pkg *models.DownloadPackage, version *models.DownloadPackageVersion) error {
+	if version.PackageID != pkg.ID {
+		return fmt.Errorf("v%d 不属于该下载包", version.Version)
+	}
+	if pkg.CurrentVersionID != nil && *pkg.CurrentVersionID == version.ID {
+		return fmt.Errorf("v%d 已是当前版本", version.Version)
+	}
+	return nil
+}
+
+// updateCurrentVersion 仅当下载包的当前版本仍为 expected（为空表示未启用版本管理）时更新，否则返回 errCurrentVersionChanged
+// 多个副本同时发布时只有一个能更新成功
+func updateCurrentVersion(tx *gorm.DB, packageID uint, expected *uint, updates map[string]interface{}) error {
+	query := tx.Model(&models.DownloadPackage{}).Where("id = ?", packageID)
+	if expected == nil {
+		query = query.Where("current_version_id IS NULL")
+	} else {
+		query = query.Where("current_version_id = ?", *expected)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errCurrentVersionChanged
+	}
+	return nil
+}
+
+// DeleteVersion 删除非当前版本
+func (s *DownloadPackageVersionService) DeleteVersion(packageID, versionID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pkg, clients, err := s.getPackage(packageID)
+	if err != nil {
+		return err
+	}
+	var version models.DownloadPackageVersion
+	if err := s.db.Where("id = ? AND package_id = ?", versionID, packageID).First(&version).Error; err != nil {
+		return fmt.Errorf("版本不存在: %w", err)
+	}
+	if pkg.CurrentVersionID != nil && *pkg.CurrentVersionID == version.ID {
+		return fmt.Errorf("v%d 是当前发布的版本，不能删除", version.Version)
+	}
+
+	if err := clients.S3.DeleteObject(clients.Config.S3BucketName, version.S3Key); err != nil {
+		return fmt.Errorf("删除版本文件失败: %w", err)
+	}
+	if err := s.db.Delete(&version).Error; err != nil {
+		return fmt.Errorf("删除版本记录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *DownloadPackageVersionService) promote(packageID uint, version *models.DownloadPackageVersion, operator string) (*PromoteVersionResult, error) {
+	log := logger.GetLogger()
+	pkg, clients, err := s.getPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPromotable(pkg, version); err != nil {
+		return nil, err
+	}
+
+	// 复制后的对象不带 public-read ACL，依赖存储桶策略公开 downloads/*
+	if err := clients.S3.EnsureBucketPolicyForDownloads(clients.Config.S3BucketName); err != nil {
+		return nil, fmt.Errorf("配置 S3 bucket policy 失败: %w", err)
+	}
+	if err := clients.S3.CopyObject(clients.Config.S3BucketName, version.S3Key, pkg.S3Key, version.FileSize); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updates := inspectionUpdates(version.FileInspection)
+		updates["current_version_id"] = version.ID
+		updates["file_size"] = version.FileSize
+		if pkg.CloudFrontID != "" {
+			// 期望哈希立即切换，而 CDN 在缓存失效完成前仍返回旧文件，先标记为待失效以暂停篡改告警
+			updates["invalidation_id"] = ""
+			updates["invalidation_status"] = models.InvalidationStatusPending
+			updates["invalidation_since"] = now
+		}
+		if err := updateCurrentVersion(tx, pkg.ID, pkg.CurrentVersionID, updates); err != nil {
+			return err
+		}
+		return tx.Model(version).Updates(map[string]interface{}{
+			"promoted_at": now,
+			"promoted_by": operator,
+		}).Error
+	})
+	if errors.Is(err, errCurrentVersionChanged) {
+		// 其他副本在复制期间发布了新版本，线上文件可能已被本次复制覆盖，恢复为数据库中的当前版本
+		return nil, s.restoreCurrentFile(pkg.ID, clients, version)
+	}
+	if err != nil {
+		// 线上文件已经切换，记录不一致时需要人工处理，重新发布同一版本即可修复
+		log.WithError(err).WithFields(map[string]interface{}{
+			"package_id": pkg.ID,
+			"version":    version.Version,
+		}).Error("下载包版本文件已切换，但更新当前版本失败")
+		return nil, fmt.Errorf("文件已切换到 v%d，但更新当前版本失败（可重新发布该版本）: %w", version.Version, err)
+	}
+	pkg.CurrentVersionID = &version.ID
+	pkg.FileSize = version.FileSize
+	pkg.FileInspection = version.FileInspection
+	if pkg.CloudFrontID != "" {
+		pkg.InvalidationSince = &now
+	}
+	version.PromotedAt = &now
+	version.PromotedBy = operator
+
+	result := &PromoteVersionResult{Package: pkg, Version: version}
+	if pkg.CloudFrontID != "" {
+		invalidationID, err := clients.CloudFront.CreateInvalidation(pkg.CloudFrontID, []string{packageInvalidationPath(pkg.FileName)})
+		status := models.InvalidationStatusInProgress
+		if err != nil {
+			log.WithError(err).WithField("package_id", pkg.ID).Warn("下载包版本已发布，但刷新 CloudFront 缓存失败")
+			result.Warning = fmt.Sprintf("文件已切换，但刷新 CloudFront 缓存失败，缓存过期前仍可能下载到旧版本: %v", err)
+			status = models.InvalidationStatusFailed
+		}
+		result.InvalidationID = invalidationID
+		pkg.InvalidationID = invalidationID
+		pkg.InvalidationStatus = status
+		if err := s.db.Model(&models.DownloadPackage{}).Where("id = ?", pkg.ID).Updates(map[string]interface{}{
+			"invalidation_id":     invalidationID,
+			"invalidation_status": status,
+		}).Error; err != nil {
+			log.WithError(err).WithField("package_id", pkg.ID).Warn("保存缓存失效状态失败")
+		}
+	}
+
+	log.WithFields(map[string]interface{}{
+		"package_id":      pkg.ID,
+		"version":         version.Version,
+		"invalidation_id": result.InvalidationID,
+		"operator":        operator,
+	}).Info("下载包版本已发布")
+	return result, nil
+}
+
+// restoreCurrentFile 并发发布冲突后，将下载包文件恢复为数据库中当前版本的文件
+func (s *DownloadPackageVersionService) restoreCurrentFile(packageID uint, clients *AWSClients, attempted *models.DownloadPackageVersion) error {
+	log := logger.GetLogger()
+	current, err := s.currentVersion(packageID)
+	if err == nil {
+		pkg, getErr := s.downloadPackageService.GetDownloadPackage(packageID)
+		if getErr != nil {
+			err = getErr
+		} else {
+			err = clients.S3.CopyObject(clients.Config.S3BucketName, current.S3Key, pkg.S3Key, current.FileSize)
+		}
+	}
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"package_id": packageID,
+			"version":    attempted.Version,
+		}).Error("并发发布冲突后恢复线上文件失败")
+		return fmt.Errorf("发布 v%d 时当前版本已被其他请求修改，且恢复线上文件失败（请重新发布当前版本）: %w", attempted.Version, err)
+	}
+	log.WithFields(map[string]interface{}{
+		"package_id": packageID,
+		"version":    attempted.Version,
+		"current":    current.Version,
+	}).Warn("发布期间当前版本已被其他请求修改，已恢复线上文件")
+	return fmt.Errorf("发布 v%d 时当前版本已被其他请求修改为 v%d，请刷新后重试", attempted.Version, current.Version)
+}
+
+// ensureInitialVersion 首次启用版本管理时，将线上文件保存为 v1 并设为当前版本，以便回滚
+func (s *DownloadPackageVersionService) ensureInitialVersion(pkg *models.DownloadPackage, clients *AWSClients) error {
+	if pkg.CurrentVersionID != nil {
+		return nil
+	}
+
+	key := versionS3Key(pkg.DomainName, pkg.FileName)
+	if err := clients.S3.CopyObject(clients.Config.S3BucketName, pkg.S3Key, key, pkg.FileSize); err != nil {
+		return fmt.Errorf("保存初始版本失败: %w", err)
+	}
+	now := time.Now()
+	initial := &models.DownloadPackageVersion{
+		PackageID:  pkg.ID,
+		Version:    1,
+		S3Key:      key,
+		FileName:   pkg.FileName,
+		FileSize:   pkg.FileSize,
+		Note:       "启用版本管理时的线上文件",
+		PromotedAt: &now,
+
+		FileInspection: pkg.FileInspection,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(initial).Error; err != nil {
+			return err
+		}
+		return updateCurrentVersion(tx, pkg.ID, nil, map[string]interface{}{"current_version_id": initial.ID})
+	})
+	if errors.Is(err, errCurrentVersionChanged) {
+		// 其他副本已经保存了初始版本，删除本次复制的文件并使用已有的当前版本
+		if err := clients.S3.DeleteObject(clients.Config.S3BucketName, key); err != nil {
+			logger.GetLogger().WithError(err).WithField("s3_key", key).Warn("删除重复的初始版本文件失败")
+		}
+		latest, err := s.downloadPackageService.GetDownloadPackage(pkg.ID)
+		if err != nil {
+			return err
+		}
+		pkg.CurrentVersionID = latest.CurrentVersionID
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("保存初始版本失败: %w", err)
+	}
+	pkg.CurrentVersionID = &initial.ID
+	return nil
+}
+
+func (s *DownloadPackageVersionService) currentVersion(packageID uint) (*models.DownloadPackageVersion, error) {
+	pkg, err := s.downloadPackageService.GetDownloadPackage(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.CurrentVersionID == nil {
+		return nil, fmt.Errorf("下载包尚未启用版本管理")
+	}
+	var current models.DownloadPackageVersion
+	if err := s.db.First(&current, *pkg.CurrentVersionID).Error; err != nil {
+		return nil, fmt.Errorf("当前版本不存在: %w", err)
+	}
+	return &current, nil
+}
+
+// getPackage 获取已处理完成的下载包及其 AWS 客户端
+func (s *DownloadPackageVersionService) getPackage(packageID uint) (*models.DownloadPackage, *AWSClients, error) {
+	pkg, err := s.downloadPackageService.GetDownloadPackage(packageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pkg.Status != models.DownloadPackageStatusCompleted {
+		return nil, nil, fmt.Errorf("下载包状态为 %s，处理完成后才能管理版本", pkg.Status)
+	}
+	clients, err := s.downloadPackageService.awsClientsForPackage(pkg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+	}
+	if clients.Config.S3BucketName == "" {
+		return nil, nil, fmt.Errorf("S3存储桶名称未配置")
+	}
+	return pkg, clients, nil
+}
+
+// versionS3Key 版本文件的 S3 键，放在 CloudFront 源路径（downloads/）之外
+func versionS3Key(domainName, fileName string) string {
+	return fmt.Sprintf("versions/%s/%d/%s", domainName, time.Now().UnixNano(), fileName)
+}
+
+// packageInvalidationPath 下载包文件的 CloudFront 失效路径，CloudFront 要求路径按 URL 编码
+func packageInvalidationPath(fileName string) string {
+	return (&url.URL{Path: "/" + fileName}).EscapedPath()
+}
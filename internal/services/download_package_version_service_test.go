@@ -0,0 +1,72 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 回滚目标按发布时间选择：跳过当前版本和从未发布的版本，重新发布过的旧版本号也可能是最近一次发布
+func TestPreviousPromotedVersion(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		promoted := base.Add(time.Duration(hours) * time.Hour)
+		return &promoted
+	}
+	versions := []models.DownloadPackageVersion{
+		{ID: 1, Version: 1, PromotedAt: at(1)},
+		{ID: 2, Version: 2, PromotedAt: at(3)},
+		{ID: 3, Version: 3, PromotedAt: at(2)},
+		{ID: 4, Version: 4},
+	}
+	if previous := previousPromotedVersion(versions, 2); previous == nil || previous.ID != 3 {
+		t.Fatalf("previous = %+v, want v3", previous)
+	}
+	if previous := previousPromotedVersion(versions[1:2], 2); previous != nil {
+		t.Fatalf("只有当前版本时不能回滚: %+v", previous)
+	}
+}
+
+// 当前版本不能重复发布，其他下载包的版本不能发布
+func TestCheckPromotable(t *testing.T) {
+	current := uint(2)
+	pkg := &models.DownloadPackage{ID: 1, CurrentVersionID: &current}
+	if err := checkPromotable(pkg, &models.DownloadPackageVersion{ID: 2, PackageID: 1, Version: 2}); err == nil || !strings.Contains(err.Error(), "已是当前版本") {
+		t.Fatalf("err = %v", err)
+	}
+	if err := checkPromotable(pkg, &models.DownloadPackageVersion{ID: 3, PackageID: 9, Version: 1}); err == nil {
+		t.Fatal("其他下载包的版本应被拒绝")
+	}
+	if err := checkPromotable(pkg, &models.DownloadPackageVersion{ID: 1, PackageID: 1, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 当前版本按条件更新：未命中（其他副本已修改）时返回 errCurrentVersionChanged
+func TestUpdateCurrentVersion(t *testing.T) {
+	db := newDryRunDB(t)
+	var statements []string
+	affected := int64(0)
+	if err := db.Callback().Update().After("gorm:update").Register("test:rows_affected", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+		tx.RowsAffected = affected
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := uint(5)
+	if err := updateCurrentVersion(db, 1, &expected, map[string]interface{}{"current_version_id": 6}); !errors.Is(err, errCurrentVersionChanged) {
+		t.Fatalf("未更新任何行时应返回冲突: %v", err)
+	}
+	affected = 1
+	if err := updateCurrentVersion(db, 1, nil, map[string]interface{}{"current_version_id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 2 || !strings.Contains(statements[0], "current_version_id = ?") || !strings.Contains(statements[1], "current_version_id IS NULL") {
+		t.Fatalf("statements = %v", statements)
+	}
+}
@@ -77,7 +77,7 @@ func (s *Ec2InstanceService) ReplaceInstance(id uint) (*models.Ec2Instance, erro
 	// 终止原实例（如果存在）
 	if inst.AWSInstanceID != "" {
 		if err := aws.TerminateInstance(client, inst.AWSInstanceID); err != nil {
-			fmt.Printf("终止原实例失败: ", err)
+			fmt.Printf("终止原实例失败: %v\n", err)
 			//return nil, fmt.Errorf("终止原实例失败: %w", err)
 		}
 	}
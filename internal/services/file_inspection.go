@@ -0,0 +1,145 @@
+package services
+
+import (
+	"aws_cdn/internal/apk"
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// isAPKFile 按扩展名判断是否需要解析 APK 元数据
+func isAPKFile(fileName string) bool {
+	return strings.EqualFold(path.Ext(fileName), ".apk")
+}
+
+// recoverInspection 作为后台检查 goroutine 的 defer 使用：解析异常文件发生 panic 时只记录日志，不让服务进程退出
+func recoverInspection(fields map[string]interface{}) {
+	if r := recover(); r != nil {
+		logger.GetLogger().WithFields(fields).Errorf("文件检查发生 panic: %v", r)
+	}
+}
+
+// inspectFile 计算文件 SHA-256，APK 文件同时解析包名、版本和签名证书
+// APK 解析需要随机读取：body 支持 ReaderAt（内存或本地文件）时直接读取，否则（对象存储下载流）先边写临时文件边计算哈希
+func inspectFile(body io.Reader, size int64, fileName string) models.FileInspection {
+	now := time.Now()
+	result := models.FileInspection{InspectedAt: &now}
+	hash := sha256.New()
+	prefix := sha256.New()
+	sink := io.MultiWriter(hash, &prefixWriter{w: prefix, n: models.FilePrefixHashSize})
+
+	readerAt, ok := body.(io.ReaderAt)
+	switch {
+	case ok:
+		if _, err := io.Copy(sink, io.NewSectionReader(readerAt, 0, size)); err != nil {
+			result.InspectError = fmt.Sprintf("读取文件失败: %v", err)
+			return result
+		}
+	case isAPKFile(fileName):
+		tmp, err := os.CreateTemp("", "apk-inspect-*.apk")
+		if err != nil {
+			result.InspectError = fmt.Sprintf("创建临时文件失败: %v", err)
+			return result
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, io.TeeReader(body, sink)); err != nil {
+			result.InspectError = fmt.Sprintf("读取文件失败: %v", err)
+			return result
+		}
+		readerAt = tmp
+	default:
+		if _, err := io.Copy(sink, body); err != nil {
+			result.InspectError = fmt.Sprintf("读取文件失败: %v", err)
+			return result
+		}
+	}
+	result.FileSHA256 = hex.EncodeToString(hash.Sum(nil))
+	result.FilePrefixSHA256 = hex.EncodeToString(prefix.Sum(nil))
+
+	if !isAPKFile(fileName) {
+		return result
+	}
+	meta, err := apk.Inspect(readerAt, size)
+	if err != nil {
+		result.InspectError = err.Error()
+		return result
+	}
+	result.APK = models.APKInfo{
+		PackageName:       meta.PackageName,
+		VersionCode:       meta.VersionCode,
+		VersionName:       meta.VersionName,
+		MinSDK:            meta.MinSDK,
+		SigningCertSHA256: meta.SigningCertSHA256,
+		SignatureSchemes:  strings.Join(meta.SignatureSchemes, ","),
+	}
+	return result
+}
+
+// prefixWriter 只写入前 n 字节，用于在同一次读取中计算文件前缀哈希
+type prefixWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if p.n > 0 {
+		chunk := b
+		if int64(len(chunk)) > p.n {
+			chunk = chunk[:p.n]
+		}
+		if _, err := p.w.Write(chunk); err != nil {
+			return 0, err
+		}
+		p.n -= int64(len(chunk))
+	}
+	return len(b), nil
+}
+
+// inspectionUpdates 生成保存检查结果的字段（embedded 字段按列名更新）
+func inspectionUpdates(result models.FileInspection) map[string]interface{} {
+	return map[string]interface{}{
+		"file_sha256":             result.FileSHA256,
+		"file_prefix_sha256":      result.FilePrefixSHA256,
+		"apk_package_name":        result.APK.PackageName,
+		"apk_version_code":        result.APK.VersionCode,
+		"apk_version_name":        result.APK.VersionName,
+		"apk_min_sdk":             result.APK.MinSDK,
+		"apk_signing_cert_sha256": result.APK.SigningCertSHA256,
+		"apk_signature_schemes":   result.APK.SignatureSchemes,
+		"inspected_at":            result.InspectedAt,
+		"inspect_error":           result.InspectError,
+	}
+}
+
+// apkReleaseProblems 检查待发布的 APK：未签名，或包名、签名证书与线上版本不一致（可能是其他品牌的包）
+func apkReleaseProblems(candidate, current models.FileInspection, fileName string) []string {
+	if !isAPKFile(fileName) {
+		return nil
+	}
+	if candidate.InspectedAt == nil {
+		return []string{"文件检查尚未完成"}
+	}
+	if candidate.InspectError != "" {
+		return []string{"APK 解析失败: " + candidate.InspectError}
+	}
+
+	var problems []string
+	if candidate.APK.SignatureSchemes == "" {
+		problems = append(problems, "APK 未签名")
+	}
+	if current.APK.PackageName != "" && candidate.APK.PackageName != current.APK.PackageName {
+		problems = append(problems, fmt.Sprintf("包名 %s 与线上版本 %s 不一致", candidate.APK.PackageName, current.APK.PackageName))
+	}
+	if current.APK.SigningCertSHA256 != "" && candidate.APK.SigningCertSHA256 != "" && candidate.APK.SigningCertSHA256 != current.APK.SigningCertSHA256 {
+		problems = append(problems, "签名证书与线上版本不一致")
+	}
+	return problems
+}
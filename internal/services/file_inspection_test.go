@@ -0,0 +1,52 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 非 APK 文件只计算 SHA-256（小于 1MB 时前缀哈希与完整哈希相同）；流式读取和随机读取结果一致
+func TestInspectFileHash(t *testing.T) {
+	const want = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // sha256("hello")
+	for _, body := range []io.Reader{strings.NewReader("hello"), bytes.NewBufferString("hello")} {
+		result := inspectFile(body, 5, "readme.txt")
+		if result.FileSHA256 != want || result.FilePrefixSHA256 != want || result.InspectError != "" || result.InspectedAt == nil {
+			t.Fatalf("inspectFile = %+v", result)
+		}
+	}
+
+	large := strings.Repeat("a", models.FilePrefixHashSize+1)
+	full, prefix := inspectFile(strings.NewReader(large), int64(len(large)), "big.bin"), inspectFile(strings.NewReader(large[:models.FilePrefixHashSize]), models.FilePrefixHashSize, "big.bin")
+	if full.FilePrefixSHA256 != prefix.FileSHA256 || full.FileSHA256 == prefix.FileSHA256 {
+		t.Fatalf("前缀哈希应只覆盖前 1MB: %+v", full)
+	}
+
+	result := inspectFile(strings.NewReader("not a zip"), 9, "app.apk")
+	if result.FileSHA256 == "" || result.InspectError == "" {
+		t.Fatalf("无效 APK 应记录哈希和解析错误: %+v", result)
+	}
+}
+
+// 发布前检查：未签名、包名或签名证书与线上版本不一致时报告问题，非 APK 文件不检查
+func TestAPKReleaseProblems(t *testing.T) {
+	now := time.Now()
+	current := models.FileInspection{InspectedAt: &now, APK: models.APKInfo{PackageName: "com.brand.a", SigningCertSHA256: "aa", SignatureSchemes: "v2"}}
+
+	if problems := apkReleaseProblems(current, current, "app.apk"); len(problems) != 0 {
+		t.Fatalf("相同包应通过检查: %v", problems)
+	}
+	candidate := models.FileInspection{InspectedAt: &now, APK: models.APKInfo{PackageName: "com.brand.b", SigningCertSHA256: "bb"}}
+	if problems := apkReleaseProblems(candidate, current, "app.apk"); len(problems) != 3 {
+		t.Fatalf("应报告未签名、包名和证书不一致: %v", problems)
+	}
+	if problems := apkReleaseProblems(models.FileInspection{}, current, "app.apk"); len(problems) != 1 {
+		t.Fatalf("未检查的文件应报告问题: %v", problems)
+	}
+	if problems := apkReleaseProblems(candidate, current, "app.zip"); problems != nil {
+		t.Fatalf("非 APK 文件不应检查: %v", problems)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"fmt"
+)
+
+// 修复计划步骤的动作
+const (
+	FixActionNone   = "none"   // 已符合预期，不做变更
+	FixActionCreate = "create" // 创建资源
+	FixActionUpdate = "update" // 修改资源
+	FixActionEnsure = "ensure" // 调用幂等接口，已配置时不产生变更
+	FixActionDelete = "delete" // 删除或清除
+)
+
+// FixPlanStep 修复计划中的一个步骤
+type FixPlanStep struct {
+	Name     string `json:"name"`               // 步骤名称
+	Current  string `json:"current"`            // 当前观测到的状态
+	Intended string `json:"intended"`           // 预期变更
+	Action   string `json:"action"`             // none、create、update、ensure、delete
+	APICall  string `json:"api_call,omitempty"` // 将调用的 AWS / Cloudflare 接口（仅修改数据库时为空）
+}
+
+// FixPlan 修复计划（dry-run 结果），由与检查接口相同的检查项生成，不修改任何资源
+type FixPlan struct {
+	Resource string        `json:"resource"` // redirect_rule、download_package
+	ID       uint          `json:"id"`
+	Domain   string        `json:"domain"`
+	Issues   []string      `json:"issues"`             // 检查发现的问题
+	Steps    []FixPlanStep `json:"steps"`              // 按执行顺序排列
+	Blockers []string      `json:"blockers,omitempty"` // 导致修复无法执行的问题
+	Warnings []string      `json:"warnings,omitempty"`
+	CanApply bool          `json:"can_apply"` // 没有阻塞问题且存在需要变更的步骤
+}
+
+// addStep 追加步骤
+func (p *FixPlan) addStep(name, current, intended, action, apiCall string) {
+	if action == FixActionNone {
+		apiCall = ""
+	}
+	p.Steps = append(p.Steps, FixPlanStep{
+		Name:     name,
+		Current:  current,
+		Intended: intended,
+		Action:   action,
+		APICall:  apiCall,
+	})
+}
+
+// finish 根据阻塞问题和步骤计算 CanApply
+func (p *FixPlan) finish() *FixPlan {
+	if p.Issues == nil {
+		p.Issues = []string{}
+	}
+	if p.Steps == nil {
+		p.Steps = []FixPlanStep{}
+	}
+	hasChange := false
+	for _, step := range p.Steps {
+		if step.Action != FixActionNone {
+			hasChange = true
+			break
+		}
+	}
+	p.CanApply = len(p.Blockers) == 0 && hasChange
+	return p
+}
+
+// dnsStepName 写入 DNS 记录的步骤名称，按域名的 DNS 提供商区分
+func dnsStepName(provider models.DNSProvider) string {
+	if provider == models.DNSProviderCloudflare {
+		return "创建 Cloudflare DNS 记录"
+	}
+	return "创建 Route 53 DNS 记录"
+}
+
+// dnsUpsertAPICall 写入 DNS 记录将调用的接口，recordType 为空时表示指向 CloudFront 的记录
+// （与 DNSProvider.CloudFrontRecord 一致：Route 53 为 A 记录 Alias，Cloudflare 为 CNAME）
+func dnsUpsertAPICall(provider models.DNSProvider, name, recordType string) string {
+	if provider == models.DNSProviderCloudflare {
+		if recordType == "" {
+			recordType = "CNAME"
+		}
+		return fmt.Sprintf("cloudflare:dns_records(UPSERT %s %s)", name, recordType)
+	}
+	if recordType == "" {
+		recordType = "A Alias"
+	}
+	return fmt.Sprintf("route53:ChangeResourceRecordSets(UPSERT %s %s)", name, recordType)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"strings"
+	"testing"
+)
+
+// 只有无变更步骤时不可执行；存在阻塞问题时即使有变更也不可执行；无变更步骤不展示接口调用。
+func TestFixPlan_finish(t *testing.T) {
+	plan := &FixPlan{}
+	plan.addStep("启用 CloudFront 分发", "已启用", "保持不变", FixActionNone, "cloudfront:UpdateDistribution")
+	if plan.finish().CanApply {
+		t.Fatal("plan without changes should not be applicable")
+	}
+	if plan.Steps[0].APICall != "" {
+		t.Fatalf("none step should not carry api call: %q", plan.Steps[0].APICall)
+	}
+	if plan.Issues == nil {
+		t.Fatal("issues should be an empty list")
+	}
+
+	plan.addStep("上传 index.html", "S3 文件不存在", "上传", FixActionCreate, "s3:PutObject")
+	if !plan.finish().CanApply {
+		t.Fatal("plan with changes should be applicable")
+	}
+
+	plan.Blockers = append(plan.Blockers, "S3存储桶名称未配置")
+	if plan.finish().CanApply {
+		t.Fatal("blocked plan should not be applicable")
+	}
+}
+
+// DNS 步骤按域名的 DNS 提供商生成：Cloudflare 域名不应出现 Route 53 接口。
+func TestDNSPlanStepsFollowProvider(t *testing.T) {
+	cloudflareCall := dnsUpsertAPICall(models.DNSProviderCloudflare, "example.com", "")
+	if !strings.HasPrefix(cloudflareCall, "cloudflare:") || !strings.Contains(cloudflareCall, "CNAME") || strings.Contains(dnsStepName(models.DNSProviderCloudflare), "Route 53") {
+		t.Fatalf("cloudflare step = %q %q", dnsStepName(models.DNSProviderCloudflare), cloudflareCall)
+	}
+	if call := dnsUpsertAPICall(models.DNSProviderAWS, "example.com", ""); !strings.HasPrefix(call, "route53:") || !strings.Contains(call, "A Alias") {
+		t.Fatalf("route 53 call = %q", call)
+	}
+}
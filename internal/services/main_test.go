@@ -0,0 +1,17 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestMain 测试时丢弃日志，避免在源码目录下创建 logs/app.log
+func TestMain(m *testing.M) {
+	logger.Log = logrus.New()
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
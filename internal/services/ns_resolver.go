@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// NSResolver 查询域名在公网上的 NS 委派
+type NSResolver interface {
+	// LookupNS 返回域名的 NS 主机名（小写、无末尾点）；域名没有 NS 记录时返回空列表和 nil
+	LookupNS(ctx context.Context, domain string) ([]string, error)
+}
+
+// defaultNSResolvers 未配置上游时使用的公共 DNS
+var defaultNSResolvers = []string{"8.8.8.8:53", "1.1.1.1:53"}
+
+// UpstreamNSResolver 通过指定的上游 DNS 服务器查询 NS，按顺序尝试，返回第一个成功的结果
+// 不使用本机的 resolv.conf，避免内网 DNS 或缓存影响委派判断
+type UpstreamNSResolver struct {
+	servers []string
+	timeout time.Duration
+}
+
+// NewUpstreamNSResolver 创建上游 NS 查询器，servers 为 host:port（省略端口时使用 53）
+func NewUpstreamNSResolver(servers []string) *UpstreamNSResolver {
+	normalized := normalizeResolverAddrs(servers)
+	if len(normalized) == 0 {
+		normalized = defaultNSResolvers
+	}
+	return &UpstreamNSResolver{servers: normalized, timeout: 5 * time.Second}
+}
+
+// LookupNS 查询域名的 NS 记录
+func (r *UpstreamNSResolver) LookupNS(ctx context.Context, domain string) ([]string, error) {
+	var lastErr error
+	for _, server := range r.servers {
+		hosts, err := r.lookup(ctx, server, domain)
+		if err == nil {
+			return hosts, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", server, err)
+	}
+	return nil, fmt.Errorf("查询 NS 失败: %w", lastErr)
+}
+
+// upstreamResolver 创建只向指定 DNS 服务器查询的解析器
+func upstreamResolver(server string, timeout time.Duration) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: timeout}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// normalizeResolverAddrs 去掉空项并补全默认端口 53
+func normalizeResolverAddrs(servers []string) []string {
+	var normalized []string
+	for _, server := range servers {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		normalized = append(normalized, server)
+	}
+	return normalized
+}
+
+func (r *UpstreamNSResolver) lookup(ctx context.Context, server, domain string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	records, err := upstreamResolver(server, r.timeout).LookupNS(ctx, normalizeDNSName(domain))
+	if err != nil {
+		// NXDOMAIN 或没有 NS 记录说明尚未委派，不是查询失败
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	hosts := make([]string, 0, len(records))
+	for _, record := range records {
+		hosts = append(hosts, normalizeDNSName(record.Host))
+	}
+	return hosts, nil
+}
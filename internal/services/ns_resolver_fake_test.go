@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeNSResolver 内存中的 NS 查询器，用于测试
+type FakeNSResolver struct {
+	mu      sync.Mutex
+	records map[string][]string
+	errors  map[string]error
+}
+
+// NewFakeNSResolver 创建内存 NS 查询器
+func NewFakeNSResolver() *FakeNSResolver {
+	return &FakeNSResolver{
+		records: make(map[string][]string),
+		errors:  make(map[string]error),
+	}
+}
+
+// SetNS 设置域名的 NS 记录
+func (r *FakeNSResolver) SetNS(domain string, hosts ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		normalized = append(normalized, normalizeDNSName(host))
+	}
+	r.records[normalizeDNSName(domain)] = normalized
+	delete(r.errors, normalizeDNSName(domain))
+}
+
+// SetError 使域名的查询返回错误
+func (r *FakeNSResolver) SetError(domain string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[normalizeDNSName(domain)] = err
+}
+
+// LookupNS 返回预设的 NS 记录
+func (r *FakeNSResolver) LookupNS(_ context.Context, domain string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	domain = normalizeDNSName(domain)
+	if err := r.errors[domain]; err != nil {
+		return nil, err
+	}
+	return append([]string{}, r.records[domain]...), nil
+}
@@ -48,6 +48,9 @@ func (s *R2BucketService) EnableR2(cfAccountID uint) error {
 	if err != nil {
 		return err
 	}
+	if err := requireCFCapabilities(cfAccount, models.CFCapabilityR2); err != nil {
+		return err
+	}
 
 	// 获取 R2 API Token（优先使用 R2APIToken，如果没有则使用 APIToken）
 	r2APIToken := s.cfAccountService.GetR2APIToken(cfAccount)
@@ -71,6 +74,9 @@ func (s *R2BucketService) CreateR2Bucket(cfAccountID uint, bucketName, location,
 	if err != nil {
 		return nil, fmt.Errorf("获取 CF 账号失败: %w", err)
 	}
+	if err := requireCFCapabilities(cfAccount, models.CFCapabilityR2); err != nil {
+		return nil, err
+	}
 
 	// 获取 R2 API Token（优先使用 R2APIToken，如果没有则使用 APIToken）
 	r2APIToken := s.cfAccountService.GetR2APIToken(cfAccount)
@@ -156,6 +162,7 @@ func (s *R2BucketService) DeleteR2Bucket(id uint) error {
 		return fmt.Errorf("获取 Cloudflare 账号失败: %w", err)
 	}
 
+	// 文件检查使用 R2 Access Key（S3 接口），与 API Token 权限无关
 	r2AccessKeyID := s.cfAccountService.GetR2AccessKeyID(cfAccount)
 	r2SecretAccessKey := s.cfAccountService.GetR2SecretAccessKey(cfAccount)
 
@@ -203,6 +210,10 @@ func (s *R2BucketService) DeleteR2Bucket(id uint) error {
 	if r2APIToken == "" {
 		return fmt.Errorf("Cloudflare账号未配置 R2 API Token 或 API Token，无法删除存储桶")
 	}
+	// 删除存储桶调用的是 Cloudflare API，需要 Token 具备 R2 权限
+	if err := requireCFCapabilities(cfAccount, models.CFCapabilityR2); err != nil {
+		return err
+	}
 
 	// 创建 R2 API 服务
 	r2API := cloudflare.NewR2APIService(r2APIToken)
@@ -174,6 +174,19 @@ func min(a, b int) int {
 	return b
 }
 
+// r2S3ForBucket 获取存储桶及其 R2 S3 服务
+func (s *R2FileService) r2S3ForBucket(r2BucketID uint) (*cloudflare.R2S3Service, *models.R2Bucket, error) {
+	var bucket models.R2Bucket
+	if err := s.db.Preload("CFAccount").First(&bucket, r2BucketID).Error; err != nil {
+		return nil, nil, fmt.Errorf("R2存储桶不存在: %w", err)
+	}
+	r2S3, err := s.getR2S3Service(&bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r2S3, &bucket, nil
+}
+
 // UploadFile 上传文件到 R2 存储桶
 func (s *R2FileService) UploadFile(r2BucketID uint, key string, body io.ReadSeeker, contentType string) error {
 	// 获取存储桶信息
@@ -340,5 +353,46 @@ func (s *R2FileService) SyncFileRecord(r2BucketID uint, filePath, fileName strin
 	existing.ContentType = contentType
 	existing.ETag = etag
 	existing.Status = "active" // 恢复为active状态
+	// 文件内容可能已变化，清空旧的检查结果，避免探测端按旧哈希误报篡改
+	existing.FileInspection = models.FileInspection{}
 	return s.db.Save(&existing).Error
 }
+
+// InspectFile 计算 R2 文件的 SHA-256 并解析 APK 元数据，保存到文件记录；body 为空时从 R2 读取
+func (s *R2FileService) InspectFile(r2BucketID uint, filePath string, body io.Reader) (*models.R2File, error) {
+	file, err := s.GetR2FileRecord(r2BucketID, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("文件记录不存在: %w", err)
+	}
+	if body == nil {
+		r2S3, _, err := s.r2S3ForBucket(r2BucketID)
+		if err != nil {
+			return nil, err
+		}
+		object, err := r2S3.GetObject(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer object.Close()
+		body = object
+	}
+
+	var size int64
+	if file.FileSize != nil {
+		size = *file.FileSize
+	}
+	result := inspectFile(body, size, filePath)
+	if err := s.db.Model(&models.R2File{}).Where("id = ?", file.ID).Updates(inspectionUpdates(result)).Error; err != nil {
+		return nil, fmt.Errorf("保存文件检查结果失败: %w", err)
+	}
+	file.FileInspection = result
+
+	if problems := apkReleaseProblems(result, models.FileInspection{}, filePath); len(problems) > 0 {
+		logger.GetLogger().WithFields(map[string]interface{}{
+			"r2_bucket_id": r2BucketID,
+			"file_path":    filePath,
+			"problems":     problems,
+		}).Warn("R2 APK 文件检查发现问题")
+	}
+	return file, nil
+}
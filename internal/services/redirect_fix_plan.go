@@ -0,0 +1,151 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// PlanFixRedirectRule 生成重定向规则的修复计划（dry-run）
+// 基于 CheckRedirectRule 相同的检查项，按 FixRedirectRule 的执行顺序列出每一步的当前状态、预期变更和将调用的接口，
+// 不修改数据库和任何云端资源。
+func (s *RedirectService) PlanFixRedirectRule(ruleID uint) (*FixPlan, error) {
+	rule, err := s.GetRedirectRule(ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取重定向规则失败: %w", err)
+	}
+	status := s.inspectRedirectRule(rule)
+
+	// DNS 步骤按源域名的 DNS 提供商生成，域名未登记时与部署一致按 Route 53 处理
+	dnsProvider := models.DNSProviderAWS
+	var domain models.Domain
+	if err := s.db.Select("dns_provider").Where("domain_name = ?", rule.SourceDomain).First(&domain).Error; err == nil && domain.DNSProvider != "" {
+		dnsProvider = domain.DNSProvider
+	}
+
+	plan := &FixPlan{
+		Resource: "redirect_rule",
+		ID:       rule.ID,
+		Domain:   rule.SourceDomain,
+		Issues:   status.Issues,
+	}
+	if s.config.S3BucketName == "" {
+		plan.Blockers = append(plan.Blockers, "S3存储桶名称未配置")
+	}
+
+	// 已记录的分发不存在时，修复会清除 ID 并重新创建
+	cloudFrontID := rule.CloudFrontID
+	if cloudFrontID != "" && !status.CloudFrontExists {
+		plan.addStep("清除失效的 CloudFront 分发 ID",
+			fmt.Sprintf("分发 %s 不存在或无法访问: %s", cloudFrontID, status.CloudFrontError),
+			"清除规则中的 CloudFront ID，按新分发重新部署",
+			FixActionDelete, "")
+		cloudFrontID = ""
+	}
+
+	if cloudFrontID != "" {
+		if status.CloudFrontEnabled {
+			plan.addStep("启用 CloudFront 分发", "已启用", "保持不变", FixActionNone, "")
+		} else {
+			plan.addStep("启用 CloudFront 分发", describeCheckError(status.CloudFrontEnabledError, "已禁用"), "启用分发",
+				FixActionUpdate, fmt.Sprintf("cloudfront:UpdateDistribution(Id=%s, Enabled=true)", cloudFrontID))
+		}
+
+		expectedOriginPath := fmt.Sprintf("/redirects/%s", rule.SourceDomain)
+		switch {
+		case status.CloudFrontOriginPathMatch:
+			plan.addStep("校正 CloudFront OriginPath", expectedOriginPath, "保持不变", FixActionNone, "")
+		case status.CloudFrontOriginPathCurrent == "" && status.CloudFrontOriginPathError != "":
+			plan.Warnings = append(plan.Warnings, status.CloudFrontOriginPathError)
+		default:
+			plan.addStep("校正 CloudFront OriginPath", status.CloudFrontOriginPathCurrent, expectedOriginPath,
+				FixActionUpdate, fmt.Sprintf("cloudfront:UpdateDistribution(Id=%s, OriginPath=%s)", cloudFrontID, expectedOriginPath))
+		}
+	}
+
+	if s.config.S3BucketName != "" {
+		if status.S3BucketPolicyConfigured {
+			plan.addStep("配置 S3 bucket policy", "已允许公开读取", "保持不变", FixActionNone, "")
+		} else {
+			plan.addStep("配置 S3 bucket policy", describeCheckError(status.S3BucketPolicyError, "未配置"), "合并公开读取策略",
+				FixActionUpdate, fmt.Sprintf("s3:PutPublicAccessBlock + s3:PutBucketPolicy(Bucket=%s)", s.config.S3BucketName))
+		}
+	}
+
+	if status.CertificateARN == "" {
+		s.planHTMLUpload(plan, rule, status)
+		plan.Warnings = append(plan.Warnings, "未找到证书，只上传HTML文件到S3，不创建CloudFront分发")
+		return plan.finish(), nil
+	}
+
+	if cloudFrontID == "" {
+		s.planHTMLUpload(plan, rule, status)
+		plan.addStep("创建 CloudFront 分发", "不存在",
+			fmt.Sprintf("创建分发（别名 %s，源路径 /redirects/%s）", rule.SourceDomain, rule.SourceDomain),
+			FixActionCreate, fmt.Sprintf("cloudfront:CreateDistribution(Alias=%s, Certificate=%s)", rule.SourceDomain, status.CertificateARN))
+		plan.addStep(dnsStepName(dnsProvider), "分发尚未创建", "指向新分发",
+			FixActionCreate, dnsUpsertAPICall(dnsProvider, rule.SourceDomain, ""))
+		if !strings.HasPrefix(rule.SourceDomain, "www.") {
+			plan.addStep("创建 www CNAME 记录", "未检查", fmt.Sprintf("www.%s 指向 %s", rule.SourceDomain, rule.SourceDomain),
+				FixActionEnsure, dnsUpsertAPICall(dnsProvider, "www."+rule.SourceDomain, "CNAME"))
+		}
+		return plan.finish(), nil
+	}
+
+	s.planHTMLUpload(plan, rule, status)
+	if status.Route53DNSConfigured {
+		plan.addStep(dnsStepName(dnsProvider), "已指向当前分发", "保持不变", FixActionNone, "")
+	} else {
+		plan.addStep(dnsStepName(dnsProvider), describeCheckError(status.Route53DNSError, "未配置"), fmt.Sprintf("指向分发 %s", cloudFrontID),
+			FixActionCreate, dnsUpsertAPICall(dnsProvider, rule.SourceDomain, ""))
+	}
+	if !strings.HasPrefix(rule.SourceDomain, "www.") {
+		if status.WWWCNAMEConfigured {
+			plan.addStep("创建 www CNAME 记录", "已配置", "保持不变", FixActionNone, "")
+		} else {
+			plan.addStep("创建 www CNAME 记录", describeCheckError(status.WWWCNAMEError, "未配置"), fmt.Sprintf("www.%s 指向 %s", rule.SourceDomain, rule.SourceDomain),
+				FixActionCreate, dnsUpsertAPICall(dnsProvider, "www."+rule.SourceDomain, "CNAME"))
+		}
+	}
+	return plan.finish(), nil
+}
+
+// planHTMLUpload 规划 index.html 的上传：与最新部署版本比较内容是否变化
+func (s *RedirectService) planHTMLUpload(plan *FixPlan, rule *models.RedirectRule, status *RedirectRuleStatus) {
+	s3Key := fmt.Sprintf("redirects/%s/index.html", rule.SourceDomain)
+	apiCall := fmt.Sprintf("s3:PutObject(Bucket=%s, Key=%s)", s.config.S3BucketName, s3Key)
+
+	htmlContent, err := s.buildRuleHTML(rule)
+	if err != nil {
+		plan.Blockers = append(plan.Blockers, fmt.Sprintf("生成HTML失败: %v", err))
+		return
+	}
+	sum := sha256.Sum256([]byte(htmlContent))
+	hash := hex.EncodeToString(sum[:])
+
+	if !status.HTMLUploaded {
+		plan.addStep("上传 index.html", describeCheckError(status.HTMLUploadError, "S3 文件不存在"), "上传当前规则生成的 HTML", FixActionCreate, apiCall)
+		return
+	}
+
+	var latest models.RedirectRuleVersion
+	if err := s.db.Select("version", "html_hash").Where("rule_id = ?", rule.ID).Order("version DESC").First(&latest).Error; err != nil {
+		plan.addStep("上传 index.html", "S3 文件存在，没有部署版本记录", "覆盖为当前规则生成的 HTML", FixActionUpdate, apiCall)
+		return
+	}
+	if latest.HTMLHash == hash {
+		plan.addStep("上传 index.html", fmt.Sprintf("S3 文件存在，与版本 %d 内容一致", latest.Version), "重新上传相同内容", FixActionEnsure, apiCall)
+		return
+	}
+	plan.addStep("上传 index.html", fmt.Sprintf("S3 文件存在，最新版本为 %d", latest.Version), "覆盖为当前规则生成的 HTML（内容有变化）", FixActionUpdate, apiCall)
+}
+
+// describeCheckError 返回检查错误信息，为空时使用默认描述
+func describeCheckError(checkErr, fallback string) string {
+	if checkErr != "" {
+		return checkErr
+	}
+	return fallback
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// healthProbeInitialWindow 目标首次统计时只回看最近这段时间的探测结果，避免扫描全部历史
+	healthProbeInitialWindow = time.Hour
+	// healthProbeBatchSize 单次最多统计的探测结果数，未统计完的结果由下次检查按游标继续
+	healthProbeBatchSize = 1000
+)
+
+// RedirectHealthService 重定向目标健康检查服务
+// 基于 speed_probe_results 中的探测结果统计每个目标的连续失败/成功次数：
+// 连续失败达到 failuresToEvict 时自动摘除（IsActive=false），连续成功达到 successesToRestore 时自动恢复。
+// 两个阈值不同形成滞回，避免目标在临界状态反复切换；摘除时保证规则至少保留 MinActiveTargets 个活跃目标。
+type RedirectHealthService struct {
+	db                 *gorm.DB
+	redirectSvc        *RedirectService
+	telegram           *TelegramService
+	speedThreshold     float64 // 速度阈值（KB/s），低于该值视为失败
+	failuresToEvict    int     // 连续失败多少次后摘除
+	successesToRestore int     // 连续成功多少次后恢复
+}
+
+// NewRedirectHealthService 创建重定向目标健康检查服务
+func NewRedirectHealthService(db *gorm.DB, redirectSvc *RedirectService, telegram *TelegramService, speedThreshold float64, failuresToEvict, successesToRestore int) *RedirectHealthService {
+	// 设置默认值
+	if failuresToEvict <= 0 {
+		failuresToEvict = 3
+	}
+	if successesToRestore <= 0 {
+		successesToRestore = 5
+	}
+
+	return &RedirectHealthService{
+		db:                 db,
+		redirectSvc:        redirectSvc,
+		telegram:           telegram,
+		speedThreshold:     speedThreshold,
+		failuresToEvict:    failuresToEvict,
+		successesToRestore: successesToRestore,
+	}
+}
+
+// UpdateHealthCheckConfig 更新规则的健康检查配置
+func (s *RedirectHealthService) UpdateHealthCheckConfig(ruleID uint, enabled bool, minActiveTargets int) error {
+	if minActiveTargets < 1 {
+		return fmt.Errorf("最少活跃目标数不能小于 1")
+	}
+	return s.db.Model(&models.RedirectRule{}).Where("id = ?", ruleID).Updates(map[string]interface{}{
+		"health_check_enabled": enabled,
+		"min_active_targets":   minActiveTargets,
+	}).Error
+}
+
+// ListHealthEvents 分页查询规则的目标摘除/恢复记录
+func (s *RedirectHealthService) ListHealthEvents(ruleID uint, page, pageSize int) ([]models.RedirectTargetHealthEvent, int64, error) {
+	var events []models.RedirectTargetHealthEvent
+	var total int64
+
+	query := s.db.Model(&models.RedirectTargetHealthEvent{}).Where("rule_id = ?", ruleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// ListProbeTargets 列出开启健康检查的规则下需要探测的目标（手动禁用的目标不探测）
+func (s *RedirectHealthService) ListProbeTargets() ([]models.RedirectRule, error) {
+	var rules []models.RedirectRule
+	if err := s.db.Where("health_check_enabled = ?", true).Preload("Targets", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_active = ? OR auto_disabled = ?", true, true)
+	}).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CheckAllRules 定时任务入口：检查所有开启健康检查的规则
+func (s *RedirectHealthService) CheckAllRules() error {
+	log := logger.GetLogger()
+
+	var rules []models.RedirectRule
+	if err := s.db.Where("health_check_enabled = ?", true).Preload("Targets").Find(&rules).Error; err != nil {
+		return fmt.Errorf("查询开启健康检查的重定向规则失败: %w", err)
+	}
+
+	for i := range rules {
+		if err := s.checkRule(&rules[i]); err != nil {
+			log.WithError(err).WithField("rule_id", rules[i].ID).Warn("重定向目标健康检查失败")
+		}
+	}
+	return nil
+}
+
+// checkRule 统计规则下每个目标的新探测结果，必要时摘除/恢复目标并重新部署
+func (s *RedirectHealthService) checkRule(rule *models.RedirectRule) error {
+	var events []models.RedirectTargetHealthEvent
+
+	// 最少保留数只统计实际承接流量的目标：已启用且应用定时切换窗口后权重大于 0
+	weights := map[uint]int{}
+	for _, target := range s.redirectSvc.effectiveTargets(rule) {
+		weights[target.ID] = target.Weight
+	}
+	activeCount := 0
+	for _, target := range rule.Targets {
+		if target.IsActive && weights[target.ID] > 0 {
+			activeCount++
+		}
+	}
+	minActive := rule.MinActiveTargets
+	if minActive < 1 {
+		minActive = 1
+	}
+
+	for i := range rule.Targets {
+		target := &rule.Targets[i]
+		// 手动禁用的目标不参与自动恢复
+		if !target.IsActive && !target.AutoDisabled {
+			continue
+		}
+
+		prevFailures := target.ConsecutiveFailures
+		if err := s.accumulateProbes(target); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"consecutive_failures":    target.ConsecutiveFailures,
+			"consecutive_successes":   target.ConsecutiveSuccesses,
+			"health_checked_at":       target.HealthCheckedAt,
+			"health_checked_probe_id": target.HealthCheckedProbeID,
+		}
+
+		switch {
+		case target.IsActive && target.ConsecutiveFailures >= s.failuresToEvict:
+			serving := weights[target.ID] > 0
+			if serving && activeCount-1 < minActive {
+				// 仅在刚达到阈值时记录一次，避免持续失败期间重复告警
+				if prevFailures < s.failuresToEvict {
+					events = append(events, s.newEvent(rule, target, models.RedirectTargetHealthSkipped,
+						fmt.Sprintf("连续 %d 次探测失败，但规则仅剩 %d 个权重大于 0 的活跃目标（最少保留 %d 个），未摘除", target.ConsecutiveFailures, activeCount, minActive)))
+				}
+				break
+			}
+			target.IsActive = false
+			target.AutoDisabled = true
+			if serving {
+				activeCount--
+			}
+			updates["is_active"] = false
+			updates["auto_disabled"] = true
+			events = append(events, s.newEvent(rule, target, models.RedirectTargetHealthEvicted,
+				fmt.Sprintf("连续 %d 次探测失败，自动摘除", target.ConsecutiveFailures)))
+		case !target.IsActive && target.AutoDisabled && target.ConsecutiveSuccesses >= s.successesToRestore:
+			target.IsActive = true
+			target.AutoDisabled = false
+			if weights[target.ID] > 0 {
+				activeCount++
+			}
+			updates["is_active"] = true
+			updates["auto_disabled"] = false
+			events = append(events, s.newEvent(rule, target, models.RedirectTargetHealthRestored,
+				fmt.Sprintf("连续 %d 次探测成功，自动恢复", target.ConsecutiveSuccesses)))
+		}
+
+		if err := s.db.Model(&models.RedirectTarget{}).Where("id = ?", target.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新目标健康状态失败: %w", err)
+		}
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := s.db.Create(&events).Error; err != nil {
+		logger.GetLogger().WithError(err).WithField("rule_id", rule.ID).Warn("保存目标健康事件失败")
+	}
+
+	// 只有摘除/恢复才需要重新部署
+	for _, event := range events {
+		if event.Action != models.RedirectTargetHealthSkipped {
+			s.redirectSvc.redeployAndInvalidate(rule, "system", "健康检查自动摘除/恢复目标")
+			break
+		}
+	}
+
+	s.notify(rule, events)
+	return nil
+}
+
+// accumulateProbes 按时间顺序累计目标自上次检查以来的探测结果
+// 以 (created_at, id) 作为游标，同一时间戳的多条结果不会被跳过或重复统计
+func (s *RedirectHealthService) accumulateProbes(target *models.RedirectTarget) error {
+	query := s.db.Where("url = ?", target.TargetURL)
+	if target.HealthCheckedAt != nil {
+		query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", *target.HealthCheckedAt, *target.HealthCheckedAt, target.HealthCheckedProbeID)
+	} else {
+		query = query.Where("created_at >= ?", time.Now().Add(-healthProbeInitialWindow))
+	}
+
+	var results []models.SpeedProbeResult
+	if err := query.Order("created_at ASC, id ASC").Limit(healthProbeBatchSize).Find(&results).Error; err != nil {
+		return fmt.Errorf("查询目标探测结果失败: %w", err)
+	}
+
+	for _, result := range results {
+		failed := result.Status == models.SpeedProbeStatusFailed ||
+			result.Status == models.SpeedProbeStatusTimeout ||
+			result.SpeedKbps < s.speedThreshold
+		if failed {
+			target.ConsecutiveFailures++
+			target.ConsecutiveSuccesses = 0
+		} else {
+			target.ConsecutiveSuccesses++
+			target.ConsecutiveFailures = 0
+		}
+		checkedAt := result.CreatedAt
+		target.HealthCheckedAt = &checkedAt
+		target.HealthCheckedProbeID = result.ID
+	}
+	return nil
+}
+
+// newEvent 构建健康事件记录
+func (s *RedirectHealthService) newEvent(rule *models.RedirectRule, target *models.RedirectTarget, action models.RedirectTargetHealthAction, reason string) models.RedirectTargetHealthEvent {
+	return models.RedirectTargetHealthEvent{
+		RuleID:               rule.ID,
+		TargetID:             target.ID,
+		TargetURL:            target.TargetURL,
+		Action:               action,
+		ConsecutiveFailures:  target.ConsecutiveFailures,
+		ConsecutiveSuccesses: target.ConsecutiveSuccesses,
+		Reason:               reason,
+		CreatedAt:            time.Now(),
+	}
+}
+
+// notify 通过 Telegram 推送摘除/恢复通知
+func (s *RedirectHealthService) notify(rule *models.RedirectRule, events []models.RedirectTargetHealthEvent) {
+	if s.telegram == nil {
+		return
+	}
+
+	var message strings.Builder
+	if s.telegram.GetSitename() != "" {
+		message.WriteString(fmt.Sprintf("[%s] ", s.telegram.GetSitename()))
+	}
+	message.WriteString(fmt.Sprintf("🔁 重定向目标健康变更\n\n规则: %s\n\n", rule.SourceDomain))
+
+	icons := map[models.RedirectTargetHealthAction]string{
+		models.RedirectTargetHealthEvicted:  "❌ 摘除",
+		models.RedirectTargetHealthRestored: "✅ 恢复",
+		models.RedirectTargetHealthSkipped:  "⚠️ 保留",
+	}
+	for i, event := range events {
+		message.WriteString(fmt.Sprintf("%d. %s %s\n   %s\n", i+1, icons[event.Action], event.TargetURL, event.Reason))
+	}
+
+	if err := s.telegram.SendMessage(message.String()); err != nil {
+		logger.GetLogger().WithError(err).WithField("rule_id", rule.ID).Warn("发送目标健康变更通知失败")
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 首次统计只回看最近一段时间；之后以 (created_at, id) 为游标，同一时间戳的后续探测结果不会被跳过
+func TestAccumulateProbesCursor(t *testing.T) {
+	db := newDryRunDB(t)
+	var statements []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := &RedirectHealthService{db: db}
+
+	target := &models.RedirectTarget{TargetURL: "https://x.example.com"}
+	if err := svc.accumulateProbes(target); err != nil {
+		t.Fatal(err)
+	}
+	checkedAt := time.Now()
+	target.HealthCheckedAt = &checkedAt
+	target.HealthCheckedProbeID = 42
+	if err := svc.accumulateProbes(target); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 2 {
+		t.Fatalf("statements = %v", statements)
+	}
+	if !strings.Contains(statements[0], "created_at >= ?") || !strings.Contains(statements[0], "LIMIT") {
+		t.Fatalf("首次统计应限定时间窗口和数量: %s", statements[0])
+	}
+	if !strings.Contains(statements[1], "(created_at > ? OR (created_at = ? AND id > ?))") || !strings.Contains(statements[1], "ORDER BY created_at ASC, id ASC") {
+		t.Fatalf("应按 (created_at, id) 游标查询: %s", statements[1])
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RedirectRouteService 重定向规则路由条件服务
+// 路由条件变化后立即重新部署规则 HTML 并失效 CloudFront 缓存
+type RedirectRouteService struct {
+	db          *gorm.DB
+	redirectSvc *RedirectService
+}
+
+// NewRedirectRouteService 创建路由条件服务
+func NewRedirectRouteService(db *gorm.DB, redirectSvc *RedirectService) *RedirectRouteService {
+	return &RedirectRouteService{
+		db:          db,
+		redirectSvc: redirectSvc,
+	}
+}
+
+// ListRoutes 列出规则的所有路由条件（按匹配顺序）
+func (s *RedirectRouteService) ListRoutes(ruleID uint) ([]models.RedirectRoute, error) {
+	var routes []models.RedirectRoute
+	if err := s.db.Where("rule_id = ?", ruleID).Order("priority DESC, id ASC").Find(&routes).Error; err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// GetRoute 获取路由条件
+func (s *RedirectRouteService) GetRoute(id uint) (*models.RedirectRoute, error) {
+	var route models.RedirectRoute
+	if err := s.db.First(&route, id).Error; err != nil {
+		return nil, fmt.Errorf("路由条件不存在: %w", err)
+	}
+	return &route, nil
+}
+
+// CreateRoute 创建路由条件并重新部署
+func (s *RedirectRouteService) CreateRoute(route *models.RedirectRoute, operator string) error {
+	rule, err := s.redirectSvc.GetRedirectRule(route.RuleID)
+	if err != nil {
+		return err
+	}
+	if err := normalizeRoute(route, rule); err != nil {
+		return err
+	}
+
+	if err := s.db.Create(route).Error; err != nil {
+		return fmt.Errorf("创建路由条件失败: %w", err)
+	}
+
+	s.redirectSvc.redeployAndInvalidate(rule, operator, "创建路由条件 "+route.Name)
+	return nil
+}
+
+// UpdateRoute 更新路由条件并重新部署
+func (s *RedirectRouteService) UpdateRoute(id uint, updated *models.RedirectRoute, operator string) error {
+	route, err := s.GetRoute(id)
+	if err != nil {
+		return err
+	}
+	rule, err := s.redirectSvc.GetRedirectRule(route.RuleID)
+	if err != nil {
+		return err
+	}
+
+	route.Name = updated.Name
+	route.Priority = updated.Priority
+	route.Countries = updated.Countries
+	route.Platforms = updated.Platforms
+	route.InApp = updated.InApp
+	route.TargetIDs = updated.TargetIDs
+	route.Enabled = updated.Enabled
+	if err := normalizeRoute(route, rule); err != nil {
+		return err
+	}
+
+	if err := s.db.Save(route).Error; err != nil {
+		return fmt.Errorf("更新路由条件失败: %w", err)
+	}
+
+	s.redirectSvc.redeployAndInvalidate(rule, operator, "更新路由条件 "+route.Name)
+	return nil
+}
+
+// DeleteRoute 删除路由条件并重新部署
+func (s *RedirectRouteService) DeleteRoute(id uint, operator string) error {
+	route, err := s.GetRoute(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(&models.RedirectRoute{}, id).Error; err != nil {
+		return err
+	}
+
+	rule, err := s.redirectSvc.GetRedirectRule(route.RuleID)
+	if err != nil {
+		return err
+	}
+	s.redirectSvc.redeployAndInvalidate(rule, operator, "删除路由条件 "+route.Name)
+	return nil
+}
+
+// normalizeRoute 校验路由条件并统一格式（国家大写、平台小写、去除空白）
+func normalizeRoute(route *models.RedirectRoute, rule *models.RedirectRule) error {
+	countries := route.GetCountries()
+	for _, country := range countries {
+		if len(country) != 2 {
+			return fmt.Errorf("无效的国家代码（应为两位 ISO 代码）: %s", country)
+		}
+	}
+	route.Countries = strings.Join(countries, ",")
+
+	var platforms []string
+	for _, platform := range route.GetPlatforms() {
+		if !platform.IsValid() {
+			return fmt.Errorf("不支持的平台: %s（可选 android、ios、desktop）", platform)
+		}
+		platforms = append(platforms, string(platform))
+	}
+	route.Platforms = strings.Join(platforms, ",")
+
+	if !route.InApp.IsValid() {
+		return fmt.Errorf("不支持的 WebView 匹配方式: %s（可选 only、exclude 或留空）", route.InApp)
+	}
+
+	ids, err := route.GetTargetIDs()
+	if err != nil {
+		return fmt.Errorf("目标ID格式错误: %w", err)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("路由至少需要指定一个目标")
+	}
+	targetIDs := map[uint]bool{}
+	for _, target := range rule.Targets {
+		targetIDs[target.ID] = true
+	}
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !targetIDs[id] {
+			return fmt.Errorf("目标 %d 不属于该重定向规则", id)
+		}
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	route.TargetIDs = strings.Join(parts, ",")
+	return nil
+}
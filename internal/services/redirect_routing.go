@@ -0,0 +1,197 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 访客设备识别表达式：服务端与生成的 HTML 使用同一份（RE2 与 JavaScript 语法兼容，均忽略大小写）
+// 应用内 WebView 与 GenerateWorkerScript 中的判断一致（Telegram / Viber / Line / WhatsApp / 微信 / 系统 WebView）
+const (
+	inAppUAPattern   = `Telegram|Viber|Line|WhatsApp|MicroMessenger|WebView|\bwv\b`
+	androidUAPattern = `Android`
+	iosUAPattern     = `iPhone|iPad|iPod`
+)
+
+var (
+	inAppUARegexp   = regexp.MustCompile(`(?i)` + inAppUAPattern)
+	androidUARegexp = regexp.MustCompile(`(?i)` + androidUAPattern)
+	iosUARegexp     = regexp.MustCompile(`(?i)` + iosUAPattern)
+)
+
+// redirectVisitor 参与路由匹配的访客信息
+type redirectVisitor struct {
+	Country  string                  // 国家 ISO 代码，未知时为空
+	Platform models.RedirectPlatform // 设备平台
+	InApp    bool                    // 是否为应用内 WebView
+}
+
+// classifyUserAgent 根据 User-Agent 判断平台和是否为应用内 WebView（先判断 Android，与 HTML 中顺序一致）
+func classifyUserAgent(ua string) (models.RedirectPlatform, bool) {
+	platform := models.RedirectPlatformDesktop
+	if androidUARegexp.MatchString(ua) {
+		platform = models.RedirectPlatformAndroid
+	} else if iosUARegexp.MatchString(ua) {
+		platform = models.RedirectPlatformIOS
+	}
+	return platform, inAppUARegexp.MatchString(ua)
+}
+
+// plannedRoute 已解析的路由（同时嵌入到生成的 HTML 中）
+type plannedRoute struct {
+	Countries []string                  `json:"countries"`
+	Platforms []models.RedirectPlatform `json:"platforms"`
+	InApp     models.RedirectInAppMode  `json:"in_app"`
+	Targets   []weightedTarget          `json:"targets"`
+}
+
+// redirectPlan 规则的完整路由方案：按顺序匹配 Routes，均不可用时使用 Default
+type redirectPlan struct {
+	Routes  []plannedRoute   `json:"routes"`
+	Default []weightedTarget `json:"default"`
+}
+
+// buildRedirectPlan 根据目标和路由条件生成路由方案
+// 只有启用的路由参与匹配，按优先级从高到低排列；被路由引用的目标不进入默认目标池。
+func buildRedirectPlan(targets []models.RedirectTarget, routes []models.RedirectRoute) redirectPlan {
+	enabled := make([]models.RedirectRoute, 0, len(routes))
+	for _, route := range routes {
+		if route.Enabled {
+			enabled = append(enabled, route)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool {
+		if enabled[i].Priority != enabled[j].Priority {
+			return enabled[i].Priority > enabled[j].Priority
+		}
+		return enabled[i].ID < enabled[j].ID
+	})
+
+	byID := make(map[uint]models.RedirectTarget, len(targets))
+	for _, target := range targets {
+		byID[target.ID] = target
+	}
+
+	plan := redirectPlan{Routes: []plannedRoute{}}
+	claimed := make(map[uint]bool)
+	for _, route := range enabled {
+		ids, _ := route.GetTargetIDs()
+		var subset []models.RedirectTarget
+		for _, id := range ids {
+			claimed[id] = true
+			if target, ok := byID[id]; ok {
+				subset = append(subset, target)
+			}
+		}
+		plan.Routes = append(plan.Routes, plannedRoute{
+			Countries: route.GetCountries(),
+			Platforms: route.GetPlatforms(),
+			InApp:     route.InApp,
+			Targets:   collectWeightedTargets(subset),
+		})
+	}
+
+	var rest []models.RedirectTarget
+	for _, target := range targets {
+		if !claimed[target.ID] {
+			rest = append(rest, target)
+		}
+	}
+	plan.Default = collectWeightedTargets(rest)
+	return plan
+}
+
+// hasTargets 方案中是否存在任何可用目标
+func (p redirectPlan) hasTargets() bool {
+	if len(p.Default) > 0 {
+		return true
+	}
+	for _, route := range p.Routes {
+		if len(route.Targets) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// needsCountry 是否有路由按国家匹配（HTML 中据此决定是否查询访客国家）
+func (p redirectPlan) needsCountry() bool {
+	for _, route := range p.Routes {
+		if len(route.Countries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// selectTargets 返回访客可用的目标：第一个匹配且有可用目标的路由，否则为默认目标池
+func (p redirectPlan) selectTargets(visitor redirectVisitor) []weightedTarget {
+	for _, route := range p.Routes {
+		if !route.matches(visitor) {
+			continue
+		}
+		if targets := allowedForPlatform(route.Targets, visitor.Platform); len(targets) > 0 {
+			return targets
+		}
+	}
+	return allowedForPlatform(p.Default, visitor.Platform)
+}
+
+// matches 访客是否满足路由条件
+func (r plannedRoute) matches(visitor redirectVisitor) bool {
+	if len(r.Countries) > 0 && !containsString(r.Countries, visitor.Country) {
+		return false
+	}
+	if len(r.Platforms) > 0 {
+		found := false
+		for _, platform := range r.Platforms {
+			if platform == visitor.Platform {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	switch r.InApp {
+	case models.RedirectInAppOnly:
+		return visitor.InApp
+	case models.RedirectInAppExclude:
+		return !visitor.InApp
+	}
+	return true
+}
+
+// allowedForPlatform iOS 访客永远不分配到 APK 链接
+func allowedForPlatform(targets []weightedTarget, platform models.RedirectPlatform) []weightedTarget {
+	if platform != models.RedirectPlatformIOS {
+		return targets
+	}
+	var result []weightedTarget
+	for _, target := range targets {
+		if !isAPKURL(target.URL) {
+			result = append(result, target)
+		}
+	}
+	return result
+}
+
+// isAPKURL 链接路径是否以 .apk 结尾（忽略查询参数和锚点）
+func isAPKURL(rawURL string) bool {
+	path := strings.SplitN(rawURL, "#", 2)[0]
+	path = strings.SplitN(path, "?", 2)[0]
+	return strings.HasSuffix(strings.ToLower(path), ".apk")
+}
+
+// containsString 字符串切片是否包含指定值
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
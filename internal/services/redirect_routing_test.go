@@ -0,0 +1,56 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"testing"
+)
+
+// Android 路由独占 APK 目标；iOS 访客即使没有专属路由也只会落到默认池中的非 APK 链接。
+func TestRedirectPlanSelectTargets(t *testing.T) {
+	targets := []models.RedirectTarget{
+		{ID: 1, TargetURL: "https://a.example.com/app.apk", Weight: 1, IsActive: true},
+		{ID: 2, TargetURL: "https://b.example.com/landing", Weight: 1, IsActive: true},
+		{ID: 3, TargetURL: "https://c.example.com/mm.apk?v=2", Weight: 1, IsActive: true},
+		{ID: 4, TargetURL: "https://d.example.com/tg", Weight: 1, IsActive: true},
+	}
+	routes := []models.RedirectRoute{
+		{ID: 1, Platforms: "android", TargetIDs: "1", Enabled: true},
+		{ID: 2, Countries: "mm", Platforms: "android", TargetIDs: "3", Priority: 10, Enabled: true},
+		{ID: 3, InApp: models.RedirectInAppOnly, TargetIDs: "4", Priority: 5, Enabled: true},
+		{ID: 4, Platforms: "ios", TargetIDs: "1", Enabled: false},
+	}
+	plan := buildRedirectPlan(targets, routes)
+
+	androidUA := "Mozilla/5.0 (Linux; Android 13; SM-A546E) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	iosUA := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Version/17.0 Mobile/15E148 Safari/604.1"
+	telegramUA := "Mozilla/5.0 (Linux; Android 13; wv) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36 Telegram-Android/10.0"
+
+	cases := []struct {
+		name    string
+		ua      string
+		country string
+		want    string
+	}{
+		{"缅甸 Android 命中高优先级国家路由", androidUA, "MM", "https://c.example.com/mm.apk?v=2"},
+		{"其他国家 Android 命中平台路由", androidUA, "TH", "https://a.example.com/app.apk"},
+		{"iOS 使用默认池", iosUA, "MM", "https://b.example.com/landing"},
+		{"应用内 WebView 命中 WebView 路由", telegramUA, "MM", "https://c.example.com/mm.apk?v=2"},
+		{"桌面应用内 WebView", "Mozilla/5.0 (Windows NT 10.0) MicroMessenger/8.0", "", "https://d.example.com/tg"},
+	}
+	for _, tc := range cases {
+		platform, inApp := classifyUserAgent(tc.ua)
+		got := plan.selectTargets(redirectVisitor{Country: tc.country, Platform: platform, InApp: inApp})
+		if len(got) != 1 || got[0].URL != tc.want {
+			t.Errorf("%s: got %+v want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// iOS 访客命中只含 APK 的路由时跳过该路由
+	iosRoutes := []models.RedirectRoute{{ID: 1, Platforms: "ios", TargetIDs: "1", Enabled: true}}
+	got := buildRedirectPlan(targets, iosRoutes).selectTargets(redirectVisitor{Platform: models.RedirectPlatformIOS})
+	for _, target := range got {
+		if isAPKURL(target.URL) {
+			t.Errorf("iOS 访客不应分配到 APK 链接: %s", target.URL)
+		}
+	}
+}
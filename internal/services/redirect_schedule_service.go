@@ -0,0 +1,380 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RedirectScheduleService 重定向规则定时流量切换服务
+// 定时任务周期性计算每条规则当前生效的窗口，窗口切换时重新部署 HTML、失效 CloudFront 缓存并记录审计日志
+type RedirectScheduleService struct {
+	db          *gorm.DB
+	redirectSvc *RedirectService
+	auditSvc    *AuditService
+}
+
+// NewRedirectScheduleService 创建定时流量切换服务
+func NewRedirectScheduleService(db *gorm.DB, redirectSvc *RedirectService, auditSvc *AuditService) *RedirectScheduleService {
+	return &RedirectScheduleService{
+		db:          db,
+		redirectSvc: redirectSvc,
+		auditSvc:    auditSvc,
+	}
+}
+
+// ListSchedules 列出规则的所有定时切换窗口
+func (s *RedirectScheduleService) ListSchedules(ruleID uint) ([]models.RedirectSchedule, error) {
+	var schedules []models.RedirectSchedule
+	if err := s.db.Where("rule_id = ?", ruleID).Order("priority DESC, id ASC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetSchedule 获取定时切换窗口
+func (s *RedirectScheduleService) GetSchedule(id uint) (*models.RedirectSchedule, error) {
+	var schedule models.RedirectSchedule
+	if err := s.db.First(&schedule, id).Error; err != nil {
+		return nil, fmt.Errorf("定时切换窗口不存在: %w", err)
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule 创建定时切换窗口，创建后立即按当前时间重新计算生效窗口
+func (s *RedirectScheduleService) CreateSchedule(schedule *models.RedirectSchedule, operator string) error {
+	rule, err := s.redirectSvc.GetRedirectRule(schedule.RuleID)
+	if err != nil {
+		return err
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = "Asia/Yangon"
+	}
+	if err := validateSchedule(schedule, rule); err != nil {
+		return err
+	}
+
+	if err := s.db.Create(schedule).Error; err != nil {
+		return fmt.Errorf("创建定时切换窗口失败: %w", err)
+	}
+
+	return s.ApplyRuleSchedules(schedule.RuleID, operator)
+}
+
+// UpdateSchedule 更新定时切换窗口，更新后立即按当前时间重新计算生效窗口
+func (s *RedirectScheduleService) UpdateSchedule(id uint, updated *models.RedirectSchedule, operator string) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	rule, err := s.redirectSvc.GetRedirectRule(schedule.RuleID)
+	if err != nil {
+		return err
+	}
+
+	schedule.Name = updated.Name
+	schedule.Timezone = updated.Timezone
+	schedule.StartTime = updated.StartTime
+	schedule.EndTime = updated.EndTime
+	schedule.Weekdays = updated.Weekdays
+	schedule.WeightsJSON = updated.WeightsJSON
+	schedule.Priority = updated.Priority
+	schedule.Enabled = updated.Enabled
+	if schedule.Timezone == "" {
+		schedule.Timezone = "Asia/Yangon"
+	}
+	if err := validateSchedule(schedule, rule); err != nil {
+		return err
+	}
+
+	if err := s.db.Save(schedule).Error; err != nil {
+		return fmt.Errorf("更新定时切换窗口失败: %w", err)
+	}
+
+	// 窗口内容变化时即使生效窗口ID不变也需要重新部署
+	return s.applyRule(rule, true, operator)
+}
+
+// DeleteSchedule 删除定时切换窗口，删除后立即按当前时间重新计算生效窗口
+func (s *RedirectScheduleService) DeleteSchedule(id uint, operator string) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(&models.RedirectSchedule{}, id).Error; err != nil {
+		return err
+	}
+	return s.ApplyRuleSchedules(schedule.RuleID, operator)
+}
+
+// ApplyAllSchedules 定时任务入口：检查所有带窗口的规则，窗口切换时重新部署
+func (s *RedirectScheduleService) ApplyAllSchedules() error {
+	log := logger.GetLogger()
+
+	// 有启用窗口的规则，以及之前有生效窗口（可能已被删除或禁用）的规则都需要检查
+	var ruleIDs []uint
+	if err := s.db.Model(&models.RedirectSchedule{}).Where("enabled = ?", true).Distinct().Pluck("rule_id", &ruleIDs).Error; err != nil {
+		return fmt.Errorf("查询定时切换窗口失败: %w", err)
+	}
+	var activeRuleIDs []uint
+	if err := s.db.Model(&models.RedirectRule{}).Where("active_schedule_id IS NOT NULL").Pluck("id", &activeRuleIDs).Error; err != nil {
+		return fmt.Errorf("查询生效中的定时切换规则失败: %w", err)
+	}
+
+	seen := map[uint]bool{}
+	for _, ruleID := range append(ruleIDs, activeRuleIDs...) {
+		if seen[ruleID] {
+			continue
+		}
+		seen[ruleID] = true
+		if err := s.ApplyRuleSchedules(ruleID, "system"); err != nil {
+			log.WithError(err).WithField("rule_id", ruleID).Warn("应用定时切换窗口失败")
+		}
+	}
+	return nil
+}
+
+// ApplyRuleSchedules 重新计算单条规则当前生效的窗口，仅在窗口切换时重新部署
+func (s *RedirectScheduleService) ApplyRuleSchedules(ruleID uint, operator string) error {
+	rule, err := s.redirectSvc.GetRedirectRule(ruleID)
+	if err != nil {
+		return err
+	}
+	return s.applyRule(rule, false, operator)
+}
+
+// applyRule 计算生效窗口并在变化（或 force）时重新部署、失效缓存、记录审计日志
+func (s *RedirectScheduleService) applyRule(rule *models.RedirectRule, force bool, operator string) error {
+	schedules, err := s.ListSchedules(rule.ID)
+	if err != nil {
+		return err
+	}
+
+	active := selectActiveSchedule(schedules, time.Now())
+	var newID *uint
+	if active != nil {
+		newID = &active.ID
+	}
+	if !force && sameScheduleID(rule.ActiveScheduleID, newID) {
+		return nil
+	}
+
+	// 先按新窗口部署，部署成功后再持久化 active_schedule_id；失败时保持原值，下次检查会重试
+	oldID := rule.ActiveScheduleID
+	rule.ActiveScheduleID = newID
+
+	message := fmt.Sprintf("定时切换重定向规则 %s 的流量窗口：%s -> %s", rule.SourceDomain, describeSchedule(schedules, oldID), describeSchedule(schedules, newID))
+
+	start := time.Now()
+	deployErr := s.redirectSvc.redeployHTML(rule, operator, message)
+	if deployErr == nil && rule.CloudFrontID != "" {
+		deployErr = s.redirectSvc.invalidateCloudFrontCache(rule.CloudFrontID)
+	}
+	if deployErr == nil {
+		if err := s.db.Model(&models.RedirectRule{}).Where("id = ?", rule.ID).Update("active_schedule_id", newID).Error; err != nil {
+			deployErr = fmt.Errorf("更新生效窗口失败: %w", err)
+		}
+	}
+	if deployErr != nil {
+		rule.ActiveScheduleID = oldID
+	}
+
+	s.logSwitch(rule, operator, oldID, newID, message, deployErr, time.Since(start))
+
+	log := logger.GetLogger()
+	entry := log.WithFields(map[string]interface{}{
+		"rule_id":       rule.ID,
+		"source_domain": rule.SourceDomain,
+		"old_schedule":  oldID,
+		"new_schedule":  newID,
+	})
+	if deployErr != nil {
+		entry.WithError(deployErr).Warn("定时切换后重新部署失败")
+		return deployErr
+	}
+	entry.Info("定时切换窗口已生效")
+	return nil
+}
+
+// logSwitch 记录窗口切换审计日志
+func (s *RedirectScheduleService) logSwitch(rule *models.RedirectRule, operator string, oldID, newID *uint, message string, deployErr error, duration time.Duration) {
+	if s.auditSvc == nil {
+		return
+	}
+	status := 200
+	if deployErr != nil {
+		status = 500
+	}
+	request := map[string]interface{}{
+		"old_schedule_id": oldID,
+		"new_schedule_id": newID,
+	}
+	entry := s.auditSvc.CreateAuditLog(0, operator, "schedule_switch", "redirects", strconv.FormatUint(uint64(rule.ID), 10),
+		"", "", "", "", request, nil, status, message, deployErr, duration)
+	if err := s.auditSvc.LogAudit(entry); err != nil {
+		logger.GetLogger().WithError(err).Warn("记录定时切换审计日志失败")
+	}
+}
+
+// validateSchedule 校验窗口配置：时间格式、时区、星期、权重只能引用规则内的目标且至少有一个正权重
+func validateSchedule(schedule *models.RedirectSchedule, rule *models.RedirectRule) error {
+	if _, err := parseClock(schedule.StartTime); err != nil {
+		return fmt.Errorf("开始时间格式错误（应为 HH:MM）: %s", schedule.StartTime)
+	}
+	if _, err := parseClock(schedule.EndTime); err != nil {
+		return fmt.Errorf("结束时间格式错误（应为 HH:MM）: %s", schedule.EndTime)
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("无效的时区: %s", schedule.Timezone)
+	}
+	if _, err := parseWeekdays(schedule.Weekdays); err != nil {
+		return err
+	}
+
+	weights, err := schedule.GetWeights()
+	if err != nil {
+		return fmt.Errorf("权重配置格式错误: %w", err)
+	}
+	targetIDs := map[uint]bool{}
+	for _, target := range rule.Targets {
+		targetIDs[target.ID] = true
+	}
+	positive := false
+	for targetID, weight := range weights {
+		if !targetIDs[targetID] {
+			return fmt.Errorf("目标 %d 不属于该重定向规则", targetID)
+		}
+		if weight < 0 {
+			return fmt.Errorf("权重不能为负数")
+		}
+		if weight > 0 {
+			positive = true
+		}
+	}
+	if !positive {
+		return fmt.Errorf("窗口内至少需要一个权重大于 0 的目标")
+	}
+	return nil
+}
+
+// selectActiveSchedule 返回 now 时刻生效的窗口（优先级最高，同优先级取ID最小）
+func selectActiveSchedule(schedules []models.RedirectSchedule, now time.Time) *models.RedirectSchedule {
+	var active *models.RedirectSchedule
+	for i := range schedules {
+		schedule := &schedules[i]
+		if !schedule.Enabled || !scheduleActiveAt(schedule, now) {
+			continue
+		}
+		if active == nil || schedule.Priority > active.Priority ||
+			(schedule.Priority == active.Priority && schedule.ID < active.ID) {
+			active = schedule
+		}
+	}
+	return active
+}
+
+// scheduleActiveAt 判断窗口在 now 时刻是否生效
+// 开始时间等于结束时间表示全天；结束时间小于开始时间表示跨天，凌晨部分按前一天的星期判断
+func scheduleActiveAt(schedule *models.RedirectSchedule, now time.Time) bool {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := parseClock(schedule.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(schedule.EndTime)
+	if err != nil {
+		return false
+	}
+	weekdays, err := parseWeekdays(schedule.Weekdays)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+
+	var inWindow bool
+	switch {
+	case start == end:
+		inWindow = true
+	case start < end:
+		inWindow = minute >= start && minute < end
+	default:
+		if minute >= start {
+			inWindow = true
+		} else if minute < end {
+			inWindow = true
+			day = local.AddDate(0, 0, -1).Weekday()
+		}
+	}
+	if !inWindow {
+		return false
+	}
+	return len(weekdays) == 0 || weekdays[day]
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseWeekdays 解析逗号分隔的星期列表（0=周日），为空表示每天
+func parseWeekdays(value string) (map[time.Weekday]bool, error) {
+	result := map[time.Weekday]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 0 || day > 6 {
+			return nil, fmt.Errorf("无效的星期: %s（应为 0-6，0=周日）", part)
+		}
+		result[time.Weekday(day)] = true
+	}
+	return result, nil
+}
+
+// sameScheduleID 比较两个可空窗口ID
+func sameScheduleID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// describeSchedule 生成窗口描述，用于审计日志
+func describeSchedule(schedules []models.RedirectSchedule, id *uint) string {
+	if id == nil {
+		return "默认权重"
+	}
+	for _, schedule := range schedules {
+		if schedule.ID == *id {
+			return fmt.Sprintf("%s(%s-%s %s)", schedule.Name, schedule.StartTime, schedule.EndTime, schedule.Timezone)
+		}
+	}
+	return fmt.Sprintf("窗口#%d", *id)
+}
+
+// EncodeScheduleWeights 将目标权重序列化为 WeightsJSON
+func EncodeScheduleWeights(weights map[uint]int) (string, error) {
+	data, err := json.Marshal(weights)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
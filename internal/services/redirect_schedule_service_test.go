@@ -0,0 +1,38 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"testing"
+	"time"
+)
+
+// 跨天窗口 22:00-06:00（仰光时间，仅周一）：周二凌晨仍属于周一开始的窗口，周一凌晨则不属于。
+func TestScheduleActiveAt_overnightWindow(t *testing.T) {
+	schedule := &models.RedirectSchedule{
+		Timezone:  "Asia/Yangon",
+		StartTime: "22:00",
+		EndTime:   "06:00",
+		Weekdays:  "1",
+		Enabled:   true,
+	}
+	loc, err := time.LoadLocation("Asia/Yangon")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2024, 1, 1, 23, 0, 0, 0, loc), true},  // 周一 23:00
+		{time.Date(2024, 1, 2, 5, 59, 0, 0, loc), true},  // 周二 05:59，窗口起始于周一
+		{time.Date(2024, 1, 2, 6, 0, 0, 0, loc), false},  // 周二 06:00，窗口结束
+		{time.Date(2024, 1, 1, 3, 0, 0, 0, loc), false},  // 周一 03:00，窗口起始于周日
+		{time.Date(2024, 1, 2, 23, 0, 0, 0, loc), false}, // 周二 23:00
+	}
+	for _, tc := range cases {
+		if got := scheduleActiveAt(schedule, tc.at.UTC()); got != tc.want {
+			t.Errorf("%s: got %v want %v", tc.at, got, tc.want)
+		}
+	}
+}
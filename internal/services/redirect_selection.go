@@ -0,0 +1,58 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+)
+
+// weightedTarget 参与加权选择的目标（同时嵌入到生成的 HTML 中）
+type weightedTarget struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// collectWeightedTargets 收集活跃且权重大于 0 的目标
+// 权重为 0 的目标保留在规则中但不分配流量，便于临时摘除而不删除
+func collectWeightedTargets(targets []models.RedirectTarget) []weightedTarget {
+	var result []weightedTarget
+	for _, target := range targets {
+		if !target.IsActive || target.Weight <= 0 {
+			continue
+		}
+		result = append(result, weightedTarget{
+			URL:    target.TargetURL,
+			Weight: target.Weight,
+		})
+	}
+	return result
+}
+
+// totalWeight 计算权重总和
+func totalWeight(targets []weightedTarget) int {
+	total := 0
+	for _, target := range targets {
+		total += target.Weight
+	}
+	return total
+}
+
+// pickWeightedTarget 根据落点选择目标，point 取值范围为 [0, totalWeight)
+func pickWeightedTarget(targets []weightedTarget, point int) string {
+	if len(targets) == 0 {
+		return ""
+	}
+	total := totalWeight(targets)
+	if total <= 0 {
+		return targets[0].URL
+	}
+	point %= total
+	if point < 0 {
+		point += total
+	}
+	for _, target := range targets {
+		if point < target.Weight {
+			return target.URL
+		}
+		point -= target.Weight
+	}
+	return targets[len(targets)-1].URL
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"testing"
+)
+
+// 80/20 权重下，遍历整个权重区间应得到 8:2 的分布；权重为 0 或未启用的目标不参与分配。
+func TestPickWeightedTarget_distribution(t *testing.T) {
+	targets := collectWeightedTargets([]models.RedirectTarget{
+		{TargetURL: "https://a.example.com", Weight: 8, IsActive: true},
+		{TargetURL: "https://b.example.com", Weight: 2, IsActive: true},
+		{TargetURL: "https://c.example.com", Weight: 0, IsActive: true},
+		{TargetURL: "https://d.example.com", Weight: 5, IsActive: false},
+	})
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets want 2", len(targets))
+	}
+
+	counts := map[string]int{}
+	for point := 0; point < 100; point++ {
+		counts[pickWeightedTarget(targets, point)]++
+	}
+	if counts["https://a.example.com"] != 80 || counts["https://b.example.com"] != 20 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+
+	if got := pickWeightedTarget(targets, -1); got != "https://b.example.com" {
+		t.Fatalf("negative point: got %q", got)
+	}
+}
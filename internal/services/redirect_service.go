@@ -2,6 +2,7 @@ package services
 
 import (
 	"aws_cdn/internal/config"
+	"aws_cdn/internal/geoip"
 	"aws_cdn/internal/logger"
 	"aws_cdn/internal/models"
 	"aws_cdn/internal/services/aws"
@@ -16,30 +17,71 @@ import (
 )
 
 type RedirectService struct {
-	db            *gorm.DB
-	cloudFrontSvc *aws.CloudFrontService
-	s3Svc         *aws.S3Service
-	domainSvc     *DomainService // 域名服务，用于查询域名和证书状态
-	config        *config.AWSConfig
+	db             *gorm.DB
+	cloudFrontSvc  *aws.CloudFrontService
+	s3Svc          *aws.S3Service
+	domainSvc      *DomainService // 域名服务，用于查询域名和证书状态
+	config         *config.AWSConfig
+	geoIP          *geoip.Reader // 国家库，未配置时为 nil（按国家匹配的路由不生效）
+	geoEndpoint    string        // 生成的 HTML 查询访客国家的接口地址，为空时不查询
+	beaconEndpoint string        // 生成的 HTML 上报点击的接口地址，为空时不上报
 }
 
-func NewRedirectService(db *gorm.DB, cloudFrontSvc *aws.CloudFrontService, s3Svc *aws.S3Service, domainSvc *DomainService, cfg *config.AWSConfig) *RedirectService {
+func NewRedirectService(db *gorm.DB, cloudFrontSvc *aws.CloudFrontService, s3Svc *aws.S3Service, domainSvc *DomainService, cfg *config.AWSConfig, geoIP *geoip.Reader, geoEndpoint, beaconEndpoint string) *RedirectService {
 	return &RedirectService{
-		db:            db,
-		cloudFrontSvc: cloudFrontSvc,
-		s3Svc:         s3Svc,
-		domainSvc:     domainSvc,
-		config:        cfg,
+		db:             db,
+		cloudFrontSvc:  cloudFrontSvc,
+		s3Svc:          s3Svc,
+		domainSvc:      domainSvc,
+		config:         cfg,
+		geoIP:          geoIP,
+		geoEndpoint:    geoEndpoint,
+		beaconEndpoint: beaconEndpoint,
 	}
 }
 
 // generateRedirectHTML 生成包含轮播逻辑的HTML文件
-func (s *RedirectService) generateRedirectHTML(targetURLs []string) (string, error) {
-	// 将目标URL列表转换为JSON字符串，用于嵌入到HTML中
-	targetsJSON, err := json.Marshal(targetURLs)
+// 浏览器端先按 plan 的路由条件（国家、平台、应用内 WebView）确定可用目标，与服务端 selectTargets 逻辑一致；
+// mode 决定浏览器端的选择算法：
+//   - round_robin: 基于 localStorage 计数器的简单轮询（忽略权重）
+//   - smooth_weighted: 首次访问按权重随机，之后在 localStorage 中保存平滑加权轮询状态
+//   - weighted_random: 每次访问都按权重随机
+//
+// beaconEndpoint 非空时，跳转前通过 navigator.sendBeacon 上报规则ID、选中的目标和访客国家。
+func (s *RedirectService) generateRedirectHTML(ruleID uint, plan redirectPlan, mode models.RedirectSelectionMode, beaconEndpoint string) (string, error) {
+	// 将路由方案转换为JSON字符串，用于嵌入到HTML中
+	planJSON, err := json.Marshal(plan)
 	if err != nil {
 		return "", fmt.Errorf("序列化目标URL失败: %w", err)
 	}
+	modeJSON, err := json.Marshal(string(mode))
+	if err != nil {
+		return "", fmt.Errorf("序列化选择算法失败: %w", err)
+	}
+	// 没有按国家匹配的路由时不查询访客国家，避免额外请求
+	geoEndpoint := ""
+	if plan.needsCountry() {
+		geoEndpoint = s.geoEndpoint
+	}
+	geoEndpointJSON, err := json.Marshal(geoEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("序列化国家查询地址失败: %w", err)
+	}
+	beaconJSON, err := json.Marshal(map[string]interface{}{
+		"endpoint": beaconEndpoint,
+		"rule_id":  ruleID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化点击上报配置失败: %w", err)
+	}
+	patternsJSON, err := json.Marshal(map[string]string{
+		"in_app":  inAppUAPattern,
+		"android": androidUAPattern,
+		"ios":     iosUAPattern,
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化设备识别规则失败: %w", err)
+	}
 
 	htmlTemplate := `<!DOCTYPE html>
 <html>
@@ -59,28 +101,194 @@ func (s *RedirectService) generateRedirectHTML(targetURLs []string) (string, err
 <body>
     <script>
         (function() {
-            // 目标URL列表（嵌入在HTML中）
-            const targets = {{.TargetsJSON}};
+            // 路由方案（嵌入在HTML中）：{routes: [{countries, platforms, in_app, targets}], default: [{url, weight}]}
+            const plan = {{.PlanJSON}};
+            const mode = {{.ModeJSON}};
+            // 访客国家查询地址（仅在有按国家匹配的路由时非空）
+            const geoEndpoint = {{.GeoEndpointJSON}};
+            // 点击上报配置（endpoint 为空时不上报）
+            const beacon = {{.BeaconJSON}};
+            // 设备识别规则，与服务端一致
+            const patterns = {{.PatternsJSON}};
             
-            if (!targets || targets.length === 0) {
-                console.error('No target URLs available');
-                return;
+            const ua = navigator.userAgent || '';
+            const inApp = new RegExp(patterns.in_app, 'i').test(ua);
+            let platform = 'desktop';
+            if (new RegExp(patterns.android, 'i').test(ua)) {
+                platform = 'android';
+            } else if (new RegExp(patterns.ios, 'i').test(ua)) {
+                platform = 'ios';
+            }
+            
+            // iOS 访客永远不分配到 APK 链接
+            function isAPK(url) {
+                const path = url.split('#')[0].split('?')[0];
+                return /\.apk$/i.test(path);
+            }
+            function allowed(list) {
+                list = list || [];
+                if (platform !== 'ios') {
+                    return list;
+                }
+                return list.filter(function(t) { return !isAPK(t.url); });
+            }
+            
+            function matches(route, country) {
+                if (route.countries && route.countries.length > 0 && route.countries.indexOf(country) < 0) {
+                    return false;
+                }
+                if (route.platforms && route.platforms.length > 0 && route.platforms.indexOf(platform) < 0) {
+                    return false;
+                }
+                if (route.in_app === 'only') {
+                    return inApp;
+                }
+                if (route.in_app === 'exclude') {
+                    return !inApp;
+                }
+                return true;
+            }
+            
+            // 第一个匹配且有可用目标的路由，否则使用默认目标池
+            function chooseTargets(country) {
+                const routes = plan.routes || [];
+                for (let i = 0; i < routes.length; i++) {
+                    if (!matches(routes[i], country)) {
+                        continue;
+                    }
+                    const list = allowed(routes[i].targets);
+                    if (list.length > 0) {
+                        return list;
+                    }
+                }
+                return allowed(plan['default']);
             }
             
-            // 从localStorage获取访问计数器
-            const storageKey = 'redirect_counter';
-            let counter = parseInt(localStorage.getItem(storageKey) || '0', 10);
+            // 上报点击，失败不影响跳转
+            function report(url, country) {
+                if (!beacon.endpoint) {
+                    return;
+                }
+                const body = JSON.stringify({ rule_id: beacon.rule_id, target: url, country: country });
+                try {
+                    if (navigator.sendBeacon && navigator.sendBeacon(beacon.endpoint, new Blob([body], { type: 'text/plain' }))) {
+                        return;
+                    }
+                    fetch(beacon.endpoint, { method: 'POST', body: body, keepalive: true, mode: 'no-cors' }).catch(function() {});
+                } catch (e) {}
+            }
+            
+            function redirect(targets, country) {
+                if (!targets || targets.length === 0) {
+                    console.error('No target URLs available');
+                    return;
+                }
+                
+                // localStorage 在部分浏览器（如隐私模式）中不可用，读写失败时降级为无状态
+                function load(key) {
+                    try { return localStorage.getItem(key); } catch (e) { return null; }
+                }
+                function save(key, value) {
+                    try { localStorage.setItem(key, value); } catch (e) {}
+                }
+            
+                let total = 0;
+                for (let i = 0; i < targets.length; i++) {
+                    total += targets[i].weight;
+                }
+            
+                // 按权重随机选择
+                function weightedRandom() {
+                    let point = Math.random() * total;
+                    for (let i = 0; i < targets.length; i++) {
+                        if (point < targets[i].weight) {
+                            return i;
+                        }
+                        point -= targets[i].weight;
+                    }
+                    return targets.length - 1;
+                }
             
-            // 选择目标URL（轮询）
-            const targetIndex = counter % targets.length;
-            const targetURL = targets[targetIndex];
+                // 简单轮询（忽略权重）
+                function roundRobin() {
+                    const storageKey = 'redirect_counter';
+                    let counter = parseInt(load(storageKey) || '0', 10);
+                    if (isNaN(counter)) {
+                        counter = 0;
+                    }
+                    const index = counter % targets.length;
+                    save(storageKey, (counter + 1).toString());
+                    return index;
+                }
             
-            // 增加计数器并保存
-            counter++;
-            localStorage.setItem(storageKey, counter.toString());
+                // 平滑加权轮询：每个访客在 localStorage 中保存各目标的当前权重
+                // 目标或权重变化后签名不同，状态自动重置
+                function smoothWeighted() {
+                    const storageKey = 'redirect_swrr_state';
+                    const signature = targets.map(function(t) { return t.weight + '|' + t.url; }).join('\n');
+                    let state = null;
+                    try { state = JSON.parse(load(storageKey) || 'null'); } catch (e) { state = null; }
+                
+                    let current;
+                    let index;
+                    if (!state || state.sig !== signature || !Array.isArray(state.current) || state.current.length !== targets.length) {
+                        // 首次访问：按权重随机，避免所有新访客都落到权重最大的目标
+                        index = weightedRandom();
+                        current = targets.map(function(t) { return t.weight; });
+                        current[index] -= total;
+                    } else {
+                        current = state.current;
+                        index = 0;
+                        for (let i = 0; i < targets.length; i++) {
+                            current[i] += targets[i].weight;
+                            if (current[i] > current[index]) {
+                                index = i;
+                            }
+                        }
+                        current[index] -= total;
+                    }
+                    save(storageKey, JSON.stringify({ sig: signature, current: current }));
+                    return index;
+                }
             
-            // 立即跳转，无感知
-            window.location.replace(targetURL);
+                let targetIndex;
+                if (mode === 'round_robin') {
+                    targetIndex = roundRobin();
+                } else if (mode === 'weighted_random') {
+                    targetIndex = weightedRandom();
+                } else {
+                    targetIndex = smoothWeighted();
+                }
+                
+                // 立即跳转，无感知
+                report(targets[targetIndex].url, country);
+                window.location.replace(targets[targetIndex].url);
+            }
+            
+            if (!geoEndpoint) {
+                redirect(chooseTargets(''), '');
+                return;
+            }
+            
+            // 查询访客国家，超时或失败时按未知国家处理
+            let done = false;
+            function finish(country) {
+                if (done) {
+                    return;
+                }
+                done = true;
+                country = String(country || '').toUpperCase();
+                redirect(chooseTargets(country), country);
+            }
+            setTimeout(function() { finish(''); }, 1500);
+            try {
+                fetch(geoEndpoint, { cache: 'no-store' })
+                    .then(function(resp) { return resp.json(); })
+                    .then(function(data) { finish(data && data.country); })
+                    .catch(function() { finish(''); });
+            } catch (e) {
+                finish('');
+            }
         })();
     </script>
 </body>
@@ -93,7 +301,11 @@ func (s *RedirectService) generateRedirectHTML(targetURLs []string) (string, err
 
 	var buf strings.Builder
 	data := map[string]interface{}{
-		"TargetsJSON": template.JS(string(targetsJSON)),
+		"PlanJSON":        template.JS(string(planJSON)),
+		"ModeJSON":        template.JS(string(modeJSON)),
+		"GeoEndpointJSON": template.JS(string(geoEndpointJSON)),
+		"BeaconJSON":      template.JS(string(beaconJSON)),
+		"PatternsJSON":    template.JS(string(patternsJSON)),
 	}
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("生成HTML失败: %w", err)
@@ -102,40 +314,103 @@ func (s *RedirectService) generateRedirectHTML(targetURLs []string) (string, err
 	return buf.String(), nil
 }
 
-// uploadHTMLOnly 仅上传HTML文件到S3（不创建CloudFront分发）
-func (s *RedirectService) uploadHTMLOnly(rule *models.RedirectRule) error {
-	if s.config.S3BucketName == "" {
-		return fmt.Errorf("S3存储桶名称未配置")
+// effectiveTargets 返回应用当前定时切换窗口权重后的目标列表
+// 没有生效窗口或窗口读取失败时返回规则自身的目标
+func (s *RedirectService) effectiveTargets(rule *models.RedirectRule) []models.RedirectTarget {
+	if rule.ActiveScheduleID == nil {
+		return rule.Targets
 	}
 
-	// 收集活跃的目标URL
-	var activeTargets []string
-	for _, target := range rule.Targets {
-		if target.IsActive {
-			activeTargets = append(activeTargets, target.TargetURL)
-		}
+	log := logger.GetLogger()
+	var schedule models.RedirectSchedule
+	if err := s.db.First(&schedule, *rule.ActiveScheduleID).Error; err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"rule_id":     rule.ID,
+			"schedule_id": *rule.ActiveScheduleID,
+		}).Warn("读取定时切换窗口失败，使用目标默认权重")
+		return rule.Targets
 	}
 
-	if len(activeTargets) == 0 {
-		return fmt.Errorf("没有可用的重定向目标")
+	weights, err := schedule.GetWeights()
+	if err != nil {
+		log.WithError(err).WithField("schedule_id", schedule.ID).Warn("解析定时切换窗口权重失败，使用目标默认权重")
+		return rule.Targets
+	}
+
+	targets := make([]models.RedirectTarget, len(rule.Targets))
+	copy(targets, rule.Targets)
+	for i := range targets {
+		targets[i].Weight = weights[targets[i].ID]
+	}
+	return targets
+}
+
+// rulePlan 生成规则的路由方案（应用定时切换窗口权重和路由条件）
+func (s *RedirectService) rulePlan(rule *models.RedirectRule) (redirectPlan, error) {
+	var routes []models.RedirectRoute
+	if err := s.db.Where("rule_id = ?", rule.ID).Find(&routes).Error; err != nil {
+		return redirectPlan{}, fmt.Errorf("查询路由条件失败: %w", err)
+	}
+	return buildRedirectPlan(s.effectiveTargets(rule), routes), nil
+}
+
+// buildRuleHTML 根据规则的活跃目标、路由条件和选择算法生成HTML内容
+func (s *RedirectService) buildRuleHTML(rule *models.RedirectRule) (string, error) {
+	plan, err := s.rulePlan(rule)
+	if err != nil {
+		return "", err
+	}
+	if !plan.hasTargets() {
+		return "", fmt.Errorf("没有可用的重定向目标")
+	}
+
+	beaconEndpoint := ""
+	if rule.ClickTracking {
+		beaconEndpoint = s.beaconEndpoint
+	}
+	htmlContent, err := s.generateRedirectHTML(rule.ID, plan, rule.EffectiveSelectionMode(), beaconEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("生成HTML文件失败: %w", err)
+	}
+	return htmlContent, nil
+}
+
+// uploadHTMLOnly 仅上传HTML文件到S3（不创建CloudFront分发）
+func (s *RedirectService) uploadHTMLOnly(rule *models.RedirectRule, operator, note string) error {
+	if s.config.S3BucketName == "" {
+		return fmt.Errorf("S3存储桶名称未配置")
 	}
 
 	// 生成HTML文件
-	htmlContent, err := s.generateRedirectHTML(activeTargets)
+	htmlContent, err := s.buildRuleHTML(rule)
 	if err != nil {
-		return fmt.Errorf("生成HTML文件失败: %w", err)
+		return err
 	}
 
+	return s.uploadRuleHTML(rule, htmlContent, operator, note, nil)
+}
+
+// uploadRuleHTML 上传 index.html 到 S3 并记录版本快照
+// rollbackFrom 非空表示本次上传为回滚，记录回滚到的源版本号
+func (s *RedirectService) uploadRuleHTML(rule *models.RedirectRule, htmlContent, operator, note string, rollbackFrom *int) error {
 	// S3目录路径：redirects/{domain}/
 	s3Path := fmt.Sprintf("redirects/%s/", rule.SourceDomain)
 	s3Key := s3Path + "index.html"
 
 	// 上传HTML文件到S3
-	return s.s3Svc.UploadHTML(s.config.S3BucketName, s3Key, htmlContent)
+	if err := s.s3Svc.UploadHTML(s.config.S3BucketName, s3Key, htmlContent); err != nil {
+		return err
+	}
+
+	// 版本记录失败不影响部署结果
+	if err := s.recordVersion(rule, htmlContent, operator, note, rollbackFrom); err != nil {
+		logger.GetLogger().WithError(err).WithField("rule_id", rule.ID).Warn("记录重定向规则版本失败")
+	}
+	return nil
 }
 
 // deployRedirectRule 部署重定向规则到S3和CloudFront
-func (s *RedirectService) deployRedirectRule(rule *models.RedirectRule, certificateARN string) error {
+func (s *RedirectService) deployRedirectRule(rule *models.RedirectRule, certificateARN, operator, note string) error {
 	// 确保 S3 bucket policy 允许公开访问
 	if s.config.S3BucketName != "" {
 		if err := s.s3Svc.EnsureBucketPolicyForPublicAccess(s.config.S3BucketName); err != nil {
@@ -156,7 +431,7 @@ func (s *RedirectService) deployRedirectRule(rule *models.RedirectRule, certific
 	}
 
 	// 先上传HTML文件
-	if err := s.uploadHTMLOnly(rule); err != nil {
+	if err := s.uploadHTMLOnly(rule, operator, note); err != nil {
 		return err
 	}
 
@@ -297,6 +572,22 @@ func (s *RedirectService) findCertificateARN(domainName string) string {
 	return certificateARN
 }
 
+// requireDefaultAWSAccount 重定向规则的 CloudFront 分发在默认 AWS 账号下，证书和 Route 53 托管区域也必须在默认账号
+// 源域名、根域名或泛域名记录属于其他 AWS 账号时拒绝，避免绑定到其他账号的证书或在错误的托管区域写记录
+func (s *RedirectService) requireDefaultAWSAccount(domainName string) error {
+	rootDomain := extractRootDomain(domainName)
+	var domains []models.Domain
+	if err := s.db.Where("domain_name IN ?", []string{domainName, rootDomain, "*." + rootDomain}).Find(&domains).Error; err != nil {
+		return fmt.Errorf("查询域名失败: %w", err)
+	}
+	for _, domain := range domains {
+		if domain.AWSAccountID != nil && *domain.AWSAccountID != 0 {
+			return fmt.Errorf("域名 %s 属于其他 AWS 账号（ID: %d），重定向规则的 CloudFront 分发在默认账号下，只能使用默认账号的域名", domain.DomainName, *domain.AWSAccountID)
+		}
+	}
+	return nil
+}
+
 // CreateRedirectRuleResult 创建重定向规则的结果
 type CreateRedirectRuleResult struct {
 	Rule     *models.RedirectRule
@@ -315,7 +606,8 @@ func (s *RedirectService) CheckDomainUsedByDownloadPackage(domainName string) (b
 }
 
 // CreateRedirectRule 创建重定向规则并自动部署
-func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []string, certificateARN string, dnsProvider string, groupID *uint) (*CreateRedirectRuleResult, error) {
+// selectionMode 为空时使用平滑加权轮询
+func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []string, certificateARN string, dnsProvider string, groupID *uint, selectionMode models.RedirectSelectionMode, operator string) (*CreateRedirectRuleResult, error) {
 	log := logger.GetLogger()
 	log.WithFields(map[string]interface{}{
 		"source_domain":   sourceDomain,
@@ -323,8 +615,15 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 		"certificate_arn": certificateARN,
 		"dns_provider":    dnsProvider,
 		"group_id":        groupID,
+		"selection_mode":  selectionMode,
 	}).Info("开始创建重定向规则")
 
+	if selectionMode == "" {
+		selectionMode = models.RedirectSelectionSmoothWeighted
+	} else if !selectionMode.IsValid() {
+		return nil, fmt.Errorf("不支持的选择算法: %s", selectionMode)
+	}
+
 	// 检查源域名是否已存在（排除软删除的记录）
 	var existingRule models.RedirectRule
 	if err := s.db.Where("source_domain = ?", sourceDomain).First(&existingRule).Error; err == nil {
@@ -335,6 +634,11 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 		return nil, fmt.Errorf("源域名 %s 的重定向规则已存在", sourceDomain)
 	}
 
+	if err := s.requireDefaultAWSAccount(sourceDomain); err != nil {
+		log.WithError(err).WithField("source_domain", sourceDomain).Error("创建重定向规则失败：域名不在默认 AWS 账号")
+		return nil, err
+	}
+
 	// 检查域名是否已被下载包使用
 	isUsed, err := s.CheckDomainUsedByDownloadPackage(sourceDomain)
 	if err != nil {
@@ -406,9 +710,10 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 
 	// 创建重定向规则
 	rule := &models.RedirectRule{
-		SourceDomain: sourceDomain,
-		GroupID:      finalGroupID,
-		Status:       models.RedirectRuleStatusPending, // 初始状态为待处理
+		SourceDomain:  sourceDomain,
+		GroupID:       finalGroupID,
+		Status:        models.RedirectRuleStatusPending, // 初始状态为待处理
+		SelectionMode: selectionMode,
 	}
 
 	if err := s.db.Create(rule).Error; err != nil {
@@ -447,7 +752,7 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 					dnsProviderEnum = models.DNSProviderCloudflare
 				}
 				// 使用重定向规则的分组ID创建域名，确保它们在同一个分组
-				domain, domainErr = s.domainSvc.TransferDomain(sourceDomain, "系统自动创建", dnsProviderEnum, nil, finalGroupID)
+				domain, domainErr = s.domainSvc.TransferDomain(sourceDomain, "系统自动创建", dnsProviderEnum, nil, nil, finalGroupID)
 				if domainErr != nil {
 					log := logger.GetLogger()
 					log.WithError(domainErr).WithFields(map[string]interface{}{
@@ -494,7 +799,7 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 	if isCloudflare || certificateARN != "" {
 		// Cloudflare 域名或已找到证书，创建 CloudFront 分发
 		// 对于 Cloudflare 域名，certificateARN 可以为空，将使用 CloudFront 默认证书
-		if err := s.deployRedirectRule(rule, certificateARN); err != nil {
+		if err := s.deployRedirectRule(rule, certificateARN, operator, "创建规则"); err != nil {
 			// 如果部署失败，记录错误但不阻止规则创建
 			// 可以后续通过更新接口重新部署
 			log := logger.GetLogger()
@@ -510,7 +815,7 @@ func (s *RedirectService) CreateRedirectRule(sourceDomain string, targetURLs []s
 		// AWS 托管域名但没有找到证书，先上传HTML文件到S3
 		// 系统会自动尝试从domain表中查找证书，如果找到会自动创建CloudFront
 		// 如果确实没有证书，可以后续通过BindDomainToCloudFront接口手动绑定CloudFront
-		if err := s.uploadHTMLOnly(rule); err != nil {
+		if err := s.uploadHTMLOnly(rule, operator, "创建规则"); err != nil {
 			log := logger.GetLogger()
 			log.WithError(err).WithField("source_domain", sourceDomain).Warn("上传HTML文件失败")
 			warningMsg := fmt.Sprintf("上传HTML文件失败: %v", err)
@@ -648,12 +953,10 @@ func (s *RedirectService) CheckRoute53RecordStatus(domainName, cloudFrontID stri
 
 	// 如果指定了 CloudFront 域名但检查失败，可能是指向了错误的分发
 	if cloudFrontDomainName != "" {
-		// 对于AWS，再次检查是否指向了其他 CloudFront（不指定域名）
-		if domain.DNSProvider == models.DNSProviderAWS {
-			existsAny, err := s.domainSvc.CheckCloudFrontAliasRecord(domain.HostedZoneID, domainName, "")
-			if err == nil && existsAny {
-				return "mismatched" // 指向了 CloudFront 但不是正确的分发
-			}
+		// 再次检查是否指向了其他 CloudFront（不指定域名）
+		existsAny, err := s.domainSvc.CheckCloudFrontAliasRecord(&domain, domainName, "")
+		if err == nil && existsAny {
+			return "mismatched" // 指向了 CloudFront 但不是正确的分发
 		}
 	}
 
@@ -855,15 +1158,21 @@ func (s *RedirectService) GetCloudFrontOriginPathInfo(rule *models.RedirectRule)
 }
 
 // AddTarget 添加重定向目标并重新部署
-func (s *RedirectService) AddTarget(ruleID uint, targetURL string) error {
+func (s *RedirectService) AddTarget(ruleID uint, targetURL string, weight int, operator string) error {
+	if weight < 0 {
+		return fmt.Errorf("权重不能为负数")
+	}
+
 	target := &models.RedirectTarget{
 		RuleID:    ruleID,
 		TargetURL: targetURL,
-		Weight:    1,
+		Weight:    weight,
 		IsActive:  true,
 	}
 
-	if err := s.db.Create(target).Error; err != nil {
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return createRedirectTarget(tx, target)
+	}); err != nil {
 		return err
 	}
 
@@ -873,9 +1182,94 @@ func (s *RedirectService) AddTarget(ruleID uint, targetURL string) error {
 		return err
 	}
 
+	s.redeployAndInvalidate(rule, operator, fmt.Sprintf("添加目标 %s", targetURL))
+	return nil
+}
+
+// createRedirectTarget 创建重定向目标
+// Weight、IsActive 带 gorm 默认值，Create 会把 0/false 替换成 1/true，因此创建后按 map 写回原值
+func createRedirectTarget(tx *gorm.DB, target *models.RedirectTarget) error {
+	weight, isActive := target.Weight, target.IsActive
+	if err := tx.Create(target).Error; err != nil {
+		return err
+	}
+	if target.Weight == weight && target.IsActive == isActive {
+		return nil
+	}
+	if err := tx.Model(target).Updates(map[string]interface{}{
+		"weight":    weight,
+		"is_active": isActive,
+	}).Error; err != nil {
+		return err
+	}
+	target.Weight, target.IsActive = weight, isActive
+	return nil
+}
+
+// UpdateTarget 更新重定向目标的权重和启用状态并重新部署
+// weight、isActive 为 nil 时保持不变
+func (s *RedirectService) UpdateTarget(targetID uint, weight *int, isActive *bool, operator string) error {
+	var target models.RedirectTarget
+	if err := s.db.First(&target, targetID).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	if weight != nil {
+		if *weight < 0 {
+			return fmt.Errorf("权重不能为负数")
+		}
+		updates["weight"] = *weight
+	}
+	if isActive != nil {
+		// 手动启停覆盖健康检查的自动摘除状态，并重新计数
+		updates["is_active"] = *isActive
+		updates["auto_disabled"] = false
+		updates["consecutive_failures"] = 0
+		updates["consecutive_successes"] = 0
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := s.db.Model(&target).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	rule, err := s.GetRedirectRule(target.RuleID)
+	if err != nil {
+		return err
+	}
+
+	s.redeployAndInvalidate(rule, operator, fmt.Sprintf("更新目标 %s", target.TargetURL))
+	return nil
+}
+
+// UpdateSelectionMode 更新规则的目标选择算法并重新部署
+func (s *RedirectService) UpdateSelectionMode(ruleID uint, mode models.RedirectSelectionMode, operator string) error {
+	if !mode.IsValid() {
+		return fmt.Errorf("不支持的选择算法: %s", mode)
+	}
+
+	if err := s.db.Model(&models.RedirectRule{}).Where("id = ?", ruleID).Update("selection_mode", mode).Error; err != nil {
+		return err
+	}
+
+	rule, err := s.GetRedirectRule(ruleID)
+	if err != nil {
+		return err
+	}
+
+	s.redeployAndInvalidate(rule, operator, fmt.Sprintf("切换选择算法为 %s", mode))
+	return nil
+}
+
+// redeployAndInvalidate 重新上传HTML并失效CloudFront缓存，失败只记录警告
+func (s *RedirectService) redeployAndInvalidate(rule *models.RedirectRule, operator, note string) {
+	log := logger.GetLogger()
+
 	// 更新S3中的index.html
-	if err := s.redeployHTML(rule); err != nil {
-		log := logger.GetLogger()
+	if err := s.redeployHTML(rule, operator, note); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"rule_id":       rule.ID,
 			"source_domain": rule.SourceDomain,
@@ -885,15 +1279,12 @@ func (s *RedirectService) AddTarget(ruleID uint, targetURL string) error {
 	// 如果有CloudFront分发，失效缓存
 	if rule.CloudFrontID != "" {
 		if err := s.invalidateCloudFrontCache(rule.CloudFrontID); err != nil {
-			log := logger.GetLogger()
 			log.WithError(err).WithFields(map[string]interface{}{
 				"rule_id":         rule.ID,
 				"distribution_id": rule.CloudFrontID,
 			}).Warn("失效CloudFront缓存失败")
 		}
 	}
-
-	return nil
 }
 
 // invalidateCloudFrontCache 失效CloudFront缓存
@@ -905,35 +1296,18 @@ func (s *RedirectService) invalidateCloudFrontCache(cloudFrontID string) error {
 }
 
 // redeployHTML 重新部署HTML文件（不重新创建CloudFront分发）
-func (s *RedirectService) redeployHTML(rule *models.RedirectRule) error {
-	// 收集活跃的目标URL
-	var activeTargets []string
-	for _, target := range rule.Targets {
-		if target.IsActive {
-			activeTargets = append(activeTargets, target.TargetURL)
-		}
-	}
-
-	if len(activeTargets) == 0 {
-		return fmt.Errorf("没有可用的重定向目标")
-	}
-
+func (s *RedirectService) redeployHTML(rule *models.RedirectRule, operator, note string) error {
 	// 生成HTML文件
-	htmlContent, err := s.generateRedirectHTML(activeTargets)
+	htmlContent, err := s.buildRuleHTML(rule)
 	if err != nil {
-		return fmt.Errorf("生成HTML文件失败: %w", err)
+		return err
 	}
 
-	// S3目录路径
-	s3Path := fmt.Sprintf("redirects/%s/", rule.SourceDomain)
-	s3Key := s3Path + "index.html"
-
-	// 上传HTML文件到S3
-	return s.s3Svc.UploadHTML(s.config.S3BucketName, s3Key, htmlContent)
+	return s.uploadRuleHTML(rule, htmlContent, operator, note, nil)
 }
 
 // RemoveTarget 删除重定向目标并重新部署
-func (s *RedirectService) RemoveTarget(targetID uint) error {
+func (s *RedirectService) RemoveTarget(targetID uint, operator string) error {
 	// 先获取目标信息，以便找到对应的规则
 	var target models.RedirectTarget
 	if err := s.db.First(&target, targetID).Error; err != nil {
@@ -953,54 +1327,42 @@ func (s *RedirectService) RemoveTarget(targetID uint) error {
 		return err
 	}
 
-	// 更新S3中的index.html
-	if err := s.redeployHTML(rule); err != nil {
-		log := logger.GetLogger()
-		log.WithError(err).WithFields(map[string]interface{}{
-			"rule_id":       rule.ID,
-			"source_domain": rule.SourceDomain,
-		}).Warn("重新部署HTML文件失败")
-	}
-
-	// 如果有CloudFront分发，失效缓存
-	if rule.CloudFrontID != "" {
-		if err := s.invalidateCloudFrontCache(rule.CloudFrontID); err != nil {
-			log := logger.GetLogger()
-			log.WithError(err).WithFields(map[string]interface{}{
-				"rule_id":         rule.ID,
-				"distribution_id": rule.CloudFrontID,
-			}).Warn("失效CloudFront缓存失败")
-		}
-	}
-
+	s.redeployAndInvalidate(rule, operator, fmt.Sprintf("删除目标 %s", target.TargetURL))
 	return nil
 }
 
-// SelectTarget 选择目标 URL（轮询算法，基于浏览器缓存）
-func (s *RedirectService) SelectTarget(ruleID uint, clientIP string) (string, error) {
+// SelectTarget 选择目标 URL（先按路由条件确定可用目标，再按规则的选择算法基于客户端哈希选择）
+func (s *RedirectService) SelectTarget(ruleID uint, clientIP, userAgent string) (string, error) {
 	redirectRule, err := s.GetRedirectRule(ruleID)
 	if err != nil {
 		return "", err
 	}
 
-	// 过滤活跃的目标
-	var activeTargets []models.RedirectTarget
-	for _, target := range redirectRule.Targets {
-		if target.IsActive {
-			activeTargets = append(activeTargets, target)
-		}
+	plan, err := s.rulePlan(redirectRule)
+	if err != nil {
+		return "", err
 	}
 
+	// 与生成的 HTML 使用相同的路由匹配逻辑
+	platform, inApp := classifyUserAgent(userAgent)
+	visitor := redirectVisitor{
+		Country:  s.LookupCountry(clientIP),
+		Platform: platform,
+		InApp:    inApp,
+	}
+	activeTargets := plan.selectTargets(visitor)
 	if len(activeTargets) == 0 {
 		return "", fmt.Errorf("没有可用的重定向目标")
 	}
 
 	// 基于客户端 IP 和时间的哈希来选择目标（模拟轮询）
-	// 实际生产环境应该在浏览器端使用 localStorage 或 cookie 来记录访问次数
+	// 服务端无法保存访客状态，加权模式下哈希值落在权重区间内即为加权随机
 	hash := s.hashClient(clientIP)
-	index := hash % len(activeTargets)
+	if redirectRule.EffectiveSelectionMode() == models.RedirectSelectionRoundRobin {
+		return activeTargets[hash%len(activeTargets)].URL, nil
+	}
 
-	return activeTargets[index].TargetURL, nil
+	return pickWeightedTarget(activeTargets, hash), nil
 }
 
 // hashClient 基于客户端信息生成哈希
@@ -1016,8 +1378,22 @@ func (s *RedirectService) hashClient(clientIP string) int {
 	return hash
 }
 
+// LookupCountry 查询 IP 所属国家（未配置国家库或查询失败时返回空字符串）
+func (s *RedirectService) LookupCountry(clientIP string) string {
+	if s.geoIP == nil || clientIP == "" {
+		return ""
+	}
+	country, err := s.geoIP.Country(clientIP)
+	if err != nil {
+		logger.GetLogger().WithError(err).WithField("ip", clientIP).Debug("查询 IP 所属国家失败")
+		return ""
+	}
+	return country
+}
+
 // HandleRedirect HTTP 重定向处理器
-func (s *RedirectService) HandleRedirect(w http.ResponseWriter, r *http.Request, sourceDomain string) {
+// clientIP 由调用方解析（如 gin 的 c.ClientIP()，只信任可信代理转发的 X-Forwarded-For）
+func (s *RedirectService) HandleRedirect(w http.ResponseWriter, r *http.Request, sourceDomain, clientIP string) {
 	// 查找重定向规则
 	var rule models.RedirectRule
 	if err := s.db.Where("source_domain = ?", sourceDomain).Preload("Targets").First(&rule).Error; err != nil {
@@ -1026,12 +1402,24 @@ func (s *RedirectService) HandleRedirect(w http.ResponseWriter, r *http.Request,
 	}
 
 	// 选择目标 URL
-	targetURL, err := s.SelectTarget(rule.ID, r.RemoteAddr)
+	targetURL, err := s.SelectTarget(rule.ID, clientIP, r.UserAgent())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if rule.ClickTracking {
+		if err := s.RecordClick(RedirectClick{
+			RuleID:    rule.ID,
+			TargetURL: targetURL,
+			Country:   s.LookupCountry(clientIP),
+			UserAgent: r.UserAgent(),
+			Source:    models.RedirectClickSourceServer,
+		}); err != nil {
+			logger.GetLogger().WithError(err).WithField("rule_id", rule.ID).Warn("记录重定向点击失败")
+		}
+	}
+
 	// 设置缓存头，让浏览器缓存选择结果
 	w.Header().Set("Cache-Control", "private, max-age=3600") // 缓存 1 小时
 	w.Header().Set("X-Target-URL", targetURL)
@@ -1108,6 +1496,9 @@ func (s *RedirectService) BindDomainToCloudFront(ruleID uint, distributionID str
 	if err != nil {
 		return err
 	}
+	if err := s.requireDefaultAWSAccount(domainName); err != nil {
+		return err
+	}
 
 	// 更新 CloudFront 分发的别名
 	if err := s.cloudFrontSvc.UpdateDistributionAliases(distributionID, []string{domainName}); err != nil {
@@ -1148,15 +1539,37 @@ type RedirectRuleStatus struct {
 
 // CheckRedirectRule 检查重定向规则的状态
 func (s *RedirectService) CheckRedirectRule(ruleID uint) (*RedirectRuleStatus, error) {
-	status := &RedirectRuleStatus{
-		Issues: []string{},
-	}
-
 	// 获取规则
 	rule, err := s.GetRedirectRule(ruleID)
 	if err != nil {
 		return nil, fmt.Errorf("获取重定向规则失败: %w", err)
 	}
+	status := s.inspectRedirectRule(rule)
+
+	// 如果所有配置都通过（没有 Issues），更新状态为 completed
+	if len(status.Issues) == 0 {
+		rule.Status = models.RedirectRuleStatusCompleted
+		if err := s.db.Save(rule).Error; err != nil {
+			// 记录错误但不影响检查结果返回
+			fmt.Printf("更新重定向规则状态失败: %v\n", err)
+		}
+	} else {
+		// 如果有问题，更新状态为 failed
+		rule.Status = models.RedirectRuleStatusFailed
+		if err := s.db.Save(rule).Error; err != nil {
+			// 记录错误但不影响检查结果返回
+			fmt.Printf("更新重定向规则状态失败: %v\n", err)
+		}
+	}
+
+	return status, nil
+}
+
+// inspectRedirectRule 检查规则在 S3、CloudFront、Route 53 上的实际状态（只读，不修改规则）
+func (s *RedirectService) inspectRedirectRule(rule *models.RedirectRule) *RedirectRuleStatus {
+	status := &RedirectRuleStatus{
+		Issues: []string{},
+	}
 	status.RuleExists = true
 
 	// 检查S3 HTML文件是否存在
@@ -1304,27 +1717,11 @@ func (s *RedirectService) CheckRedirectRule(ruleID uint) (*RedirectRuleStatus, e
 	// 判断是否可以修复
 	status.CanFix = len(status.Issues) > 0 && s.config.S3BucketName != ""
 
-	// 如果所有配置都通过（没有 Issues），更新状态为 completed
-	if len(status.Issues) == 0 {
-		rule.Status = models.RedirectRuleStatusCompleted
-		if err := s.db.Save(rule).Error; err != nil {
-			// 记录错误但不影响检查结果返回
-			fmt.Printf("更新重定向规则状态失败: %v\n", err)
-		}
-	} else {
-		// 如果有问题，更新状态为 failed
-		rule.Status = models.RedirectRuleStatusFailed
-		if err := s.db.Save(rule).Error; err != nil {
-			// 记录错误但不影响检查结果返回
-			fmt.Printf("更新重定向规则状态失败: %v\n", err)
-		}
-	}
-
-	return status, nil
+	return status
 }
 
 // FixRedirectRule 修复重定向规则
-func (s *RedirectService) FixRedirectRule(ruleID uint) (*CreateRedirectRuleResult, error) {
+func (s *RedirectService) FixRedirectRule(ruleID uint, operator string) (*CreateRedirectRuleResult, error) {
 	// 获取规则
 	rule, err := s.GetRedirectRule(ruleID)
 	if err != nil {
@@ -1399,7 +1796,7 @@ func (s *RedirectService) FixRedirectRule(ruleID uint) (*CreateRedirectRuleResul
 				deploymentWarnings = append(deploymentWarnings, warningMsg)
 			}
 
-			if err := s.redeployHTML(rule); err != nil {
+			if err := s.redeployHTML(rule, operator, "修复规则"); err != nil {
 				warningMsg := fmt.Sprintf("重新部署HTML文件失败: %v", err)
 				deploymentWarnings = append(deploymentWarnings, warningMsg)
 			}
@@ -1411,7 +1808,7 @@ func (s *RedirectService) FixRedirectRule(ruleID uint) (*CreateRedirectRuleResul
 			}
 		} else {
 			// 创建新的CloudFront分发
-			if err := s.deployRedirectRule(rule, certificateARN); err != nil {
+			if err := s.deployRedirectRule(rule, certificateARN, operator, "修复规则"); err != nil {
 				warningMsg := fmt.Sprintf("部署重定向规则失败: %v", err)
 				deploymentWarnings = append(deploymentWarnings, warningMsg)
 			}
@@ -1427,7 +1824,7 @@ func (s *RedirectService) FixRedirectRule(ruleID uint) (*CreateRedirectRuleResul
 		}
 	} else {
 		// 没有证书，只上传HTML文件
-		if err := s.uploadHTMLOnly(rule); err != nil {
+		if err := s.uploadHTMLOnly(rule, operator, "修复规则"); err != nil {
 			warningMsg := fmt.Sprintf("上传HTML文件失败: %v", err)
 			deploymentWarnings = append(deploymentWarnings, warningMsg)
 		} else {
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 点击统计的时间粒度
+const (
+	RedirectStatsHourly = "hour"
+	RedirectStatsDaily  = "day"
+)
+
+// redirectStatsMaxDays 单次查询的最大天数
+const redirectStatsMaxDays = 93
+
+// RedirectClick 一次点击
+type RedirectClick struct {
+	RuleID    uint
+	TargetURL string
+	Country   string // 国家 ISO 代码，未知为空
+	UserAgent string
+	Source    models.RedirectClickSource
+	At        time.Time
+}
+
+// RedirectStatsPoint 一个时间段的点击汇总
+type RedirectStatsPoint struct {
+	Period     string           `json:"period"`      // 按天为 2006-01-02（与站点日数据的 date 一致），按小时为 2006-01-02 15:00
+	Clicks     int64            `json:"clicks"`      // 总点击数
+	ByTarget   map[string]int64 `json:"by_target"`   // 目标 URL -> 点击数
+	ByCountry  map[string]int64 `json:"by_country"`  // 国家 -> 点击数，未知国家为空字符串
+	ByPlatform map[string]int64 `json:"by_platform"` // android、ios、desktop，应用内 WebView 追加 _inapp 后缀
+	BySource   map[string]int64 `json:"by_source"`   // beacon、server
+}
+
+// RedirectStats 点击统计查询结果
+type RedirectStats struct {
+	RuleID      uint                 `json:"rule_id"`
+	Granularity string               `json:"granularity"`
+	Start       string               `json:"start"`
+	End         string               `json:"end"`
+	Total       int64                `json:"total"`
+	Points      []RedirectStatsPoint `json:"points"`
+}
+
+// RecordClick 记录一次点击（按小时累加）；目标必须属于该规则，规则未开启点击统计时忽略
+func (s *RedirectService) RecordClick(click RedirectClick) error {
+	rule, err := s.GetRedirectRule(click.RuleID)
+	if err != nil {
+		return err
+	}
+	return s.RecordRuleClick(rule, click)
+}
+
+// RecordRuleClick 为已加载（含目标）的规则记录一次点击，规则未开启点击统计时忽略
+func (s *RedirectService) RecordRuleClick(rule *models.RedirectRule, click RedirectClick) error {
+	if !rule.ClickTracking {
+		return nil
+	}
+
+	var targetID uint
+	for _, target := range rule.Targets {
+		if target.TargetURL == click.TargetURL {
+			targetID = target.ID
+			break
+		}
+	}
+	if targetID == 0 {
+		return fmt.Errorf("目标不属于该重定向规则: %s", click.TargetURL)
+	}
+
+	at := click.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	country := strings.ToUpper(click.Country)
+	if len(country) != 2 {
+		country = ""
+	}
+	platform, inApp := classifyUserAgent(click.UserAgent)
+
+	stat := &models.RedirectClickStat{
+		RuleID:   rule.ID,
+		Bucket:   at.Truncate(time.Hour),
+		TargetID: targetID,
+		Country:  country,
+		Platform: platform,
+		InApp:    inApp,
+		Source:   click.Source,
+		Clicks:   1,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"clicks":     gorm.Expr("clicks + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(stat).Error
+}
+
+// ClickRateLimiter 按键（客户端 IP + 规则）限制公共点击上报的频率
+// 固定时间窗口计数，只在当前实例内生效，用于挡住刷量脚本而不是精确限流
+type ClickRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*clickWindow
+}
+
+type clickWindow struct {
+	start time.Time
+	count int
+}
+
+// clickRateLimiterPruneSize 记录的键超过该数量时清理已过期的窗口
+const clickRateLimiterPruneSize = 10000
+
+// NewClickRateLimiter 创建限流器，每个键在 window 内最多允许 limit 次
+func NewClickRateLimiter(limit int, window time.Duration) *ClickRateLimiter {
+	return &ClickRateLimiter{limit: limit, window: window, windows: make(map[string]*clickWindow)}
+}
+
+// Allow 记录一次请求，当前窗口内超过 limit 次时返回 false
+func (l *ClickRateLimiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w := l.windows[key]
+	if w == nil || now.Sub(w.start) >= l.window {
+		if w == nil && len(l.windows) >= clickRateLimiterPruneSize {
+			for k, existing := range l.windows {
+				if now.Sub(existing.start) >= l.window {
+					delete(l.windows, k)
+				}
+			}
+		}
+		w = &clickWindow{start: now}
+		l.windows[key] = w
+	}
+	w.count++
+	return w.count <= l.limit
+}
+
+// GetClickStats 查询规则在 [start, end] 日期范围（本地时区，含两端）内的点击统计
+func (s *RedirectService) GetClickStats(ruleID uint, granularity string, start, end time.Time) (*RedirectStats, error) {
+	rule, err := s.GetRedirectRule(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if granularity == "" {
+		granularity = RedirectStatsDaily
+	}
+	if granularity != RedirectStatsDaily && granularity != RedirectStatsHourly {
+		return nil, fmt.Errorf("不支持的统计粒度: %s（可选 hour、day）", granularity)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	if end.Sub(start) > redirectStatsMaxDays*24*time.Hour {
+		return nil, fmt.Errorf("查询范围不能超过 %d 天", redirectStatsMaxDays)
+	}
+
+	var rows []models.RedirectClickStat
+	if err := s.db.Where("rule_id = ? AND bucket >= ? AND bucket < ?", ruleID, start, end.AddDate(0, 0, 1)).
+		Order("bucket ASC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询点击统计失败: %w", err)
+	}
+
+	// 已删除的目标仍需显示 URL
+	targetURLs := map[uint]string{}
+	var targets []models.RedirectTarget
+	if err := s.db.Unscoped().Where("rule_id = ?", rule.ID).Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		targetURLs[target.ID] = target.TargetURL
+	}
+
+	stats := &RedirectStats{
+		RuleID:      ruleID,
+		Granularity: granularity,
+		Start:       start.Format("2006-01-02"),
+		End:         end.Format("2006-01-02"),
+		Points:      rollupClickStats(rows, granularity, targetURLs),
+	}
+	for _, point := range stats.Points {
+		stats.Total += point.Clicks
+	}
+	return stats, nil
+}
+
+// rollupClickStats 将小时聚合行按粒度汇总（本地时区），结果按时间升序
+func rollupClickStats(rows []models.RedirectClickStat, granularity string, targetURLs map[uint]string) []RedirectStatsPoint {
+	layout := "2006-01-02"
+	if granularity == RedirectStatsHourly {
+		layout = "2006-01-02 15:00"
+	}
+
+	points := map[string]*RedirectStatsPoint{}
+	for _, row := range rows {
+		period := row.Bucket.In(time.Local).Format(layout)
+		point, ok := points[period]
+		if !ok {
+			point = &RedirectStatsPoint{
+				Period:     period,
+				ByTarget:   map[string]int64{},
+				ByCountry:  map[string]int64{},
+				ByPlatform: map[string]int64{},
+				BySource:   map[string]int64{},
+			}
+			points[period] = point
+		}
+
+		targetURL, ok := targetURLs[row.TargetID]
+		if !ok {
+			targetURL = fmt.Sprintf("#%d", row.TargetID)
+		}
+		platform := string(row.Platform)
+		if row.InApp {
+			platform += "_inapp"
+		}
+
+		point.Clicks += row.Clicks
+		point.ByTarget[targetURL] += row.Clicks
+		point.ByCountry[row.Country] += row.Clicks
+		point.ByPlatform[platform] += row.Clicks
+		point.BySource[string(row.Source)] += row.Clicks
+	}
+
+	result := make([]RedirectStatsPoint, 0, len(points))
+	for _, point := range points {
+		result = append(result, *point)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period < result[j].Period
+	})
+	return result
+}
+
+// UpdateClickTracking 开启/关闭点击统计并重新部署（beacon 写在 HTML 中）
+// 未配置 PUBLIC_BASE_URL 时 HTML 不发送 beacon，只统计服务端 HandleRedirect 的跳转
+func (s *RedirectService) UpdateClickTracking(ruleID uint, enabled bool, operator string) error {
+	rule, err := s.GetRedirectRule(ruleID)
+	if err != nil {
+		return err
+	}
+	if rule.ClickTracking == enabled {
+		return nil
+	}
+
+	if err := s.db.Model(rule).Update("click_tracking", enabled).Error; err != nil {
+		return err
+	}
+	rule.ClickTracking = enabled
+
+	note := "关闭点击统计"
+	if enabled {
+		note = "开启点击统计"
+	}
+	s.redeployAndInvalidate(rule, operator, note)
+	return nil
+}
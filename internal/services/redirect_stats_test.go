@@ -0,0 +1,62 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"testing"
+	"time"
+)
+
+// 同一天的多个小时汇总为一个日数据点，应用内 WebView 单独计数，已不存在的目标以 #ID 显示。
+func TestRollupClickStats_daily(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	rows := []models.RedirectClickStat{
+		{Bucket: day.Add(9 * time.Hour), TargetID: 1, Country: "MM", Platform: models.RedirectPlatformAndroid, Source: models.RedirectClickSourceBeacon, Clicks: 3},
+		{Bucket: day.Add(20 * time.Hour), TargetID: 2, Country: "TH", Platform: models.RedirectPlatformAndroid, InApp: true, Source: models.RedirectClickSourceServer, Clicks: 2},
+		{Bucket: day.Add(30 * time.Hour), TargetID: 9, Platform: models.RedirectPlatformIOS, Source: models.RedirectClickSourceBeacon, Clicks: 1},
+	}
+	targetURLs := map[uint]string{1: "https://a.example.com", 2: "https://b.example.com"}
+
+	points := rollupClickStats(rows, RedirectStatsDaily, targetURLs)
+	if len(points) != 2 {
+		t.Fatalf("got %d points want 2", len(points))
+	}
+
+	first := points[0]
+	if first.Period != "2026-03-01" || first.Clicks != 5 {
+		t.Fatalf("unexpected first point: %+v", first)
+	}
+	if first.ByTarget["https://a.example.com"] != 3 || first.ByTarget["https://b.example.com"] != 2 {
+		t.Fatalf("unexpected by_target: %v", first.ByTarget)
+	}
+	if first.ByPlatform["android"] != 3 || first.ByPlatform["android_inapp"] != 2 {
+		t.Fatalf("unexpected by_platform: %v", first.ByPlatform)
+	}
+
+	second := points[1]
+	if second.Period != "2026-03-02" || second.ByTarget["#9"] != 1 || second.ByCountry[""] != 1 {
+		t.Fatalf("unexpected second point: %+v", second)
+	}
+
+	hourly := rollupClickStats(rows, RedirectStatsHourly, targetURLs)
+	if len(hourly) != 3 || hourly[0].Period != "2026-03-01 09:00" {
+		t.Fatalf("unexpected hourly points: %+v", hourly)
+	}
+}
+
+// 同一 IP 对同一规则在窗口内超过限制后被拒绝，其他规则和下一个窗口不受影响
+func TestClickRateLimiter(t *testing.T) {
+	limiter := NewClickRateLimiter(2, time.Minute)
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !limiter.Allow("1.2.3.4|1", now) || !limiter.Allow("1.2.3.4|1", now) {
+		t.Fatal("limit 以内应放行")
+	}
+	if limiter.Allow("1.2.3.4|1", now.Add(time.Second)) {
+		t.Fatal("超过 limit 应拒绝")
+	}
+	if !limiter.Allow("1.2.3.4|2", now) {
+		t.Fatal("其他规则应单独计数")
+	}
+	if !limiter.Allow("1.2.3.4|1", now.Add(time.Minute)) {
+		t.Fatal("新窗口应重新计数")
+	}
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// 导入导出文件格式
+const (
+	RedirectTransferFormatYAML = "yaml"
+	RedirectTransferFormatCSV  = "csv"
+)
+
+// redirectCSVHeader CSV 表头：每行一个目标，同一规则的多行 source_domain 相同；没有目标的规则 target_url 为空
+var redirectCSVHeader = []string{"source_domain", "group", "selection_mode", "note", "target_url", "weight", "active"}
+
+// RedirectTransferTarget 导入导出的目标
+// Weight、Active 省略时与 CSV 一致，默认权重 1 且启用
+type RedirectTransferTarget struct {
+	URL    string `json:"url" yaml:"url"`
+	Weight *int   `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Active *bool  `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+// EffectiveWeight 返回目标权重，未填写时为 1
+func (t RedirectTransferTarget) EffectiveWeight() int {
+	if t.Weight == nil {
+		return 1
+	}
+	return *t.Weight
+}
+
+// EffectiveActive 返回目标是否启用，未填写时为启用
+func (t RedirectTransferTarget) EffectiveActive() bool {
+	if t.Active == nil {
+		return true
+	}
+	return *t.Active
+}
+
+// RedirectTransferRule 导入导出的规则
+type RedirectTransferRule struct {
+	SourceDomain  string                   `json:"source_domain" yaml:"source_domain"`
+	Group         string                   `json:"group,omitempty" yaml:"group,omitempty"`
+	SelectionMode string                   `json:"selection_mode,omitempty" yaml:"selection_mode,omitempty"`
+	Note          string                   `json:"note,omitempty" yaml:"note,omitempty"`
+	Targets       []RedirectTransferTarget `json:"targets" yaml:"targets"`
+}
+
+// redirectTransferFile YAML 文件结构
+type redirectTransferFile struct {
+	Rules []RedirectTransferRule `yaml:"rules"`
+}
+
+// 导入动作
+const (
+	RedirectImportActionCreate    = "create"
+	RedirectImportActionUpdate    = "update"
+	RedirectImportActionUnchanged = "unchanged"
+	RedirectImportActionError     = "error"
+)
+
+// RedirectImportItem 单条规则的导入结果（dry-run 时为预计变更）
+type RedirectImportItem struct {
+	SourceDomain string   `json:"source_domain"`
+	Action       string   `json:"action"`               // create、update、unchanged、error
+	Changes      []string `json:"changes,omitempty"`    // 字段级变更说明
+	S3Objects    []string `json:"s3_objects,omitempty"` // 将被上传/覆盖的 S3 对象
+	CloudFront   []string `json:"cloudfront,omitempty"` // 将被创建或失效缓存的 CloudFront 分发
+	Warnings     []string `json:"warnings,omitempty"`   // 部署警告
+	Error        string   `json:"error,omitempty"`      // 失败原因
+}
+
+// RedirectImportResult 导入结果
+type RedirectImportResult struct {
+	DryRun    bool                 `json:"dry_run"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Failed    int                  `json:"failed"`
+	Items     []RedirectImportItem `json:"items"`
+}
+
+// RedirectTransferService 重定向规则批量导入导出服务
+// 导入以 source_domain 为键幂等执行：不存在则创建，存在则按文件同步分组、备注、选择算法和目标列表；
+// 文件中未出现的规则不会被删除。
+type RedirectTransferService struct {
+	db          *gorm.DB
+	redirectSvc *RedirectService
+	groupSvc    *GroupService
+}
+
+// NewRedirectTransferService 创建导入导出服务
+func NewRedirectTransferService(db *gorm.DB, redirectSvc *RedirectService, groupSvc *GroupService) *RedirectTransferService {
+	return &RedirectTransferService{
+		db:          db,
+		redirectSvc: redirectSvc,
+		groupSvc:    groupSvc,
+	}
+}
+
+// Export 导出规则（groupID 为空时导出全部）
+func (s *RedirectTransferService) Export(format string, groupID *uint) ([]byte, error) {
+	query := s.db.Preload("Targets").Preload("Group").Order("source_domain ASC")
+	if groupID != nil {
+		query = query.Where("group_id = ?", *groupID)
+	}
+	var rules []models.RedirectRule
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询重定向规则失败: %w", err)
+	}
+
+	items := make([]RedirectTransferRule, 0, len(rules))
+	for _, rule := range rules {
+		item := RedirectTransferRule{
+			SourceDomain:  rule.SourceDomain,
+			SelectionMode: string(rule.EffectiveSelectionMode()),
+			Note:          rule.Note,
+			Targets:       []RedirectTransferTarget{},
+		}
+		if rule.Group != nil {
+			item.Group = rule.Group.Name
+		}
+		for _, target := range rule.Targets {
+			weight, active := target.Weight, target.IsActive
+			item.Targets = append(item.Targets, RedirectTransferTarget{
+				URL:    target.TargetURL,
+				Weight: &weight,
+				Active: &active,
+			})
+		}
+		items = append(items, item)
+	}
+
+	switch format {
+	case RedirectTransferFormatYAML:
+		return yaml.Marshal(redirectTransferFile{Rules: items})
+	case RedirectTransferFormatCSV:
+		return encodeRedirectCSV(items)
+	}
+	return nil, fmt.Errorf("不支持的格式: %s（可选 yaml、csv）", format)
+}
+
+// Import 导入规则；dryRun 为 true 时只计算变更，不写数据库、不部署
+func (s *RedirectTransferService) Import(format string, data []byte, dryRun bool, operator string) (*RedirectImportResult, error) {
+	var rules []RedirectTransferRule
+	var err error
+	switch format {
+	case RedirectTransferFormatYAML:
+		var file redirectTransferFile
+		if err = yaml.Unmarshal(data, &file); err == nil {
+			rules = file.Rules
+		}
+	case RedirectTransferFormatCSV:
+		rules, err = decodeRedirectCSV(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("不支持的格式: %s（可选 yaml、csv）", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析导入文件失败: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("导入文件中没有规则")
+	}
+
+	result := &RedirectImportResult{DryRun: dryRun, Items: []RedirectImportItem{}}
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		rule.SourceDomain = strings.ToLower(strings.TrimSpace(rule.SourceDomain))
+
+		var item RedirectImportItem
+		if seen[rule.SourceDomain] {
+			item = RedirectImportItem{SourceDomain: rule.SourceDomain, Action: RedirectImportActionError, Error: "文件中源域名重复"}
+		} else {
+			seen[rule.SourceDomain] = true
+			item = s.importRule(rule, dryRun, operator)
+		}
+
+		switch item.Action {
+		case RedirectImportActionCreate:
+			result.Created++
+		case RedirectImportActionUpdate:
+			result.Updated++
+		case RedirectImportActionUnchanged:
+			result.Unchanged++
+		default:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+	}
+	logImportResult(result, operator)
+	return result, nil
+}
+
+// importRule 导入单条规则
+func (s *RedirectTransferService) importRule(in RedirectTransferRule, dryRun bool, operator string) RedirectImportItem {
+	item := RedirectImportItem{SourceDomain: in.SourceDomain}
+	fail := func(err error) RedirectImportItem {
+		item.Action = RedirectImportActionError
+		item.Error = err.Error()
+		return item
+	}
+
+	if err := validateTransferRule(&in); err != nil {
+		return fail(err)
+	}
+
+	var existing models.RedirectRule
+	err := s.db.Preload("Targets").Preload("Group").Where("source_domain = ?", in.SourceDomain).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fail(err)
+	}
+	if err != nil {
+		return s.createRule(in, item, dryRun, operator)
+	}
+	return s.updateRule(&existing, in, item, dryRun, operator)
+}
+
+// createRule 创建新规则（通过 RedirectService 完成部署）
+func (s *RedirectTransferService) createRule(in RedirectTransferRule, item RedirectImportItem, dryRun bool, operator string) RedirectImportItem {
+	item.Action = RedirectImportActionCreate
+	item.Changes = append(item.Changes, fmt.Sprintf("创建规则（%d 个目标）", len(in.Targets)))
+	s3Key := fmt.Sprintf("redirects/%s/index.html", in.SourceDomain)
+	item.S3Objects = []string{s3Key}
+
+	// 与 CreateRedirectRule 的部署条件一致：Cloudflare 托管或能找到证书时才创建 CloudFront 分发
+	if s.willCreateDistribution(in.SourceDomain) {
+		item.CloudFront = []string{fmt.Sprintf("创建分发（%s）", in.SourceDomain)}
+	} else {
+		item.Warnings = append(item.Warnings, "未找到证书，只上传 S3 文件，不创建 CloudFront 分发")
+	}
+
+	groupID, groupChange, err := s.resolveGroup(in.Group, dryRun)
+	if err != nil {
+		item.Action = RedirectImportActionError
+		item.Error = err.Error()
+		return item
+	}
+	if groupChange != "" {
+		item.Changes = append(item.Changes, groupChange)
+	}
+	if dryRun {
+		return item
+	}
+
+	urls := make([]string, 0, len(in.Targets))
+	for _, target := range in.Targets {
+		urls = append(urls, target.URL)
+	}
+	created, err := s.redirectSvc.CreateRedirectRule(in.SourceDomain, urls, "", "", groupID, models.RedirectSelectionMode(in.SelectionMode), operator)
+	if err != nil {
+		item.Action = RedirectImportActionError
+		item.Error = err.Error()
+		return item
+	}
+	item.Warnings = append(item.Warnings[:0], created.Warnings...)
+
+	// CreateRedirectRule 创建的目标权重均为 1，按文件补齐权重、启用状态和备注
+	rule := created.Rule
+	wantByURL := make(map[string]RedirectTransferTarget, len(in.Targets))
+	for _, target := range in.Targets {
+		wantByURL[target.URL] = target
+	}
+	needRedeploy := false
+	for i := range rule.Targets {
+		want, ok := wantByURL[rule.Targets[i].TargetURL]
+		if !ok {
+			continue
+		}
+		if want.EffectiveWeight() != rule.Targets[i].Weight || want.EffectiveActive() != rule.Targets[i].IsActive {
+			if err := s.db.Model(&rule.Targets[i]).Updates(map[string]interface{}{
+				"weight":    want.EffectiveWeight(),
+				"is_active": want.EffectiveActive(),
+			}).Error; err != nil {
+				item.Warnings = append(item.Warnings, fmt.Sprintf("更新目标 %s 失败: %v", want.URL, err))
+				continue
+			}
+			needRedeploy = true
+		}
+	}
+	if in.Note != "" {
+		if err := s.redirectSvc.UpdateRedirectRuleNote(rule.ID, in.Note); err != nil {
+			item.Warnings = append(item.Warnings, fmt.Sprintf("更新备注失败: %v", err))
+		}
+	}
+	if needRedeploy {
+		if reloaded, err := s.redirectSvc.GetRedirectRule(rule.ID); err == nil {
+			s.redirectSvc.redeployAndInvalidate(reloaded, operator, "批量导入")
+		}
+	}
+	return item
+}
+
+// updateRule 按文件同步已有规则
+func (s *RedirectTransferService) updateRule(rule *models.RedirectRule, in RedirectTransferRule, item RedirectImportItem, dryRun bool, operator string) RedirectImportItem {
+	var ruleUpdates = map[string]interface{}{}
+
+	// 分组
+	currentGroup := ""
+	if rule.Group != nil {
+		currentGroup = rule.Group.Name
+	}
+	if in.Group != "" && in.Group != currentGroup {
+		groupID, groupChange, err := s.resolveGroup(in.Group, dryRun)
+		if err != nil {
+			item.Action = RedirectImportActionError
+			item.Error = err.Error()
+			return item
+		}
+		if groupChange != "" {
+			item.Changes = append(item.Changes, groupChange)
+		}
+		item.Changes = append(item.Changes, fmt.Sprintf("分组: %s -> %s", currentGroup, in.Group))
+		ruleUpdates["group_id"] = groupID
+	}
+
+	// 备注：与分组一致，文件中未填写时保留现有备注
+	if in.Note != "" && in.Note != rule.Note {
+		item.Changes = append(item.Changes, fmt.Sprintf("备注: %q -> %q", rule.Note, in.Note))
+		ruleUpdates["note"] = in.Note
+	}
+
+	// 选择算法（影响 HTML）
+	htmlChanged := false
+	mode := models.RedirectSelectionMode(in.SelectionMode)
+	if in.SelectionMode != "" && mode != rule.EffectiveSelectionMode() {
+		item.Changes = append(item.Changes, fmt.Sprintf("选择算法: %s -> %s", rule.EffectiveSelectionMode(), mode))
+		ruleUpdates["selection_mode"] = mode
+		htmlChanged = true
+	}
+
+	// 目标（按 URL 匹配）
+	existingByURL := make(map[string]models.RedirectTarget, len(rule.Targets))
+	for _, target := range rule.Targets {
+		existingByURL[target.TargetURL] = target
+	}
+	wanted := make(map[string]bool, len(in.Targets))
+	var toCreate []RedirectTransferTarget
+	toUpdate := map[uint]map[string]interface{}{}
+	for _, target := range in.Targets {
+		wanted[target.URL] = true
+		current, ok := existingByURL[target.URL]
+		if !ok {
+			item.Changes = append(item.Changes, fmt.Sprintf("新增目标 %s（权重 %d）", target.URL, target.EffectiveWeight()))
+			toCreate = append(toCreate, target)
+			continue
+		}
+		updates := map[string]interface{}{}
+		if weight := target.EffectiveWeight(); current.Weight != weight {
+			item.Changes = append(item.Changes, fmt.Sprintf("目标 %s 权重: %d -> %d", target.URL, current.Weight, weight))
+			updates["weight"] = weight
+		}
+		if active := target.EffectiveActive(); current.IsActive != active {
+			item.Changes = append(item.Changes, fmt.Sprintf("目标 %s 启用: %v -> %v", target.URL, current.IsActive, active))
+			updates["is_active"] = active
+			updates["auto_disabled"] = false
+		}
+		if len(updates) > 0 {
+			toUpdate[current.ID] = updates
+		}
+	}
+	var toDelete []models.RedirectTarget
+	for _, target := range rule.Targets {
+		if !wanted[target.TargetURL] {
+			item.Changes = append(item.Changes, fmt.Sprintf("删除目标 %s", target.TargetURL))
+			toDelete = append(toDelete, target)
+		}
+	}
+	if len(toCreate) > 0 || len(toUpdate) > 0 || len(toDelete) > 0 {
+		htmlChanged = true
+	}
+
+	if len(item.Changes) == 0 {
+		item.Action = RedirectImportActionUnchanged
+		return item
+	}
+	item.Action = RedirectImportActionUpdate
+	if htmlChanged {
+		item.S3Objects = []string{fmt.Sprintf("redirects/%s/index.html", rule.SourceDomain)}
+		if rule.CloudFrontID != "" {
+			item.CloudFront = []string{fmt.Sprintf("失效缓存 %s /index.html", rule.CloudFrontID)}
+		}
+	}
+	if dryRun {
+		return item
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(ruleUpdates) > 0 {
+			if err := tx.Model(&models.RedirectRule{}).Where("id = ?", rule.ID).Updates(ruleUpdates).Error; err != nil {
+				return err
+			}
+		}
+		for _, target := range toCreate {
+			if err := createRedirectTarget(tx, &models.RedirectTarget{
+				RuleID:    rule.ID,
+				TargetURL: target.URL,
+				Weight:    target.EffectiveWeight(),
+				IsActive:  target.EffectiveActive(),
+			}); err != nil {
+				return err
+			}
+		}
+		for id, updates := range toUpdate {
+			if err := tx.Model(&models.RedirectTarget{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		for _, target := range toDelete {
+			if err := tx.Delete(&models.RedirectTarget{}, target.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		item.Action = RedirectImportActionError
+		item.Error = fmt.Sprintf("更新规则失败: %v", err)
+		return item
+	}
+
+	if htmlChanged {
+		reloaded, err := s.redirectSvc.GetRedirectRule(rule.ID)
+		if err != nil {
+			item.Warnings = append(item.Warnings, err.Error())
+			return item
+		}
+		s.redirectSvc.redeployAndInvalidate(reloaded, operator, "批量导入")
+	}
+	return item
+}
+
+// resolveGroup 按名称查找分组，不存在时创建（dry-run 只返回说明）；名称为空时返回 nil（使用默认分组）
+func (s *RedirectTransferService) resolveGroup(name string, dryRun bool) (*uint, string, error) {
+	if name == "" {
+		return nil, "", nil
+	}
+	var group models.Group
+	err := s.db.Where("name = ?", name).First(&group).Error
+	if err == nil {
+		return &group.ID, "", nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", err
+	}
+	change := fmt.Sprintf("创建分组 %s", name)
+	if dryRun {
+		return nil, change, nil
+	}
+	created, err := s.groupSvc.CreateGroup(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("创建分组 %s 失败: %w", name, err)
+	}
+	return &created.ID, change, nil
+}
+
+// willCreateDistribution 预测 CreateRedirectRule 是否会创建 CloudFront 分发
+func (s *RedirectTransferService) willCreateDistribution(sourceDomain string) bool {
+	var domain models.Domain
+	if err := s.db.Where("domain_name = ?", sourceDomain).First(&domain).Error; err == nil {
+		if domain.DNSProvider == models.DNSProviderCloudflare || domain.CertificateARN != "" {
+			return true
+		}
+	}
+	return s.redirectSvc.findCertificateARN(sourceDomain) != ""
+}
+
+// validateTransferRule 校验并补全导入规则
+func validateTransferRule(rule *RedirectTransferRule) error {
+	if rule.SourceDomain == "" {
+		return fmt.Errorf("源域名不能为空")
+	}
+	rule.Group = strings.TrimSpace(rule.Group)
+	if rule.SelectionMode != "" && !models.RedirectSelectionMode(rule.SelectionMode).IsValid() {
+		return fmt.Errorf("不支持的选择算法: %s", rule.SelectionMode)
+	}
+	if len(rule.Targets) == 0 {
+		return fmt.Errorf("至少需要一个目标")
+	}
+	urls := map[string]bool{}
+	for i := range rule.Targets {
+		target := &rule.Targets[i]
+		target.URL = strings.TrimSpace(target.URL)
+		if target.URL == "" {
+			return fmt.Errorf("目标 URL 不能为空")
+		}
+		if urls[target.URL] {
+			return fmt.Errorf("目标 %s 重复", target.URL)
+		}
+		urls[target.URL] = true
+		if target.EffectiveWeight() < 0 {
+			return fmt.Errorf("目标 %s 的权重不能为负数", target.URL)
+		}
+	}
+	return nil
+}
+
+// encodeRedirectCSV 编码为 CSV（每行一个目标）
+func encodeRedirectCSV(rules []RedirectTransferRule) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(redirectCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		base := []string{rule.SourceDomain, rule.Group, rule.SelectionMode, rule.Note}
+		if len(rule.Targets) == 0 {
+			if err := writer.Write(append(base, "", "", "")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, target := range rule.Targets {
+			row := append(append([]string{}, base...), target.URL, strconv.Itoa(target.EffectiveWeight()), strconv.FormatBool(target.EffectiveActive()))
+			if err := writer.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// decodeRedirectCSV 解析 CSV，同一 source_domain 的多行合并为一条规则（规则字段取第一行）
+func decodeRedirectCSV(r io.Reader) ([]RedirectTransferRule, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["source_domain"]; !ok {
+		return nil, fmt.Errorf("CSV 缺少 source_domain 列")
+	}
+	if _, ok := columns["target_url"]; !ok {
+		return nil, fmt.Errorf("CSV 缺少 target_url 列")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	byDomain := map[string]*RedirectTransferRule{}
+	var order []string
+	for line, record := range records[1:] {
+		domain := strings.ToLower(field(record, "source_domain"))
+		if domain == "" {
+			continue
+		}
+		rule, ok := byDomain[domain]
+		if !ok {
+			rule = &RedirectTransferRule{
+				SourceDomain:  domain,
+				Group:         field(record, "group"),
+				SelectionMode: field(record, "selection_mode"),
+				Note:          field(record, "note"),
+			}
+			byDomain[domain] = rule
+			order = append(order, domain)
+		}
+
+		targetURL := field(record, "target_url")
+		if targetURL == "" {
+			continue
+		}
+		target := RedirectTransferTarget{URL: targetURL}
+		if value := field(record, "weight"); value != "" {
+			weight, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行权重格式错误: %s", line+2, value)
+			}
+			target.Weight = &weight
+		}
+		if value := field(record, "active"); value != "" {
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行 active 格式错误: %s", line+2, value)
+			}
+			target.Active = &active
+		}
+		rule.Targets = append(rule.Targets, target)
+	}
+
+	rules := make([]RedirectTransferRule, 0, len(order))
+	for _, domain := range order {
+		rules = append(rules, *byDomain[domain])
+	}
+	return rules, nil
+}
+
+// logImportResult 记录导入汇总日志
+func logImportResult(result *RedirectImportResult, operator string) {
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"dry_run":   result.DryRun,
+		"created":   result.Created,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+		"failed":    result.Failed,
+		"operator":  operator,
+	}).Info("重定向规则批量导入完成")
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CSV 导出后再解析应得到相同的规则；没有目标的规则导出一行空 target_url，解析后目标为空。
+func TestRedirectCSV_roundTrip(t *testing.T) {
+	rules := []RedirectTransferRule{
+		{
+			SourceDomain:  "a.example.com",
+			Group:         "品牌A",
+			SelectionMode: "smooth_weighted",
+			Note:          "备注, 含逗号",
+			Targets: []RedirectTransferTarget{
+				{URL: "https://x.example.com", Weight: transferInt(8), Active: transferBool(true)},
+				{URL: "https://y.example.com", Weight: transferInt(0), Active: transferBool(false)},
+			},
+		},
+		{SourceDomain: "b.example.com", Targets: nil},
+	}
+
+	data, err := encodeRedirectCSV(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeRedirectCSV(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rules) {
+		t.Fatalf("round trip mismatch:\n got  %+v\n want %+v", got, rules)
+	}
+}
+
+// 未填写 weight、active 列时默认权重 1 且启用；格式错误时报告行号。
+func TestDecodeRedirectCSV_defaults(t *testing.T) {
+	got, err := decodeRedirectCSV(bytes.NewReader([]byte("source_domain,target_url\nA.example.com,https://x.example.com\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].SourceDomain != "a.example.com" || len(got[0].Targets) != 1 {
+		t.Fatalf("unexpected rules: %+v", got)
+	}
+	if target := got[0].Targets[0]; target.EffectiveWeight() != 1 || !target.EffectiveActive() {
+		t.Fatalf("unexpected defaults: %+v", target)
+	}
+
+	if _, err := decodeRedirectCSV(bytes.NewReader([]byte("source_domain,target_url,weight\na.example.com,https://x.example.com,abc\n"))); err == nil {
+		t.Fatal("expected weight parse error")
+	}
+}
+
+// YAML 省略 weight、active 时与 CSV 一致默认权重 1 且启用；显式填写 0/false 时保留
+func TestRedirectTransferTarget_yamlDefaults(t *testing.T) {
+	var file redirectTransferFile
+	data := "rules:\n  - source_domain: a.example.com\n    targets:\n      - url: https://x.example.com\n      - url: https://y.example.com\n        weight: 0\n        active: false\n"
+	if err := yaml.Unmarshal([]byte(data), &file); err != nil {
+		t.Fatal(err)
+	}
+	targets := file.Rules[0].Targets
+	if targets[0].EffectiveWeight() != 1 || !targets[0].EffectiveActive() {
+		t.Fatalf("unexpected defaults: %+v", targets[0])
+	}
+	if targets[1].EffectiveWeight() != 0 || targets[1].EffectiveActive() {
+		t.Fatalf("explicit zero values lost: %+v", targets[1])
+	}
+}
+
+func transferInt(v int) *int { return &v }
+
+func transferBool(v bool) *bool { return &v }
+
+// 文件中未填写备注时与分组一致保留现有备注，填写时才更新
+func TestUpdateRule_keepsNoteWhenOmitted(t *testing.T) {
+	svc := &RedirectTransferService{}
+	rule := &models.RedirectRule{ID: 1, SourceDomain: "a.example.com", Note: "现有备注"}
+
+	item := svc.updateRule(rule, RedirectTransferRule{SourceDomain: "a.example.com"}, RedirectImportItem{}, true, "tester")
+	if item.Action != RedirectImportActionUnchanged {
+		t.Fatalf("省略备注不应产生变更: %+v", item)
+	}
+	item = svc.updateRule(rule, RedirectTransferRule{SourceDomain: "a.example.com", Note: "新备注"}, RedirectImportItem{}, true, "tester")
+	if item.Action != RedirectImportActionUpdate || len(item.Changes) != 1 {
+		t.Fatalf("填写备注应更新: %+v", item)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// recordVersion 记录规则的部署版本；内容与最新版本相同时跳过（回滚除外）
+func (s *RedirectService) recordVersion(rule *models.RedirectRule, htmlContent, operator, note string, rollbackFrom *int) error {
+	sum := sha256.Sum256([]byte(htmlContent))
+	hash := hex.EncodeToString(sum[:])
+
+	var latest models.RedirectRuleVersion
+	err := s.db.Where("rule_id = ?", rule.ID).Order("version DESC").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && latest.HTMLHash == hash && rollbackFrom == nil {
+		return nil
+	}
+
+	snapshots := make([]models.RedirectTargetSnapshot, 0, len(rule.Targets))
+	for _, target := range rule.Targets {
+		snapshots = append(snapshots, models.RedirectTargetSnapshot{
+			ID:           target.ID,
+			TargetURL:    target.TargetURL,
+			Weight:       target.Weight,
+			IsActive:     target.IsActive,
+			AutoDisabled: target.AutoDisabled,
+		})
+	}
+	targetsJSON, err := json.Marshal(snapshots)
+	if err != nil {
+		return fmt.Errorf("序列化目标快照失败: %w", err)
+	}
+
+	if operator == "" {
+		operator = "system"
+	}
+	version := &models.RedirectRuleVersion{
+		RuleID:        rule.ID,
+		Version:       latest.Version + 1,
+		TargetsJSON:   string(targetsJSON),
+		SelectionMode: rule.EffectiveSelectionMode(),
+		HTMLHash:      hash,
+		HTMLContent:   htmlContent,
+		Operator:      operator,
+		Note:          note,
+		RollbackFrom:  rollbackFrom,
+	}
+	return s.db.Create(version).Error
+}
+
+// ListVersions 分页查询规则的部署版本（按版本号倒序）
+func (s *RedirectService) ListVersions(ruleID uint, page, pageSize int) ([]models.RedirectRuleVersion, int64, error) {
+	var versions []models.RedirectRuleVersion
+	var total int64
+
+	query := s.db.Model(&models.RedirectRuleVersion{}).Where("rule_id = ?", ruleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("version DESC").Offset(offset).Limit(pageSize).Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+// RollbackToVersion 回滚到指定版本
+// 先按快照恢复目标列表（含已删除的目标）和选择算法，使后续的定时切换、健康检查基于回滚后的状态重新部署；
+// 再原样上传该版本的 index.html 并失效 CloudFront 缓存，回滚本身记录为一个新版本。
+func (s *RedirectService) RollbackToVersion(ruleID uint, version int, operator string) error {
+	if _, err := s.GetRedirectRule(ruleID); err != nil {
+		return err
+	}
+	if s.config.S3BucketName == "" {
+		return fmt.Errorf("S3存储桶名称未配置")
+	}
+
+	var target models.RedirectRuleVersion
+	if err := s.db.Where("rule_id = ? AND version = ?", ruleID, version).First(&target).Error; err != nil {
+		return fmt.Errorf("版本 %d 不存在: %w", version, err)
+	}
+	if target.HTMLContent == "" {
+		return fmt.Errorf("版本 %d 没有保存 HTML 内容，无法回滚", version)
+	}
+	snapshots, err := target.GetTargets()
+	if err != nil {
+		return fmt.Errorf("解析版本 %d 的目标快照失败: %w", version, err)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		keepIDs := make([]uint, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			keepIDs = append(keepIDs, snapshot.ID)
+
+			var existing models.RedirectTarget
+			err := tx.Unscoped().Where("id = ? AND rule_id = ?", snapshot.ID, ruleID).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// 目标已被彻底删除，按原 ID 重新创建
+				restored := &models.RedirectTarget{
+					ID:           snapshot.ID,
+					RuleID:       ruleID,
+					TargetURL:    snapshot.TargetURL,
+					Weight:       snapshot.Weight,
+					IsActive:     snapshot.IsActive,
+					AutoDisabled: snapshot.AutoDisabled,
+				}
+				if err := createRedirectTarget(tx, restored); err != nil {
+					return fmt.Errorf("恢复目标 %s 失败: %w", snapshot.TargetURL, err)
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Unscoped().Model(&existing).Updates(map[string]interface{}{
+				"target_url":            snapshot.TargetURL,
+				"weight":                snapshot.Weight,
+				"is_active":             snapshot.IsActive,
+				"auto_disabled":         snapshot.AutoDisabled,
+				"consecutive_failures":  0,
+				"consecutive_successes": 0,
+				"deleted_at":            nil,
+			}).Error; err != nil {
+				return fmt.Errorf("恢复目标 %s 失败: %w", snapshot.TargetURL, err)
+			}
+		}
+
+		// 删除快照之后新增的目标
+		query := tx.Where("rule_id = ?", ruleID)
+		if len(keepIDs) > 0 {
+			query = query.Where("id NOT IN ?", keepIDs)
+		}
+		if err := query.Delete(&models.RedirectTarget{}).Error; err != nil {
+			return fmt.Errorf("删除快照外的目标失败: %w", err)
+		}
+
+		if target.SelectionMode.IsValid() {
+			if err := tx.Model(&models.RedirectRule{}).Where("id = ?", ruleID).Update("selection_mode", target.SelectionMode).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rule, err := s.GetRedirectRule(ruleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.uploadRuleHTML(rule, target.HTMLContent, operator, fmt.Sprintf("回滚到版本 %d", version), &version); err != nil {
+		return fmt.Errorf("上传版本 %d 的HTML失败: %w", version, err)
+	}
+
+	if rule.CloudFrontID != "" {
+		if err := s.invalidateCloudFrontCache(rule.CloudFrontID); err != nil {
+			return fmt.Errorf("失效CloudFront缓存失败: %w", err)
+		}
+	}
+	return nil
+}
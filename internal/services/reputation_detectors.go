@@ -0,0 +1,283 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ReputationSignal 单个检测器对域名的检测结果
+type ReputationSignal struct {
+	Result models.ReputationResult
+	Detail string
+}
+
+// ReputationDetector 域名信誉检测器
+type ReputationDetector interface {
+	// Name 检测器名称，写入 domain_reputation_checks.detector
+	Name() string
+	// Check 检测域名，检测器自身出错时返回 ReputationResultError
+	Check(ctx context.Context, domain string) ReputationSignal
+}
+
+// defaultReputationResolvers 默认的解析检测服务器：境外公共 DNS 作为对照，境内公共 DNS 用于发现运营商污染
+var defaultReputationResolvers = []string{"8.8.8.8:53", "1.1.1.1:53", "223.5.5.5:53", "119.29.29.29:53"}
+
+// DNSResolutionDetector 通过多个上游 DNS 解析域名，比较结果发现 DNS 污染或拦截
+type DNSResolutionDetector struct {
+	resolvers []string
+	timeout   time.Duration
+	// lookup 向指定服务器查询域名的 IP，测试中可替换
+	lookup func(ctx context.Context, server, host string) ([]string, error)
+}
+
+// NewDNSResolutionDetector 创建解析检测器，resolvers 为 host:port（省略端口时使用 53）
+func NewDNSResolutionDetector(resolvers []string) *DNSResolutionDetector {
+	normalized := normalizeResolverAddrs(resolvers)
+	if len(normalized) == 0 {
+		normalized = defaultReputationResolvers
+	}
+	d := &DNSResolutionDetector{resolvers: normalized, timeout: 5 * time.Second}
+	d.lookup = d.upstreamLookup
+	return d
+}
+
+// Name 检测器名称
+func (d *DNSResolutionDetector) Name() string { return "dns" }
+
+// dnsAnswer 单个解析服务器的应答
+type dnsAnswer struct {
+	Server   string
+	IPs      []string
+	NotFound bool  // NXDOMAIN 或没有 A/AAAA 记录
+	Err      error // 超时等查询失败
+}
+
+// Check 向所有解析服务器查询域名
+func (d *DNSResolutionDetector) Check(ctx context.Context, domain string) ReputationSignal {
+	answers := make([]dnsAnswer, len(d.resolvers))
+	var wg sync.WaitGroup
+	for i, server := range d.resolvers {
+		wg.Add(1)
+		go func(i int, server string) {
+			defer wg.Done()
+			answer := dnsAnswer{Server: server}
+			ips, err := d.lookup(ctx, server, domain)
+			var dnsErr *net.DNSError
+			switch {
+			case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+				answer.NotFound = true
+			case err != nil:
+				answer.Err = err
+			default:
+				answer.IPs = ips
+			}
+			answers[i] = answer
+		}(i, server)
+	}
+	wg.Wait()
+	return evaluateDNSAnswers(answers)
+}
+
+func (d *DNSResolutionDetector) upstreamLookup(ctx context.Context, server, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return upstreamResolver(server, d.timeout).LookupHost(ctx, normalizeDNSName(host))
+}
+
+// evaluateDNSAnswers 根据各服务器的应答判断是否被拦截
+// 部分服务器返回正常 IP、其他服务器返回保留地址（127.0.0.1、0.0.0.0、内网地址）视为拦截；
+// NXDOMAIN 可能只是单个服务器的缓存或临时故障，只有多数应答的服务器都返回 NXDOMAIN 时才视为拦截，否则视为警告；
+// 只有超时视为警告；所有服务器都 NXDOMAIN 说明域名本身没有解析，也视为警告。
+func evaluateDNSAnswers(answers []dnsAnswer) ReputationSignal {
+	var good, polluted, notFound, failed []string
+	for _, answer := range answers {
+		switch {
+		case answer.Err != nil:
+			failed = append(failed, fmt.Sprintf("%s 查询失败(%v)", answer.Server, answer.Err))
+		case answer.NotFound:
+			notFound = append(notFound, answer.Server+" 返回 NXDOMAIN")
+		default:
+			if bogus := bogusIPs(answer.IPs); len(bogus) > 0 {
+				polluted = append(polluted, fmt.Sprintf("%s 返回保留地址 %s", answer.Server, strings.Join(bogus, ",")))
+			} else {
+				good = append(good, answer.Server)
+			}
+		}
+	}
+
+	answered := len(good) + len(polluted) + len(notFound)
+	notFoundMajority := len(notFound)*2 > answered
+	switch {
+	case answered == 0:
+		return ReputationSignal{Result: models.ReputationResultError, Detail: strings.Join(failed, "；")}
+	case len(good) == 0:
+		return ReputationSignal{Result: models.ReputationResultWarning, Detail: "所有解析服务器均无法解析: " + strings.Join(append(append(polluted, notFound...), failed...), "；")}
+	case len(polluted) > 0 || notFoundMajority:
+		return ReputationSignal{Result: models.ReputationResultBlocked, Detail: strings.Join(append(polluted, notFound...), "；")}
+	case len(notFound) > 0 || len(failed) > 0:
+		return ReputationSignal{Result: models.ReputationResultWarning, Detail: strings.Join(append(notFound, failed...), "；")}
+	}
+	return ReputationSignal{Result: models.ReputationResultOK}
+}
+
+// bogusIPs 返回不可能是 CDN 地址的保留 IP（DNS 污染常见的返回值）
+func bogusIPs(ips []string) []string {
+	var bogus []string
+	for _, value := range ips {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			continue
+		}
+		if ip.IsLoopback() || ip.IsUnspecified() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+			bogus = append(bogus, value)
+		}
+	}
+	return bogus
+}
+
+// HTTPReachabilityDetector 通过 HTTPS 请求检查域名是否可访问
+type HTTPReachabilityDetector struct {
+	client *http.Client
+}
+
+// NewHTTPReachabilityDetector 创建 HTTP 可达性检测器（不跟随跳转）
+func NewHTTPReachabilityDetector() *HTTPReachabilityDetector {
+	return &HTTPReachabilityDetector{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Name 检测器名称
+func (d *HTTPReachabilityDetector) Name() string { return "http" }
+
+// Check 请求 https://域名/，连接被重置（SNI 阻断的典型表现）视为拦截，其他连接失败和 5xx 视为警告
+func (d *HTTPReachabilityDetector) Check(ctx context.Context, domain string) ReputationSignal {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+normalizeDNSName(domain)+"/", nil)
+	if err != nil {
+		return ReputationSignal{Result: models.ReputationResultError, Detail: err.Error()}
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return classifyHTTPError(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return ReputationSignal{Result: models.ReputationResultWarning, Detail: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	}
+	return ReputationSignal{Result: models.ReputationResultOK, Detail: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+}
+
+// classifyHTTPError 区分连接被重置和其他请求失败
+func classifyHTTPError(err error) ReputationSignal {
+	var certErr *tls.CertificateVerificationError
+	switch {
+	case errors.Is(err, syscall.ECONNRESET):
+		return ReputationSignal{Result: models.ReputationResultBlocked, Detail: "连接被重置: " + err.Error()}
+	case errors.As(err, &certErr):
+		return ReputationSignal{Result: models.ReputationResultWarning, Detail: "证书校验失败: " + err.Error()}
+	}
+	return ReputationSignal{Result: models.ReputationResultWarning, Detail: "请求失败: " + err.Error()}
+}
+
+// SafeBrowsingLookup 恶意网址查询接口（Google Safe Browsing Lookup API 风格）
+type SafeBrowsingLookup interface {
+	// Lookup 返回被标记的 URL 及其威胁类型，未被标记的 URL 不出现在结果中
+	Lookup(ctx context.Context, urls []string) (map[string][]string, error)
+}
+
+// LocalSafeBrowsingStub 本地的恶意网址查询实现，按主机名手动标记，用于未接入外部服务时和测试
+type LocalSafeBrowsingStub struct {
+	mu      sync.Mutex
+	threats map[string][]string // 主机名 -> 威胁类型
+}
+
+// NewLocalSafeBrowsingStub 创建本地恶意网址查询实现
+func NewLocalSafeBrowsingStub() *LocalSafeBrowsingStub {
+	return &LocalSafeBrowsingStub{threats: make(map[string][]string)}
+}
+
+// Flag 标记主机名，threatTypes 如 SOCIAL_ENGINEERING、MALWARE
+func (s *LocalSafeBrowsingStub) Flag(host string, threatTypes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threats[normalizeDNSName(host)] = threatTypes
+}
+
+// Unflag 取消标记
+func (s *LocalSafeBrowsingStub) Unflag(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.threats, normalizeDNSName(host))
+}
+
+// Lookup 按 URL 的主机名查询标记
+func (s *LocalSafeBrowsingStub) Lookup(_ context.Context, urls []string) (map[string][]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches := make(map[string][]string)
+	for _, u := range urls {
+		host := u
+		if i := strings.Index(host, "://"); i >= 0 {
+			host = host[i+3:]
+		}
+		if i := strings.IndexAny(host, "/:?#"); i >= 0 {
+			host = host[:i]
+		}
+		if threats, ok := s.threats[normalizeDNSName(host)]; ok {
+			matches[u] = threats
+		}
+	}
+	return matches, nil
+}
+
+// SafeBrowsingDetector 检查域名是否被浏览器的恶意网址库标记
+type SafeBrowsingDetector struct {
+	lookup SafeBrowsingLookup
+}
+
+// NewSafeBrowsingDetector 创建恶意网址检测器
+func NewSafeBrowsingDetector(lookup SafeBrowsingLookup) *SafeBrowsingDetector {
+	return &SafeBrowsingDetector{lookup: lookup}
+}
+
+// Name 检测器名称
+func (d *SafeBrowsingDetector) Name() string { return "safe_browsing" }
+
+// Check 查询域名的 http 和 https 首页
+func (d *SafeBrowsingDetector) Check(ctx context.Context, domain string) ReputationSignal {
+	host := normalizeDNSName(domain)
+	matches, err := d.lookup.Lookup(ctx, []string{"https://" + host + "/", "http://" + host + "/"})
+	if err != nil {
+		return ReputationSignal{Result: models.ReputationResultError, Detail: err.Error()}
+	}
+	if len(matches) == 0 {
+		return ReputationSignal{Result: models.ReputationResultOK}
+	}
+	seen := make(map[string]bool)
+	var threats []string
+	for _, types := range matches {
+		for _, t := range types {
+			if !seen[t] {
+				seen[t] = true
+				threats = append(threats, t)
+			}
+		}
+	}
+	sort.Strings(threats)
+	return ReputationSignal{Result: models.ReputationResultBlocked, Detail: "被标记为 " + strings.Join(threats, ", ")}
+}
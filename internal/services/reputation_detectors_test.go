@@ -0,0 +1,74 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// 多个解析器的结果对比：部分返回正常 IP、部分返回保留地址时判定为拦截；NXDOMAIN 需多数解析器一致才判定为拦截。
+func TestDNSResolutionDetector(t *testing.T) {
+	answers := map[string]func() ([]string, error){}
+	detector := NewDNSResolutionDetector([]string{"a", "b", "c"})
+	detector.lookup = func(_ context.Context, server, _ string) ([]string, error) {
+		return answers[server]()
+	}
+	public := func() ([]string, error) { return []string{"13.32.1.1"}, nil }
+	nxdomain := func() ([]string, error) { return nil, &net.DNSError{Err: "no such host", IsNotFound: true} }
+	timeout := func() ([]string, error) { return nil, errors.New("i/o timeout") }
+
+	cases := []struct {
+		name    string
+		a, b, c func() ([]string, error)
+		want    models.ReputationResult
+	}{
+		{"consistent", public, public, public, models.ReputationResultOK},
+		{"single nxdomain", public, public, nxdomain, models.ReputationResultWarning},
+		{"majority nxdomain", public, nxdomain, nxdomain, models.ReputationResultBlocked},
+		{"polluted", public, public, func() ([]string, error) { return []string{"127.0.0.1"}, nil }, models.ReputationResultBlocked},
+		{"timeout", public, public, timeout, models.ReputationResultWarning},
+		{"all failed", timeout, timeout, timeout, models.ReputationResultError},
+	}
+	for _, tc := range cases {
+		answers["a:53"], answers["b:53"], answers["c:53"] = tc.a, tc.b, tc.c
+		if got := detector.Check(context.Background(), "example.com"); got.Result != tc.want {
+			t.Fatalf("%s: got %s (%s), want %s", tc.name, got.Result, got.Detail, tc.want)
+		}
+	}
+}
+
+// 本地恶意网址库按主机名匹配，被标记的域名判定为拦截。
+func TestSafeBrowsingDetectorWithStub(t *testing.T) {
+	stub := NewLocalSafeBrowsingStub()
+	detector := NewSafeBrowsingDetector(stub)
+	if got := detector.Check(context.Background(), "example.com"); got.Result != models.ReputationResultOK {
+		t.Fatalf("unflagged domain: %+v", got)
+	}
+	stub.Flag("Example.com.", "SOCIAL_ENGINEERING")
+	if got := detector.Check(context.Background(), "example.com"); got.Result != models.ReputationResultBlocked {
+		t.Fatalf("flagged domain: %+v", got)
+	}
+}
+
+// 综合结果：任一拦截即疑似被拦截，检测器出错不参与判断。
+func TestAggregateReputation(t *testing.T) {
+	check := func(result models.ReputationResult) models.DomainReputationCheck {
+		return models.DomainReputationCheck{Detector: "x", Result: result}
+	}
+	cases := []struct {
+		checks []models.DomainReputationCheck
+		want   models.DomainReputation
+	}{
+		{[]models.DomainReputationCheck{check(models.ReputationResultOK), check(models.ReputationResultError)}, models.DomainReputationOK},
+		{[]models.DomainReputationCheck{check(models.ReputationResultOK), check(models.ReputationResultWarning)}, models.DomainReputationWarning},
+		{[]models.DomainReputationCheck{check(models.ReputationResultWarning), check(models.ReputationResultBlocked)}, models.DomainReputationSuspectedBlocked},
+		{[]models.DomainReputationCheck{check(models.ReputationResultError)}, models.DomainReputationUnknown},
+	}
+	for i, tc := range cases {
+		if got, _ := aggregateReputation(tc.checks); got != tc.want {
+			t.Fatalf("case %d: got %s, want %s", i, got, tc.want)
+		}
+	}
+}
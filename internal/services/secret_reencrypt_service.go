@@ -0,0 +1,66 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ReencryptResult 一张表的重新加密结果
+type ReencryptResult struct {
+	Table       string   `json:"table"`
+	Total       int      `json:"total"`       // 记录总数（包含已软删除的）
+	Reencrypted int      `json:"reencrypted"` // 重新加密的记录数（dry run 时为需要重新加密的记录数）
+	Failed      []string `json:"failed"`      // 解密或保存失败的记录及原因
+}
+
+// reencryptable 凭证由模型钩子加密存储的模型
+type reencryptable interface {
+	NeedsReencrypt() bool
+}
+
+// ReencryptSecrets 将明文或旧版本主密钥加密的凭证用当前主密钥重新加密
+// 用于启用加密后迁移历史明文数据，以及轮换主密钥后迁移旧密文；dryRun 时只统计不写入。
+func ReencryptSecrets(db *gorm.DB, dryRun bool) ([]ReencryptResult, error) {
+	var cfAccounts []models.CFAccount
+	if err := db.Unscoped().Order("id").Find(&cfAccounts).Error; err != nil {
+		return nil, fmt.Errorf("获取Cloudflare账号失败: %w", err)
+	}
+	cfResult := ReencryptResult{Table: models.CFAccount{}.TableName(), Total: len(cfAccounts)}
+	for i := range cfAccounts {
+		account := &cfAccounts[i]
+		reencryptRecord(db, &cfResult, account, account.ID, account.SecretsError, dryRun)
+	}
+
+	var awsAccounts []models.AWSAccount
+	if err := db.Unscoped().Order("id").Find(&awsAccounts).Error; err != nil {
+		return nil, fmt.Errorf("获取AWS账号失败: %w", err)
+	}
+	awsResult := ReencryptResult{Table: models.AWSAccount{}.TableName(), Total: len(awsAccounts)}
+	for i := range awsAccounts {
+		account := &awsAccounts[i]
+		reencryptRecord(db, &awsResult, account, account.ID, account.SecretsError, dryRun)
+	}
+
+	return []ReencryptResult{cfResult, awsResult}, nil
+}
+
+// reencryptRecord 重新保存一条记录，由 BeforeSave 钩子使用当前主密钥加密
+// 解密失败的记录跳过，避免用空值覆盖无法解密的凭证。
+func reencryptRecord(db *gorm.DB, result *ReencryptResult, record reencryptable, id uint, secretsError string, dryRun bool) {
+	if secretsError != "" {
+		result.Failed = append(result.Failed, fmt.Sprintf("#%d: %s", id, secretsError))
+		return
+	}
+	if !record.NeedsReencrypt() {
+		return
+	}
+	if !dryRun {
+		if err := db.Unscoped().Save(record).Error; err != nil {
+			result.Failed = append(result.Failed, fmt.Sprintf("#%d: %v", id, err))
+			return
+		}
+	}
+	result.Reencrypted++
+}
@@ -5,6 +5,7 @@ import (
 	"aws_cdn/internal/models"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,6 +14,13 @@ import (
 
 const (
 	TimeWindowMinutes = 60
+
+	// contentMismatchAlertCooldown 同一 URL 的篡改告警发送后，冷却期内不再重复发送
+	contentMismatchAlertCooldown = time.Hour
+	// contentMismatchWindow 统计内容不一致上报的时间窗口
+	contentMismatchWindow = 30 * time.Minute
+	// contentMismatchMinIPs 时间窗口内至少有多少个不同 IP 上报内容不一致才发送篡改告警（单个来源的上报可能是伪造的）
+	contentMismatchMinIPs = 3
 )
 
 type SpeedProbeService struct {
@@ -21,6 +29,8 @@ type SpeedProbeService struct {
 	telegram             *TelegramService
 	speedThreshold       float64 // 速度阈值（KB/s）
 	failureRateThreshold float64 // 失败率阈值（0-1）
+
+	downloadPackageService *DownloadPackageService // 查询下载包发布后的缓存失效状态
 }
 
 // IPDetail IP探测详情
@@ -51,6 +61,11 @@ func NewSpeedProbeServiceWithTwoDBs(db *gorm.DB, db2 *gorm.DB, telegram *Telegra
 	}
 }
 
+// SetDownloadPackageService 设置下载包服务，用于发布版本后缓存失效完成前暂停篡改告警
+func (s *SpeedProbeService) SetDownloadPackageService(downloadPackageService *DownloadPackageService) {
+	s.downloadPackageService = downloadPackageService
+}
+
 // ReportProbeResult 上报探测结果
 func (s *SpeedProbeService) ReportProbeResult(result *models.SpeedProbeResult) error {
 	log := logger.GetLogger()
@@ -63,6 +78,10 @@ func (s *SpeedProbeService) ReportProbeResult(result *models.SpeedProbeResult) e
 		return fmt.Errorf("客户端IP不能为空")
 	}
 
+	batch := []models.SpeedProbeResult{*result}
+	confirmed := s.verifyContentMismatches(batch)
+	*result = batch[0]
+
 	// 保存到第一个数据库
 	if err := s.db.Create(result).Error; err != nil {
 		log.WithError(err).Error("保存探测结果到第一个数据库失败")
@@ -94,6 +113,8 @@ func (s *SpeedProbeService) ReportProbeResult(result *models.SpeedProbeResult) e
 		"status":    result.Status,
 	}).Info("探测结果已保存")
 
+	go s.alertContentMismatches([]models.SpeedProbeResult{*result}, confirmed)
+
 	return nil
 }
 
@@ -115,6 +136,8 @@ func (s *SpeedProbeService) BatchReportProbeResults(results []models.SpeedProbeR
 		}
 	}
 
+	confirmed := s.verifyContentMismatches(results)
+
 	// 批量保存到第一个数据库
 	if err := s.db.Create(&results).Error; err != nil {
 		log.WithError(err).Error("批量保存探测结果到第一个数据库失败")
@@ -144,6 +167,8 @@ func (s *SpeedProbeService) BatchReportProbeResults(results []models.SpeedProbeR
 		"count": len(results),
 	}).Info("批量探测结果已保存")
 
+	go s.alertContentMismatches(results, confirmed)
+
 	return nil
 }
 
@@ -199,6 +224,7 @@ func (s *SpeedProbeService) CheckAndPrepareAlertForURL(url string, timeWindowMin
 		// 判断该次探测是否失败
 		isFailed := result.Status == models.SpeedProbeStatusFailed ||
 			result.Status == models.SpeedProbeStatusTimeout ||
+			result.Status == models.SpeedProbeStatusContentMismatch ||
 			result.SpeedKbps < s.speedThreshold
 
 		stats.UserAgent = result.UserAgent
@@ -313,6 +339,7 @@ func (s *SpeedProbeService) CheckAndPrepareAlertForURL(url string, timeWindowMin
 			AvgSpeedKbps:    globalAvgSpeed,
 			AlertSent:       false,
 			IPDetails:       string(ipDetailsJSON),
+			AlertType:       models.SpeedAlertTypeSpeed,
 		}
 
 		// 构建告警消息
@@ -326,6 +353,234 @@ func (s *SpeedProbeService) CheckAndPrepareAlertForURL(url string, timeWindowMin
 	return nil, nil
 }
 
+// verifyContentMismatches 核实上报的内容不一致（上报接口无需鉴权，不能直接信任 content_mismatch 状态）
+// 只有链接能对应到保存了哈希的下载包或 R2 文件，且上报的 content_sha256 与完整哈希、前缀哈希都不相同时才保留该状态，否则按普通失败保存
+// 返回核实后的 URL 及其对应的下载包（R2 文件为 nil）
+func (s *SpeedProbeService) verifyContentMismatches(results []models.SpeedProbeResult) map[string]*models.DownloadPackage {
+	log := logger.GetLogger()
+	confirmed := make(map[string]*models.DownloadPackage)
+
+	for i := range results {
+		result := &results[i]
+		if result.Status != models.SpeedProbeStatusContentMismatch {
+			continue
+		}
+		expected, pkg, err := s.expectedContentForURL(result.URL)
+		if err != nil {
+			log.WithError(err).WithField("url", result.URL).Warn("查询链接对应的上传文件失败")
+		}
+		if err != nil || expected == nil || !contentMismatchConfirmed(result.ContentSHA256, *expected) {
+			log.WithFields(map[string]interface{}{
+				"url":            result.URL,
+				"client_ip":      result.ClientIP,
+				"content_sha256": result.ContentSHA256,
+			}).Warn("内容不一致上报未通过服务端核实，按失败保存")
+			result.Status = models.SpeedProbeStatusFailed
+			result.ErrorMessage = "内容不一致上报未通过服务端核实: " + result.ErrorMessage
+			continue
+		}
+		confirmed[result.URL] = pkg
+	}
+	return confirmed
+}
+
+// contentMismatchConfirmed 上报的哈希与上传文件的完整哈希、前缀哈希都不相同时才认定内容不一致
+func contentMismatchConfirmed(reported string, expected models.FileInspection) bool {
+	if reported == "" || expected.FileSHA256 == "" {
+		return false
+	}
+	return !strings.EqualFold(reported, expected.FileSHA256) && !strings.EqualFold(reported, expected.FilePrefixSHA256)
+}
+
+// contentMismatchCorroborated 内容不一致上报来自至少 contentMismatchMinIPs 个不同 IP 时才可信
+func contentMismatchCorroborated(results []models.SpeedProbeResult) bool {
+	ips := make(map[string]bool)
+	for _, result := range results {
+		if result.Status == models.SpeedProbeStatusContentMismatch {
+			ips[result.ClientIP] = true
+		}
+	}
+	return len(ips) >= contentMismatchMinIPs
+}
+
+// expectedContentForURL 查找链接对应的上传文件检查结果：下载包按下载地址匹配，R2 文件按自定义域名和文件路径匹配
+// 找不到保存了哈希的文件时返回 nil
+func (s *SpeedProbeService) expectedContentForURL(rawURL string) (*models.FileInspection, *models.DownloadPackage, error) {
+	var pkg models.DownloadPackage
+	err := s.db.Where("download_url = ? AND status = ? AND file_sha256 != ''", rawURL, models.DownloadPackageStatusCompleted).First(&pkg).Error
+	if err == nil {
+		return &pkg.FileInspection, &pkg, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, nil, err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, nil
+	}
+	var domains []models.R2CustomDomain
+	if err := s.db.Where("domain = ? AND status = ?", parsed.Hostname(), "active").Find(&domains).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, domain := range domains {
+		filePath := strings.TrimPrefix(parsed.Path, "/")
+		if filePath == "" {
+			filePath = domain.DefaultFilePath
+		}
+		var file models.R2File
+		err := s.db.Where("r2_bucket_id = ? AND file_path = ? AND status = ? AND file_sha256 != ''", domain.R2BucketID, filePath, "active").
+			First(&file).Error
+		if err == nil {
+			return &file.FileInspection, nil, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, nil
+}
+
+// alertContentMismatches 对核实后的内容不一致结果立即发送篡改告警（不等待定时速度检查，也不与速度告警合并）
+// 时间窗口内至少 contentMismatchMinIPs 个不同 IP 上报内容不一致才告警，冷却期内已发送过篡改告警的 URL 只保存探测结果不重复告警；
+// 下载包发布新版本后，CloudFront 缓存失效完成前仍会返回旧文件，此时不告警
+func (s *SpeedProbeService) alertContentMismatches(results []models.SpeedProbeResult, confirmed map[string]*models.DownloadPackage) {
+	log := logger.GetLogger()
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, result := range results {
+		if result.Status != models.SpeedProbeStatusContentMismatch || seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+		urls = append(urls, result.URL)
+	}
+
+	for _, url := range urls {
+		if pkg := confirmed[url]; pkg != nil {
+			completed := pkg.InvalidationStatus == "" || pkg.InvalidationStatus == models.InvalidationStatusCompleted
+			if !completed && s.downloadPackageService != nil {
+				var err error
+				if completed, err = s.downloadPackageService.InvalidationCompleted(pkg); err != nil {
+					log.WithError(err).WithField("package_id", pkg.ID).Warn("查询缓存失效状态失败")
+				}
+			}
+			if !completed {
+				log.WithFields(map[string]interface{}{
+					"url":                 url,
+					"package_id":          pkg.ID,
+					"invalidation_status": pkg.InvalidationStatus,
+				}).Info("下载包缓存失效尚未完成，暂不发送篡改告警")
+				continue
+			}
+		}
+
+		// 上报接口无需鉴权，只有时间窗口内多个不同 IP 都上报了内容不一致才认为可信
+		var recent []models.SpeedProbeResult
+		if err := s.db.Where("url = ? AND status = ? AND created_at >= ?",
+			url, models.SpeedProbeStatusContentMismatch, time.Now().Add(-contentMismatchWindow)).
+			Order("created_at DESC").Find(&recent).Error; err != nil {
+			log.WithError(err).WithField("url", url).Error("查询内容不一致上报失败")
+			continue
+		}
+		if !contentMismatchCorroborated(recent) {
+			log.WithFields(map[string]interface{}{
+				"url":     url,
+				"reports": len(recent),
+			}).Info("内容不一致上报的来源 IP 不足，暂不发送篡改告警")
+			continue
+		}
+
+		var count int64
+		if err := s.db.Model(&models.SpeedAlertLog{}).
+			Where("url = ? AND alert_type = ? AND alert_sent = ? AND created_at >= ?",
+				url, models.SpeedAlertTypeContentMismatch, true, time.Now().Add(-contentMismatchAlertCooldown)).
+			Count(&count).Error; err != nil {
+			log.WithError(err).WithField("url", url).Error("查询篡改告警记录失败")
+			continue
+		}
+		if count > 0 {
+			log.WithField("url", url).Info("该URL在冷却期内已发送篡改告警，跳过")
+			continue
+		}
+
+		alert := s.buildContentMismatchAlert(url, recent)
+		if s.telegram != nil {
+			if err := s.telegram.SendMessage(alert.AlertMessage); err != nil {
+				log.WithError(err).WithField("url", url).Error("发送篡改告警失败")
+			} else {
+				alert.AlertSent = true
+			}
+		}
+		if err := s.db.Create(alert).Error; err != nil {
+			log.WithError(err).WithField("url", url).Error("保存篡改告警记录失败")
+		}
+
+		log.WithFields(map[string]interface{}{
+			"url":        url,
+			"ips":        alert.FailedIPs,
+			"alert_sent": alert.AlertSent,
+		}).Warn("检测到下载内容与上传文件不一致")
+	}
+}
+
+// buildContentMismatchAlert 构建篡改告警记录和消息
+func (s *SpeedProbeService) buildContentMismatchAlert(url string, results []models.SpeedProbeResult) *models.SpeedAlertLog {
+	now := time.Now()
+	windowStart := now
+	var ipDetails []IPDetail
+	seenIPs := make(map[string]bool)
+	for _, result := range results {
+		if !result.CreatedAt.IsZero() && result.CreatedAt.Before(windowStart) {
+			windowStart = result.CreatedAt
+		}
+		if seenIPs[result.ClientIP] {
+			continue
+		}
+		seenIPs[result.ClientIP] = true
+		ipDetails = append(ipDetails, IPDetail{
+			IP:         result.ClientIP,
+			Probes:     1,
+			FailedRate: 100,
+			Status:     fmt.Sprintf("内容不一致, SHA-256: %s, %s", result.ContentSHA256, result.ErrorMessage),
+		})
+	}
+
+	message := "🚨🚨 下载内容篡改告警（高优先级）\n\n"
+	if s.telegram != nil && s.telegram.GetSitename() != "" {
+		message = fmt.Sprintf("[%s] 🚨🚨 下载内容篡改告警（高优先级）\n\n", s.telegram.GetSitename())
+	}
+	message += fmt.Sprintf("链接地址: %s\n", url)
+	message += fmt.Sprintf("发现时间: %s\n", now.Format("2006-01-02 15:04:05"))
+	message += fmt.Sprintf("异常IP数量: %d\n\n", len(ipDetails))
+
+	displayCount := len(ipDetails)
+	if displayCount > 10 {
+		displayCount = 10
+	}
+	for i := 0; i < displayCount; i++ {
+		message += fmt.Sprintf("❌ IP: %s | %s\n", ipDetails[i].IP, ipDetails[i].Status)
+	}
+	if len(ipDetails) > 10 {
+		message += fmt.Sprintf("... 还有 %d 个IP未显示\n", len(ipDetails)-10)
+	}
+	message += "\n⚠️ 探测下载到的文件与上传的文件不一致，可能是 CDN 缓存异常、域名被劫持或运营商篡改，请立即检查！"
+
+	ipDetailsJSON, _ := json.Marshal(ipDetails)
+	return &models.SpeedAlertLog{
+		URL:             url,
+		TimeWindowStart: windowStart,
+		TimeWindowEnd:   now,
+		TotalIPs:        len(ipDetails),
+		FailedIPs:       len(ipDetails),
+		FailedRate:      100,
+		AlertMessage:    message,
+		IPDetails:       string(ipDetailsJSON),
+		AlertType:       models.SpeedAlertTypeContentMismatch,
+	}
+}
+
 // CheckAndAlertForIP 检查指定IP的探测结果并发送告警（如果需要）
 // 已废弃：现在使用 CheckAndAlertForURL 按URL维度检查
 // 该方法保留仅为了向后兼容，但不再执行任何操作
@@ -678,6 +933,7 @@ type AlertLogFilters struct {
 	AlertSent        *bool      // 是否已发送告警
 	FailedRateMin    *float64   // 未达标率下限
 	FailedRateMax    *float64   // 未达标率上限
+	AlertType        string     // 告警类型：speed / content_mismatch
 }
 
 // ListAlertLogs 分页查询告警记录，支持丰富筛选
@@ -712,6 +968,9 @@ func (s *SpeedProbeService) ListAlertLogs(page, pageSize int, filters *AlertLogF
 		if filters.FailedRateMax != nil {
 			query = query.Where("failed_rate <= ?", *filters.FailedRateMax)
 		}
+		if filters.AlertType != "" {
+			query = query.Where("alert_type = ?", filters.AlertType)
+		}
 	}
 
 	if err := query.Count(&total).Error; err != nil {
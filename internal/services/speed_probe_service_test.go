@@ -0,0 +1,65 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"strings"
+	"testing"
+)
+
+// 篡改告警按 IP 去重，类型为 content_mismatch，消息包含探测到的实际哈希
+func TestBuildContentMismatchAlert(t *testing.T) {
+	s := &SpeedProbeService{}
+	results := []models.SpeedProbeResult{
+		{URL: "https://d.example.com/app.apk", ClientIP: "1.1.1.1", Status: models.SpeedProbeStatusContentMismatch, ContentSHA256: "abc", ErrorMessage: "文件 SHA-256 不一致"},
+		{URL: "https://d.example.com/app.apk", ClientIP: "1.1.1.1", Status: models.SpeedProbeStatusContentMismatch, ContentSHA256: "abc"},
+		{URL: "https://d.example.com/app.apk", ClientIP: "2.2.2.2", Status: models.SpeedProbeStatusContentMismatch, ContentSHA256: "def"},
+	}
+
+	alert := s.buildContentMismatchAlert("https://d.example.com/app.apk", results)
+	if alert.AlertType != models.SpeedAlertTypeContentMismatch || alert.TotalIPs != 2 || alert.FailedIPs != 2 {
+		t.Fatalf("alert = %+v", alert)
+	}
+	if !strings.Contains(alert.AlertMessage, "高优先级") || !strings.Contains(alert.AlertMessage, "abc") || !strings.Contains(alert.AlertMessage, "def") {
+		t.Fatalf("告警消息 = %s", alert.AlertMessage)
+	}
+}
+
+// 上报的哈希与上传文件的完整哈希或前缀哈希相同时不认定为篡改；没有保存哈希或未上报哈希时无法核实
+func TestContentMismatchConfirmed(t *testing.T) {
+	expected := models.FileInspection{FileSHA256: "aaa", FilePrefixSHA256: "bbb"}
+	cases := []struct {
+		reported string
+		expected models.FileInspection
+		want     bool
+	}{
+		{"ccc", expected, true},
+		{"AAA", expected, false},
+		{"bbb", expected, false},
+		{"", expected, false},
+		{"ccc", models.FileInspection{}, false},
+	}
+	for _, c := range cases {
+		if got := contentMismatchConfirmed(c.reported, c.expected); got != c.want {
+			t.Fatalf("contentMismatchConfirmed(%q, %+v) = %v", c.reported, c.expected, got)
+		}
+	}
+}
+
+// 同一 IP 反复上报任意哈希（伪造）不足以触发篡改告警，需要多个不同 IP 的上报互相印证
+func TestContentMismatchCorroborated(t *testing.T) {
+	mismatch := func(ip string) models.SpeedProbeResult {
+		return models.SpeedProbeResult{URL: "https://d.example.com/app.apk", ClientIP: ip, Status: models.SpeedProbeStatusContentMismatch, ContentSHA256: "x"}
+	}
+	spoofed := []models.SpeedProbeResult{mismatch("6.6.6.6"), mismatch("6.6.6.6"), mismatch("6.6.6.6"), mismatch("6.6.6.6")}
+	if contentMismatchCorroborated(spoofed) {
+		t.Fatal("单个 IP 的重复上报不应认定为篡改")
+	}
+	failed := mismatch("2.2.2.2")
+	failed.Status = models.SpeedProbeStatusFailed
+	if contentMismatchCorroborated([]models.SpeedProbeResult{mismatch("1.1.1.1"), failed, mismatch("1.1.1.1")}) {
+		t.Fatal("未核实的上报不应计入")
+	}
+	if !contentMismatchCorroborated([]models.SpeedProbeResult{mismatch("1.1.1.1"), mismatch("2.2.2.2"), mismatch("3.3.3.3")}) {
+		t.Fatal("多个不同 IP 的上报应认定为篡改")
+	}
+}
@@ -0,0 +1,711 @@
+package services
+
+import (
+	"aws_cdn/internal/logger"
+	"aws_cdn/internal/models"
+	"aws_cdn/internal/services/aws"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gorm.io/gorm"
+)
+
+const (
+	minUploadPartSize     = 5 << 20  // S3 要求除最后一片外每片至少 5MB
+	defaultUploadPartSize = 16 << 20 // 默认分片大小，代理上传时每个请求最多占用这么多内存
+	maxUploadPartSize     = 512 << 20
+	maxUploadParts        = 10000 // S3 单次分片上传最多 10000 片
+	uploadSessionTTL      = 72 * time.Hour
+	uploadPartURLExpiry   = time.Hour
+	maxPutUploadSize      = 5 << 30          // S3 单次 PUT 最大 5GB
+	uploadPutURLExpiry    = 15 * time.Minute // 预签名只在请求开始时校验，过期前开始的上传不受影响
+
+	// 代理上传的分片整片缓存在内存中，超过该大小需使用预签名地址直传
+	maxProxyPartSize = 64 << 20
+	// 直传未提供 ETag 时，对象写入时间早于会话创建时间超过该偏差即视为旧文件
+	putObjectClockSkew = time.Minute
+	// 完成请求处理中断（进程重启）时会话停留在 completing，超过该时间改为 failed 以便重新完成
+	completingSessionTimeout = 30 * time.Minute
+)
+
+// uploadStore 上传会话使用的对象存储，S3（按存储桶绑定）和 R2 都实现该接口
+type uploadStore interface {
+	PresignPutObject(key, contentType string, expires time.Duration) (string, error)
+	HeadObject(key string) (*s3.HeadObjectOutput, error)
+	CreateMultipartUpload(key, contentType string) (string, error)
+	PresignUploadPart(key, uploadID string, partNumber int64, expires time.Duration) (string, error)
+	UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) error
+	ListUploadedParts(key, uploadID string) ([]*s3.Part, error)
+	CompleteMultipartUpload(key, uploadID string, parts []*s3.CompletedPart) (string, error)
+	AbortMultipartUpload(key, uploadID string) error
+}
+
+// s3BucketStore 将 S3Service 绑定到指定存储桶
+type s3BucketStore struct {
+	s3     *aws.S3Service
+	bucket string
+}
+
+func (b *s3BucketStore) PresignPutObject(key, contentType string, expires time.Duration) (string, error) {
+	return b.s3.PresignPutObject(b.bucket, key, contentType, expires)
+}
+
+func (b *s3BucketStore) HeadObject(key string) (*s3.HeadObjectOutput, error) {
+	return b.s3.HeadObject(b.bucket, key)
+}
+
+func (b *s3BucketStore) CreateMultipartUpload(key, contentType string) (string, error) {
+	return b.s3.CreateMultipartUpload(b.bucket, key, contentType)
+}
+
+func (b *s3BucketStore) PresignUploadPart(key, uploadID string, partNumber int64, expires time.Duration) (string, error) {
+	return b.s3.PresignUploadPart(b.bucket, key, uploadID, partNumber, expires)
+}
+
+func (b *s3BucketStore) UploadPart(key, uploadID string, partNumber int64, body io.ReadSeeker) error {
+	return b.s3.UploadPart(b.bucket, key, uploadID, partNumber, body)
+}
+
+func (b *s3BucketStore) ListUploadedParts(key, uploadID string) ([]*s3.Part, error) {
+	return b.s3.ListUploadedParts(b.bucket, key, uploadID)
+}
+
+func (b *s3BucketStore) CompleteMultipartUpload(key, uploadID string, parts []*s3.CompletedPart) (string, error) {
+	return b.s3.CompleteMultipartUpload(b.bucket, key, uploadID, parts)
+}
+
+func (b *s3BucketStore) AbortMultipartUpload(key, uploadID string) error {
+	return b.s3.AbortMultipartUpload(b.bucket, key, uploadID)
+}
+
+// CreateUploadSessionRequest 发起上传的参数
+type CreateUploadSessionRequest struct {
+	Target       models.UploadTarget `json:"target" binding:"required"`
+	Mode         models.UploadMode   `json:"mode"`           // multipart（默认）或 put
+	DomainID     uint                `json:"domain_id"`      // 下载包：域名 ID
+	AWSAccountID *uint               `json:"aws_account_id"` // 下载包：AWS 账号 ID，默认与域名一致
+	R2BucketID   uint                `json:"r2_bucket_id"`   // R2 文件：存储桶 ID
+	PackageID    uint                `json:"package_id"`     // 下载包版本：下载包 ID
+	Note         string              `json:"note"`           // 下载包版本：版本备注
+	Key          string              `json:"key"`            // R2 文件：对象路径，默认为文件名
+	FileName     string              `json:"file_name" binding:"required"`
+	FileSize     int64               `json:"file_size" binding:"required"`
+	ContentType  string              `json:"content_type"`
+	PartSize     int64               `json:"part_size"` // 分片大小（字节），默认 16MB
+}
+
+// UploadedPart 已上传的分片
+type UploadedPart struct {
+	PartNumber int64  `json:"part_number"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+}
+
+// UploadSessionDetail 上传会话及已上传的分片，客户端据此续传缺失的分片
+type UploadSessionDetail struct {
+	*models.UploadSession
+	UploadedParts []UploadedPart `json:"uploaded_parts"`
+	MissingParts  []int64        `json:"missing_parts"`
+}
+
+// UploadPartURL 分片预签名上传地址
+type UploadPartURL struct {
+	PartNumber int64  `json:"part_number"`
+	URL        string `json:"url"`
+}
+
+// UploadSessionService 下载包与 R2 文件共用的上传会话
+// 分片上传：客户端按分片上传（预签名地址直传存储桶，或经服务端代理），断线后查询已上传分片续传，全部上传后合并并创建记录。
+// 直传：客户端通过预签名 PUT 地址直接上传到存储桶，文件不经过服务端，完成时 HEAD 校验后创建记录。
+type UploadSessionService struct {
+	db                     *gorm.DB
+	downloadPackageService *DownloadPackageService
+	versionService         *DownloadPackageVersionService
+	r2FileService          *R2FileService
+}
+
+// NewUploadSessionService 创建分片上传会话服务
+func NewUploadSessionService(db *gorm.DB, downloadPackageService *DownloadPackageService, versionService *DownloadPackageVersionService, r2FileService *R2FileService) *UploadSessionService {
+	return &UploadSessionService{
+		db:                     db,
+		downloadPackageService: downloadPackageService,
+		versionService:         versionService,
+		r2FileService:          r2FileService,
+	}
+}
+
+// CreateSession 发起上传；直传方式同时返回预签名 PUT 地址
+func (s *UploadSessionService) CreateSession(req CreateUploadSessionRequest, operator string) (*models.UploadSession, error) {
+	if req.FileSize <= 0 {
+		return nil, fmt.Errorf("文件大小必须大于 0")
+	}
+	fileName, err := sanitizeFileName(req.FileName)
+	if err != nil {
+		return nil, err
+	}
+	req.FileName = fileName
+	if req.Mode == "" {
+		req.Mode = models.UploadModeMultipart
+	}
+	var partSize, partCount int64
+	switch req.Mode {
+	case models.UploadModeMultipart:
+		if partSize, partCount, err = uploadPartLayout(req.FileSize, req.PartSize); err != nil {
+			return nil, err
+		}
+	case models.UploadModePut:
+		if req.FileSize > maxPutUploadSize {
+			return nil, fmt.Errorf("直传文件不能超过 5GB，请使用分片上传")
+		}
+		partSize, partCount = req.FileSize, 1
+	default:
+		return nil, fmt.Errorf("不支持的上传方式: %s", req.Mode)
+	}
+	if req.ContentType == "" {
+		req.ContentType = mime.TypeByExtension(filepath.Ext(req.FileName))
+	}
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+
+	session := &models.UploadSession{
+		Target:      req.Target,
+		Mode:        req.Mode,
+		FileName:    req.FileName,
+		FileSize:    req.FileSize,
+		ContentType: req.ContentType,
+		PartSize:    partSize,
+		PartCount:   partCount,
+		Status:      models.UploadSessionStatusUploading,
+		CreatedBy:   operator,
+		ExpiresAt:   time.Now().Add(uploadSessionTTL),
+	}
+
+	switch req.Target {
+	case models.UploadTargetDownloadPackage:
+		// 与表单上传相同的校验（证书、账号、域名占用），对象键也由下载包规则生成
+		pkg, _, err := s.downloadPackageService.PrepareDownloadPackage(req.DomainID, req.AWSAccountID, req.FileName, req.FileSize)
+		if err != nil {
+			return nil, err
+		}
+		clients, err := s.downloadPackageService.awsClientsForPackage(pkg)
+		if err != nil {
+			return nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+		}
+		session.DomainID = &pkg.DomainID
+		session.AWSAccountID = pkg.AWSAccountID
+		session.Bucket = clients.Config.S3BucketName
+		session.ObjectKey = pkg.S3Key
+	case models.UploadTargetPackageVersion:
+		pkg, key, err := s.versionService.NewVersionKey(req.PackageID)
+		if err != nil {
+			return nil, err
+		}
+		clients, err := s.downloadPackageService.awsClientsForPackage(pkg)
+		if err != nil {
+			return nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+		}
+		session.PackageID = &pkg.ID
+		session.AWSAccountID = pkg.AWSAccountID
+		session.Bucket = clients.Config.S3BucketName
+		session.ObjectKey = key
+		session.Note = req.Note
+	case models.UploadTargetR2File:
+		if req.R2BucketID == 0 {
+			return nil, fmt.Errorf("r2_bucket_id 是必需的")
+		}
+		_, bucket, err := s.r2FileService.r2S3ForBucket(req.R2BucketID)
+		if err != nil {
+			return nil, err
+		}
+		session.R2BucketID = &bucket.ID
+		session.Bucket = bucket.BucketName
+		session.ObjectKey = strings.TrimPrefix(req.Key, "/")
+		if session.ObjectKey == "" {
+			session.ObjectKey = req.FileName
+		}
+	default:
+		return nil, fmt.Errorf("不支持的上传目标: %s", req.Target)
+	}
+
+	store, err := s.storeFor(session)
+	if err != nil {
+		return nil, err
+	}
+	if session.Mode == models.UploadModePut {
+		if session.UploadURL, err = store.PresignPutObject(session.ObjectKey, session.ContentType, uploadPutURLExpiry); err != nil {
+			return nil, err
+		}
+		if err := s.db.Create(session).Error; err != nil {
+			return nil, fmt.Errorf("保存上传会话失败: %w", err)
+		}
+	} else {
+		if session.UploadID, err = store.CreateMultipartUpload(session.ObjectKey, session.ContentType); err != nil {
+			return nil, err
+		}
+		if err := s.db.Create(session).Error; err != nil {
+			_ = store.AbortMultipartUpload(session.ObjectKey, session.UploadID)
+			return nil, fmt.Errorf("保存上传会话失败: %w", err)
+		}
+	}
+
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"session_id": session.ID,
+		"target":     session.Target,
+		"mode":       session.Mode,
+		"bucket":     session.Bucket,
+		"key":        session.ObjectKey,
+		"file_size":  session.FileSize,
+		"part_count": session.PartCount,
+	}).Info("上传会话已创建")
+	return session, nil
+}
+
+// ListSessions 列出上传会话，status 为空时列出全部
+func (s *UploadSessionService) ListSessions(status string) ([]models.UploadSession, error) {
+	query := s.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var sessions []models.UploadSession
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("获取上传会话失败: %w", err)
+	}
+	return sessions, nil
+}
+
+// GetSession 获取上传会话；上传中的分片上传会话同时返回已上传和缺失的分片
+func (s *UploadSessionService) GetSession(id uint) (*UploadSessionDetail, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+	detail := &UploadSessionDetail{UploadSession: session}
+	if session.Status != models.UploadSessionStatusUploading || session.Mode != models.UploadModeMultipart {
+		return detail, nil
+	}
+
+	store, err := s.storeFor(session)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := store.ListUploadedParts(session.ObjectKey, session.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	detail.UploadedParts, detail.MissingParts = summarizeUploadedParts(parts, session.PartCount)
+	return detail, nil
+}
+
+// PresignPut 重新生成直传会话的预签名 PUT 地址（原地址过期时使用）
+func (s *UploadSessionService) PresignPut(id uint) (*models.UploadSession, error) {
+	session, err := s.getUploadingSession(id, models.UploadModePut)
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.storeFor(session)
+	if err != nil {
+		return nil, err
+	}
+	if session.UploadURL, err = store.PresignPutObject(session.ObjectKey, session.ContentType, uploadPutURLExpiry); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// PresignParts 为指定分片生成预签名 PUT 地址，客户端直传存储桶（需要存储桶 CORS 允许 PUT）
+func (s *UploadSessionService) PresignParts(id uint, partNumbers []int64) ([]UploadPartURL, error) {
+	session, err := s.getUploadingSession(id, models.UploadModeMultipart)
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.storeFor(session)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]UploadPartURL, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		if partNumber < 1 || partNumber > session.PartCount {
+			return nil, fmt.Errorf("分片序号 %d 超出范围（1-%d）", partNumber, session.PartCount)
+		}
+		url, err := store.PresignUploadPart(session.ObjectKey, session.UploadID, partNumber, uploadPartURLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, UploadPartURL{PartNumber: partNumber, URL: url})
+	}
+	return urls, nil
+}
+
+// UploadPart 经服务端代理上传单个分片，每次只缓存一个分片
+func (s *UploadSessionService) UploadPart(id uint, partNumber int64, body io.Reader) error {
+	session, err := s.getUploadingSession(id, models.UploadModeMultipart)
+	if err != nil {
+		return err
+	}
+	if partNumber < 1 || partNumber > session.PartCount {
+		return fmt.Errorf("分片序号 %d 超出范围（1-%d）", partNumber, session.PartCount)
+	}
+
+	expected := expectedPartSize(session, partNumber)
+	if expected > maxProxyPartSize {
+		return fmt.Errorf("分片大小 %dMB 超过代理上传上限 %dMB，请使用预签名地址直传分片", expected>>20, maxProxyPartSize>>20)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, expected+1))
+	if err != nil {
+		return fmt.Errorf("读取分片数据失败: %w", err)
+	}
+	if int64(len(data)) != expected {
+		return fmt.Errorf("分片 %d 大小应为 %d 字节，实际 %d 字节", partNumber, expected, len(data))
+	}
+
+	store, err := s.storeFor(session)
+	if err != nil {
+		return err
+	}
+	return store.UploadPart(session.ObjectKey, session.UploadID, partNumber, bytes.NewReader(data))
+}
+
+// CompleteSession 校验上传结果后创建下载包或 R2 文件记录
+// 分片上传校验分片齐全后合并；直传通过 HEAD 校验对象大小，clientETag（客户端 PUT 响应中的 ETag）用于确认对象就是本次上传的文件
+// 文件已合并但创建记录失败的会话（状态 failed）可再次调用，对象仍存在时只重新创建记录
+func (s *UploadSessionService) CompleteSession(id uint, clientETag string) (*models.UploadSession, error) {
+	log := logger.GetLogger()
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+	retry := session.Status == models.UploadSessionStatusFailed
+	if !retry {
+		if session, err = s.getUploadingSession(id); err != nil {
+			return nil, err
+		}
+	}
+	store, err := s.storeFor(session)
+	if err != nil {
+		return nil, err
+	}
+
+	// 原子地占用会话，并发或重复的完成请求只有一个能继续，避免重复创建记录
+	previousStatus := session.Status
+	if err := claimSession(s.db, session.ID, previousStatus); err != nil {
+		return nil, err
+	}
+	etag, err := finishUpload(store, session, retry, clientETag)
+	if err != nil {
+		// 文件尚未合并，恢复原状态以便客户端修正后重试
+		if releaseErr := s.db.Model(session).Update("status", previousStatus).Error; releaseErr != nil {
+			log.WithError(releaseErr).WithField("session_id", session.ID).Error("恢复上传会话状态失败")
+		}
+		return nil, err
+	}
+
+	resultID, err := s.createResult(session, etag)
+	if err != nil {
+		log.WithError(err).WithField("session_id", session.ID).Error("文件已上传，但创建记录失败")
+		s.db.Model(session).Updates(map[string]interface{}{
+			"status":        models.UploadSessionStatusFailed,
+			"error_message": err.Error(),
+		})
+		return nil, fmt.Errorf("文件已上传到 %s，但创建记录失败: %w", session.ObjectKey, err)
+	}
+
+	session.Status = models.UploadSessionStatusCompleted
+	session.ResultID = &resultID
+	session.ErrorMessage = ""
+	if err := s.db.Model(session).Select("status", "result_id", "error_message").Updates(session).Error; err != nil {
+		return nil, fmt.Errorf("更新上传会话失败: %w", err)
+	}
+	log.WithFields(map[string]interface{}{
+		"session_id": session.ID,
+		"target":     session.Target,
+		"key":        session.ObjectKey,
+		"result_id":  resultID,
+	}).Info("上传会话已完成")
+	return session, nil
+}
+
+// finishUpload 校验上传结果并合并分片，返回对象的 ETag
+// 重新完成（retry）时只确认对象仍存在且大小一致；直传通过 HEAD 校验；分片上传校验分片齐全后合并
+func finishUpload(store uploadStore, session *models.UploadSession, retry bool, clientETag string) (string, error) {
+	var etag string
+	if retry {
+		head, err := store.HeadObject(session.ObjectKey)
+		if err != nil {
+			return "", fmt.Errorf("上传的文件不存在，无法重新创建记录: %w", err)
+		}
+		if size := awsSDK.Int64Value(head.ContentLength); size != session.FileSize {
+			return "", fmt.Errorf("文件大小应为 %d 字节，存储桶中为 %d 字节，无法重新创建记录", session.FileSize, size)
+		}
+		etag = awsSDK.StringValue(head.ETag)
+	} else if session.Mode == models.UploadModePut {
+		head, err := store.HeadObject(session.ObjectKey)
+		if err != nil {
+			return "", fmt.Errorf("文件尚未上传或无法访问: %w", err)
+		}
+		if err := verifyPutObject(head, session, clientETag); err != nil {
+			return "", err
+		}
+		// 与分片上传一致设置 public-read ACL（R2 不支持对象 ACL）
+		if s3Store, ok := store.(*s3BucketStore); ok {
+			if err := s3Store.s3.PutObjectPublicRead(s3Store.bucket, session.ObjectKey); err != nil {
+				return "", err
+			}
+		}
+		etag = awsSDK.StringValue(head.ETag)
+	} else {
+		parts, err := store.ListUploadedParts(session.ObjectKey, session.UploadID)
+		if err != nil {
+			return "", err
+		}
+		completed, err := completedParts(parts, session)
+		if err != nil {
+			return "", err
+		}
+		if etag, err = store.CompleteMultipartUpload(session.ObjectKey, session.UploadID, completed); err != nil {
+			return "", err
+		}
+	}
+	return etag, nil
+}
+
+// claimSession 仅当会话仍为 from 状态时改为 completing，否则说明其他完成请求正在处理或已完成
+func claimSession(db *gorm.DB, id uint, from models.UploadSessionStatus) error {
+	result := db.Model(&models.UploadSession{}).Where("id = ? AND status = ?", id, from).
+		Update("status", models.UploadSessionStatusCompleting)
+	if result.Error != nil {
+		return fmt.Errorf("更新上传会话失败: %w", result.Error)
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("上传会话正在完成或状态已变化，请稍后查询会话状态")
+	}
+	return nil
+}
+
+// AbortSession 取消上传并释放已上传的分片
+func (s *UploadSessionService) AbortSession(id uint) error {
+	session, err := s.getUploadingSession(id)
+	if err != nil {
+		return err
+	}
+	return s.abort(session)
+}
+
+// CleanupExpiredSessions 取消超过有效期仍未完成的上传（定时任务入口），避免未合并的分片持续占用存储
+func (s *UploadSessionService) CleanupExpiredSessions() error {
+	log := logger.GetLogger()
+	var sessions []models.UploadSession
+	if err := s.db.Where("status = ? AND expires_at < ?", models.UploadSessionStatusUploading, time.Now()).Find(&sessions).Error; err != nil {
+		return fmt.Errorf("查询过期上传会话失败: %w", err)
+	}
+	for i := range sessions {
+		if err := s.abort(&sessions[i]); err != nil {
+			log.WithError(err).WithField("session_id", sessions[i].ID).Warn("取消过期上传会话失败")
+		}
+	}
+	if len(sessions) > 0 {
+		log.WithField("count", len(sessions)).Info("已清理过期的分片上传会话")
+	}
+
+	// 完成过程被中断的会话改为 failed，可再次调用完成接口
+	result := s.db.Model(&models.UploadSession{}).
+		Where("status = ? AND updated_at < ?", models.UploadSessionStatusCompleting, time.Now().Add(-completingSessionTimeout)).
+		Updates(map[string]interface{}{
+			"status":        models.UploadSessionStatusFailed,
+			"error_message": "完成上传的过程被中断，请重新调用完成接口",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("处理中断的上传会话失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.WithField("count", result.RowsAffected).Warn("完成过程被中断的上传会话已标记为失败")
+	}
+	return nil
+}
+
+func (s *UploadSessionService) abort(session *models.UploadSession) error {
+	// 直传没有需要释放的分片；已上传的对象可能覆盖了同名文件，不做删除
+	if session.Mode == models.UploadModeMultipart {
+		store, err := s.storeFor(session)
+		if err != nil {
+			return err
+		}
+		if err := store.AbortMultipartUpload(session.ObjectKey, session.UploadID); err != nil {
+			return err
+		}
+	}
+	return s.db.Model(session).Update("status", models.UploadSessionStatusAborted).Error
+}
+
+// createResult 上传完成后创建下载包、同步 R2 文件记录或登记下载包版本，返回记录 ID
+func (s *UploadSessionService) createResult(session *models.UploadSession, etag string) (uint, error) {
+	switch session.Target {
+	case models.UploadTargetDownloadPackage:
+		pkg, err := s.downloadPackageService.CreateUploadedDownloadPackage(*session.DomainID, session.AWSAccountID, session.FileName, session.FileSize)
+		if err != nil {
+			return 0, err
+		}
+		return pkg.ID, nil
+	case models.UploadTargetPackageVersion:
+		version, err := s.versionService.RegisterVersion(*session.PackageID, session.ObjectKey, session.FileName, session.FileSize, session.Note, session.CreatedBy)
+		if err != nil {
+			return 0, err
+		}
+		return version.ID, nil
+	case models.UploadTargetR2File:
+		fileSize := session.FileSize
+		contentType := session.ContentType
+		if err := s.r2FileService.SyncFileRecord(*session.R2BucketID, session.ObjectKey, session.FileName, &fileSize, &contentType, &etag); err != nil {
+			return 0, err
+		}
+		var file models.R2File
+		if err := s.db.Where("r2_bucket_id = ? AND file_path = ?", *session.R2BucketID, session.ObjectKey).First(&file).Error; err != nil {
+			return 0, err
+		}
+		// 大文件重新下载计算哈希，不阻塞完成请求
+		go func(r2BucketID uint, filePath string) {
+			defer recoverInspection(map[string]interface{}{"r2_bucket_id": r2BucketID, "file_path": filePath})
+			if _, err := s.r2FileService.InspectFile(r2BucketID, filePath, nil); err != nil {
+				logger.GetLogger().WithError(err).WithField("file_path", filePath).Warn("检查 R2 文件失败")
+			}
+		}(*session.R2BucketID, session.ObjectKey)
+		return file.ID, nil
+	}
+	return 0, fmt.Errorf("不支持的上传目标: %s", session.Target)
+}
+
+func (s *UploadSessionService) storeFor(session *models.UploadSession) (uploadStore, error) {
+	switch session.Target {
+	case models.UploadTargetDownloadPackage, models.UploadTargetPackageVersion:
+		clients, err := s.downloadPackageService.awsClientsForPackage(&models.DownloadPackage{AWSAccountID: session.AWSAccountID})
+		if err != nil {
+			return nil, fmt.Errorf("获取 AWS 账号客户端失败: %w", err)
+		}
+		return &s3BucketStore{s3: clients.S3, bucket: session.Bucket}, nil
+	case models.UploadTargetR2File:
+		if session.R2BucketID == nil {
+			return nil, fmt.Errorf("上传会话缺少 R2 存储桶")
+		}
+		r2S3, _, err := s.r2FileService.r2S3ForBucket(*session.R2BucketID)
+		if err != nil {
+			return nil, err
+		}
+		return r2S3, nil
+	}
+	return nil, fmt.Errorf("不支持的上传目标: %s", session.Target)
+}
+
+func (s *UploadSessionService) getSession(id uint) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := s.db.First(&session, id).Error; err != nil {
+		return nil, fmt.Errorf("上传会话不存在: %w", err)
+	}
+	return &session, nil
+}
+
+// getUploadingSession 获取上传中的会话，指定 mode 时同时校验上传方式
+func (s *UploadSessionService) getUploadingSession(id uint, mode ...models.UploadMode) (*models.UploadSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadSessionStatusUploading {
+		return nil, fmt.Errorf("上传会话状态为 %s，无法继续操作", session.Status)
+	}
+	if len(mode) > 0 && session.Mode != mode[0] {
+		return nil, fmt.Errorf("上传会话的上传方式为 %s，不支持该操作", session.Mode)
+	}
+	return session, nil
+}
+
+// uploadPartLayout 计算分片大小和分片数，分片数超过上限时自动增大分片
+func uploadPartLayout(fileSize, partSize int64) (int64, int64, error) {
+	if partSize == 0 {
+		partSize = defaultUploadPartSize
+	}
+	if partSize < minUploadPartSize || partSize > maxUploadPartSize {
+		return 0, 0, fmt.Errorf("分片大小必须在 %dMB 到 %dMB 之间", minUploadPartSize>>20, maxUploadPartSize>>20)
+	}
+	if minimum := (fileSize + maxUploadParts - 1) / maxUploadParts; partSize < minimum {
+		partSize = (minimum + 1<<20 - 1) >> 20 << 20 // 向上取整到 MB
+	}
+	if partSize > maxUploadPartSize {
+		return 0, 0, fmt.Errorf("文件过大，超过 %d 个 %dMB 分片", maxUploadParts, maxUploadPartSize>>20)
+	}
+	return partSize, (fileSize + partSize - 1) / partSize, nil
+}
+
+// expectedPartSize 除最后一片外每片都是 PartSize
+func expectedPartSize(session *models.UploadSession, partNumber int64) int64 {
+	if partNumber < session.PartCount {
+		return session.PartSize
+	}
+	return session.FileSize - (session.PartCount-1)*session.PartSize
+}
+
+func summarizeUploadedParts(parts []*s3.Part, partCount int64) ([]UploadedPart, []int64) {
+	uploaded := make([]UploadedPart, 0, len(parts))
+	seen := make(map[int64]bool, len(parts))
+	for _, part := range parts {
+		number := awsSDK.Int64Value(part.PartNumber)
+		seen[number] = true
+		uploaded = append(uploaded, UploadedPart{PartNumber: number, Size: awsSDK.Int64Value(part.Size), ETag: awsSDK.StringValue(part.ETag)})
+	}
+	sort.Slice(uploaded, func(i, j int) bool { return uploaded[i].PartNumber < uploaded[j].PartNumber })
+
+	missing := []int64{}
+	for number := int64(1); number <= partCount; number++ {
+		if !seen[number] {
+			missing = append(missing, number)
+		}
+	}
+	return uploaded, missing
+}
+
+// completedParts 校验分片齐全且大小与会话一致，生成合并参数
+func completedParts(parts []*s3.Part, session *models.UploadSession) ([]*s3.CompletedPart, error) {
+	uploaded, missing := summarizeUploadedParts(parts, session.PartCount)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("还有 %d 个分片未上传（如 %d），请续传后再完成", len(missing), missing[0])
+	}
+
+	completed := make([]*s3.CompletedPart, 0, session.PartCount)
+	for _, part := range uploaded {
+		if part.PartNumber > session.PartCount {
+			continue
+		}
+		if expected := expectedPartSize(session, part.PartNumber); part.Size != expected {
+			return nil, fmt.Errorf("分片 %d 大小应为 %d 字节，实际 %d 字节，请重新上传该分片", part.PartNumber, expected, part.Size)
+		}
+		completed = append(completed, &s3.CompletedPart{PartNumber: awsSDK.Int64(part.PartNumber), ETag: awsSDK.String(part.ETag)})
+	}
+	return completed, nil
+}
+
+// verifyPutObject 校验直传对象的大小和 ETag（比较时忽略引号）
+// 客户端未提供 ETag 时要求对象在会话创建之后写入，避免把同名同大小的旧文件当成本次上传
+func verifyPutObject(head *s3.HeadObjectOutput, session *models.UploadSession, clientETag string) error {
+	if size := awsSDK.Int64Value(head.ContentLength); size != session.FileSize {
+		return fmt.Errorf("文件大小应为 %d 字节，存储桶中为 %d 字节，请重新上传", session.FileSize, size)
+	}
+	if clientETag == "" {
+		// LastModified 精确到秒，并允许服务器与存储桶之间有少量时钟偏差
+		if head.LastModified == nil || head.LastModified.Before(session.CreatedAt.Add(-putObjectClockSkew).Truncate(time.Second)) {
+			return fmt.Errorf("存储桶中的文件早于本次上传会话，请上传文件后再完成，或提供 PUT 响应中的 ETag")
+		}
+		return nil
+	}
+	if strings.Trim(clientETag, `"`) != strings.Trim(awsSDK.StringValue(head.ETag), `"`) {
+		return fmt.Errorf("ETag 不一致，存储桶中的文件不是本次上传的文件，请重新上传")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	awsSDK "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"gorm.io/gorm"
+)
+
+// 默认 16MB 分片；文件过大时自动增大分片使分片数不超过 10000；客户端指定的分片不能小于 5MB
+func TestUploadPartLayout(t *testing.T) {
+	if size, count, err := uploadPartLayout(100<<20+1, 0); err != nil || size != 16<<20 || count != 7 {
+		t.Fatalf("uploadPartLayout = %d, %d, %v", size, count, err)
+	}
+	size, count, err := uploadPartLayout(200<<30, 0)
+	if err != nil || count > maxUploadParts || size%(1<<20) != 0 || size*count < 200<<30 {
+		t.Fatalf("大文件分片 = %d, %d, %v", size, count, err)
+	}
+	if _, _, err := uploadPartLayout(100<<20, 1<<20); err == nil {
+		t.Fatal("小于 5MB 的分片应被拒绝")
+	}
+}
+
+// 分片缺失或大小不一致时不能合并，最后一片允许小于分片大小
+func TestCompletedParts(t *testing.T) {
+	session := &models.UploadSession{FileSize: 25, PartSize: 10, PartCount: 3}
+	part := func(number, size int64) *s3.Part {
+		return &s3.Part{PartNumber: awsSDK.Int64(number), Size: awsSDK.Int64(size), ETag: awsSDK.String("e")}
+	}
+
+	if _, err := completedParts([]*s3.Part{part(1, 10), part(3, 5)}, session); err == nil {
+		t.Fatal("缺少分片 2 时应拒绝合并")
+	}
+	if _, err := completedParts([]*s3.Part{part(1, 10), part(2, 9), part(3, 5)}, session); err == nil {
+		t.Fatal("分片大小不一致时应拒绝合并")
+	}
+	completed, err := completedParts([]*s3.Part{part(3, 5), part(1, 10), part(2, 10)}, session)
+	if err != nil || len(completed) != 3 || awsSDK.Int64Value(completed[0].PartNumber) != 1 {
+		t.Fatalf("completedParts = %v, %v", completed, err)
+	}
+}
+
+// 直传对象大小必须与会话一致；客户端提供的 ETag 忽略引号后比较，未提供时对象不能早于会话创建
+func TestVerifyPutObject(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 0, 0, 500, time.UTC)
+	session := &models.UploadSession{FileSize: 100, CreatedAt: created}
+	head := &s3.HeadObjectOutput{ContentLength: awsSDK.Int64(100), ETag: awsSDK.String(`"abc"`), LastModified: awsSDK.Time(created.Truncate(time.Second))}
+
+	if err := verifyPutObject(head, session, ""); err != nil {
+		t.Fatalf("未提供 ETag 时对象在会话创建后写入即可: %v", err)
+	}
+	stale := &s3.HeadObjectOutput{ContentLength: awsSDK.Int64(100), ETag: awsSDK.String(`"abc"`), LastModified: awsSDK.Time(created.Add(-time.Hour))}
+	if err := verifyPutObject(stale, session, ""); err == nil {
+		t.Fatal("未提供 ETag 时会话创建前已存在的同大小对象应被拒绝")
+	}
+	if err := verifyPutObject(stale, session, "abc"); err != nil {
+		t.Fatalf("提供 ETag 时以 ETag 为准: %v", err)
+	}
+	if err := verifyPutObject(head, session, "abc"); err != nil {
+		t.Fatalf("ETag 比较应忽略引号: %v", err)
+	}
+	if err := verifyPutObject(head, session, `"def"`); err == nil {
+		t.Fatal("ETag 不一致时应拒绝")
+	}
+	if err := verifyPutObject(&s3.HeadObjectOutput{ContentLength: awsSDK.Int64(99)}, session, ""); err == nil {
+		t.Fatal("大小不一致时应拒绝")
+	}
+}
+
+// 完成请求按当前状态条件更新为 completing，并发或重复的请求未命中任何行时被拒绝
+func TestClaimSession(t *testing.T) {
+	db := newDryRunDB(t)
+	var statements []string
+	affected := int64(0)
+	if err := db.Callback().Update().After("gorm:update").Register("test:rows_affected", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+		tx.RowsAffected = affected
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := claimSession(db, 1, models.UploadSessionStatusUploading); err == nil {
+		t.Fatal("会话已被其他请求占用时应拒绝")
+	}
+	affected = 1
+	if err := claimSession(db, 1, models.UploadSessionStatusFailed); err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 2 || !strings.Contains(statements[1], "status = ?") {
+		t.Fatalf("statements = %v", statements)
+	}
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseZoneFile 解析 BIND 格式的区域文件，返回按名称和类型聚合的记录集
+// 支持 $ORIGIN、$TTL、"@"、相对名称、省略的所有者（沿用上一条）、括号续行和 ; 注释；
+// 不支持 $INCLUDE 和 $GENERATE。同一记录集的 TTL 取第一条记录的值。
+func ParseZoneFile(data, origin string) ([]DNSRecord, []string, error) {
+	origin = normalizeDNSName(origin)
+	defaultTTL := int64(defaultDNSRecordTTL)
+	var warnings []string
+	var records []DNSRecord
+	index := make(map[string]int)
+	lastOwner := ""
+
+	lines, err := zoneFileLogicalLines(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, line := range lines {
+		tokens := line.tokens
+		if len(tokens) == 0 {
+			continue
+		}
+		if strings.HasPrefix(tokens[0], "$") {
+			switch strings.ToUpper(tokens[0]) {
+			case "$ORIGIN":
+				if len(tokens) < 2 {
+					return nil, nil, fmt.Errorf("第 %d 行: $ORIGIN 缺少参数", line.number)
+				}
+				origin = zoneFileName(tokens[1], origin)
+			case "$TTL":
+				if len(tokens) < 2 {
+					return nil, nil, fmt.Errorf("第 %d 行: $TTL 缺少参数", line.number)
+				}
+				ttl, ok := parseZoneFileTTL(tokens[1])
+				if !ok {
+					return nil, nil, fmt.Errorf("第 %d 行: 无效的 $TTL: %s", line.number, tokens[1])
+				}
+				defaultTTL = ttl
+			default:
+				warnings = append(warnings, fmt.Sprintf("第 %d 行: 不支持的指令 %s，已忽略", line.number, tokens[0]))
+			}
+			continue
+		}
+
+		owner := lastOwner
+		if !line.continued {
+			owner = zoneFileName(tokens[0], origin)
+			tokens = tokens[1:]
+		}
+		if owner == "" {
+			return nil, nil, fmt.Errorf("第 %d 行: 缺少记录名称", line.number)
+		}
+		lastOwner = owner
+
+		// TTL 和类别（IN）的顺序可以互换，且都可以省略
+		ttl := defaultTTL
+		for len(tokens) > 0 {
+			if value, ok := parseZoneFileTTL(tokens[0]); ok {
+				ttl = value
+			} else if class := strings.ToUpper(tokens[0]); class == "IN" || class == "CH" || class == "HS" {
+				if class != "IN" {
+					return nil, nil, fmt.Errorf("第 %d 行: 不支持的类别 %s", line.number, class)
+				}
+			} else {
+				break
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) < 2 {
+			return nil, nil, fmt.Errorf("第 %d 行: 记录格式错误", line.number)
+		}
+		recordType := strings.ToUpper(tokens[0])
+		value, err := zoneFileValue(recordType, tokens[1:], origin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("第 %d 行: %w", line.number, err)
+		}
+		if owner != origin && !strings.HasSuffix(owner, "."+origin) {
+			warnings = append(warnings, fmt.Sprintf("第 %d 行: %s 不属于区域 %s，已忽略", line.number, owner, origin))
+			continue
+		}
+
+		key := dnsRecordKey(owner, recordType)
+		i, ok := index[key]
+		if !ok {
+			i = len(records)
+			index[key] = i
+			records = append(records, DNSRecord{Name: owner, Type: recordType, TTL: ttl})
+		} else if records[i].TTL != ttl {
+			warnings = append(warnings, fmt.Sprintf("第 %d 行: %s %s 的 TTL 与前面的记录不一致，使用 %d", line.number, owner, recordType, records[i].TTL))
+		}
+		records[i].Values = append(records[i].Values, value)
+	}
+	return records, warnings, nil
+}
+
+// RenderZoneFile 将记录集渲染为 BIND 格式的区域文件，名称相对于 origin
+// Route 53 Alias 记录无法用标准格式表示，以注释形式输出。
+func RenderZoneFile(origin string, records []DNSRecord) string {
+	origin = normalizeDNSName(origin)
+	sorted := append([]DNSRecord(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ni, nj := normalizeDNSName(sorted[i].Name), normalizeDNSName(sorted[j].Name)
+		if (ni == origin) != (nj == origin) {
+			return ni == origin
+		}
+		if ni != nj {
+			return ni < nj
+		}
+		return zoneFileTypeOrder(sorted[i].Type) < zoneFileTypeOrder(sorted[j].Type)
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "; %s 区域文件，导出时间 %s\n", origin, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "$ORIGIN %s.\n", origin)
+	fmt.Fprintf(&b, "$TTL %d\n\n", defaultDNSRecordTTL)
+	for _, record := range sorted {
+		name := zoneFileRelativeName(normalizeDNSName(record.Name), origin)
+		if record.AliasTarget != "" {
+			fmt.Fprintf(&b, "; %s\tALIAS\t%s\t%s.\t; Route 53 Alias 记录（托管区域 %s），BIND 格式不支持\n",
+				name, record.Type, record.AliasTarget, record.AliasZoneID)
+			continue
+		}
+		for _, value := range record.Values {
+			fmt.Fprintf(&b, "%s\t%d\tIN\t%s\t%s\n", name, record.TTL, record.Type, zoneFileRenderValue(record.Type, value))
+		}
+	}
+	return b.String()
+}
+
+type zoneFileLine struct {
+	number    int      // 起始行号
+	continued bool     // 以空白开头，沿用上一条记录的名称
+	tokens    []string // 引号内的内容保留引号
+}
+
+// zoneFileLogicalLines 去掉注释，合并括号续行并分词
+func zoneFileLogicalLines(data string) ([]zoneFileLine, error) {
+	var lines []zoneFileLine
+	var current *zoneFileLine
+	depth := 0
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if current == nil {
+			current = &zoneFileLine{
+				number:    i + 1,
+				continued: len(raw) > 0 && (raw[0] == ' ' || raw[0] == '\t'),
+			}
+		}
+		var token strings.Builder
+		inQuote, escaped := false, false
+		flush := func() {
+			if token.Len() > 0 {
+				current.tokens = append(current.tokens, token.String())
+				token.Reset()
+			}
+		}
+	scan:
+		for _, r := range raw {
+			switch {
+			case escaped:
+				token.WriteRune(r)
+				escaped = false
+			case r == '\\':
+				token.WriteRune(r)
+				escaped = true
+			case r == '"':
+				token.WriteRune(r)
+				inQuote = !inQuote
+			case inQuote:
+				token.WriteRune(r)
+			case r == ';':
+				break scan
+			case r == '(':
+				flush()
+				depth++
+			case r == ')':
+				flush()
+				if depth == 0 {
+					return nil, fmt.Errorf("第 %d 行: 括号不匹配", i+1)
+				}
+				depth--
+			case r == ' ' || r == '\t':
+				flush()
+			default:
+				token.WriteRune(r)
+			}
+		}
+		if inQuote {
+			return nil, fmt.Errorf("第 %d 行: 引号不匹配", i+1)
+		}
+		flush()
+		if depth == 0 {
+			lines = append(lines, *current)
+			current = nil
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("区域文件结束时括号未闭合")
+	}
+	return lines, nil
+}
+
+// zoneFileName 将区域文件中的名称转换为完整域名
+func zoneFileName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return normalizeDNSName(name)
+	case origin == "":
+		return normalizeDNSName(name)
+	}
+	return normalizeDNSName(name + "." + origin)
+}
+
+// zoneFileRelativeName 将完整域名转换为相对于 origin 的名称
+func zoneFileRelativeName(name, origin string) string {
+	if name == origin {
+		return "@"
+	}
+	if strings.HasSuffix(name, "."+origin) {
+		return strings.TrimSuffix(name, "."+origin)
+	}
+	return name + "."
+}
+
+// parseZoneFileTTL 解析 TTL，支持纯秒数和 1h30m 这样的单位写法
+func parseZoneFileTTL(token string) (int64, bool) {
+	if token == "" || token[0] < '0' || token[0] > '9' {
+		return 0, false
+	}
+	if n, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return n, true
+	}
+	var total, current int64
+	hasDigit := false
+	for _, r := range strings.ToLower(token) {
+		if r >= '0' && r <= '9' {
+			current = current*10 + int64(r-'0')
+			hasDigit = true
+			continue
+		}
+		if !hasDigit {
+			return 0, false
+		}
+		switch r {
+		case 's':
+			total += current
+		case 'm':
+			total += current * 60
+		case 'h':
+			total += current * 3600
+		case 'd':
+			total += current * 86400
+		case 'w':
+			total += current * 604800
+		default:
+			return 0, false
+		}
+		current, hasDigit = 0, false
+	}
+	if hasDigit {
+		return 0, false
+	}
+	return total, true
+}
+
+// zoneFileValue 将记录数据转换为 DNSRecord 的统一值格式
+func zoneFileValue(recordType string, rdata []string, origin string) (string, error) {
+	switch recordType {
+	case "CNAME", "NS":
+		return zoneFileName(rdata[0], origin), nil
+	case "MX":
+		if len(rdata) < 2 {
+			return "", fmt.Errorf("MX 记录缺少目标")
+		}
+		if _, err := strconv.Atoi(rdata[0]); err != nil {
+			return "", fmt.Errorf("MX 优先级无效: %s", rdata[0])
+		}
+		return rdata[0] + " " + zoneFileName(rdata[1], origin), nil
+	case "TXT":
+		return unquoteTXTValue(strings.Join(rdata, " ")), nil
+	case "CAA":
+		if len(rdata) < 3 {
+			return "", fmt.Errorf("CAA 记录格式错误")
+		}
+		return fmt.Sprintf("%s %s %s", rdata[0], strings.ToLower(rdata[1]), quoteTXTValue(unquoteTXTValue(strings.Join(rdata[2:], " ")))), nil
+	}
+	return strings.Join(rdata, " "), nil
+}
+
+// zoneFileRenderValue 将统一格式的值渲染为区域文件中的记录数据
+func zoneFileRenderValue(recordType, value string) string {
+	switch recordType {
+	case "CNAME", "NS":
+		return normalizeDNSName(value) + "."
+	case "MX":
+		if priority, target, err := parseMXValue(value); err == nil {
+			return fmt.Sprintf("%d %s.", priority, normalizeDNSName(target))
+		}
+	case "TXT":
+		return quoteTXTValue(value)
+	}
+	return value
+}
+
+// zoneFileTypeOrder 导出时同名记录的类型顺序
+func zoneFileTypeOrder(recordType string) int {
+	order := []string{"SOA", "NS", "A", "AAAA", "CNAME", "MX", "TXT", "CAA"}
+	for i, t := range order {
+		if t == recordType {
+			return i
+		}
+	}
+	return len(order)
+}
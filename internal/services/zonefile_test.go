@@ -0,0 +1,126 @@
+package services
+
+import (
+	"aws_cdn/internal/models"
+	"strings"
+	"testing"
+)
+
+const testZoneFile = `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.registrar.net. hostmaster.example.com. (
+		2024010101 ; serial
+		7200 3600 1209600 300 )
+@		IN	NS	ns1.registrar.net.
+@	300	IN	A	192.0.2.1
+		IN	A	192.0.2.2 ; 同名第二个值
+www	IN	300	CNAME	@
+mail.example.com.	3600	MX	10 mx1
+@	IN	MX	20 mx2.example.net.
+_dmarc	TXT	"v=DMARC1; p=none" "; rua=mailto:d@example.com"
+@	CAA	0 issue "amazon.com"
+other.net.	A	192.0.2.9
+`
+
+// 区域文件解析：SOA 括号续行、省略所有者、相对名称、TTL 单位、引号内的分号、TTL 不一致和区域外记录告警。
+func TestParseZoneFile(t *testing.T) {
+	records, warnings, err := ParseZoneFile(testZoneFile, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第二个 A 记录省略 TTL 时使用 $TTL，与记录集的 TTL 不一致
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "TTL") || !strings.Contains(warnings[1], "other.net") {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+	byKey := make(map[string]DNSRecord)
+	for _, r := range records {
+		byKey[r.Name+" "+r.Type] = r
+	}
+	if len(records) != 8 {
+		t.Fatalf("got %d records: %+v", len(records), records)
+	}
+	if soa := byKey["example.com SOA"]; soa.TTL != 3600 || !strings.HasSuffix(soa.Values[0], "1209600 300") {
+		t.Fatalf("unexpected soa: %+v", soa)
+	}
+	if a := byKey["example.com A"]; a.TTL != 300 || len(a.Values) != 2 || a.Values[1] != "192.0.2.2" {
+		t.Fatalf("unexpected A: %+v", a)
+	}
+	if cname := byKey["www.example.com CNAME"]; cname.TTL != 300 || cname.Values[0] != "example.com" {
+		t.Fatalf("unexpected cname: %+v", cname)
+	}
+	if mx := byKey["mail.example.com MX"]; mx.Values[0] != "10 mx1.example.com" {
+		t.Fatalf("unexpected mx: %+v", mx)
+	}
+	if mx := byKey["example.com MX"]; mx.Values[0] != "20 mx2.example.net" {
+		t.Fatalf("unexpected apex mx: %+v", mx)
+	}
+	if txt := byKey["_dmarc.example.com TXT"]; txt.Values[0] != "v=DMARC1; p=none; rua=mailto:d@example.com" {
+		t.Fatalf("unexpected txt: %+v", txt)
+	}
+	if caa := byKey["example.com CAA"]; caa.Values[0] != `0 issue "amazon.com"` {
+		t.Fatalf("unexpected caa: %+v", caa)
+	}
+
+	if _, _, err := ParseZoneFile("@ IN SOA a. b. ( 1 2 3", "example.com"); err == nil {
+		t.Fatal("unclosed parenthesis should fail")
+	}
+}
+
+// 导出的区域文件再次解析后记录一致，Alias 记录以注释输出。
+func TestRenderZoneFileRoundTrip(t *testing.T) {
+	records, _, err := ParseZoneFile(testZoneFile, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias := (&Route53DNSProvider{}).CloudFrontRecord("cdn.example.com", "d111.cloudfront.net")
+	content := RenderZoneFile("example.com", append(records, alias))
+	if !strings.Contains(content, "; cdn\tALIAS\tA\td111.cloudfront.net.") {
+		t.Fatalf("alias not rendered as comment:\n%s", content)
+	}
+
+	again, _, err := ParseZoneFile(content, "example.com")
+	if err != nil {
+		t.Fatalf("parse exported zone: %v\n%s", err, content)
+	}
+	if len(again) != len(records) {
+		t.Fatalf("got %d records want %d:\n%s", len(again), len(records), content)
+	}
+	for _, want := range records {
+		found := false
+		for _, got := range again {
+			if got.Name == want.Name && got.Type == want.Type && got.TTL == want.TTL && sameDNSValues(got.Type, got.Values, want.Values) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("record lost in round trip: %+v\n%s", want, content)
+		}
+	}
+}
+
+// 导入差异：新增、值变化、TTL 变化、相同、提供商管理的类型跳过；Cloudflare 已代理的记录保持代理。
+func TestPlanZoneImportItem(t *testing.T) {
+	domain := &models.Domain{DomainName: "example.com", DNSProvider: models.DNSProviderCloudflare}
+	a := DNSRecord{Name: "example.com", Type: "A", Values: []string{"192.0.2.1"}, TTL: 300}
+
+	if _, item := planZoneImportItem(domain, a, nil); item.Action != "create" {
+		t.Fatalf("expected create: %+v", item)
+	}
+	current := DNSRecord{Name: "example.com", Type: "A", Values: []string{"192.0.2.1"}, TTL: 300}
+	if _, item := planZoneImportItem(domain, a, &current); item.Action != "unchanged" {
+		t.Fatalf("expected unchanged: %+v", item)
+	}
+	current.Values = []string{"192.0.2.7"}
+	current.TTL = 600
+	if _, item := planZoneImportItem(domain, a, &current); item.Action != "update" || len(item.Changes) != 2 {
+		t.Fatalf("expected update with 2 changes: %+v", item)
+	}
+	proxied := DNSRecord{Name: "example.com", Type: "A", Values: []string{"192.0.2.1"}, TTL: 1, Proxied: true}
+	if record, item := planZoneImportItem(domain, a, &proxied); item.Action != "unchanged" || !record.Proxied {
+		t.Fatalf("proxied record should stay proxied: %+v %+v", record, item)
+	}
+	ns := DNSRecord{Name: "example.com", Type: "NS", Values: []string{"ns1.registrar.net"}, TTL: 300}
+	if _, item := planZoneImportItem(domain, ns, nil); item.Action != "skip" {
+		t.Fatalf("expected skip: %+v", item)
+	}
+}